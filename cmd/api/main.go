@@ -4,8 +4,14 @@ import (
 	"blog-backend/internal/config"
 	"blog-backend/internal/handlers"
 	"blog-backend/internal/handlers/middleware"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+	"blog-backend/internal/repository/memory"
 	"blog-backend/internal/repository/postgres"
+	"blog-backend/pkg/blobstore"
 	"blog-backend/pkg/jwt"
+	"blog-backend/pkg/mailer"
+	"blog-backend/pkg/oidc"
 	"blog-backend/service"
 	"context"
 	"log"
@@ -22,6 +28,15 @@ func main() {
 
 	// Инициализация JWT секретного ключа
 	jwt.InitAuth()
+	jwt.SetAccessTokenTTL(cfg.AccessTokenTTL)
+
+	// Алгоритм хеширования новых паролей (старые хеши пересчитываются при входе)
+	jwt.SetPasswordHasher(newPasswordHasher(cfg))
+
+	// Ключи RS256/EdDSA для access токенов (если не заданы — HS256 с JWT_SECRET)
+	if err := jwt.LoadKeys(cfg.JWTSigningKeyFile, cfg.JWTVerifyKeyFiles); err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
 
 	// Инициализация БД с настройками пула (db.go)
 	db, err := postgres.NewDB(cfg) // ← из db.go!
@@ -40,47 +55,250 @@ func main() {
 	userRepo := postgres.NewPostgresUserRepository(db)
 	postRepo := postgres.NewPostgresPostRepository(db)
 	commentRepo := postgres.NewPostgresCommentRepository(db)
+	refreshTokenRepo := postgres.NewPostgresRefreshTokenRepository(db)
+	revokedTokenRepo := postgres.NewPostgresRevokedTokenRepository(db)
+	passwordResetRepo := postgres.NewPostgresPasswordResetRepository(db)
+	patRepo := postgres.NewPostgresPersonalAccessTokenRepository(db)
+	recoveryCodeRepo := postgres.NewPostgresRecoveryCodeRepository(db)
+	dataExportRepo := postgres.NewPostgresDataExportRepository(db)
+	invitationRepo := postgres.NewPostgresInvitationRepository(db)
+	followRepo := postgres.NewPostgresFollowRepository(db)
+	blockRepo := postgres.NewPostgresBlockRepository(db)
+	sessionRepo := postgres.NewPostgresSessionRepository(db)
+	oidcFlowRepo := postgres.NewPostgresOIDCFlowRepository(db)
+
+	// Счетчики неудачных входов: в памяти или общие в БД (LOGIN_ATTEMPTS_STORE)
+	var loginAttemptRepo repository.LoginAttemptRepository = memory.NewMemoryLoginAttemptRepository()
+	if cfg.LoginAttemptsStore == "postgres" {
+		loginAttemptRepo = postgres.NewPostgresLoginAttemptRepository(db)
+	}
+
+	// Отправка писем (log/file/smtp из .env)
+	mail, err := newMailer(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Хранилище загруженных файлов (аватары)
+	storage, err := blobstore.NewLocalStorage(cfg.StorageDir, cfg.StoragePublicURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Отозванные токены: кеш в памяти + синхронизация с БД
+	revocationStore := service.NewRevocationStore(revokedTokenRepo, cfg)
+	if err := revocationStore.Start(); err != nil {
+		log.Fatal(err)
+	}
 
 	// Service - уровень бизнес-логики (зависит от интерфейса Repository)
-	userService := service.NewUserService(userRepo)
+	sessionService := service.NewSessionService(sessionRepo, revocationStore, cfg)
+	tokenService := service.NewTokenService(refreshTokenRepo, patRepo, userRepo, revocationStore, sessionService, cfg)
+	verificationService := service.NewEmailVerificationService(userRepo, mail, cfg)
+	mfaService := service.NewMFAService(userRepo, recoveryCodeRepo, tokenService, cfg)
+	loginThrottleService := service.NewLoginThrottleService(loginAttemptRepo, cfg)
+	registrationService := service.NewRegistrationService(invitationRepo, cfg)
+	userService := service.NewUserService(userRepo, tokenService, verificationService, mfaService, loginThrottleService, registrationService)
+	passwordResetService := service.NewPasswordResetService(userRepo, passwordResetRepo, tokenService, loginThrottleService, mail, cfg)
+	avatarService := service.NewAvatarService(userRepo, storage, cfg)
+	accountService := service.NewAccountService(userRepo, tokenService, verificationService, avatarService, cfg)
+	patService := service.NewPersonalAccessTokenService(patRepo, userRepo)
 	postService := service.NewPostService(postRepo, userRepo, cfg)
-	commentService := service.NewCommentService(postRepo, commentRepo, userRepo)
+	tagService := service.NewTagService(postRepo, userRepo)
+	revisionService := service.NewRevisionService(postRepo, userRepo, postService)
+	authorService := service.NewAuthorService(userRepo, postRepo, followRepo)
+	followService := service.NewFollowService(followRepo, blockRepo, userRepo, postRepo)
+	blockService := service.NewBlockService(blockRepo, followRepo, userRepo)
+	commentService := service.NewCommentService(postRepo, commentRepo, userRepo, blockRepo, cfg)
+	dataExportService := service.NewDataExportService(dataExportRepo, userRepo, postRepo, commentRepo, loginThrottleService, sessionService, mail, cfg)
+	if err := dataExportService.Start(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Посты, сохраненные до появления HTML версии, рендерятся один раз в фоне
+	go func() {
+		rendered, err := postService.RenderStoredContent(context.Background())
+		if err != nil {
+			log.Printf("Failed to render stored posts: %v", err)
+		}
+		if rendered > 0 {
+			log.Printf("📝 Rendered HTML for %d stored posts", rendered)
+		}
+	}()
+
+	// Вход через OpenID Connect (nil, если OIDC_ISSUER_URL не задан)
+	var oidcService *service.OIDCService
+	if cfg.OIDCIssuerURL != "" {
+		provider := oidc.NewProvider(oidc.Config{
+			IssuerURL:    cfg.OIDCIssuerURL,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       cfg.OIDCScopes,
+		})
+		oidcService = service.NewOIDCService(provider, oidcFlowRepo, userRepo, tokenService, mfaService, registrationService, cfg)
+	}
+
+	// JWT middleware с проверкой отозванных токенов и завершенных сессий, поддержкой personal access токенов
+	authenticator := middleware.NewAuthenticator(sessionService, patService)
 
 	// Логгер
 	stdLogger := log.New(log.Writer(), "", log.LstdFlags)
 
 	// Handler - уровень HTTP (зависит от Service)
 	userHandler := handlers.NewUserHandler(userService, stdLogger)
+	passwordHandler := handlers.NewPasswordHandler(passwordResetService, stdLogger)
+	verificationHandler := handlers.NewVerificationHandler(verificationService, stdLogger)
+	tokenHandler := handlers.NewTokenHandler(patService, stdLogger)
+	sessionHandler := handlers.NewSessionHandler(sessionService, stdLogger)
+	mfaHandler := handlers.NewMFAHandler(mfaService, stdLogger)
+	accountHandler := handlers.NewAccountHandler(accountService, stdLogger)
+	avatarHandler := handlers.NewAvatarHandler(avatarService, stdLogger)
+	loginAttemptHandler := handlers.NewLoginAttemptHandler(loginThrottleService, stdLogger)
+	invitationHandler := handlers.NewInvitationHandler(registrationService, stdLogger)
 	postHandler := handlers.NewPostHandler(postService, stdLogger)
+	tagHandler := handlers.NewTagHandler(tagService, stdLogger)
+	revisionHandler := handlers.NewRevisionHandler(revisionService, stdLogger)
+	authorHandler := handlers.NewAuthorHandler(authorService, stdLogger)
+	followHandler := handlers.NewFollowHandler(followService, stdLogger)
+	blockHandler := handlers.NewBlockHandler(blockService, stdLogger)
+	exportHandler := handlers.NewExportHandler(dataExportService, stdLogger)
 	commentHandler := handlers.NewCommentHandler(commentService)
 
 	// Настройка HTTP маршрутов для пользователей
 	mux.HandleFunc("/api/register", userHandler.RegisterHandler)
 	mux.HandleFunc("/api/login", userHandler.LoginHandler)
-	mux.HandleFunc("/api/profile", middleware.AuthMiddleware(userHandler.ProfileHandler))
+	mux.HandleFunc("POST /api/auth/refresh", userHandler.RefreshHandler)
+	mux.HandleFunc("POST /api/auth/mfa/verify", mfaHandler.VerifyHandler)
+	if oidcService != nil {
+		oidcHandler := handlers.NewOIDCHandler(oidcService, stdLogger)
+		mux.HandleFunc("GET /api/auth/oidc/login", oidcHandler.Login)
+		mux.HandleFunc("GET /api/auth/oidc/callback", oidcHandler.Callback)
+	}
+	mux.HandleFunc("POST /api/logout", authenticator.AuthMiddleware(userHandler.LogoutHandler))
+	mux.HandleFunc("POST /api/logout/all", authenticator.AuthMiddleware(userHandler.LogoutAllHandler))
+	mux.HandleFunc("POST /api/password/forgot", passwordHandler.ForgotPasswordHandler)
+	mux.HandleFunc("POST /api/password/reset", passwordHandler.ResetPasswordHandler)
+	mux.HandleFunc("GET /api/verify-email", verificationHandler.VerifyEmailHandler)
+	mux.HandleFunc("POST /api/verify-email/resend", authenticator.AuthMiddleware(verificationHandler.ResendVerificationHandler))
+	mux.HandleFunc("/api/profile", authenticator.AuthMiddleware(userHandler.ProfileHandler, model.ScopeProfileRead))
 	mux.HandleFunc("/api/health", handlers.HealthHandler(userRepo))
+	mux.HandleFunc("GET /.well-known/jwks.json", handlers.JWKSHandler)
+
+	// Управление аккаунтом (только по JWT)
+	mux.HandleFunc("PATCH /api/profile", authenticator.AuthMiddleware(accountHandler.UpdateProfile))
+	mux.HandleFunc("POST /api/profile/password", authenticator.AuthMiddleware(accountHandler.ChangePassword))
+	mux.HandleFunc("DELETE /api/profile", authenticator.AuthMiddleware(accountHandler.DeleteAccount))
+
+	// Аватар: загрузка с обрезкой и уменьшением, файлы раздаются из STORAGE_DIR
+	mux.HandleFunc("PUT /api/profile/avatar", authenticator.AuthMiddleware(avatarHandler.UploadAvatar))
+	mux.HandleFunc("DELETE /api/profile/avatar", authenticator.AuthMiddleware(avatarHandler.DeleteAvatar))
+	mux.Handle("GET /uploads/", http.StripPrefix("/uploads/", storage.Handler()))
+
+	// Выгрузка персональных данных: архив собирается в фоне, ссылка на скачивание истекает
+	mux.HandleFunc("POST /api/profile/export", authenticator.AuthMiddleware(exportHandler.RequestExport))
+	mux.HandleFunc("GET /api/profile/export/{id}", authenticator.AuthMiddleware(exportHandler.GetExport))
+	mux.HandleFunc("GET /api/exports/download", exportHandler.Download)
+
+	// Двухфакторная аутентификация (TOTP)
+	mux.HandleFunc("POST /api/profile/mfa/totp", authenticator.AuthMiddleware(mfaHandler.EnrollHandler))
+	mux.HandleFunc("POST /api/profile/mfa/totp/confirm", authenticator.AuthMiddleware(mfaHandler.ConfirmHandler))
+	mux.HandleFunc("DELETE /api/profile/mfa/totp", authenticator.AuthMiddleware(mfaHandler.DisableHandler))
+	mux.HandleFunc("POST /api/profile/mfa/recovery-codes", authenticator.AuthMiddleware(mfaHandler.RecoveryCodesHandler))
+
+	// Personal access токены (управление только по JWT — PAT не может выпускать другие PAT)
+	mux.HandleFunc("POST /api/tokens", authenticator.AuthMiddleware(tokenHandler.CreateToken))
+	mux.HandleFunc("GET /api/tokens", authenticator.AuthMiddleware(tokenHandler.ListTokens))
+	mux.HandleFunc("DELETE /api/tokens/{id}", authenticator.AuthMiddleware(tokenHandler.RevokeToken))
+
+	// Сессии: где выполнен вход, завершение отдельных устройств (только по JWT)
+	mux.HandleFunc("GET /api/sessions", authenticator.AuthMiddleware(sessionHandler.ListSessions))
+	mux.HandleFunc("DELETE /api/sessions/{id}", authenticator.AuthMiddleware(sessionHandler.TerminateSession))
+
+	// Администрирование пользователей (только admin)
+	requireAdmin := middleware.RequireRole(model.RoleAdmin)
+	mux.HandleFunc("PUT /api/admin/users/{id}/role", authenticator.AuthMiddleware(requireAdmin(userHandler.ChangeRoleHandler)))
+	mux.HandleFunc("GET /api/admin/login-attempts", authenticator.AuthMiddleware(requireAdmin(loginAttemptHandler.ListFailedAttempts)))
+	mux.HandleFunc("POST /api/admin/invitations", authenticator.AuthMiddleware(requireAdmin(invitationHandler.CreateInvitation)))
+	mux.HandleFunc("GET /api/admin/invitations", authenticator.AuthMiddleware(requireAdmin(invitationHandler.ListInvitations)))
+	mux.HandleFunc("DELETE /api/admin/invitations/{id}", authenticator.AuthMiddleware(requireAdmin(invitationHandler.RevokeInvitation)))
 
 	// Настройка HTTP маршрутов для постов
-	// GET /api/posts — получить список постов (доступно всем)
-	// POST /api/posts — создать пост (только авторизованный пользователь)
+	// GET /api/posts — получить список постов (доступно всем), ?tag=go&tag=postgres&match=any|all — по тегам
+	// POST /api/posts — создать пост (author, editor, admin; reader не может)
+	requireWriter := middleware.RequireRole(model.RoleAuthor, model.RoleEditor, model.RoleAdmin)
 	mux.HandleFunc("GET /api/posts", postHandler.ListPosts)
-	mux.HandleFunc("POST /api/posts", middleware.AuthMiddleware(postHandler.CreatePost))
+	// GET /api/posts/search?q= — полнотекстовый поиск (доступно всем)
+	mux.HandleFunc("GET /api/posts/search", postHandler.SearchPosts)
+	mux.HandleFunc("POST /api/posts", authenticator.AuthMiddleware(requireWriter(postHandler.CreatePost), model.ScopePostsWrite))
 
 	// GET /api/posts/{postid} — получить один пост
-	// PUT /api/posts/{postid} — обновить пост (только автор)
-	// DELETE /api/posts/{postid} — удалить пост (только автор)
+	// PUT /api/posts/{postid} — обновить пост (автор или editor/admin)
+	// DELETE /api/posts/{postid} — удалить пост (автор или editor/admin)
 	mux.HandleFunc("GET /api/posts/{postid}", postHandler.GetPost)
-	mux.HandleFunc("PUT /api/posts/{postid}", middleware.AuthMiddleware(postHandler.UpdatePost))
-	mux.HandleFunc("DELETE /api/posts/{postid}", middleware.AuthMiddleware(postHandler.DeletePost))
+	mux.HandleFunc("PUT /api/posts/{postid}", authenticator.AuthMiddleware(postHandler.UpdatePost, model.ScopePostsWrite))
+	mux.HandleFunc("DELETE /api/posts/{postid}", authenticator.AuthMiddleware(postHandler.DeletePost, model.ScopePostsWrite))
+
+	// Теги: список для всех, переименование и объединение — editor/admin
+	requireEditor := middleware.RequireRole(model.RoleEditor, model.RoleAdmin)
+	mux.HandleFunc("GET /api/tags", tagHandler.ListTags)
+	mux.HandleFunc("PUT /api/tags/{name}", authenticator.AuthMiddleware(requireEditor(tagHandler.RenameTag), model.ScopePostsWrite))
+	mux.HandleFunc("POST /api/tags/{name}/merge", authenticator.AuthMiddleware(requireEditor(tagHandler.MergeTags), model.ScopePostsWrite))
 
 	// Настройка HTTP маршрутов для комментариев
-	mux.HandleFunc("POST /api/posts/{postId}/comments", middleware.AuthMiddleware(commentHandler.CreateComment))
-	mux.HandleFunc("GET /api/posts/{postId}/comments", commentHandler.GetComments)
+	mux.HandleFunc("POST /api/posts/{postId}/comments", authenticator.AuthMiddleware(commentHandler.CreateComment, model.ScopeCommentsWrite))
+	// GET /api/posts/by-slug/{slug} — пост по слагу (прежний слаг → 301 на текущий).
+	// ServeMux считает его конфликтующим с GET /api/posts/{postId}/comments (под оба подходит
+	// /api/posts/by-slug/comments), поэтому оба GET обслуживает один маршрут.
+	// GET /api/posts/{postId}/revisions — там же, иначе пост со слагом "revisions" не открыть
+	getComments := authenticator.OptionalAuthMiddleware(commentHandler.GetComments, model.ScopeCommentsRead)
+	listRevisions := authenticator.AuthMiddleware(revisionHandler.ListRevisions, model.ScopePostsRead)
+	mux.HandleFunc("GET /api/posts/{postId}/{resource}", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.PathValue("postId") == "by-slug":
+			r.SetPathValue("slug", r.PathValue("resource"))
+			postHandler.GetPostBySlug(w, r)
+		case r.PathValue("resource") == "comments":
+			getComments(w, r)
+		case r.PathValue("resource") == "revisions":
+			listRevisions(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	mux.HandleFunc("DELETE /api/posts/{postId}/comments/{commentId}", authenticator.AuthMiddleware(commentHandler.DeleteComment, model.ScopeCommentsWrite))
+
+	// История версий поста: сравнение — автору и editor/admin, восстановление — только автору
+	mux.HandleFunc("GET /api/posts/{postId}/revisions/diff", authenticator.AuthMiddleware(revisionHandler.Diff, model.ScopePostsRead))
+	mux.HandleFunc("POST /api/posts/{postId}/revisions/{revision}/restore", authenticator.AuthMiddleware(revisionHandler.Restore, model.ScopePostsWrite))
+
+	// Публичные страницы авторов (без email и данных входа)
+	mux.HandleFunc("GET /api/users/{username}", authorHandler.GetProfile)
+	mux.HandleFunc("GET /api/users/{username}/posts", authorHandler.ListPosts)
+	mux.HandleFunc("GET /api/users/{username}/followers", followHandler.ListFollowers)
+	mux.HandleFunc("GET /api/users/{username}/following", followHandler.ListFollowing)
+
+	// Подписки и персональная лента
+	mux.HandleFunc("POST /api/users/{id}/follow", authenticator.AuthMiddleware(followHandler.Follow))
+	mux.HandleFunc("DELETE /api/users/{id}/follow", authenticator.AuthMiddleware(followHandler.Unfollow))
+	mux.HandleFunc("GET /api/feed", authenticator.AuthMiddleware(followHandler.Feed, model.ScopePostsRead))
+
+	// Блокировка (запрет комментариев и подписки) и скрытие (mute) пользователей
+	mux.HandleFunc("POST /api/users/{id}/block", authenticator.AuthMiddleware(blockHandler.Block))
+	mux.HandleFunc("DELETE /api/users/{id}/block", authenticator.AuthMiddleware(blockHandler.Unblock))
+	mux.HandleFunc("POST /api/users/{id}/mute", authenticator.AuthMiddleware(blockHandler.Mute))
+	mux.HandleFunc("DELETE /api/users/{id}/mute", authenticator.AuthMiddleware(blockHandler.Unmute))
+	mux.HandleFunc("GET /api/profile/blocks", authenticator.AuthMiddleware(blockHandler.ListBlocked))
+	mux.HandleFunc("GET /api/profile/mutes", authenticator.AuthMiddleware(blockHandler.ListMuted))
 
 	// 2. Оборачиваем mux в middleware цепочку
 	// для перехвата паник и логирования
 	handler := middleware.LoggingMiddleware(mux)
 	handler = middleware.PanicRecoveryMiddleware(handler)
+	if cfg.TrustProxyHeaders {
+		// Первым в цепочке: IP клиента нужен и в логах, и при учете неудачных входов
+		handler = middleware.RealIPMiddleware(handler)
+	}
 
 	// Создаем HTTP сервер для graceful shutdown
 	port := config.GetEnv("SERVER_PORT", "8080")
@@ -93,6 +311,19 @@ func main() {
 		IdleTimeout:  120 * time.Second,
 	}
 
+	// SIGHUP перечитывает ключи подписи без перезапуска (ротация ключей)
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			if err := jwt.LoadKeys(cfg.JWTSigningKeyFile, cfg.JWTVerifyKeyFiles); err != nil {
+				log.Printf("Failed to reload JWT keys: %v", err)
+				continue
+			}
+			log.Println("🔑 JWT keys reloaded")
+		}
+	}()
+
 	// Канал для сигналов завершения
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
@@ -102,6 +333,7 @@ func main() {
 		log.Printf("🚀 Server starting on port %s", port)
 		log.Printf("📝 Register: POST http://localhost:%s/api/register", port)
 		log.Printf("🔐 Login: POST http://localhost:%s/api/login", port)
+		log.Printf("🔄 Refresh: POST http://localhost:%s/api/auth/refresh", port)
 		log.Printf("👤 Profile: GET http://localhost:%s/api/profile (requires token)", port)
 		log.Printf("❤️ Health: GET http://localhost:%s/api/health", port)
 
@@ -124,6 +356,12 @@ func main() {
 		postService.Stop()
 	}()
 
+	// Останавливаем синхронизацию отозванных токенов
+	revocationStore.Stop()
+
+	// Прерываем сборку архивов и очистку выгрузок
+	dataExportService.Stop()
+
 	// Останавливаем HTTP сервер
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Printf("HTTP Server forced shutdown: %v", err)
@@ -131,8 +369,36 @@ func main() {
 		log.Println("HTTP Server stopped")
 	}
 
+	// Дожидаемся писем сброса пароля, начатых до остановки
+	passwordResetService.Wait()
+
 	// Закрываем БД соединения
 	db.SetMaxOpenConns(0)
 
 	log.Println("✅ Graceful shutdown complete!")
 }
+
+// newMailer выбирает реализацию отправки писем по MAIL_DRIVER
+func newMailer(cfg *config.Config) (mailer.Mailer, error) {
+	switch cfg.MailDriver {
+	case "smtp":
+		return mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom), nil
+	case "file":
+		return mailer.NewFileMailer(cfg.MailDir)
+	default:
+		return mailer.NewLogMailer(os.Stdout), nil
+	}
+}
+
+// newPasswordHasher выбирает алгоритм хеширования паролей по PASSWORD_HASH_ALGORITHM
+func newPasswordHasher(cfg *config.Config) jwt.PasswordHasher {
+	if cfg.PasswordHashAlgorithm == "bcrypt" {
+		return jwt.BcryptHasher{Cost: cfg.BcryptCost}
+	}
+
+	params := jwt.DefaultArgon2Params
+	params.Memory = cfg.Argon2Memory
+	params.Iterations = cfg.Argon2Iterations
+	params.Parallelism = cfg.Argon2Parallelism
+	return jwt.Argon2idHasher{Params: params}
+}
@@ -4,10 +4,15 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"blog-backend/pkg/secretbox"
+
 	"github.com/joho/godotenv"
+	"golang.org/x/net/idna"
 )
 
 // getEnv получает значение переменной окружения или возвращает значение по умолчанию
@@ -28,11 +33,92 @@ type Config struct {
 	ServerPort  string `mapstructure:"SERVER_PORT"`
 	Environment string `mapstructure:"ENVIRONMENT"`
 
+	// Асимметричная подпись access токенов (RS256/EdDSA). Пусто = HS256 с JWT_SECRET
+	JWTSigningKeyFile string   `mapstructure:"JWT_SIGNING_KEY_FILE"`
+	JWTVerifyKeyFiles []string `mapstructure:"JWT_VERIFY_KEY_FILES"` // предыдущие ключи при ротации
+
 	// Настройки планировщика
 	SchedulerEnabled   bool          `mapstructure:"SCHEDULER_ENABLED"`
 	PostTickerDuration time.Duration `mapstructure:"POST_TICKER_DURATION"`
 	PostWorkersCount   int           `mapstructure:"POST_WORKERS_COUNT"`
 	PostBatchSize      int           `mapstructure:"POST_BATCH_SIZE"`
+
+	// Время жизни токенов
+	AccessTokenTTL  time.Duration `mapstructure:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL time.Duration `mapstructure:"REFRESH_TOKEN_TTL"`
+
+	// Интервал синхронизации кеша отозванных токенов с БД
+	RevocationSyncInterval time.Duration `mapstructure:"REVOCATION_SYNC_INTERVAL"`
+
+	// Публичный адрес приложения (для ссылок в письмах)
+	AppBaseURL string `mapstructure:"APP_BASE_URL"`
+
+	// Отправка писем
+	MailDriver   string `mapstructure:"MAIL_DRIVER"` // log, file, smtp
+	MailDir      string `mapstructure:"MAIL_DIR"`    // для MAIL_DRIVER=file
+	MailFrom     string `mapstructure:"MAIL_FROM"`
+	SMTPHost     string `mapstructure:"SMTP_HOST"`
+	SMTPPort     string `mapstructure:"SMTP_PORT"`
+	SMTPUsername string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
+
+	// Время жизни ссылки для сброса пароля
+	PasswordResetTTL time.Duration `mapstructure:"PASSWORD_RESET_TTL"`
+
+	// Подтверждение email
+	EmailVerificationTTL time.Duration `mapstructure:"EMAIL_VERIFICATION_TTL"`
+	RequireVerifiedEmail bool          `mapstructure:"REQUIRE_VERIFIED_EMAIL"` // запрет постов/комментариев без подтверждения
+
+	// Название сервиса в приложении-аутентификаторе (2FA)
+	MFAIssuer string `mapstructure:"MFA_ISSUER"`
+	// Ключ шифрования секретов TOTP в БД (AES-256-GCM, 32 байта в base64)
+	MFAEncryptionKey string `mapstructure:"MFA_ENCRYPTION_KEY"`
+
+	// Защита от перебора паролей
+	LoginAttemptsStore    string        `mapstructure:"LOGIN_ATTEMPTS_STORE"` // memory, postgres
+	LoginMaxFailures      int           `mapstructure:"LOGIN_MAX_FAILURES"`   // неудач подряд на один email до блокировки
+	LoginMaxFailuresPerIP int           `mapstructure:"LOGIN_MAX_FAILURES_PER_IP"`
+	LoginLockout          time.Duration `mapstructure:"LOGIN_LOCKOUT"`     // первая блокировка, далее удваивается
+	LoginLockoutMax       time.Duration `mapstructure:"LOGIN_LOCKOUT_MAX"` // предел удвоения
+
+	// Хеширование паролей: argon2id (по умолчанию) или bcrypt.
+	// Хеши другого алгоритма или с другими параметрами пересчитываются при входе
+	PasswordHashAlgorithm string `mapstructure:"PASSWORD_HASH_ALGORITHM"`
+	BcryptCost            int    `mapstructure:"BCRYPT_COST"`
+	Argon2Memory          uint32 `mapstructure:"ARGON2_MEMORY_KIB"`
+	Argon2Iterations      uint32 `mapstructure:"ARGON2_ITERATIONS"`
+	Argon2Parallelism     uint8  `mapstructure:"ARGON2_PARALLELISM"`
+
+	// Удаление аккаунта: anonymize (посты и комментарии остаются обезличенными) или delete (удаляются)
+	AccountDeletionMode string `mapstructure:"ACCOUNT_DELETION_MODE"`
+
+	// Выгрузка персональных данных: каталог ZIP архивов и срок действия ссылки на скачивание
+	ExportDir string        `mapstructure:"EXPORT_DIR"`
+	ExportTTL time.Duration `mapstructure:"EXPORT_TTL"`
+
+	// Регистрация: open, invite-only (по приглашениям администратора) или closed
+	RegistrationMode           string        `mapstructure:"REGISTRATION_MODE"`
+	RegistrationAllowedDomains []string      `mapstructure:"REGISTRATION_ALLOWED_DOMAINS"` // пусто = любые домены
+	InvitationTTL              time.Duration `mapstructure:"INVITATION_TTL"`               // срок действия приглашения по умолчанию
+
+	// Хранилище загруженных файлов: local (каталог STORAGE_DIR, раздается по /uploads/)
+	StorageDriver    string `mapstructure:"STORAGE_DRIVER"`
+	StorageDir       string `mapstructure:"STORAGE_DIR"`
+	StoragePublicURL string `mapstructure:"STORAGE_PUBLIC_URL"` // по умолчанию APP_BASE_URL/uploads
+
+	// Аватары: максимальный размер загружаемого файла в байтах
+	AvatarMaxBytes int64 `mapstructure:"AVATAR_MAX_BYTES"`
+
+	// Вход через OpenID Connect (SSO). Пустой OIDC_ISSUER_URL — вход выключен
+	OIDCIssuerURL     string   `mapstructure:"OIDC_ISSUER_URL"`
+	OIDCClientID      string   `mapstructure:"OIDC_CLIENT_ID"`
+	OIDCClientSecret  string   `mapstructure:"OIDC_CLIENT_SECRET"`
+	OIDCRedirectURL   string   `mapstructure:"OIDC_REDIRECT_URL"` // по умолчанию APP_BASE_URL/api/auth/oidc/callback
+	OIDCScopes        []string `mapstructure:"OIDC_SCOPES"`
+	OIDCAutoProvision bool     `mapstructure:"OIDC_AUTO_PROVISION"` // создавать пользователя при первом входе
+
+	// Брать адрес клиента из X-Forwarded-For / X-Real-IP (только за доверенным прокси)
+	TrustProxyHeaders bool `mapstructure:"TRUST_PROXY_HEADERS"`
 }
 
 func Load() *Config {
@@ -65,6 +151,102 @@ func Load() *Config {
 		log.Fatal("SCHEDULER_ENABLED invalid")
 	}
 
+	// Время жизни access и refresh токенов
+	accessTokenTTL, err := time.ParseDuration(GetEnv("ACCESS_TOKEN_TTL", "15m"))
+	if err != nil || accessTokenTTL <= 0 {
+		log.Fatal("ACCESS_TOKEN_TTL invalid (use 5m, 15m, 1h)")
+	}
+	refreshTokenTTL, err := time.ParseDuration(GetEnv("REFRESH_TOKEN_TTL", "720h"))
+	if err != nil || refreshTokenTTL <= accessTokenTTL {
+		log.Fatal("REFRESH_TOKEN_TTL invalid (must be longer than ACCESS_TOKEN_TTL)")
+	}
+
+	// Синхронизация кеша отозванных токенов (для нескольких экземпляров сервиса)
+	revocationSyncInterval, err := time.ParseDuration(GetEnv("REVOCATION_SYNC_INTERVAL", "30s"))
+	if err != nil || revocationSyncInterval <= 0 {
+		log.Fatal("REVOCATION_SYNC_INTERVAL invalid (use 10s, 30s, 1m)")
+	}
+
+	// Время жизни ссылки для сброса пароля
+	passwordResetTTL, err := time.ParseDuration(GetEnv("PASSWORD_RESET_TTL", "1h"))
+	if err != nil || passwordResetTTL <= 0 {
+		log.Fatal("PASSWORD_RESET_TTL invalid (use 30m, 1h)")
+	}
+
+	// Подтверждение email
+	emailVerificationTTL, err := time.ParseDuration(GetEnv("EMAIL_VERIFICATION_TTL", "48h"))
+	if err != nil || emailVerificationTTL <= 0 {
+		log.Fatal("EMAIL_VERIFICATION_TTL invalid (use 24h, 48h)")
+	}
+	requireVerifiedEmail, err := strconv.ParseBool(GetEnv("REQUIRE_VERIFIED_EMAIL", "false"))
+	if err != nil {
+		log.Fatal("REQUIRE_VERIFIED_EMAIL invalid")
+	}
+
+	// Защита от перебора паролей
+	loginMaxFailures, err := strconv.Atoi(GetEnv("LOGIN_MAX_FAILURES", "5"))
+	if err != nil || loginMaxFailures < 1 {
+		log.Fatal("LOGIN_MAX_FAILURES invalid (must be >= 1)")
+	}
+	loginMaxFailuresPerIP, err := strconv.Atoi(GetEnv("LOGIN_MAX_FAILURES_PER_IP", "20"))
+	if err != nil || loginMaxFailuresPerIP < 1 {
+		log.Fatal("LOGIN_MAX_FAILURES_PER_IP invalid (must be >= 1)")
+	}
+	loginLockout, err := time.ParseDuration(GetEnv("LOGIN_LOCKOUT", "1m"))
+	if err != nil || loginLockout <= 0 {
+		log.Fatal("LOGIN_LOCKOUT invalid (use 30s, 1m, 5m)")
+	}
+	loginLockoutMax, err := time.ParseDuration(GetEnv("LOGIN_LOCKOUT_MAX", "1h"))
+	if err != nil || loginLockoutMax < loginLockout || loginLockoutMax > 24*time.Hour {
+		log.Fatal("LOGIN_LOCKOUT_MAX invalid (must be between LOGIN_LOCKOUT and 24h)")
+	}
+	trustProxyHeaders, err := strconv.ParseBool(GetEnv("TRUST_PROXY_HEADERS", "false"))
+	if err != nil {
+		log.Fatal("TRUST_PROXY_HEADERS invalid")
+	}
+
+	// Хеширование паролей
+	bcryptCost, err := strconv.Atoi(GetEnv("BCRYPT_COST", "10"))
+	if err != nil || bcryptCost < 4 || bcryptCost > 31 {
+		log.Fatal("BCRYPT_COST invalid (must be 4-31)")
+	}
+	argon2Memory, err := strconv.ParseUint(GetEnv("ARGON2_MEMORY_KIB", "65536"), 10, 32)
+	if err != nil || argon2Memory < 1024 {
+		log.Fatal("ARGON2_MEMORY_KIB invalid (min 1024, recommended 65536)")
+	}
+	argon2Iterations, err := strconv.ParseUint(GetEnv("ARGON2_ITERATIONS", "3"), 10, 32)
+	if err != nil || argon2Iterations < 1 {
+		log.Fatal("ARGON2_ITERATIONS invalid (must be >= 1)")
+	}
+	argon2Parallelism, err := strconv.ParseUint(GetEnv("ARGON2_PARALLELISM", "2"), 10, 8)
+	if err != nil || argon2Parallelism < 1 {
+		log.Fatal("ARGON2_PARALLELISM invalid (must be 1-255)")
+	}
+
+	// Выгрузка персональных данных
+	exportTTL, err := time.ParseDuration(GetEnv("EXPORT_TTL", "24h"))
+	if err != nil || exportTTL <= 0 {
+		log.Fatal("EXPORT_TTL invalid (use 24h, 72h)")
+	}
+
+	// Приглашения на регистрацию
+	invitationTTL, err := time.ParseDuration(GetEnv("INVITATION_TTL", "168h"))
+	if err != nil || invitationTTL <= 0 {
+		log.Fatal("INVITATION_TTL invalid (use 72h, 168h)")
+	}
+
+	// Аватары
+	avatarMaxBytes, err := strconv.ParseInt(GetEnv("AVATAR_MAX_BYTES", "5242880"), 10, 64)
+	if err != nil || avatarMaxBytes < 1024 || avatarMaxBytes > 50<<20 {
+		log.Fatal("AVATAR_MAX_BYTES invalid (must be 1024-52428800)")
+	}
+
+	// OpenID Connect
+	oidcAutoProvision, err := strconv.ParseBool(GetEnv("OIDC_AUTO_PROVISION", "true"))
+	if err != nil {
+		log.Fatal("OIDC_AUTO_PROVISION invalid (true, false)")
+	}
+
 	// Создаём конфиг из переменных окружения
 	cfg := &Config{
 		DBHost:      GetEnv("DB_HOST", "localhost"),
@@ -76,11 +258,74 @@ func Load() *Config {
 		ServerPort:  GetEnv("SERVER_PORT", "8080"),
 		Environment: GetEnv("ENVIRONMENT", "development"),
 
+		JWTSigningKeyFile: GetEnv("JWT_SIGNING_KEY_FILE", ""),
+		JWTVerifyKeyFiles: splitList(GetEnv("JWT_VERIFY_KEY_FILES", "")),
+
 		// Планировщик из .env
 		SchedulerEnabled:   schedulerEnabled,
 		PostTickerDuration: tickerDuration,
 		PostWorkersCount:   workersCount,
 		PostBatchSize:      batchSize,
+
+		// Токены
+		AccessTokenTTL:  accessTokenTTL,
+		RefreshTokenTTL: refreshTokenTTL,
+
+		RevocationSyncInterval: revocationSyncInterval,
+
+		AppBaseURL: GetEnv("APP_BASE_URL", "http://localhost:8080"),
+
+		// Почта
+		MailDriver:   GetEnv("MAIL_DRIVER", "log"),
+		MailDir:      GetEnv("MAIL_DIR", "tmp/mail"),
+		MailFrom:     GetEnv("MAIL_FROM", "no-reply@localhost"),
+		SMTPHost:     GetEnv("SMTP_HOST", ""),
+		SMTPPort:     GetEnv("SMTP_PORT", "587"),
+		SMTPUsername: GetEnv("SMTP_USERNAME", ""),
+		SMTPPassword: GetEnv("SMTP_PASSWORD", ""),
+
+		PasswordResetTTL: passwordResetTTL,
+
+		EmailVerificationTTL: emailVerificationTTL,
+		RequireVerifiedEmail: requireVerifiedEmail,
+
+		MFAIssuer:        GetEnv("MFA_ISSUER", "Blog"),
+		MFAEncryptionKey: GetEnv("MFA_ENCRYPTION_KEY", ""),
+
+		LoginAttemptsStore:    GetEnv("LOGIN_ATTEMPTS_STORE", "memory"),
+		LoginMaxFailures:      loginMaxFailures,
+		LoginMaxFailuresPerIP: loginMaxFailuresPerIP,
+		LoginLockout:          loginLockout,
+		LoginLockoutMax:       loginLockoutMax,
+
+		PasswordHashAlgorithm: GetEnv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+		BcryptCost:            bcryptCost,
+		Argon2Memory:          uint32(argon2Memory),
+		Argon2Iterations:      uint32(argon2Iterations),
+		Argon2Parallelism:     uint8(argon2Parallelism),
+
+		AccountDeletionMode: GetEnv("ACCOUNT_DELETION_MODE", "anonymize"),
+
+		ExportDir: GetEnv("EXPORT_DIR", "tmp/exports"),
+		ExportTTL: exportTTL,
+
+		RegistrationMode:           GetEnv("REGISTRATION_MODE", "open"),
+		RegistrationAllowedDomains: splitList(strings.ToLower(GetEnv("REGISTRATION_ALLOWED_DOMAINS", ""))),
+		InvitationTTL:              invitationTTL,
+
+		StorageDriver:    GetEnv("STORAGE_DRIVER", "local"),
+		StorageDir:       GetEnv("STORAGE_DIR", "uploads"),
+		StoragePublicURL: GetEnv("STORAGE_PUBLIC_URL", ""),
+		AvatarMaxBytes:   avatarMaxBytes,
+
+		OIDCIssuerURL:     strings.TrimRight(GetEnv("OIDC_ISSUER_URL", ""), "/"),
+		OIDCClientID:      GetEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret:  GetEnv("OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:   GetEnv("OIDC_REDIRECT_URL", ""),
+		OIDCScopes:        splitList(GetEnv("OIDC_SCOPES", "openid,email,profile")),
+		OIDCAutoProvision: oidcAutoProvision,
+
+		TrustProxyHeaders: trustProxyHeaders,
 	}
 
 	// Валидация
@@ -93,9 +338,66 @@ func Load() *Config {
 	if len(cfg.JWTSecret) < 32 {
 		log.Fatal("JWT_SECRET too short (min 32 chars)")
 	}
+	if len(cfg.JWTVerifyKeyFiles) > 0 && cfg.JWTSigningKeyFile == "" {
+		log.Fatal("JWT_VERIFY_KEY_FILES requires JWT_SIGNING_KEY_FILE")
+	}
 	if cfg.ServerPort == "" {
 		cfg.ServerPort = "8080"
 	}
+	if _, err := secretbox.New(cfg.MFAEncryptionKey); err != nil {
+		log.Fatal("MFA_ENCRYPTION_KEY required (32 bytes in base64: openssl rand -base64 32)")
+	}
+	switch cfg.MailDriver {
+	case "log", "file":
+	case "smtp":
+		if cfg.SMTPHost == "" {
+			log.Fatal("SMTP_HOST required for MAIL_DRIVER=smtp")
+		}
+	default:
+		log.Fatal("MAIL_DRIVER invalid (log, file, smtp)")
+	}
+	if cfg.LoginAttemptsStore != "memory" && cfg.LoginAttemptsStore != "postgres" {
+		log.Fatal("LOGIN_ATTEMPTS_STORE invalid (memory, postgres)")
+	}
+	if cfg.PasswordHashAlgorithm != "argon2id" && cfg.PasswordHashAlgorithm != "bcrypt" {
+		log.Fatal("PASSWORD_HASH_ALGORITHM invalid (argon2id, bcrypt)")
+	}
+	if cfg.AccountDeletionMode != "anonymize" && cfg.AccountDeletionMode != "delete" {
+		log.Fatal("ACCOUNT_DELETION_MODE invalid (anonymize, delete)")
+	}
+	switch cfg.RegistrationMode {
+	case "open", "invite-only", "closed":
+	default:
+		log.Fatal("REGISTRATION_MODE invalid (open, invite-only, closed)")
+	}
+	for i, domain := range cfg.RegistrationAllowedDomains {
+		// Домены email хранятся в punycode (jwt.NormalizeEmail) — так же приводим и список
+		ascii, err := idna.Lookup.ToASCII(domain)
+		if err != nil || strings.ContainsAny(domain, "@ ") || !strings.Contains(ascii, ".") {
+			log.Fatalf("REGISTRATION_ALLOWED_DOMAINS invalid domain %q (use example.com,corp.example.org)", domain)
+		}
+		cfg.RegistrationAllowedDomains[i] = ascii
+	}
+	if cfg.StorageDriver != "local" {
+		log.Fatal("STORAGE_DRIVER invalid (local)")
+	}
+	if cfg.StoragePublicURL == "" {
+		cfg.StoragePublicURL = strings.TrimRight(cfg.AppBaseURL, "/") + "/uploads"
+	}
+	if cfg.OIDCIssuerURL != "" {
+		if !strings.HasPrefix(cfg.OIDCIssuerURL, "https://") && !strings.HasPrefix(cfg.OIDCIssuerURL, "http://") {
+			log.Fatal("OIDC_ISSUER_URL invalid (use https://sso.example.com/realms/main)")
+		}
+		if cfg.OIDCClientID == "" {
+			log.Fatal("OIDC_CLIENT_ID required when OIDC_ISSUER_URL is set")
+		}
+		if !slices.Contains(cfg.OIDCScopes, "openid") {
+			log.Fatal("OIDC_SCOPES must include openid")
+		}
+		if cfg.OIDCRedirectURL == "" {
+			cfg.OIDCRedirectURL = strings.TrimRight(cfg.AppBaseURL, "/") + "/api/auth/oidc/callback"
+		}
+	}
 
 	log.Printf("📅 Scheduler config: ticker=%v, workers=%d, batch=%d",
 		cfg.PostTickerDuration, cfg.PostWorkersCount, cfg.PostBatchSize)
@@ -103,6 +405,17 @@ func Load() *Config {
 	return cfg
 }
 
+// splitList разбирает список через запятую, пропуская пустые элементы
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // DatabaseURL формирует строку подключения PostgreSQL
 func (c *Config) DatabaseURL() string {
 	return fmt.Sprintf(
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/internal/model"
+	"blog-backend/pkg/auth"
+	"blog-backend/service"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// AccountHandler обрабатывает изменение профиля, смену пароля и удаление аккаунта
+type AccountHandler struct {
+	accountService *service.AccountService
+	log            *log.Logger
+}
+
+// NewAccountHandler создает новый AccountHandler
+func NewAccountHandler(accountService *service.AccountService, logger *log.Logger) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+		log:            logger,
+	}
+}
+
+// UpdateProfile меняет username, email (с повторным подтверждением) и bio
+// PATCH /api/profile
+func (h *AccountHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	var req model.UpdateProfileRequest
+	if err := parseJSONRequest(r, &req); err != nil {
+		middleware.AbortError(w, r, "Invalid JSON", http.StatusBadRequest, err)
+		return
+	}
+	if req.Username == nil && req.Email == nil && req.Bio == nil {
+		middleware.AbortError(w, r, "Nothing to update", http.StatusBadRequest, nil)
+		return
+	}
+
+	user, err := h.accountService.UpdateProfile(r.Context(), userID, req)
+	if err != nil {
+		h.abortAccountError(w, r, err, "Failed to update profile")
+		return
+	}
+
+	sendJSONResponse(w, profileResponse(user), http.StatusOK)
+}
+
+// ChangePassword меняет пароль по текущему паролю и завершает все сессии
+// POST /api/profile/password
+func (h *AccountHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	var req model.ChangePasswordRequest
+	if err := parseJSONRequest(r, &req); err != nil {
+		middleware.AbortError(w, r, "Invalid JSON", http.StatusBadRequest, err)
+		return
+	}
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		middleware.AbortError(w, r, "current_password and new_password are required", http.StatusBadRequest, nil)
+		return
+	}
+
+	if err := h.accountService.ChangePassword(r.Context(), userID, req); err != nil {
+		h.abortAccountError(w, r, err, "Failed to change password")
+		return
+	}
+
+	sendJSONResponse(w, map[string]string{
+		"message": "Password changed, all sessions have been logged out. Please log in again",
+	}, http.StatusOK)
+}
+
+// DeleteAccount удаляет аккаунт текущего пользователя (подтверждение паролем)
+// DELETE /api/profile
+func (h *AccountHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	var req model.DeleteAccountRequest
+	if err := parseJSONRequest(r, &req); err != nil {
+		middleware.AbortError(w, r, "Invalid JSON", http.StatusBadRequest, err)
+		return
+	}
+	if req.Password == "" {
+		middleware.AbortError(w, r, "password is required", http.StatusBadRequest, nil)
+		return
+	}
+
+	if err := h.accountService.DeleteAccount(r.Context(), userID, req.Password); err != nil {
+		h.abortAccountError(w, r, err, "Failed to delete account")
+		return
+	}
+
+	h.log.Printf("User %d deleted their account", userID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// abortAccountError переводит ошибки AccountService в HTTP коды
+func (h *AccountHandler) abortAccountError(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidProfile), errors.Is(err, service.ErrInvalidUsername), errors.Is(err, service.ErrInvalidPassword):
+		middleware.AbortError(w, r, err.Error(), http.StatusBadRequest, err)
+	case errors.Is(err, service.ErrInvalidCurrentPassword):
+		middleware.AbortError(w, r, "Current password is incorrect", http.StatusForbidden, err)
+	case errors.Is(err, service.ErrUsernameTaken), errors.Is(err, service.ErrUsernameConfusable), errors.Is(err, service.ErrEmailTaken):
+		middleware.AbortError(w, r, err.Error(), http.StatusConflict, err)
+	default:
+		middleware.AbortError(w, r, fallback, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,214 @@
+// internal/handlers/account_handler_test.go
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"testing"
+
+	"blog-backend/internal/handlers"
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+	"blog-backend/internal/repository/memory"
+	"blog-backend/pkg/blobstore"
+	"blog-backend/pkg/jwt"
+	"blog-backend/service"
+)
+
+// setupAccountTestRouter - роутер со входом, управлением аккаунтом и аватаром
+func setupAccountTestRouter(t *testing.T, deletionMode string) (http.Handler, repository.UserRepository, *RecordingMailer) {
+	t.Helper()
+
+	userRepo := NewMemoryUserRepository()
+	cfg := NewTestConfig()
+	cfg.AccountDeletionMode = deletionMode
+
+	revocations := service.NewRevocationStore(NewMemoryRevokedTokenRepository(), cfg)
+	tokenSvc := service.NewTokenService(NewMemoryRefreshTokenRepository(), NewMemoryPersonalAccessTokenRepository(), userRepo, revocations, service.NewSessionService(NewMemorySessionRepository(), revocations, cfg), cfg)
+	mail := &RecordingMailer{}
+	verifier := service.NewEmailVerificationService(userRepo, mail, cfg)
+	mfaSvc := service.NewMFAService(userRepo, NewMemoryRecoveryCodeRepository(), tokenSvc, cfg)
+	throttle := service.NewLoginThrottleService(memory.NewMemoryLoginAttemptRepository(), cfg)
+	userSvc := service.NewUserService(userRepo, tokenSvc, verifier, mfaSvc, throttle, service.NewRegistrationService(NewMemoryInvitationRepository(), cfg))
+	storage, err := blobstore.NewLocalStorage(t.TempDir(), "http://blog.test/uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	avatarSvc := service.NewAvatarService(userRepo, storage, cfg)
+	accountSvc := service.NewAccountService(userRepo, tokenSvc, verifier, avatarSvc, cfg)
+
+	logger := log.New(io.Discard, "", 0)
+	userHandler := handlers.NewUserHandler(userSvc, logger)
+	accountHandler := handlers.NewAccountHandler(accountSvc, logger)
+	avatarHandler := handlers.NewAvatarHandler(avatarSvc, logger)
+	authenticator := middleware.NewAuthenticator(revocations, nil)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/auth/login", userHandler.LoginHandler)
+	mux.HandleFunc("POST /api/auth/refresh", userHandler.RefreshHandler)
+	mux.HandleFunc("/api/profile", authenticator.AuthMiddleware(userHandler.ProfileHandler))
+	mux.HandleFunc("PATCH /api/profile", authenticator.AuthMiddleware(accountHandler.UpdateProfile))
+	mux.HandleFunc("POST /api/profile/password", authenticator.AuthMiddleware(accountHandler.ChangePassword))
+	mux.HandleFunc("DELETE /api/profile", authenticator.AuthMiddleware(accountHandler.DeleteAccount))
+	mux.HandleFunc("PUT /api/profile/avatar", authenticator.AuthMiddleware(avatarHandler.UploadAvatar))
+	mux.HandleFunc("DELETE /api/profile/avatar", authenticator.AuthMiddleware(avatarHandler.DeleteAvatar))
+	mux.Handle("GET /uploads/", http.StripPrefix("/uploads/", storage.Handler()))
+
+	return mux, userRepo, mail
+}
+
+// TestUpdateProfile - изменение username, bio и email
+func TestUpdateProfile(t *testing.T) {
+	router, userRepo, mail := setupAccountTestRouter(t, model.AccountDeletionAnonymize)
+	userRepo.MarkEmailVerified(context.Background(), 1, "test@example.com")
+	userRepo.CreateUser(context.Background(), "other@example.com", "otheruser", "hash")
+	access, _ := loginTokens(t, router)
+
+	// 1. Username и bio
+	w := doAuthJSON(router, http.MethodPatch, "/api/profile", access,
+		`{"username": "renamed", "bio": "Пишу о Go"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var profile struct {
+		Username      string `json:"username"`
+		Email         string `json:"email"`
+		Bio           string `json:"bio"`
+		EmailVerified bool   `json:"email_verified"`
+	}
+	json.NewDecoder(w.Body).Decode(&profile)
+	if profile.Username != "renamed" || profile.Bio != "Пишу о Go" || !profile.EmailVerified {
+		t.Errorf("unexpected profile after update: %+v", profile)
+	}
+
+	// 2. Ошибки валидации и конфликты
+	cases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"empty body", `{}`, http.StatusBadRequest},
+		{"short username", `{"username": "ab"}`, http.StatusBadRequest},
+		{"reserved username", `{"username": "deleted-7"}`, http.StatusBadRequest},
+		{"taken username", `{"username": "otheruser"}`, http.StatusConflict},
+		{"empty email", `{"email": "", "current_password": "password123"}`, http.StatusBadRequest},
+		{"email without password", `{"email": "new@example.com"}`, http.StatusForbidden},
+		{"email wrong password", `{"email": "new@example.com", "current_password": "wrong"}`, http.StatusForbidden},
+		{"taken email", `{"email": "other@example.com", "current_password": "password123"}`, http.StatusConflict},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if w := doAuthJSON(router, http.MethodPatch, "/api/profile", access, tc.body); w.Code != tc.want {
+				t.Errorf("expected %d, got %d: %s", tc.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// 3. Новый email требует повторного подтверждения
+	w = doAuthJSON(router, http.MethodPatch, "/api/profile", access,
+		`{"email": "new@example.com", "current_password": "password123"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("change email: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	json.NewDecoder(w.Body).Decode(&profile)
+	if profile.Email != "new@example.com" || profile.EmailVerified {
+		t.Errorf("expected unverified new email, got %+v", profile)
+	}
+	if msg, sent := mail.Last(); !sent || msg.To != "new@example.com" {
+		t.Errorf("expected verification email to new address, got %+v", msg)
+	}
+
+	// 4. Вход уже по новому email
+	if w := doJSON(router, http.MethodPost, "/api/auth/login",
+		`{"email": "new@example.com", "password": "password123"}`); w.Code != http.StatusOK {
+		t.Errorf("login with new email: expected 200, got %d", w.Code)
+	}
+}
+
+// TestChangePassword - смена пароля завершает все сессии
+func TestChangePassword(t *testing.T) {
+	router, _, _ := setupAccountTestRouter(t, model.AccountDeletionAnonymize)
+	access, refresh := loginTokens(t, router)
+	otherAccess, _ := loginTokens(t, router) // вторая сессия
+
+	if w := doAuthJSON(router, http.MethodPost, "/api/profile/password", access,
+		`{"current_password": "wrong", "new_password": "newpassword456"}`); w.Code != http.StatusForbidden {
+		t.Errorf("wrong current password: expected 403, got %d", w.Code)
+	}
+	if w := doAuthJSON(router, http.MethodPost, "/api/profile/password", access,
+		`{"current_password": "password123", "new_password": "short"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("weak password: expected 400, got %d", w.Code)
+	}
+
+	if w := doAuthJSON(router, http.MethodPost, "/api/profile/password", access,
+		`{"current_password": "password123", "new_password": "newpassword456"}`); w.Code != http.StatusOK {
+		t.Fatalf("change password: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Токены всех сессий больше не действуют
+	for _, token := range []string{access, otherAccess} {
+		if w := doAuthJSON(router, http.MethodGet, "/api/profile", token, ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("old access token: expected 401, got %d", w.Code)
+		}
+	}
+	if w := doJSON(router, http.MethodPost, "/api/auth/refresh", `{"refresh_token": "`+refresh+`"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("old refresh token: expected 401, got %d", w.Code)
+	}
+
+	// Вход только с новым паролем
+	if w := doJSON(router, http.MethodPost, "/api/auth/login",
+		`{"email": "test@example.com", "password": "password123"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("login with old password: expected 401, got %d", w.Code)
+	}
+	if w := doJSON(router, http.MethodPost, "/api/auth/login",
+		`{"email": "test@example.com", "password": "newpassword456"}`); w.Code != http.StatusOK {
+		t.Errorf("login with new password: expected 200, got %d", w.Code)
+	}
+}
+
+// TestDeleteAccount - удаление аккаунта в обоих режимах
+func TestDeleteAccount(t *testing.T) {
+	t.Run("anonymize", func(t *testing.T) {
+		router, userRepo, _ := setupAccountTestRouter(t, model.AccountDeletionAnonymize)
+		access, _ := loginTokens(t, router)
+
+		if w := doAuthJSON(router, http.MethodDelete, "/api/profile", access, `{"password": "wrong"}`); w.Code != http.StatusForbidden {
+			t.Errorf("wrong password: expected 403, got %d", w.Code)
+		}
+		if w := doAuthJSON(router, http.MethodDelete, "/api/profile", access, `{"password": "password123"}`); w.Code != http.StatusNoContent {
+			t.Fatalf("delete: expected 204, got %d: %s", w.Code, w.Body.String())
+		}
+
+		// Пользователь остается для постов и комментариев, но без личных данных
+		user, err := userRepo.GetUserByID(context.Background(), 1)
+		if err != nil || user.DeletedAt == nil || user.Username != "deleted-1" || user.Email == "test@example.com" {
+			t.Errorf("expected anonymized user, got %+v (%v)", user, err)
+		}
+		if jwt.CheckPassword("password123", user.PasswordHash) {
+			t.Errorf("anonymized user must not be able to log in")
+		}
+		if w := doAuthJSON(router, http.MethodGet, "/api/profile", access, ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("token after delete: expected 401, got %d", w.Code)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		router, userRepo, _ := setupAccountTestRouter(t, model.AccountDeletionHard)
+		access, _ := loginTokens(t, router)
+
+		if w := doAuthJSON(router, http.MethodDelete, "/api/profile", access, `{"password": "password123"}`); w.Code != http.StatusNoContent {
+			t.Fatalf("delete: expected 204, got %d: %s", w.Code, w.Body.String())
+		}
+		if user, _ := userRepo.GetUserByID(context.Background(), 1); user != nil {
+			t.Errorf("expected user to be deleted, got %+v", user)
+		}
+		if w := doJSON(router, http.MethodPost, "/api/auth/login",
+			`{"email": "test@example.com", "password": "password123"}`); w.Code != http.StatusUnauthorized {
+			t.Errorf("login after delete: expected 401, got %d", w.Code)
+		}
+	})
+}
@@ -4,47 +4,100 @@ package handlers_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"blog-backend/internal/handlers"
+	"blog-backend/internal/handlers/middleware"
 	"blog-backend/internal/repository"
+	"blog-backend/internal/repository/memory"
+	"blog-backend/pkg/jwt"
 	"blog-backend/service"
 )
 
+// newTestUserService - UserService с in-memory хранилищами токенов
+func newTestUserService(userRepo repository.UserRepository) (*service.UserService, *service.RevocationStore) {
+	return newTestUserServiceWithPATs(userRepo, NewMemoryPersonalAccessTokenRepository())
+}
+
+// newTestUserServiceWithPATs - то же с заданным хранилищем personal access токенов (их отзывает LogoutAll)
+func newTestUserServiceWithPATs(userRepo repository.UserRepository, patRepo repository.PersonalAccessTokenRepository) (*service.UserService, *service.RevocationStore) {
+	cfg := NewTestConfig()
+	revocations := service.NewRevocationStore(NewMemoryRevokedTokenRepository(), cfg)
+	tokenSvc := service.NewTokenService(NewMemoryRefreshTokenRepository(), patRepo, userRepo, revocations, service.NewSessionService(NewMemorySessionRepository(), revocations, cfg), cfg)
+	verifier := service.NewEmailVerificationService(userRepo, &RecordingMailer{}, cfg)
+	mfaSvc := service.NewMFAService(userRepo, NewMemoryRecoveryCodeRepository(), tokenSvc, cfg)
+	throttle := service.NewLoginThrottleService(memory.NewMemoryLoginAttemptRepository(), cfg)
+	return service.NewUserService(userRepo, tokenSvc, verifier, mfaSvc, throttle, service.NewRegistrationService(NewMemoryInvitationRepository(), cfg)), revocations
+}
+
 // setupAuthTestRouter - создает полный тестовый роутер
 func setupAuthTestRouter() (http.Handler, repository.UserRepository) {
 	// Используем существующий UserService из user_service.go
 	userRepo := NewMemoryUserRepository()
-	// cfg := NewTestConfig()
+	return setupAuthTestRouterWithRepo(userRepo)
+}
 
-	// UserService принимает userRepo
-	userSvc := service.NewUserService(userRepo)
+// setupAuthTestRouterWithRepo - использует существующий repo
+func setupAuthTestRouterWithRepo(userRepo repository.UserRepository) (http.Handler, repository.UserRepository) {
+	// UserService принимает userRepo и сервис токенов
+	userSvc, revocations := newTestUserService(userRepo)
 	logger := log.New(io.Discard, "", 0)
-
 	userHandler := handlers.NewUserHandler(userSvc, logger)
+	authenticator := middleware.NewAuthenticator(revocations, nil)
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("POST /api/auth/register", userHandler.RegisterHandler)
 	mux.HandleFunc("POST /api/auth/login", userHandler.LoginHandler)
+	mux.HandleFunc("POST /api/auth/refresh", userHandler.RefreshHandler)
+	mux.HandleFunc("POST /api/logout", authenticator.AuthMiddleware(userHandler.LogoutHandler))
+	mux.HandleFunc("POST /api/logout/all", authenticator.AuthMiddleware(userHandler.LogoutAllHandler))
+	mux.HandleFunc("GET /api/profile", authenticator.AuthMiddleware(userHandler.ProfileHandler))
 
 	return mux, userRepo
 }
 
-// setupAuthTestRouterWithRepo - использует существующий repo
-func setupAuthTestRouterWithRepo(userRepo repository.UserRepository) (http.Handler, repository.UserRepository) {
-	userSvc := service.NewUserService(userRepo)
-	logger := log.New(io.Discard, "", 0)
-	userHandler := handlers.NewUserHandler(userSvc, logger)
+// doJSON - выполняет JSON запрос к роутеру
+func doJSON(router http.Handler, method, url, body string) *httptest.ResponseRecorder {
+	return doAuthJSON(router, method, url, "", body)
+}
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("POST /api/auth/register", userHandler.RegisterHandler)
-	mux.HandleFunc("POST /api/auth/login", userHandler.LoginHandler)
+// doAuthJSON - выполняет JSON запрос с access токеном
+func doAuthJSON(router http.Handler, method, url, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, bytes.NewBuffer([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
-	return mux, userRepo
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// loginTokens - входит тестовым пользователем и возвращает пару токенов
+func loginTokens(t *testing.T, router http.Handler) (string, string) {
+	t.Helper()
+
+	w := doJSON(router, http.MethodPost, "/api/auth/login",
+		`{"email": "test@example.com", "password": "password123"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("login failed: %d %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("invalid login response: %v", err)
+	}
+	return resp.Token, resp.RefreshToken
 }
 
 // TestRegisterHandler - Регистрация пользователей
@@ -157,3 +210,137 @@ func TestLoginHandler(t *testing.T) {
 		})
 	}
 }
+
+// TestRefreshHandler - ротация refresh токенов и обнаружение повторного использования
+func TestRefreshHandler(t *testing.T) {
+	router, _ := setupAuthTestRouter()
+	_, refreshToken := loginTokens(t, router)
+
+	// 1. Первый обмен — успешен, выдается новый refresh токен
+	w := doJSON(router, http.MethodPost, "/api/auth/refresh",
+		`{"refresh_token": "`+refreshToken+`"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Token == "" || resp.RefreshToken == "" || resp.RefreshToken == refreshToken {
+		t.Fatalf("expected new token pair, got %+v", resp)
+	}
+
+	// 2. Повторное использование старого токена — 401 и отзыв цепочки
+	w = doJSON(router, http.MethodPost, "/api/auth/refresh",
+		`{"refresh_token": "`+refreshToken+`"}`)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("reuse: expected 401, got %d", w.Code)
+	}
+
+	// 3. Новый токен из скомпрометированной цепочки тоже отозван
+	w = doJSON(router, http.MethodPost, "/api/auth/refresh",
+		`{"refresh_token": "`+resp.RefreshToken+`"}`)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("revoked family: expected 401, got %d", w.Code)
+	}
+
+	// 4. Неизвестный и пустой токены
+	if w := doJSON(router, http.MethodPost, "/api/auth/refresh", `{"refresh_token": "unknown"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("unknown token: expected 401, got %d", w.Code)
+	}
+	if w := doJSON(router, http.MethodPost, "/api/auth/refresh", `{"refresh_token": ""}`); w.Code != http.StatusBadRequest {
+		t.Errorf("empty token: expected 400, got %d", w.Code)
+	}
+}
+
+// TestLogoutHandler - отзыв текущего токена
+func TestLogoutHandler(t *testing.T) {
+	router, _ := setupAuthTestRouter()
+	accessToken, refreshToken := loginTokens(t, router)
+
+	if w := doAuthJSON(router, http.MethodGet, "/api/profile", accessToken, ""); w.Code != http.StatusOK {
+		t.Fatalf("profile before logout: expected 200, got %d", w.Code)
+	}
+
+	w := doAuthJSON(router, http.MethodPost, "/api/logout", accessToken,
+		`{"refresh_token": "`+refreshToken+`"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("logout: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Access токен отозван
+	if w := doAuthJSON(router, http.MethodGet, "/api/profile", accessToken, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("profile after logout: expected 401, got %d", w.Code)
+	}
+
+	// Refresh токен тоже больше не работает
+	if w := doJSON(router, http.MethodPost, "/api/auth/refresh", `{"refresh_token": "`+refreshToken+`"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("refresh after logout: expected 401, got %d", w.Code)
+	}
+}
+
+// TestLogoutAllHandler - выход со всех устройств
+func TestLogoutAllHandler(t *testing.T) {
+	router, _ := setupAuthTestRouter()
+	firstAccess, firstRefresh := loginTokens(t, router)
+	secondAccess, _ := loginTokens(t, router)
+
+	if w := doAuthJSON(router, http.MethodPost, "/api/logout/all", secondAccess, ""); w.Code != http.StatusOK {
+		t.Fatalf("logout all: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	for name, token := range map[string]string{"first": firstAccess, "second": secondAccess} {
+		if w := doAuthJSON(router, http.MethodGet, "/api/profile", token, ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s device: expected 401, got %d", name, w.Code)
+		}
+	}
+
+	if w := doJSON(router, http.MethodPost, "/api/auth/refresh", `{"refresh_token": "`+firstRefresh+`"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("refresh after logout all: expected 401, got %d", w.Code)
+	}
+}
+
+// TestLoginRehashesPassword - при входе bcrypt хеш заменяется на argon2id и обратно
+func TestLoginRehashesPassword(t *testing.T) {
+	router, userRepo := setupAuthTestRouter()
+	ctx := context.Background()
+
+	// Облегченные параметры argon2id, чтобы тест был быстрым
+	jwt.SetPasswordHasher(jwt.Argon2idHasher{Params: jwt.Argon2Params{
+		Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32,
+	}})
+	t.Cleanup(func() { jwt.SetPasswordHasher(jwt.BcryptHasher{Cost: 10}) })
+
+	storedHash := func() string {
+		user, _ := userRepo.GetUserByID(ctx, 1)
+		return user.PasswordHash
+	}
+	if !strings.HasPrefix(storedHash(), "$2a$") {
+		t.Fatalf("expected legacy bcrypt hash, got %q", storedHash())
+	}
+
+	// 1. Вход с bcrypt хешем работает и пересчитывает хеш
+	loginTokens(t, router)
+	upgraded := storedHash()
+	if !strings.HasPrefix(upgraded, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("expected argon2id hash after login, got %q", upgraded)
+	}
+
+	// 2. Повторный вход проверяет argon2id и не пересчитывает хеш с теми же параметрами
+	loginTokens(t, router)
+	if storedHash() != upgraded {
+		t.Error("hash with current parameters should not be rehashed")
+	}
+	if w := doJSON(router, http.MethodPost, "/api/auth/login", `{"email": "test@example.com", "password": "wrong-password"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong password: expected 401, got %d", w.Code)
+	}
+
+	// 3. Смена алгоритма обратно на bcrypt тоже применяется при входе
+	jwt.SetPasswordHasher(jwt.BcryptHasher{Cost: 10})
+	loginTokens(t, router)
+	if !strings.HasPrefix(storedHash(), "$2a$10$") {
+		t.Errorf("expected bcrypt hash after switching back, got %q", storedHash())
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/service"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// Размер страницы списков по умолчанию и максимальный
+const (
+	defaultPageLimit = 10
+	maxPageLimit     = 100
+)
+
+// AuthorHandler обрабатывает публичные страницы авторов
+type AuthorHandler struct {
+	authorService *service.AuthorService
+	log           *log.Logger
+}
+
+// NewAuthorHandler создает новый AuthorHandler
+func NewAuthorHandler(authorService *service.AuthorService, logger *log.Logger) *AuthorHandler {
+	return &AuthorHandler{
+		authorService: authorService,
+		log:           logger,
+	}
+}
+
+// GetProfile возвращает публичный профиль автора
+// GET /api/users/{username}
+func (h *AuthorHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	profile, err := h.authorService.GetProfile(r.Context(), r.PathValue("username"))
+	if err != nil {
+		h.abortAuthorError(w, r, err, "Failed to get profile")
+		return
+	}
+
+	sendJSONResponse(w, profile, http.StatusOK)
+}
+
+// ListPosts возвращает опубликованные посты автора с пагинацией
+// GET /api/users/{username}/posts?limit=&offset=
+func (h *AuthorHandler) ListPosts(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePagination(r)
+
+	posts, total, err := h.authorService.ListPosts(r.Context(), r.PathValue("username"), limit, offset)
+	if err != nil {
+		h.abortAuthorError(w, r, err, "Failed to list posts")
+		return
+	}
+
+	sendJSONResponse(w, Response{
+		Data:  posts,
+		Total: total,
+	}, http.StatusOK)
+}
+
+// abortAuthorError переводит ошибки AuthorService в HTTP коды
+func (h *AuthorHandler) abortAuthorError(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	if errors.Is(err, service.ErrAuthorNotFound) {
+		middleware.AbortError(w, r, "User not found", http.StatusNotFound, err)
+		return
+	}
+	middleware.AbortError(w, r, fallback, http.StatusInternalServerError, err)
+}
+
+// parsePagination читает limit и offset из query (limit 1-100, по умолчанию 10)
+func parsePagination(r *http.Request) (int, int) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
@@ -0,0 +1,113 @@
+// internal/handlers/author_handler_test.go
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"blog-backend/internal/handlers"
+	"blog-backend/internal/model"
+	"blog-backend/service"
+)
+
+// setupAuthorTestRouter - роутер публичных страниц авторов
+// testuser (ID=1): 3 опубликованных поста и черновик, otheruser (ID=2): 1 пост
+func setupAuthorTestRouter(t *testing.T) http.Handler {
+	t.Helper()
+
+	ctx := context.Background()
+	userRepo := NewMemoryUserRepository()
+	postRepo := NewMemoryPostStorage()
+	userRepo.CreateUser(ctx, "other@example.com", "otheruser", "hash")
+
+	for i, author := range []int{1, 1, 1, 2} {
+		postRepo.CreatePost(ctx, &model.Post{
+			Title:    fmt.Sprintf("Post %d", i+1),
+			Content:  "Content",
+			AuthorID: author,
+			Status:   "published",
+		})
+	}
+	postRepo.CreatePost(ctx, &model.Post{Title: "Draft", Content: "Content", AuthorID: 1, Status: "draft"})
+
+	authorSvc := service.NewAuthorService(userRepo, postRepo, NewMemoryFollowRepository(userRepo))
+	authorHandler := handlers.NewAuthorHandler(authorSvc, log.New(io.Discard, "", 0))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/users/{username}", authorHandler.GetProfile)
+	mux.HandleFunc("GET /api/users/{username}/posts", authorHandler.ListPosts)
+	return mux
+}
+
+// TestAuthorProfile - публичный профиль без email и хеша пароля
+func TestAuthorProfile(t *testing.T) {
+	router := setupAuthorTestRouter(t)
+
+	w := doJSON(router, http.MethodGet, "/api/users/testuser", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	for _, secret := range []string{"test@example.com", "email", "password", "$2a$"} {
+		if strings.Contains(body, secret) {
+			t.Errorf("public profile leaks %q: %s", secret, body)
+		}
+	}
+
+	var profile model.PublicProfile
+	json.Unmarshal([]byte(body), &profile)
+	if profile.Username != "testuser" || profile.PostCount != 3 || profile.JoinedAt.IsZero() {
+		t.Errorf("unexpected profile: %+v", profile)
+	}
+
+	if w := doJSON(router, http.MethodGet, "/api/users/nobody", ""); w.Code != http.StatusNotFound {
+		t.Errorf("unknown user: expected 404, got %d", w.Code)
+	}
+}
+
+// TestAuthorPosts - посты автора с пагинацией (без черновиков и чужих постов)
+func TestAuthorPosts(t *testing.T) {
+	router := setupAuthorTestRouter(t)
+
+	tests := []struct {
+		url       string
+		wantCount int
+		wantTotal int
+	}{
+		{"/api/users/testuser/posts", 3, 3},
+		{"/api/users/testuser/posts?limit=2", 2, 3},
+		{"/api/users/testuser/posts?limit=2&offset=2", 1, 3},
+		{"/api/users/otheruser/posts", 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			w := doJSON(router, http.MethodGet, tt.url, "")
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var resp struct {
+				Data  []*model.Post `json:"data"`
+				Total int           `json:"total"`
+			}
+			json.NewDecoder(w.Body).Decode(&resp)
+			if len(resp.Data) != tt.wantCount || resp.Total != tt.wantTotal {
+				t.Errorf("expected %d posts of %d, got %d of %d", tt.wantCount, tt.wantTotal, len(resp.Data), resp.Total)
+			}
+			for _, post := range resp.Data {
+				if post.Status != "published" {
+					t.Errorf("draft %d listed on author page", post.ID)
+				}
+			}
+		})
+	}
+
+	if w := doJSON(router, http.MethodGet, "/api/users/nobody/posts", ""); w.Code != http.StatusNotFound {
+		t.Errorf("unknown user: expected 404, got %d", w.Code)
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/pkg/auth"
+	"blog-backend/service"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+)
+
+// avatarFormField - имя поля multipart формы с файлом аватара
+const avatarFormField = "avatar"
+
+// AvatarHandler обрабатывает загрузку и удаление аватара
+type AvatarHandler struct {
+	avatarService *service.AvatarService
+	log           *log.Logger
+}
+
+// NewAvatarHandler создает новый AvatarHandler
+func NewAvatarHandler(avatarService *service.AvatarService, logger *log.Logger) *AvatarHandler {
+	return &AvatarHandler{
+		avatarService: avatarService,
+		log:           logger,
+	}
+}
+
+// UploadAvatar загружает аватар (multipart/form-data, поле avatar: JPEG, PNG или WebP)
+// PUT /api/profile/avatar
+func (h *AvatarHandler) UploadAvatar(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	// Запас на заголовки multipart; сам файл ограничивается ниже
+	maxBytes := h.avatarService.MaxBytes()
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+64<<10)
+
+	data, err := readAvatarPart(r, maxBytes)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge), errors.Is(err, service.ErrAvatarTooLarge):
+			middleware.AbortError(w, r, "Avatar file is too large", http.StatusRequestEntityTooLarge, err)
+		default:
+			middleware.AbortError(w, r, "Expected multipart/form-data with an avatar file", http.StatusBadRequest, err)
+		}
+		return
+	}
+
+	user, err := h.avatarService.Upload(r.Context(), userID, data)
+	if err != nil {
+		h.abortAvatarError(w, r, err, "Failed to upload avatar")
+		return
+	}
+
+	sendJSONResponse(w, profileResponse(user), http.StatusOK)
+}
+
+// DeleteAvatar удаляет аватар
+// DELETE /api/profile/avatar
+func (h *AvatarHandler) DeleteAvatar(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	user, err := h.avatarService.Remove(r.Context(), userID)
+	if err != nil {
+		h.abortAvatarError(w, r, err, "Failed to delete avatar")
+		return
+	}
+
+	sendJSONResponse(w, profileResponse(user), http.StatusOK)
+}
+
+// readAvatarPart читает файл из поля avatar потоком, не сохраняя форму во временные файлы
+func readAvatarPart(r *http.Request, maxBytes int64) ([]byte, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, errors.New("avatar field is missing")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() != avatarFormField {
+			part.Close()
+			continue
+		}
+		defer part.Close()
+
+		data, err := io.ReadAll(io.LimitReader(part, maxBytes+1))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(data)) > maxBytes {
+			return nil, service.ErrAvatarTooLarge
+		}
+		return data, nil
+	}
+}
+
+// abortAvatarError переводит ошибки AvatarService в HTTP коды
+func (h *AvatarHandler) abortAvatarError(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrAvatarTooLarge):
+		middleware.AbortError(w, r, "Avatar file is too large", http.StatusRequestEntityTooLarge, err)
+	case errors.Is(err, service.ErrUnsupportedAvatarFormat):
+		middleware.AbortError(w, r, err.Error(), http.StatusUnsupportedMediaType, err)
+	case errors.Is(err, service.ErrInvalidAvatar):
+		middleware.AbortError(w, r, err.Error(), http.StatusBadRequest, err)
+	default:
+		middleware.AbortError(w, r, fallback, http.StatusInternalServerError, err)
+	}
+}
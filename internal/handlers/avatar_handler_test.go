@@ -0,0 +1,191 @@
+// internal/handlers/avatar_handler_test.go
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"blog-backend/internal/model"
+)
+
+// tinyWebP - корректный WebP 1x1 (lossless)
+const tinyWebP = "UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA=="
+
+// doAvatarUpload - загружает файл в поле field multipart формы
+func doAvatarUpload(router http.Handler, token, field string, data []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile(field, "avatar.bin")
+	part.Write(data)
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPut, "/api/profile/avatar", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// testPNG - PNG width x height с полупрозрачной левой половиной
+func testPNG(width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			alpha := uint8(255)
+			if x < width/2 {
+				alpha = 0
+			}
+			img.Set(x, y, color.NRGBA{R: 200, G: 30, B: 30, A: alpha})
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// fetchAvatar - скачивает копию аватара по ссылке из профиля и декодирует ее
+func fetchAvatar(t *testing.T, router http.Handler, link string) (image.Image, int) {
+	t.Helper()
+
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("invalid avatar url %q: %v", link, err)
+	}
+	w := doJSON(router, http.MethodGet, parsed.RequestURI(), "")
+	if w.Code != http.StatusOK {
+		return nil, w.Code
+	}
+	img, err := jpeg.Decode(w.Body)
+	if err != nil {
+		t.Fatalf("avatar is not a JPEG: %v", err)
+	}
+	return img, w.Code
+}
+
+// avatarProfile - ссылки на аватар из ответа профиля
+type avatarProfile struct {
+	AvatarURL      *string `json:"avatar_url"`
+	AvatarSmallURL *string `json:"avatar_small_url"`
+}
+
+// TestUploadAvatar - загрузка, обрезка до квадрата, уменьшение и удаление аватара
+func TestUploadAvatar(t *testing.T) {
+	router, _, _ := setupAccountTestRouter(t, model.AccountDeletionAnonymize)
+	access, _ := loginTokens(t, router)
+
+	w := doAvatarUpload(router, access, "avatar", testPNG(600, 400))
+	if w.Code != http.StatusOK {
+		t.Fatalf("upload: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var profile avatarProfile
+	json.NewDecoder(w.Body).Decode(&profile)
+	if profile.AvatarURL == nil || profile.AvatarSmallURL == nil ||
+		!strings.HasPrefix(*profile.AvatarURL, "http://blog.test/uploads/avatars/1/256.jpg?v=") {
+		t.Fatalf("unexpected avatar urls: %+v", profile)
+	}
+
+	// Обе копии квадратные, прозрачность залита белым
+	for link, size := range map[string]int{*profile.AvatarURL: 256, *profile.AvatarSmallURL: 64} {
+		img, code := fetchAvatar(t, router, link)
+		if code != http.StatusOK {
+			t.Fatalf("fetch %s: expected 200, got %d", link, code)
+		}
+		if b := img.Bounds(); b.Dx() != size || b.Dy() != size {
+			t.Errorf("expected %dx%d, got %dx%d", size, size, b.Dx(), b.Dy())
+		}
+		if r, g, b, _ := img.At(2, size/2).RGBA(); r>>8 < 240 || g>>8 < 240 || b>>8 < 240 {
+			t.Errorf("expected transparent area to become white, got %d,%d,%d", r>>8, g>>8, b>>8)
+		}
+	}
+
+	// Ссылка на аватар есть в профиле
+	w = doAuthJSON(router, http.MethodGet, "/api/profile", access, "")
+	var current avatarProfile
+	json.NewDecoder(w.Body).Decode(&current)
+	if current.AvatarURL == nil || *current.AvatarURL != *profile.AvatarURL {
+		t.Errorf("expected avatar in profile, got %+v", current)
+	}
+
+	// Удаление убирает ссылки и файлы
+	w = doAuthJSON(router, http.MethodDelete, "/api/profile/avatar", access, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	json.NewDecoder(w.Body).Decode(&current)
+	if current.AvatarURL != nil || current.AvatarSmallURL != nil {
+		t.Errorf("expected avatar to be removed, got %+v", current)
+	}
+	if _, code := fetchAvatar(t, router, *profile.AvatarURL); code != http.StatusNotFound {
+		t.Errorf("expected avatar file to be deleted, got %d", code)
+	}
+}
+
+// TestUploadAvatarValidation - формат проверяется по содержимому, размеры ограничены
+func TestUploadAvatarValidation(t *testing.T) {
+	router, _, _ := setupAccountTestRouter(t, model.AccountDeletionAnonymize)
+	access, _ := loginTokens(t, router)
+
+	webp, _ := base64.StdEncoding.DecodeString(tinyWebP)
+	truncated := testPNG(200, 200)[:100]
+
+	cases := []struct {
+		name  string
+		field string
+		data  []byte
+		want  int
+	}{
+		{"text file", "avatar", []byte("<svg xmlns='http://www.w3.org/2000/svg'></svg>"), http.StatusUnsupportedMediaType},
+		{"too small", "avatar", testPNG(20, 20), http.StatusBadRequest},
+		{"webp too small", "avatar", webp, http.StatusBadRequest},
+		{"truncated png", "avatar", truncated, http.StatusBadRequest},
+		{"wrong field", "file", testPNG(100, 100), http.StatusBadRequest},
+		{"too large", "avatar", append(testPNG(100, 100), make([]byte, 6<<20)...), http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if w := doAvatarUpload(router, access, tc.field, tc.data); w.Code != tc.want {
+				t.Errorf("expected %d, got %d: %s", tc.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	if w := doAuthJSON(router, http.MethodPut, "/api/profile/avatar", access, `{"avatar": "x"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("json body: expected 400, got %d", w.Code)
+	}
+	if w := doAvatarUpload(router, "", "avatar", testPNG(100, 100)); w.Code != http.StatusUnauthorized {
+		t.Errorf("unauthenticated: expected 401, got %d", w.Code)
+	}
+}
+
+// TestDeleteAccountRemovesAvatar - файлы аватара удаляются вместе с аккаунтом
+func TestDeleteAccountRemovesAvatar(t *testing.T) {
+	router, _, _ := setupAccountTestRouter(t, model.AccountDeletionAnonymize)
+	access, _ := loginTokens(t, router)
+
+	w := doAvatarUpload(router, access, "avatar", testPNG(100, 100))
+	var profile avatarProfile
+	json.NewDecoder(w.Body).Decode(&profile)
+	if profile.AvatarURL == nil {
+		t.Fatalf("upload failed: %d %s", w.Code, w.Body.String())
+	}
+
+	if w := doAuthJSON(router, http.MethodDelete, "/api/profile", access, `{"password": "password123"}`); w.Code != http.StatusNoContent {
+		t.Fatalf("delete account: expected 204, got %d", w.Code)
+	}
+	if _, code := fetchAvatar(t, router, *profile.AvatarURL); code != http.StatusNotFound {
+		t.Errorf("expected avatar file to be deleted with account, got %d", code)
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/pkg/auth"
+	"blog-backend/service"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// BlockHandler обрабатывает блокировку и скрытие (mute) пользователей
+type BlockHandler struct {
+	blockService *service.BlockService
+	log          *log.Logger
+}
+
+// NewBlockHandler создает новый BlockHandler
+func NewBlockHandler(blockService *service.BlockService, logger *log.Logger) *BlockHandler {
+	return &BlockHandler{
+		blockService: blockService,
+		log:          logger,
+	}
+}
+
+// Block блокирует пользователя: он не сможет комментировать посты и подписываться
+// POST /api/users/{id}/block
+func (h *BlockHandler) Block(w http.ResponseWriter, r *http.Request) {
+	h.changeRelation(w, r, h.blockService.Block, "User blocked", "Failed to block user")
+}
+
+// Unblock снимает блокировку
+// DELETE /api/users/{id}/block
+func (h *BlockHandler) Unblock(w http.ResponseWriter, r *http.Request) {
+	h.changeRelation(w, r, h.blockService.Unblock, "User unblocked", "Failed to unblock user")
+}
+
+// Mute скрывает посты и комментарии пользователя из ленты и списков комментариев
+// POST /api/users/{id}/mute
+func (h *BlockHandler) Mute(w http.ResponseWriter, r *http.Request) {
+	h.changeRelation(w, r, h.blockService.Mute, "User muted", "Failed to mute user")
+}
+
+// Unmute снова показывает посты и комментарии пользователя
+// DELETE /api/users/{id}/mute
+func (h *BlockHandler) Unmute(w http.ResponseWriter, r *http.Request) {
+	h.changeRelation(w, r, h.blockService.Unmute, "User unmuted", "Failed to unmute user")
+}
+
+// ListBlocked возвращает заблокированных текущим пользователем
+// GET /api/profile/blocks
+func (h *BlockHandler) ListBlocked(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	users, err := h.blockService.ListBlocked(r.Context(), userID)
+	if err != nil {
+		middleware.AbortError(w, r, "Failed to list blocked users", http.StatusInternalServerError, err)
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"blocked": users,
+	}, http.StatusOK)
+}
+
+// ListMuted возвращает скрытых текущим пользователем
+// GET /api/profile/mutes
+func (h *BlockHandler) ListMuted(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	users, err := h.blockService.ListMuted(r.Context(), userID)
+	if err != nil {
+		middleware.AbortError(w, r, "Failed to list muted users", http.StatusInternalServerError, err)
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"muted": users,
+	}, http.StatusOK)
+}
+
+// changeRelation разбирает {id} и выполняет действие текущего пользователя над ним
+func (h *BlockHandler) changeRelation(
+	w http.ResponseWriter,
+	r *http.Request,
+	action func(ctx context.Context, userID, targetID int) error,
+	message, fallback string,
+) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+	targetID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		middleware.AbortError(w, r, "Invalid user ID", http.StatusBadRequest, err)
+		return
+	}
+
+	if err := action(r.Context(), userID, targetID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrCannotRestrictSelf):
+			middleware.AbortError(w, r, "You cannot block or mute yourself", http.StatusBadRequest, err)
+		case errors.Is(err, service.ErrAuthorNotFound):
+			middleware.AbortError(w, r, "User not found", http.StatusNotFound, err)
+		default:
+			middleware.AbortError(w, r, fallback, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"message": message,
+	}, http.StatusOK)
+}
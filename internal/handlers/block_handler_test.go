@@ -0,0 +1,371 @@
+// internal/handlers/block_handler_test.go
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"blog-backend/internal/handlers"
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+	"blog-backend/pkg/jwt"
+	"blog-backend/service"
+)
+
+// restriction - блокировка или скрытие в MemoryBlockRepository
+type restriction struct {
+	userID    int
+	targetID  int
+	createdAt time.Time
+}
+
+// MemoryBlockRepository — in-memory хранилище блокировок и скрытых пользователей
+type MemoryBlockRepository struct {
+	blocks   []restriction
+	mutes    []restriction
+	userRepo repository.UserRepository // имена для списков
+	mu       sync.RWMutex
+}
+
+// NewMemoryBlockRepository создает пустое хранилище блокировок
+func NewMemoryBlockRepository(userRepo repository.UserRepository) *MemoryBlockRepository {
+	return &MemoryBlockRepository{userRepo: userRepo}
+}
+
+// Block блокирует пользователя (повторная блокировка не ошибка)
+func (r *MemoryBlockRepository) Block(ctx context.Context, blockerID, blockedID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.blocks = addRestriction(r.blocks, blockerID, blockedID)
+	return nil
+}
+
+// Unblock снимает блокировку
+func (r *MemoryBlockRepository) Unblock(ctx context.Context, blockerID, blockedID int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var removed bool
+	r.blocks, removed = removeRestriction(r.blocks, blockerID, blockedID)
+	return removed, nil
+}
+
+// IsBlocked проверяет, заблокировал ли blockerID пользователя userID
+func (r *MemoryBlockRepository) IsBlocked(ctx context.Context, blockerID, userID int) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, b := range r.blocks {
+		if b.userID == blockerID && b.targetID == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ListBlocked возвращает заблокированных (новые первыми)
+func (r *MemoryBlockRepository) ListBlocked(ctx context.Context, blockerID int) ([]*model.RestrictedUser, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.list(ctx, r.blocks, blockerID), nil
+}
+
+// Mute скрывает пользователя (повтор не ошибка)
+func (r *MemoryBlockRepository) Mute(ctx context.Context, muterID, mutedID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.mutes = addRestriction(r.mutes, muterID, mutedID)
+	return nil
+}
+
+// Unmute снова показывает пользователя
+func (r *MemoryBlockRepository) Unmute(ctx context.Context, muterID, mutedID int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var removed bool
+	r.mutes, removed = removeRestriction(r.mutes, muterID, mutedID)
+	return removed, nil
+}
+
+// ListMuted возвращает скрытых (новые первыми)
+func (r *MemoryBlockRepository) ListMuted(ctx context.Context, muterID int) ([]*model.RestrictedUser, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.list(ctx, r.mutes, muterID), nil
+}
+
+// muted возвращает множество скрытых пользователем авторов (для ленты и комментариев)
+func (r *MemoryBlockRepository) muted(muterID int) map[int]bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make(map[int]bool)
+	for _, m := range r.mutes {
+		if m.userID == muterID {
+			ids[m.targetID] = true
+		}
+	}
+	return ids
+}
+
+// list собирает пользователей из блокировок или скрытий userID
+func (r *MemoryBlockRepository) list(ctx context.Context, items []restriction, userID int) []*model.RestrictedUser {
+	var users []*model.RestrictedUser
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].userID != userID {
+			continue
+		}
+		user, err := r.userRepo.GetUserByID(ctx, items[i].targetID)
+		if err != nil || user == nil {
+			continue
+		}
+		users = append(users, &model.RestrictedUser{ID: user.ID, Username: user.Username, Since: items[i].createdAt})
+	}
+	return users
+}
+
+// addRestriction добавляет запись, если ее еще нет
+func addRestriction(items []restriction, userID, targetID int) []restriction {
+	for _, item := range items {
+		if item.userID == userID && item.targetID == targetID {
+			return items
+		}
+	}
+	return append(items, restriction{userID, targetID, time.Now()})
+}
+
+// removeRestriction удаляет запись, false — ее не было
+func removeRestriction(items []restriction, userID, targetID int) ([]restriction, bool) {
+	for i, item := range items {
+		if item.userID == userID && item.targetID == targetID {
+			return append(items[:i], items[i+1:]...), true
+		}
+	}
+	return items, false
+}
+
+var _ repository.BlockRepository = (*MemoryBlockRepository)(nil)
+
+// setupBlockTestRouter - роутер блокировок, подписок, ленты и комментариев.
+// testuser (ID=1) и alice (ID=2, пароль password123) — авторы, bob (ID=3) комментирует.
+// Пост 1 — alice, пост 2 — bob, на посту 1 есть комментарий bob
+func setupBlockTestRouter(t *testing.T) http.Handler {
+	t.Helper()
+
+	ctx := context.Background()
+	userRepo := NewMemoryUserRepository()
+	hash, _ := jwt.HashPassword("password123")
+	userRepo.CreateUser(ctx, "alice@example.com", "alice", hash)
+	userRepo.CreateUser(ctx, "bob@example.com", "bob", "hash")
+
+	blockRepo := NewMemoryBlockRepository(userRepo)
+	followRepo := NewMemoryFollowRepository(userRepo)
+	postRepo := NewMemoryPostStorage().(*MemoryPostStorage)
+	postRepo.follows = followRepo
+	postRepo.mutes = blockRepo
+	commentRepo := NewMemoryCommentRepository().(*MemoryCommentRepository)
+	commentRepo.mutes = blockRepo
+
+	postRepo.CreatePost(ctx, &model.Post{Title: "Пост alice", Content: "Текст", AuthorID: 2, Status: "published"})
+	postRepo.CreatePost(ctx, &model.Post{Title: "Пост bob", Content: "Текст", AuthorID: 3, Status: "published"})
+	commentRepo.Create(ctx, &model.Comment{PostID: 1, AuthorID: 3, Content: "Комментарий bob"})
+
+	userSvc, revocations := newTestUserService(userRepo)
+	followSvc := service.NewFollowService(followRepo, blockRepo, userRepo, postRepo)
+	blockSvc := service.NewBlockService(blockRepo, followRepo, userRepo)
+	commentSvc := service.NewCommentService(postRepo, commentRepo, userRepo, blockRepo, NewTestConfig())
+
+	logger := log.New(io.Discard, "", 0)
+	userHandler := handlers.NewUserHandler(userSvc, logger)
+	followHandler := handlers.NewFollowHandler(followSvc, logger)
+	blockHandler := handlers.NewBlockHandler(blockSvc, logger)
+	commentHandler := handlers.NewCommentHandler(commentSvc)
+	authenticator := middleware.NewAuthenticator(revocations, nil)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/auth/login", userHandler.LoginHandler)
+	mux.HandleFunc("GET /api/users/{username}/following", followHandler.ListFollowing)
+	mux.HandleFunc("POST /api/users/{id}/follow", authenticator.AuthMiddleware(followHandler.Follow))
+	mux.HandleFunc("GET /api/feed", authenticator.AuthMiddleware(followHandler.Feed))
+	mux.HandleFunc("POST /api/users/{id}/block", authenticator.AuthMiddleware(blockHandler.Block))
+	mux.HandleFunc("DELETE /api/users/{id}/block", authenticator.AuthMiddleware(blockHandler.Unblock))
+	mux.HandleFunc("POST /api/users/{id}/mute", authenticator.AuthMiddleware(blockHandler.Mute))
+	mux.HandleFunc("DELETE /api/users/{id}/mute", authenticator.AuthMiddleware(blockHandler.Unmute))
+	mux.HandleFunc("GET /api/profile/blocks", authenticator.AuthMiddleware(blockHandler.ListBlocked))
+	mux.HandleFunc("GET /api/profile/mutes", authenticator.AuthMiddleware(blockHandler.ListMuted))
+	mux.HandleFunc("POST /api/posts/{postId}/comments", authenticator.AuthMiddleware(commentHandler.CreateComment))
+	mux.HandleFunc("GET /api/posts/{postId}/comments", authenticator.OptionalAuthMiddleware(commentHandler.GetComments))
+	return mux
+}
+
+// loginAlice - входит как alice и возвращает access токен
+func loginAlice(t *testing.T, router http.Handler) string {
+	t.Helper()
+
+	w := doJSON(router, http.MethodPost, "/api/auth/login", `{"email": "alice@example.com", "password": "password123"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("alice login failed: %d %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Token string `json:"token"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	return resp.Token
+}
+
+// TestBlock - заблокированный не может подписаться и комментировать, подписки разрываются
+func TestBlock(t *testing.T) {
+	router := setupBlockTestRouter(t)
+	token, _ := loginTokens(t, router)
+	aliceToken := loginAlice(t, router)
+
+	if w := doAuthJSON(router, http.MethodPost, "/api/users/2/follow", token, ""); w.Code != http.StatusOK {
+		t.Fatalf("follow failed: %d %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		token          string
+		expectedStatus int
+	}{
+		{"alice_blocks_testuser", http.MethodPost, "/api/users/1/block", aliceToken, http.StatusOK},
+		{"block_again", http.MethodPost, "/api/users/1/block", aliceToken, http.StatusOK},
+		{"block_self", http.MethodPost, "/api/users/2/block", aliceToken, http.StatusBadRequest},
+		{"block_unknown", http.MethodPost, "/api/users/99/block", aliceToken, http.StatusNotFound},
+		{"block_invalid_id", http.MethodPost, "/api/users/abc/block", aliceToken, http.StatusBadRequest},
+		{"follow_blocker", http.MethodPost, "/api/users/2/follow", token, http.StatusForbidden},
+		{"block_unauthenticated", http.MethodPost, "/api/users/1/block", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := doAuthJSON(router, tt.method, tt.url, tt.token, ""); w.Code != tt.expectedStatus {
+				t.Errorf("expected %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// Блокировка разорвала подписку testuser на alice
+	w := doJSON(router, http.MethodGet, "/api/users/testuser/following", "")
+	var following handlers.Response
+	json.NewDecoder(w.Body).Decode(&following)
+	if following.Total != 0 {
+		t.Errorf("expected follow to be removed by block, got total=%d", following.Total)
+	}
+
+	// Комментировать посты alice нельзя, посты других авторов — можно
+	if w := doAuthJSON(router, http.MethodPost, "/api/posts/1/comments", token, `{"content": "Привет"}`); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 commenting on blocker's post, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doAuthJSON(router, http.MethodPost, "/api/posts/2/comments", token, `{"content": "Привет"}`); w.Code != http.StatusCreated {
+		t.Errorf("expected 201 commenting on other post, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doAuthJSON(router, http.MethodGet, "/api/profile/blocks", aliceToken, "")
+	var blocks struct {
+		Blocked []model.RestrictedUser `json:"blocked"`
+	}
+	json.NewDecoder(w.Body).Decode(&blocks)
+	if len(blocks.Blocked) != 1 || blocks.Blocked[0].Username != "testuser" {
+		t.Errorf("expected testuser in block list, got %+v", blocks.Blocked)
+	}
+
+	// После снятия блокировки все снова разрешено
+	if w := doAuthJSON(router, http.MethodDelete, "/api/users/1/block", aliceToken, ""); w.Code != http.StatusOK {
+		t.Fatalf("unblock failed: %d %s", w.Code, w.Body.String())
+	}
+	if w := doAuthJSON(router, http.MethodPost, "/api/users/2/follow", token, ""); w.Code != http.StatusOK {
+		t.Errorf("expected follow after unblock, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doAuthJSON(router, http.MethodPost, "/api/posts/1/comments", token, `{"content": "Привет"}`); w.Code != http.StatusCreated {
+		t.Errorf("expected comment after unblock, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestMute - скрытый автор пропадает из ленты и комментариев того, кто скрыл
+func TestMute(t *testing.T) {
+	router := setupBlockTestRouter(t)
+	token, _ := loginTokens(t, router)
+
+	doAuthJSON(router, http.MethodPost, "/api/users/2/follow", token, "")
+	doAuthJSON(router, http.MethodPost, "/api/users/3/follow", token, "")
+
+	feedAuthors := func() map[int]bool {
+		w := doAuthJSON(router, http.MethodGet, "/api/feed", token, "")
+		var feed struct {
+			Data []model.Post `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&feed)
+		authors := make(map[int]bool)
+		for _, post := range feed.Data {
+			authors[post.AuthorID] = true
+		}
+		return authors
+	}
+	commentCount := func(token string) int {
+		w := doAuthJSON(router, http.MethodGet, "/api/posts/1/comments", token, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("get comments failed: %d %s", w.Code, w.Body.String())
+		}
+		var comments []model.Comment
+		json.NewDecoder(w.Body).Decode(&comments)
+		return len(comments)
+	}
+
+	if authors := feedAuthors(); !authors[2] || !authors[3] {
+		t.Fatalf("expected both authors in feed, got %v", authors)
+	}
+
+	if w := doAuthJSON(router, http.MethodPost, "/api/users/3/mute", token, ""); w.Code != http.StatusOK {
+		t.Fatalf("mute failed: %d %s", w.Code, w.Body.String())
+	}
+	if w := doAuthJSON(router, http.MethodPost, "/api/users/1/mute", token, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 muting self, got %d", w.Code)
+	}
+
+	if authors := feedAuthors(); !authors[2] || authors[3] {
+		t.Errorf("expected only alice in feed after mute, got %v", authors)
+	}
+	if n := commentCount(token); n != 0 {
+		t.Errorf("expected muted comment to be hidden, got %d comments", n)
+	}
+	// Анонимный читатель видит все комментарии, неверный токен по-прежнему 401
+	if n := commentCount(""); n != 1 {
+		t.Errorf("expected anonymous viewer to see 1 comment, got %d", n)
+	}
+	if w := doAuthJSON(router, http.MethodGet, "/api/posts/1/comments", "invalid", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for invalid token, got %d", w.Code)
+	}
+
+	w := doAuthJSON(router, http.MethodGet, "/api/profile/mutes", token, "")
+	var mutes struct {
+		Muted []model.RestrictedUser `json:"muted"`
+	}
+	json.NewDecoder(w.Body).Decode(&mutes)
+	if len(mutes.Muted) != 1 || mutes.Muted[0].Username != "bob" {
+		t.Errorf("expected bob in mute list, got %+v", mutes.Muted)
+	}
+
+	if w := doAuthJSON(router, http.MethodDelete, "/api/users/3/mute", token, ""); w.Code != http.StatusOK {
+		t.Fatalf("unmute failed: %d %s", w.Code, w.Body.String())
+	}
+	if authors := feedAuthors(); !authors[3] {
+		t.Errorf("expected bob back in feed after unmute, got %v", authors)
+	}
+	if n := commentCount(token); n != 1 {
+		t.Errorf("expected comment visible after unmute, got %d", n)
+	}
+}
@@ -3,8 +3,10 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"blog-backend/internal/handlers/middleware"
 	"blog-backend/service"
@@ -57,6 +59,18 @@ func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
 
 	comment, err := h.commentSvc.CreateComment(r.Context(), userID, postID, req.Content)
 	if err != nil {
+		if errors.Is(err, service.ErrPermissionDenied) {
+			middleware.AbortError(w, r, "Permission denied", http.StatusForbidden, err)
+			return
+		}
+		if errors.Is(err, service.ErrEmailNotVerified) {
+			middleware.AbortError(w, r, "Email not verified", http.StatusForbidden, err)
+			return
+		}
+		if errors.Is(err, service.ErrBlocked) {
+			middleware.AbortError(w, r, "You have been blocked by the post author", http.StatusForbidden, err)
+			return
+		}
 		middleware.AbortError(w, r, "Failed to create comment", http.StatusInternalServerError, err)
 		return
 	}
@@ -69,6 +83,43 @@ func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// DELETE /api/posts/{postId}/comments/{commentId}
+// Удалить может автор комментария или editor/admin (модерация)
+func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(r.PathValue("postId"))
+	if err != nil {
+		middleware.AbortError(w, r, "Invalid post ID", http.StatusBadRequest, err)
+		return
+	}
+	commentID, err := strconv.Atoi(r.PathValue("commentId"))
+	if err != nil {
+		middleware.AbortError(w, r, "Invalid comment ID", http.StatusBadRequest, err)
+		return
+	}
+
+	// userID из JWT middleware
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	if err := h.commentSvc.DeleteComment(r.Context(), userID, postID, commentID); err != nil {
+		switch {
+		case strings.Contains(err.Error(), "comment not found"):
+			middleware.AbortError(w, r, "Comment not found", http.StatusNotFound, err)
+		case errors.Is(err, service.ErrPermissionDenied):
+			middleware.AbortError(w, r, "Permission denied", http.StatusForbidden, err)
+		default:
+			middleware.AbortError(w, r, "Failed to delete comment", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	// 204 No Content для DELETE
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // GET /api/posts/{postId}/comments
 func (h *CommentHandler) GetComments(w http.ResponseWriter, r *http.Request) {
 	// postId автоматически из пути
@@ -79,7 +130,10 @@ func (h *CommentHandler) GetComments(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	comments, err := h.commentSvc.GetCommentsByPostID(r.Context(), postID)
+	// Вход необязателен: вошедшему пользователю не показываются комментарии скрытых им авторов
+	viewerID, _ := r.Context().Value("userID").(int)
+
+	comments, err := h.commentSvc.GetCommentsByPostID(r.Context(), postID, viewerID)
 	if err != nil {
 		middleware.AbortError(w, r, "Failed to get comments", http.StatusInternalServerError, err)
 		return
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/pkg/auth"
+	"blog-backend/service"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ExportHandler обрабатывает выгрузку персональных данных
+type ExportHandler struct {
+	exportService *service.DataExportService
+	log           *log.Logger
+}
+
+// NewExportHandler создает новый ExportHandler
+func NewExportHandler(exportService *service.DataExportService, logger *log.Logger) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+		log:           logger,
+	}
+}
+
+// RequestExport запускает сборку архива с данными пользователя
+// POST /api/profile/export
+func (h *ExportHandler) RequestExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	export, err := h.exportService.RequestExport(r.Context(), userID)
+	if err != nil {
+		h.abortExportError(w, r, err, "Failed to start data export")
+		return
+	}
+
+	statusURL := fmt.Sprintf("/api/profile/export/%d", export.ID)
+	w.Header().Set("Location", statusURL)
+	sendJSONResponse(w, map[string]interface{}{
+		"message":    "Export started, the download link will be sent by email",
+		"export":     export,
+		"status_url": statusURL,
+	}, http.StatusAccepted)
+}
+
+// GetExport возвращает статус выгрузки и ссылку на скачивание, когда архив готов
+// GET /api/profile/export/{id}
+func (h *ExportHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+	exportID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		middleware.AbortError(w, r, "Invalid export ID", http.StatusBadRequest, err)
+		return
+	}
+
+	export, downloadURL, err := h.exportService.GetExport(r.Context(), userID, exportID)
+	if err != nil {
+		h.abortExportError(w, r, err, "Failed to get data export")
+		return
+	}
+
+	response := map[string]interface{}{
+		"export": export,
+	}
+	if downloadURL != "" {
+		response["download_url"] = downloadURL
+	}
+	sendJSONResponse(w, response, http.StatusOK)
+}
+
+// Download отдает ZIP архив по подписанной ссылке (вход не нужен, ссылка истекает)
+// GET /api/exports/download?token=
+func (h *ExportHandler) Download(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		middleware.AbortError(w, r, "token is required", http.StatusBadRequest, nil)
+		return
+	}
+
+	export, file, err := h.exportService.OpenDownload(r.Context(), token)
+	if err != nil {
+		h.abortExportError(w, r, err, "Failed to download data export")
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="blog-export-%d.zip"`, export.ID))
+	w.Header().Set("Cache-Control", "no-store")
+	var modTime time.Time
+	if export.CompletedAt != nil {
+		modTime = *export.CompletedAt
+	}
+	http.ServeContent(w, r, "", modTime, file)
+}
+
+// abortExportError переводит ошибки DataExportService в HTTP коды
+func (h *ExportHandler) abortExportError(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrExportInProgress):
+		middleware.AbortError(w, r, "Export already in progress", http.StatusConflict, err)
+	case errors.Is(err, service.ErrExportNotFound):
+		middleware.AbortError(w, r, "Export not found", http.StatusNotFound, err)
+	case errors.Is(err, service.ErrInvalidExportToken):
+		middleware.AbortError(w, r, "Invalid or expired download link", http.StatusGone, err)
+	default:
+		middleware.AbortError(w, r, fallback, http.StatusInternalServerError, err)
+	}
+}
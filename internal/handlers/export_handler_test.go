@@ -0,0 +1,475 @@
+// internal/handlers/export_handler_test.go
+package handlers_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"blog-backend/internal/handlers"
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+	"blog-backend/internal/repository/memory"
+	"blog-backend/service"
+)
+
+// MemoryCommentRepository — in-memory хранилище комментариев
+type MemoryCommentRepository struct {
+	comments []*model.Comment
+	mu       sync.Mutex
+	nextID   int
+	mutes    *MemoryBlockRepository // скрытые авторы для GetVisibleByPostID (nil = никто не скрыт)
+}
+
+// NewMemoryCommentRepository создает пустое хранилище комментариев
+func NewMemoryCommentRepository() repository.CommentRepository {
+	return &MemoryCommentRepository{nextID: 1}
+}
+
+// Create сохраняет комментарий с уникальным ID
+func (r *MemoryCommentRepository) Create(ctx context.Context, comment *model.Comment) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	comment.ID = r.nextID
+	comment.CreatedAt = time.Now()
+	r.comments = append(r.comments, comment)
+	r.nextID++
+	return comment.ID, nil
+}
+
+// GetByID возвращает комментарий по ID
+func (r *MemoryCommentRepository) GetByID(ctx context.Context, id int) (*model.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, comment := range r.comments {
+		if comment.ID == id {
+			return comment, nil
+		}
+	}
+	return nil, fmt.Errorf("comment not found")
+}
+
+// GetByPostID возвращает комментарии поста
+func (r *MemoryCommentRepository) GetByPostID(ctx context.Context, postID int) ([]*model.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []*model.Comment
+	for _, comment := range r.comments {
+		if comment.PostID == postID {
+			result = append(result, comment)
+		}
+	}
+	return result, nil
+}
+
+// GetVisibleByPostID возвращает комментарии поста без скрытых зрителем авторов
+func (r *MemoryCommentRepository) GetVisibleByPostID(ctx context.Context, postID, viewerID int) ([]*model.Comment, error) {
+	muted := map[int]bool{}
+	if r.mutes != nil {
+		muted = r.mutes.muted(viewerID)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []*model.Comment
+	for _, comment := range r.comments {
+		if comment.PostID == postID && !muted[comment.AuthorID] {
+			result = append(result, comment)
+		}
+	}
+	return result, nil
+}
+
+// GetByAuthorID возвращает комментарии пользователя
+func (r *MemoryCommentRepository) GetByAuthorID(ctx context.Context, authorID int) ([]*model.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []*model.Comment
+	for _, comment := range r.comments {
+		if comment.AuthorID == authorID {
+			result = append(result, comment)
+		}
+	}
+	return result, nil
+}
+
+// Delete удаляет комментарий по ID
+func (r *MemoryCommentRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, comment := range r.comments {
+		if comment.ID == id {
+			r.comments = append(r.comments[:i], r.comments[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("comment not found")
+}
+
+// MemoryDataExportRepository — in-memory хранилище выгрузок данных
+type MemoryDataExportRepository struct {
+	exports     map[int]*model.DataExport
+	mu          sync.Mutex
+	nextID      int
+	staleLatest bool // GetLatestForUser ничего не находит — как проверка, выполненная до параллельного запроса
+}
+
+// NewMemoryDataExportRepository создает пустое хранилище выгрузок
+func NewMemoryDataExportRepository() *MemoryDataExportRepository {
+	return &MemoryDataExportRepository{
+		exports: make(map[int]*model.DataExport),
+		nextID:  1,
+	}
+}
+
+// Create сохраняет выгрузку с уникальным ID (вторая pending выгрузка пользователя — ошибка, как индекс в БД)
+func (r *MemoryDataExportRepository) Create(ctx context.Context, export *model.DataExport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.exports {
+		if existing.UserID == export.UserID && existing.Status == model.ExportStatusPending {
+			return repository.ErrExportPending
+		}
+	}
+
+	export.ID = r.nextID
+	export.CreatedAt = time.Now()
+	copied := *export
+	r.exports[export.ID] = &copied
+	r.nextID++
+	return nil
+}
+
+// GetByID возвращает копию выгрузки (nil, если не найдена)
+func (r *MemoryDataExportRepository) GetByID(ctx context.Context, id int) (*model.DataExport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	export, exists := r.exports[id]
+	if !exists {
+		return nil, nil
+	}
+	copied := *export
+	return &copied, nil
+}
+
+// GetLatestForUser возвращает последнюю выгрузку пользователя
+func (r *MemoryDataExportRepository) GetLatestForUser(ctx context.Context, userID int) (*model.DataExport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var latest *model.DataExport
+	for _, export := range r.exports {
+		if r.staleLatest {
+			break
+		}
+		if export.UserID == userID && (latest == nil || export.ID > latest.ID) {
+			latest = export
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+	copied := *latest
+	return &copied, nil
+}
+
+// MarkReady сохраняет путь к готовому архиву
+func (r *MemoryDataExportRepository) MarkReady(ctx context.Context, id int, filePath string, sizeBytes int64, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	export := r.exports[id]
+	export.Status = model.ExportStatusReady
+	export.FilePath = filePath
+	export.SizeBytes = sizeBytes
+	export.CompletedAt = &now
+	export.ExpiresAt = &expiresAt
+	return nil
+}
+
+// MarkFailed помечает выгрузку неудачной
+func (r *MemoryDataExportRepository) MarkFailed(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.exports[id].Status = model.ExportStatusFailed
+	r.exports[id].CompletedAt = &now
+	return nil
+}
+
+// DeleteExpired удаляет выгрузки с истекшим сроком
+func (r *MemoryDataExportRepository) DeleteExpired(ctx context.Context, now time.Time) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var paths []string
+	for id, export := range r.exports {
+		if export.ExpiresAt != nil && export.ExpiresAt.Before(now) {
+			paths = append(paths, export.FilePath)
+			delete(r.exports, id)
+		}
+	}
+	return paths, nil
+}
+
+// expire переводит срок действия выгрузки в прошлое
+func (r *MemoryDataExportRepository) expire(id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	past := time.Now().Add(-time.Minute)
+	r.exports[id].ExpiresAt = &past
+}
+
+// stall переводит время создания выгрузки в прошлое (зависшая сборка)
+func (r *MemoryDataExportRepository) stall(id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.exports[id].CreatedAt = time.Now().Add(-time.Hour)
+}
+
+// status возвращает текущий статус выгрузки
+func (r *MemoryDataExportRepository) status(id int) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.exports[id].Status
+}
+
+var _ repository.CommentRepository = (*MemoryCommentRepository)(nil)
+var _ repository.DataExportRepository = (*MemoryDataExportRepository)(nil)
+
+// setupExportTestRouter - роутер со входом и выгрузкой данных.
+// У testuser (ID=1) опубликованный пост, черновик, комментарий и неудачный вход
+func setupExportTestRouter(t *testing.T) (http.Handler, *MemoryDataExportRepository, *RecordingMailer) {
+	t.Helper()
+
+	ctx := context.Background()
+	userRepo := NewMemoryUserRepository()
+	postRepo := NewMemoryPostStorage()
+	commentRepo := NewMemoryCommentRepository()
+	exportRepo := NewMemoryDataExportRepository()
+
+	cfg := NewTestConfig()
+	cfg.AppBaseURL = "http://blog.test"
+	cfg.ExportDir = t.TempDir()
+	cfg.ExportTTL = time.Hour
+
+	postRepo.CreatePost(ctx, &model.Post{Title: "Первый пост", Content: "Привет, мир", AuthorID: 1, Status: "published"})
+	postRepo.CreatePost(ctx, &model.Post{Title: "Черновик", Content: "Пока не готово", AuthorID: 1, Status: "draft"})
+	commentRepo.Create(ctx, &model.Comment{PostID: 1, AuthorID: 1, Content: "Мой комментарий"})
+
+	revocations := service.NewRevocationStore(NewMemoryRevokedTokenRepository(), cfg)
+	sessionSvc := service.NewSessionService(NewMemorySessionRepository(), revocations, cfg)
+	tokenSvc := service.NewTokenService(NewMemoryRefreshTokenRepository(), NewMemoryPersonalAccessTokenRepository(), userRepo, revocations, sessionSvc, cfg)
+	mail := &RecordingMailer{}
+	verifier := service.NewEmailVerificationService(userRepo, mail, cfg)
+	mfaSvc := service.NewMFAService(userRepo, NewMemoryRecoveryCodeRepository(), tokenSvc, cfg)
+	throttle := service.NewLoginThrottleService(memory.NewMemoryLoginAttemptRepository(), cfg)
+	userSvc := service.NewUserService(userRepo, tokenSvc, verifier, mfaSvc, throttle, service.NewRegistrationService(NewMemoryInvitationRepository(), cfg))
+	exportSvc := service.NewDataExportService(exportRepo, userRepo, postRepo, commentRepo, throttle, sessionSvc, mail, cfg)
+	t.Cleanup(exportSvc.Stop)
+
+	logger := log.New(io.Discard, "", 0)
+	userHandler := handlers.NewUserHandler(userSvc, logger)
+	exportHandler := handlers.NewExportHandler(exportSvc, logger)
+	authenticator := middleware.NewAuthenticator(revocations, nil)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/auth/login", userHandler.LoginHandler)
+	mux.HandleFunc("POST /api/profile/export", authenticator.AuthMiddleware(exportHandler.RequestExport))
+	mux.HandleFunc("GET /api/profile/export/{id}", authenticator.AuthMiddleware(exportHandler.GetExport))
+	mux.HandleFunc("GET /api/exports/download", exportHandler.Download)
+
+	// Неудачный вход попадает в историю входов
+	doJSON(mux, http.MethodPost, "/api/auth/login", `{"email": "test@example.com", "password": "wrong"}`)
+
+	return mux, exportRepo, mail
+}
+
+// waitForExport - ждет завершения фоновой сборки и возвращает ссылку на скачивание
+func waitForExport(t *testing.T, router http.Handler, token string, id int) string {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		w := doAuthJSON(router, http.MethodGet, fmt.Sprintf("/api/profile/export/%d", id), token, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("export status: expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Export      model.DataExport `json:"export"`
+			DownloadURL string           `json:"download_url"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+
+		switch resp.Export.Status {
+		case model.ExportStatusReady:
+			return resp.DownloadURL
+		case model.ExportStatusFailed:
+			t.Fatalf("export failed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("export %d not ready in time", id)
+	return ""
+}
+
+// TestDataExport - запрос выгрузки → фоновая сборка → скачивание ZIP по ссылке
+func TestDataExport(t *testing.T) {
+	router, exportRepo, mail := setupExportTestRouter(t)
+	access, _ := loginTokens(t, router)
+
+	// 1. Запуск выгрузки
+	w := doAuthJSON(router, http.MethodPost, "/api/profile/export", access, "")
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("request export: expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var started struct {
+		Export model.DataExport `json:"export"`
+	}
+	json.NewDecoder(w.Body).Decode(&started)
+
+	link := waitForExport(t, router, access, started.Export.ID)
+	if !strings.HasPrefix(link, "http://blog.test/api/exports/download?token=") {
+		t.Fatalf("unexpected download link: %q", link)
+	}
+	if msg, sent := mail.Last(); !sent || !strings.Contains(msg.Body, link) {
+		t.Errorf("expected email with download link, got %+v", msg)
+	}
+
+	// 2. Чужой пользователь не видит выгрузку
+	if w := doAuthJSON(router, http.MethodGet, fmt.Sprintf("/api/profile/export/%d", started.Export.ID+1), access, ""); w.Code != http.StatusNotFound {
+		t.Errorf("unknown export: expected 404, got %d", w.Code)
+	}
+
+	// 3. Скачивание без входа, по ссылке
+	parsed, _ := url.Parse(link)
+	w = doJSON(router, http.MethodGet, parsed.RequestURI(), "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("download: expected 200 zip, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	files := make(map[string]string)
+	for _, f := range archive.File {
+		rc, _ := f.Open()
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+
+	checks := map[string]string{
+		"profile.json":       `"email": "test@example.com"`,
+		"posts.json":         "Черновик",
+		"posts/1.md":         "# Первый пост",
+		"posts/2.md":         "status: draft",
+		"comments.json":      "Мой комментарий",
+		"login_history.json": "invalid_password",
+	}
+	for name, want := range checks {
+		if !strings.Contains(files[name], want) {
+			t.Errorf("%s: expected %q, got %q", name, want, files[name])
+		}
+	}
+
+	// История входов: успешные входы (сессии) и неудачные попытки
+	var history struct {
+		Sessions []struct {
+			CreatedAt time.Time  `json:"created_at"`
+			EndedAt   *time.Time `json:"ended_at"`
+		} `json:"sessions"`
+		FailedAttempts []json.RawMessage `json:"failed_attempts"`
+	}
+	if err := json.Unmarshal([]byte(files["login_history.json"]), &history); err != nil {
+		t.Fatalf("login_history.json: %v", err)
+	}
+	if len(history.Sessions) != 1 || history.Sessions[0].CreatedAt.IsZero() || history.Sessions[0].EndedAt != nil {
+		t.Errorf("expected one active session in login history, got %+v", history.Sessions)
+	}
+	if len(history.FailedAttempts) != 1 {
+		t.Errorf("expected one failed attempt in login history, got %d", len(history.FailedAttempts))
+	}
+	if strings.Contains(files["profile.json"], "$2a$") || strings.Contains(files["profile.json"], "password") {
+		t.Errorf("profile.json leaks password hash: %s", files["profile.json"])
+	}
+
+	// 4. Подделанная и истекшая ссылки
+	if w := doJSON(router, http.MethodGet, parsed.RequestURI()+"x", ""); w.Code != http.StatusGone {
+		t.Errorf("tampered link: expected 410, got %d", w.Code)
+	}
+	exportRepo.expire(started.Export.ID)
+	if w := doJSON(router, http.MethodGet, parsed.RequestURI(), ""); w.Code != http.StatusGone {
+		t.Errorf("expired link: expected 410, got %d", w.Code)
+	}
+
+	// 5. Без входа выгрузку не запросить
+	if w := doJSON(router, http.MethodPost, "/api/profile/export", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous export: expected 401, got %d", w.Code)
+	}
+}
+
+// TestDataExportInProgress - одна собираемая выгрузка на пользователя, в том числе при параллельных запросах
+func TestDataExportInProgress(t *testing.T) {
+	router, exportRepo, _ := setupExportTestRouter(t)
+	access, _ := loginTokens(t, router)
+	ctx := context.Background()
+
+	pending := &model.DataExport{UserID: 1, Status: model.ExportStatusPending}
+	if err := exportRepo.Create(ctx, pending); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// 1. Выгрузка уже собирается
+	if w := doAuthJSON(router, http.MethodPost, "/api/profile/export", access, ""); w.Code != http.StatusConflict {
+		t.Errorf("export in progress: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// 2. Параллельный запрос: проверка ее не увидела, но запись не проходит
+	exportRepo.staleLatest = true
+	if w := doAuthJSON(router, http.MethodPost, "/api/profile/export", access, ""); w.Code != http.StatusConflict {
+		t.Errorf("concurrent export: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	exportRepo.staleLatest = false
+
+	// 3. Зависшая выгрузка помечается неудачной и не мешает новой
+	exportRepo.stall(pending.ID)
+	w := doAuthJSON(router, http.MethodPost, "/api/profile/export", access, "")
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("export after stalled one: expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	if status := exportRepo.status(pending.ID); status != model.ExportStatusFailed {
+		t.Errorf("stalled export: expected status failed, got %q", status)
+	}
+
+	var started struct {
+		Export model.DataExport `json:"export"`
+	}
+	json.NewDecoder(w.Body).Decode(&started)
+	waitForExport(t, router, access, started.Export.ID)
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/internal/model"
+	"blog-backend/pkg/auth"
+	"blog-backend/service"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// FeedResponse страница ленты (next_cursor пустой — постов больше нет)
+type FeedResponse struct {
+	Data       []*model.Post `json:"data"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// FollowHandler обрабатывает подписки на авторов и ленту
+type FollowHandler struct {
+	followService *service.FollowService
+	log           *log.Logger
+}
+
+// NewFollowHandler создает новый FollowHandler
+func NewFollowHandler(followService *service.FollowService, logger *log.Logger) *FollowHandler {
+	return &FollowHandler{
+		followService: followService,
+		log:           logger,
+	}
+}
+
+// Follow подписывает текущего пользователя на автора
+// POST /api/users/{id}/follow
+func (h *FollowHandler) Follow(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+	followeeID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		middleware.AbortError(w, r, "Invalid user ID", http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.followService.Follow(r.Context(), userID, followeeID); err != nil {
+		h.abortFollowError(w, r, err, "Failed to follow user")
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"message": "Followed",
+	}, http.StatusOK)
+}
+
+// Unfollow отменяет подписку текущего пользователя
+// DELETE /api/users/{id}/follow
+func (h *FollowHandler) Unfollow(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+	followeeID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		middleware.AbortError(w, r, "Invalid user ID", http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.followService.Unfollow(r.Context(), userID, followeeID); err != nil {
+		h.abortFollowError(w, r, err, "Failed to unfollow user")
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"message": "Unfollowed",
+	}, http.StatusOK)
+}
+
+// ListFollowers возвращает подписчиков автора с пагинацией
+// GET /api/users/{username}/followers?limit=&offset=
+func (h *FollowHandler) ListFollowers(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePagination(r)
+
+	users, total, err := h.followService.ListFollowers(r.Context(), r.PathValue("username"), limit, offset)
+	if err != nil {
+		h.abortFollowError(w, r, err, "Failed to list followers")
+		return
+	}
+
+	sendJSONResponse(w, Response{
+		Data:  users,
+		Total: total,
+	}, http.StatusOK)
+}
+
+// ListFollowing возвращает авторов, на которых подписан пользователь
+// GET /api/users/{username}/following?limit=&offset=
+func (h *FollowHandler) ListFollowing(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePagination(r)
+
+	users, total, err := h.followService.ListFollowing(r.Context(), r.PathValue("username"), limit, offset)
+	if err != nil {
+		h.abortFollowError(w, r, err, "Failed to list following")
+		return
+	}
+
+	sendJSONResponse(w, Response{
+		Data:  users,
+		Total: total,
+	}, http.StatusOK)
+}
+
+// Feed возвращает опубликованные посты авторов из подписок, новые первыми.
+// Следующая страница запрашивается с cursor из next_cursor
+// GET /api/feed?limit=&cursor=
+func (h *FollowHandler) Feed(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+	limit, _ := parsePagination(r)
+
+	posts, next, err := h.followService.Feed(r.Context(), userID, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		h.abortFollowError(w, r, err, "Failed to load feed")
+		return
+	}
+
+	sendJSONResponse(w, FeedResponse{
+		Data:       posts,
+		NextCursor: next,
+	}, http.StatusOK)
+}
+
+// abortFollowError переводит ошибки FollowService в HTTP коды
+func (h *FollowHandler) abortFollowError(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrAuthorNotFound):
+		middleware.AbortError(w, r, "User not found", http.StatusNotFound, err)
+	case errors.Is(err, service.ErrCannotFollowSelf):
+		middleware.AbortError(w, r, "You cannot follow yourself", http.StatusBadRequest, err)
+	case errors.Is(err, service.ErrBlocked):
+		middleware.AbortError(w, r, "You have been blocked by this user", http.StatusForbidden, err)
+	case errors.Is(err, service.ErrInvalidCursor):
+		middleware.AbortError(w, r, "Invalid cursor", http.StatusBadRequest, err)
+	default:
+		middleware.AbortError(w, r, fallback, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,309 @@
+// internal/handlers/follow_handler_test.go
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"blog-backend/internal/handlers"
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+	"blog-backend/service"
+)
+
+// follow - подписка в MemoryFollowRepository
+type follow struct {
+	followerID int
+	followeeID int
+	createdAt  time.Time
+}
+
+// MemoryFollowRepository — in-memory хранилище подписок
+type MemoryFollowRepository struct {
+	follows  []follow
+	userRepo repository.UserRepository // имена и удаленные аккаунты для списков
+	mu       sync.RWMutex
+}
+
+// NewMemoryFollowRepository создает пустое хранилище подписок
+func NewMemoryFollowRepository(userRepo repository.UserRepository) *MemoryFollowRepository {
+	return &MemoryFollowRepository{userRepo: userRepo}
+}
+
+// Follow добавляет подписку (повторная подписка не ошибка)
+func (r *MemoryFollowRepository) Follow(ctx context.Context, followerID, followeeID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, f := range r.follows {
+		if f.followerID == followerID && f.followeeID == followeeID {
+			return nil
+		}
+	}
+	r.follows = append(r.follows, follow{followerID, followeeID, time.Now()})
+	return nil
+}
+
+// Unfollow удаляет подписку
+func (r *MemoryFollowRepository) Unfollow(ctx context.Context, followerID, followeeID int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, f := range r.follows {
+		if f.followerID == followerID && f.followeeID == followeeID {
+			r.follows = append(r.follows[:i], r.follows[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ListFollowers возвращает подписчиков (новые первыми)
+func (r *MemoryFollowRepository) ListFollowers(ctx context.Context, userID, limit, offset int) ([]*model.FollowUser, error) {
+	users := r.list(ctx, func(f follow) (int, bool) { return f.followerID, f.followeeID == userID })
+	return paginateFollowUsers(users, limit, offset), nil
+}
+
+// ListFollowing возвращает подписки (новые первыми)
+func (r *MemoryFollowRepository) ListFollowing(ctx context.Context, userID, limit, offset int) ([]*model.FollowUser, error) {
+	users := r.list(ctx, func(f follow) (int, bool) { return f.followeeID, f.followerID == userID })
+	return paginateFollowUsers(users, limit, offset), nil
+}
+
+// CountFollowers количество подписчиков
+func (r *MemoryFollowRepository) CountFollowers(ctx context.Context, userID int) (int, error) {
+	return len(r.list(ctx, func(f follow) (int, bool) { return f.followerID, f.followeeID == userID })), nil
+}
+
+// CountFollowing количество подписок
+func (r *MemoryFollowRepository) CountFollowing(ctx context.Context, userID int) (int, error) {
+	return len(r.list(ctx, func(f follow) (int, bool) { return f.followeeID, f.followerID == userID })), nil
+}
+
+// list выбирает пользователей по подпискам (удаленные аккаунты пропускаются)
+func (r *MemoryFollowRepository) list(ctx context.Context, match func(follow) (int, bool)) []*model.FollowUser {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var users []*model.FollowUser
+	for i := len(r.follows) - 1; i >= 0; i-- {
+		id, ok := match(r.follows[i])
+		if !ok {
+			continue
+		}
+		user, err := r.userRepo.GetUserByID(ctx, id)
+		if err != nil || user == nil || user.DeletedAt != nil {
+			continue
+		}
+		users = append(users, &model.FollowUser{ID: user.ID, Username: user.Username, FollowedAt: r.follows[i].createdAt})
+	}
+	return users
+}
+
+// followees возвращает множество авторов, на которых подписан пользователь (для ленты)
+func (r *MemoryFollowRepository) followees(followerID int) map[int]bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make(map[int]bool)
+	for _, f := range r.follows {
+		if f.followerID == followerID {
+			ids[f.followeeID] = true
+		}
+	}
+	return ids
+}
+
+// paginateFollowUsers применяет limit/offset
+func paginateFollowUsers(users []*model.FollowUser, limit, offset int) []*model.FollowUser {
+	if offset >= len(users) {
+		return nil
+	}
+	end := offset + limit
+	if end > len(users) {
+		end = len(users)
+	}
+	return users[offset:end]
+}
+
+// setupFollowTestRouter - роутер подписок и ленты.
+// testuser (ID=1) — читатель, alice (ID=2) и bob (ID=3) — авторы
+func setupFollowTestRouter(t *testing.T) (http.Handler, *MemoryPostStorage) {
+	t.Helper()
+
+	ctx := context.Background()
+	userRepo := NewMemoryUserRepository()
+	userRepo.CreateUser(ctx, "alice@example.com", "alice", "hash")
+	userRepo.CreateUser(ctx, "bob@example.com", "bob", "hash")
+
+	followRepo := NewMemoryFollowRepository(userRepo)
+	postRepo := NewMemoryPostStorage().(*MemoryPostStorage)
+	postRepo.follows = followRepo
+
+	userSvc, revocations := newTestUserService(userRepo)
+	followSvc := service.NewFollowService(followRepo, NewMemoryBlockRepository(userRepo), userRepo, postRepo)
+	authorSvc := service.NewAuthorService(userRepo, postRepo, followRepo)
+
+	logger := log.New(io.Discard, "", 0)
+	userHandler := handlers.NewUserHandler(userSvc, logger)
+	followHandler := handlers.NewFollowHandler(followSvc, logger)
+	authorHandler := handlers.NewAuthorHandler(authorSvc, logger)
+	authenticator := middleware.NewAuthenticator(revocations, nil)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/auth/login", userHandler.LoginHandler)
+	mux.HandleFunc("GET /api/users/{username}", authorHandler.GetProfile)
+	mux.HandleFunc("GET /api/users/{username}/followers", followHandler.ListFollowers)
+	mux.HandleFunc("GET /api/users/{username}/following", followHandler.ListFollowing)
+	mux.HandleFunc("POST /api/users/{id}/follow", authenticator.AuthMiddleware(followHandler.Follow))
+	mux.HandleFunc("DELETE /api/users/{id}/follow", authenticator.AuthMiddleware(followHandler.Unfollow))
+	mux.HandleFunc("GET /api/feed", authenticator.AuthMiddleware(followHandler.Feed))
+	return mux, postRepo
+}
+
+// TestFollow - подписка, отписка, списки и счетчики в профиле
+func TestFollow(t *testing.T) {
+	router, _ := setupFollowTestRouter(t)
+	token, _ := loginTokens(t, router)
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		expectedStatus int
+	}{
+		{"follow_alice", http.MethodPost, "/api/users/2/follow", http.StatusOK},
+		{"follow_again", http.MethodPost, "/api/users/2/follow", http.StatusOK},
+		{"follow_bob", http.MethodPost, "/api/users/3/follow", http.StatusOK},
+		{"follow_self", http.MethodPost, "/api/users/1/follow", http.StatusBadRequest},
+		{"follow_unknown", http.MethodPost, "/api/users/99/follow", http.StatusNotFound},
+		{"follow_invalid_id", http.MethodPost, "/api/users/abc/follow", http.StatusBadRequest},
+		{"unfollow_bob", http.MethodDelete, "/api/users/3/follow", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := doAuthJSON(router, tt.method, tt.url, token, ""); w.Code != tt.expectedStatus {
+				t.Errorf("expected %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	if w := doJSON(router, http.MethodPost, "/api/users/2/follow", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("follow without token: expected 401, got %d", w.Code)
+	}
+
+	// Списки публичные: alice видит подписчика testuser, testuser подписан только на alice
+	var resp struct {
+		Data  []model.FollowUser `json:"data"`
+		Total int                `json:"total"`
+	}
+	w := doJSON(router, http.MethodGet, "/api/users/alice/followers", "")
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Total != 1 || len(resp.Data) != 1 || resp.Data[0].Username != "testuser" {
+		t.Errorf("unexpected followers of alice: %+v", resp)
+	}
+	w = doJSON(router, http.MethodGet, "/api/users/testuser/following", "")
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Total != 1 || len(resp.Data) != 1 || resp.Data[0].Username != "alice" {
+		t.Errorf("unexpected following of testuser: %+v", resp)
+	}
+	if w := doJSON(router, http.MethodGet, "/api/users/nobody/followers", ""); w.Code != http.StatusNotFound {
+		t.Errorf("followers of unknown user: expected 404, got %d", w.Code)
+	}
+
+	var profile model.PublicProfile
+	w = doJSON(router, http.MethodGet, "/api/users/alice", "")
+	json.NewDecoder(w.Body).Decode(&profile)
+	if profile.FollowerCount != 1 || profile.FollowingCount != 0 {
+		t.Errorf("unexpected counters in profile: %+v", profile)
+	}
+}
+
+// failingUserRepository - GetUserByID всегда возвращает ошибку хранилища
+type failingUserRepository struct {
+	repository.UserRepository
+	err error
+}
+
+func (r failingUserRepository) GetUserByID(ctx context.Context, id int) (*model.User, error) {
+	return nil, r.err
+}
+
+// TestFollowUserLookupError - ошибка БД при поиске автора не превращается в 404
+func TestFollowUserLookupError(t *testing.T) {
+	dbErr := errors.New("connection refused")
+	userRepo := failingUserRepository{UserRepository: NewMemoryUserRepository(), err: dbErr}
+	followSvc := service.NewFollowService(NewMemoryFollowRepository(userRepo), NewMemoryBlockRepository(userRepo), userRepo, NewMemoryPostStorage())
+
+	err := followSvc.Follow(context.Background(), 1, 2)
+	if !errors.Is(err, dbErr) || errors.Is(err, service.ErrAuthorNotFound) {
+		t.Errorf("expected storage error, got %v", err)
+	}
+}
+
+// TestFeed - лента подписок: только опубликованные посты, новые первыми, курсор без пропусков и повторов
+func TestFeed(t *testing.T) {
+	router, postRepo := setupFollowTestRouter(t)
+	token, _ := loginTokens(t, router)
+	ctx := context.Background()
+
+	// 5 постов alice, 1 черновик alice, 2 поста bob (на bob не подписываемся)
+	for i := 1; i <= 5; i++ {
+		postRepo.CreatePost(ctx, &model.Post{Title: fmt.Sprintf("alice %d", i), AuthorID: 2, Status: "published"})
+	}
+	postRepo.CreatePost(ctx, &model.Post{Title: "alice draft", AuthorID: 2, Status: "draft"})
+	postRepo.CreatePost(ctx, &model.Post{Title: "bob 1", AuthorID: 3, Status: "published"})
+	postRepo.CreatePost(ctx, &model.Post{Title: "bob 2", AuthorID: 3, Status: "published"})
+
+	// Без подписок лента пустая (и это [], а не null)
+	w := doAuthJSON(router, http.MethodGet, "/api/feed", token, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("empty feed: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if body := w.Body.String(); body != "{\"data\":[]}\n" {
+		t.Errorf("unexpected empty feed: %s", body)
+	}
+
+	doAuthJSON(router, http.MethodPost, "/api/users/2/follow", token, "")
+
+	// Листаем по 2 поста
+	var titles []string
+	cursor := ""
+	for page := 0; page < 5; page++ {
+		w := doAuthJSON(router, http.MethodGet, "/api/feed?limit=2&cursor="+url.QueryEscape(cursor), token, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("page %d: expected 200, got %d: %s", page, w.Code, w.Body.String())
+		}
+		var resp handlers.FeedResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		for _, post := range resp.Data {
+			titles = append(titles, post.Title)
+		}
+		if resp.NextCursor == "" {
+			break
+		}
+		cursor = resp.NextCursor
+	}
+
+	want := []string{"alice 5", "alice 4", "alice 3", "alice 2", "alice 1"}
+	if fmt.Sprint(titles) != fmt.Sprint(want) {
+		t.Errorf("expected feed %v, got %v", want, titles)
+	}
+
+	if w := doAuthJSON(router, http.MethodGet, "/api/feed?cursor=garbage", token, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid cursor: expected 400, got %d", w.Code)
+	}
+	if w := doJSON(router, http.MethodGet, "/api/feed", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("feed without token: expected 401, got %d", w.Code)
+	}
+}
@@ -0,0 +1,141 @@
+// internal/handlers/identity_handler_test.go
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"blog-backend/internal/model"
+)
+
+// registerUser - POST /api/auth/register
+func registerUser(router http.Handler, email, username string) *httptest.ResponseRecorder {
+	body := fmt.Sprintf(`{"email": %q, "username": %q, "password": "password123"}`, email, username)
+	return doJSON(router, http.MethodPost, "/api/auth/register", body)
+}
+
+// TestRegisterNormalizesEmail - адрес хранится в нижнем регистре, регистр не создает второй аккаунт
+func TestRegisterNormalizesEmail(t *testing.T) {
+	router, _ := setupAuthTestRouter()
+
+	w := registerUser(router, "  Bob@Example.COM ", "bob")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		User struct {
+			Email string `json:"email"`
+		} `json:"user"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.User.Email != "bob@example.com" {
+		t.Errorf("expected normalized email, got %q", resp.User.Email)
+	}
+
+	if w := registerUser(router, "bob@example.com", "bob2"); w.Code != http.StatusConflict {
+		t.Errorf("same email in other case: expected 409, got %d", w.Code)
+	}
+	w = doJSON(router, http.MethodPost, "/api/auth/login", `{"email": "BOB@example.com", "password": "password123"}`)
+	if w.Code != http.StatusOK {
+		t.Errorf("login with other case: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestRegisterEmailValidation - адрес разбирается по RFC 5322
+func TestRegisterEmailValidation(t *testing.T) {
+	router, _ := setupAuthTestRouter()
+
+	for _, email := range []string{
+		"plainaddress",
+		"Bob <bob@example.com>",
+		"bob@localhost",
+		"bob@@example.com",
+		"bob.@example.com",
+		"bob@exa_mple.com",
+		"bob@example..com",
+		`"bob smith"@example.com`,
+	} {
+		t.Run(email, func(t *testing.T) {
+			if w := registerUser(router, email, "someone"); w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// Международный домен хранится в punycode
+	w := registerUser(router, "Иван@Почта.рф", "ivan")
+	var resp struct {
+		User struct {
+			Email string `json:"email"`
+		} `json:"user"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusCreated || resp.User.Email != "иван@xn--80a1acny.xn--p1ai" {
+		t.Errorf("idn email: expected 201 with punycode domain, got %d %q", w.Code, resp.User.Email)
+	}
+}
+
+// TestRegisterUsernameRules - набор символов, служебные имена и похожие написания
+func TestRegisterUsernameRules(t *testing.T) {
+	router, _ := setupAuthTestRouter()
+	if w := registerUser(router, "ace@example.com", "ace"); w.Code != http.StatusCreated {
+		t.Fatalf("register ace: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	cases := []struct {
+		name     string
+		username string
+		want     int
+	}{
+		{"too short", "ab", http.StatusBadRequest},
+		{"space", "bad name", http.StatusBadRequest},
+		{"symbol", "bob!", http.StatusBadRequest},
+		{"leading separator", "_bob", http.StatusBadRequest},
+		{"trailing separator", "bob.", http.StatusBadRequest},
+		{"consecutive separators", "bob..smith", http.StatusBadRequest},
+		{"reserved", "admin", http.StatusBadRequest},
+		{"reserved other case", "API", http.StatusBadRequest},
+		{"reserved lookalike", "Adm1n", http.StatusBadRequest},
+		{"reserved in cyrillic", "аdmin", http.StatusBadRequest},
+		{"mixed alphabets", "tеstuser", http.StatusBadRequest},
+		{"deleted prefix", "deleted-7", http.StatusBadRequest},
+		{"taken other case", "TestUser", http.StatusConflict},
+		{"taken in cyrillic", "асе", http.StatusConflict},
+		{"latin", "ivan.petrov", http.StatusCreated},
+		{"cyrillic", "иван_петров", http.StatusCreated},
+	}
+	for i, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := registerUser(router, fmt.Sprintf("user%d@example.com", i), tc.username)
+			if w.Code != tc.want {
+				t.Errorf("%q: expected %d, got %d: %s", tc.username, tc.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestUpdateProfileUsernameRules - смена имени проверяется по тем же правилам
+func TestUpdateProfileUsernameRules(t *testing.T) {
+	router, userRepo, _ := setupAccountTestRouter(t, model.AccountDeletionAnonymize)
+	userRepo.CreateUser(t.Context(), "ace@example.com", "ace", "hash")
+	access, _ := loginTokens(t, router)
+
+	cases := []struct {
+		username string
+		want     int
+	}{
+		{"TestUser", http.StatusOK}, // свое имя в другом регистре
+		{"Support", http.StatusBadRequest},
+		{"ACE", http.StatusConflict},
+		{"асе", http.StatusConflict},
+	}
+	for _, tc := range cases {
+		body := fmt.Sprintf(`{"username": %q}`, tc.username)
+		if w := doAuthJSON(router, http.MethodPatch, "/api/profile", access, body); w.Code != tc.want {
+			t.Errorf("%q: expected %d, got %d: %s", tc.username, tc.want, w.Code, w.Body.String())
+		}
+	}
+}
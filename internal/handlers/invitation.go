@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/internal/model"
+	"blog-backend/pkg/auth"
+	"blog-backend/service"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// InvitationHandler управляет приглашениями на регистрацию (только admin)
+type InvitationHandler struct {
+	registrationService *service.RegistrationService
+	log                 *log.Logger
+}
+
+// NewInvitationHandler создает новый InvitationHandler
+func NewInvitationHandler(registrationService *service.RegistrationService, logger *log.Logger) *InvitationHandler {
+	return &InvitationHandler{
+		registrationService: registrationService,
+		log:                 logger,
+	}
+}
+
+// CreateInvitation выпускает одноразовый код приглашения (показывается один раз)
+// POST /api/admin/invitations
+func (h *InvitationHandler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	var req model.CreateInvitationRequest
+	if err := parseJSONRequest(r, &req); err != nil {
+		middleware.AbortError(w, r, "Invalid JSON", http.StatusBadRequest, err)
+		return
+	}
+
+	invitation, code, err := h.registrationService.CreateInvitation(r.Context(), adminID, req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInvitationData) {
+			middleware.AbortError(w, r, err.Error(), http.StatusBadRequest, err)
+			return
+		}
+		middleware.AbortError(w, r, "Failed to create invitation", http.StatusInternalServerError, err)
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"message":     "Invitation created. Copy the code now, it will not be shown again",
+		"invite_code": code,
+		"invitation":  invitation,
+	}, http.StatusCreated)
+}
+
+// ListInvitations возвращает все приглашения, включая использованные
+// GET /api/admin/invitations
+func (h *InvitationHandler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	invitations, err := h.registrationService.ListInvitations(r.Context())
+	if err != nil {
+		middleware.AbortError(w, r, "Failed to list invitations", http.StatusInternalServerError, err)
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"invitations": invitations,
+	}, http.StatusOK)
+}
+
+// RevokeInvitation удаляет неиспользованное приглашение
+// DELETE /api/admin/invitations/{id}
+func (h *InvitationHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	invitationID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		middleware.AbortError(w, r, "Invalid invitation ID", http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.registrationService.RevokeInvitation(r.Context(), invitationID); err != nil {
+		if errors.Is(err, service.ErrInvitationNotFound) {
+			middleware.AbortError(w, r, "Invitation not found or already used", http.StatusNotFound, err)
+			return
+		}
+		middleware.AbortError(w, r, "Failed to revoke invitation", http.StatusInternalServerError, err)
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"message": "Invitation revoked",
+	}, http.StatusOK)
+}
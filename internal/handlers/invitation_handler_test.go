@@ -0,0 +1,239 @@
+// internal/handlers/invitation_handler_test.go
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"blog-backend/internal/handlers"
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository/memory"
+	"blog-backend/service"
+)
+
+// setupRegistrationTestRouter - роутер регистрации с заданной политикой и управлением приглашениями.
+// Пользователь ID=1 — администратор
+func setupRegistrationTestRouter(mode string, allowedDomains ...string) (http.Handler, *MemoryInvitationRepository) {
+	userRepo := NewMemoryUserRepository()
+	userRepo.UpdateUserRole(context.Background(), 1, model.RoleAdmin)
+	invitations := NewMemoryInvitationRepository()
+	userRepo.(*MemoryUserRepository).invitations = invitations
+
+	cfg := NewTestConfig()
+	cfg.RegistrationMode = mode
+	cfg.RegistrationAllowedDomains = allowedDomains
+
+	revocations := service.NewRevocationStore(NewMemoryRevokedTokenRepository(), cfg)
+	tokenSvc := service.NewTokenService(NewMemoryRefreshTokenRepository(), NewMemoryPersonalAccessTokenRepository(), userRepo, revocations, service.NewSessionService(NewMemorySessionRepository(), revocations, cfg), cfg)
+	verifier := service.NewEmailVerificationService(userRepo, &RecordingMailer{}, cfg)
+	mfaSvc := service.NewMFAService(userRepo, NewMemoryRecoveryCodeRepository(), tokenSvc, cfg)
+	throttle := service.NewLoginThrottleService(memory.NewMemoryLoginAttemptRepository(), cfg)
+	registrationSvc := service.NewRegistrationService(invitations, cfg)
+	userSvc := service.NewUserService(userRepo, tokenSvc, verifier, mfaSvc, throttle, registrationSvc)
+
+	logger := log.New(io.Discard, "", 0)
+	userHandler := handlers.NewUserHandler(userSvc, logger)
+	invitationHandler := handlers.NewInvitationHandler(registrationSvc, logger)
+	authenticator := middleware.NewAuthenticator(revocations, nil)
+	requireAdmin := middleware.RequireRole(model.RoleAdmin)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/auth/register", userHandler.RegisterHandler)
+	mux.HandleFunc("POST /api/auth/login", userHandler.LoginHandler)
+	mux.HandleFunc("POST /api/admin/invitations", authenticator.AuthMiddleware(requireAdmin(invitationHandler.CreateInvitation)))
+	mux.HandleFunc("GET /api/admin/invitations", authenticator.AuthMiddleware(requireAdmin(invitationHandler.ListInvitations)))
+	mux.HandleFunc("DELETE /api/admin/invitations/{id}", authenticator.AuthMiddleware(requireAdmin(invitationHandler.RevokeInvitation)))
+
+	return mux, invitations
+}
+
+// createInvitation - администратор выпускает приглашение, возвращает код и ID
+func createInvitation(t *testing.T, router http.Handler, adminToken, body string) (string, int) {
+	t.Helper()
+
+	w := doAuthJSON(router, http.MethodPost, "/api/admin/invitations", adminToken, body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create invitation: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		InviteCode string           `json:"invite_code"`
+		Invitation model.Invitation `json:"invitation"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.InviteCode == "" {
+		t.Fatal("invite code not returned")
+	}
+	return resp.InviteCode, resp.Invitation.ID
+}
+
+// registerBody - тело запроса регистрации
+func registerBody(email, username, inviteCode string) string {
+	return fmt.Sprintf(`{"email": %q, "username": %q, "password": "password123", "invite_code": %q}`, email, username, inviteCode)
+}
+
+// TestRegistrationModes - open, closed и invite-only без кода
+func TestRegistrationModes(t *testing.T) {
+	tests := []struct {
+		mode           string
+		expectedStatus int
+	}{
+		{model.RegistrationOpen, http.StatusCreated},
+		{model.RegistrationClosed, http.StatusForbidden},
+		{model.RegistrationInviteOnly, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			router, _ := setupRegistrationTestRouter(tt.mode)
+			w := doJSON(router, http.MethodPost, "/api/auth/register", registerBody("new@example.com", "newuser", ""))
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// Закрытая регистрация не раскрывает занятые адреса
+	router, _ := setupRegistrationTestRouter(model.RegistrationClosed)
+	w := doJSON(router, http.MethodPost, "/api/auth/register", registerBody("test@example.com", "dup", ""))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("existing email with closed registration: expected 403, got %d", w.Code)
+	}
+}
+
+// TestInviteOnlyRegistration - одноразовые коды, привязка к email, истечение и отзыв
+func TestInviteOnlyRegistration(t *testing.T) {
+	router, invitations := setupRegistrationTestRouter(model.RegistrationInviteOnly)
+	adminToken, _ := loginTokens(t, router)
+
+	// 1. Код для любого адреса работает ровно один раз
+	code, _ := createInvitation(t, router, adminToken, `{}`)
+	if w := doJSON(router, http.MethodPost, "/api/auth/register", registerBody("first@example.com", "first", code)); w.Code != http.StatusCreated {
+		t.Fatalf("register with invitation: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(router, http.MethodPost, "/api/auth/register", registerBody("second@example.com", "second", code)); w.Code != http.StatusForbidden {
+		t.Errorf("reused invitation: expected 403, got %d", w.Code)
+	}
+	if w := doJSON(router, http.MethodPost, "/api/auth/register", registerBody("second@example.com", "second", "bogus")); w.Code != http.StatusForbidden {
+		t.Errorf("unknown code: expected 403, got %d", w.Code)
+	}
+
+	// 2. Приглашение на конкретный адрес (регистр не важен)
+	code, _ = createInvitation(t, router, adminToken, `{"email": "Bound@example.com"}`)
+	if w := doJSON(router, http.MethodPost, "/api/auth/register", registerBody("other@example.com", "other", code)); w.Code != http.StatusForbidden {
+		t.Errorf("invitation for another email: expected 403, got %d", w.Code)
+	}
+	if w := doJSON(router, http.MethodPost, "/api/auth/register", registerBody("bound@example.com", "bound", code)); w.Code != http.StatusCreated {
+		t.Errorf("bound invitation: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// 3. Истекшее приглашение
+	code, id := createInvitation(t, router, adminToken, `{}`)
+	invitations.expire(id)
+	if w := doJSON(router, http.MethodPost, "/api/auth/register", registerBody("late@example.com", "late", code)); w.Code != http.StatusForbidden {
+		t.Errorf("expired invitation: expected 403, got %d", w.Code)
+	}
+
+	// 4. Отзыв: неиспользованное удаляется, использованное — нет
+	code, id = createInvitation(t, router, adminToken, `{}`)
+	if w := doAuthJSON(router, http.MethodDelete, fmt.Sprintf("/api/admin/invitations/%d", id), adminToken, ""); w.Code != http.StatusOK {
+		t.Fatalf("revoke: expected 200, got %d", w.Code)
+	}
+	if w := doJSON(router, http.MethodPost, "/api/auth/register", registerBody("revoked@example.com", "revoked", code)); w.Code != http.StatusForbidden {
+		t.Errorf("revoked invitation: expected 403, got %d", w.Code)
+	}
+	if w := doAuthJSON(router, http.MethodDelete, "/api/admin/invitations/1", adminToken, ""); w.Code != http.StatusNotFound {
+		t.Errorf("revoke used invitation: expected 404, got %d", w.Code)
+	}
+
+	// 5. Список: коды и хеши не раскрываются, использованные видны с used_by
+	w := doAuthJSON(router, http.MethodGet, "/api/admin/invitations", adminToken, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("list: expected 200, got %d", w.Code)
+	}
+	if body := w.Body.String(); strings.Contains(body, "code") {
+		t.Errorf("invitation list leaks codes: %s", body)
+	}
+	var list struct {
+		Invitations []model.Invitation `json:"invitations"`
+	}
+	json.NewDecoder(w.Body).Decode(&list)
+	if len(list.Invitations) != 3 {
+		t.Fatalf("expected 3 invitations, got %d", len(list.Invitations))
+	}
+	if used := list.Invitations[2]; used.UsedAt == nil || used.UsedBy == nil {
+		t.Errorf("first invitation should be marked used: %+v", used)
+	}
+}
+
+// TestInvitationRedeemRace - код использовали после проверки (Check видит его свободным):
+// регистрация отклоняется и не оставляет пользователя
+func TestInvitationRedeemRace(t *testing.T) {
+	router, invitations := setupRegistrationTestRouter(model.RegistrationInviteOnly)
+	adminToken, _ := loginTokens(t, router)
+
+	code, _ := createInvitation(t, router, adminToken, `{}`)
+	invitations.staleReads = true
+	if w := doJSON(router, http.MethodPost, "/api/auth/register", registerBody("first@example.com", "first", code)); w.Code != http.StatusCreated {
+		t.Fatalf("register with invitation: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(router, http.MethodPost, "/api/auth/register", registerBody("second@example.com", "second", code)); w.Code != http.StatusForbidden {
+		t.Errorf("concurrently used invitation: expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Пользователь не создан: вход с его данными не проходит
+	w := doJSON(router, http.MethodPost, "/api/auth/login", `{"email": "second@example.com", "password": "password123"}`)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("login as rejected user: expected 401, got %d", w.Code)
+	}
+}
+
+// TestInvitationAdminOnly - приглашения выпускает только администратор
+func TestInvitationAdminOnly(t *testing.T) {
+	router, _ := setupRegistrationTestRouter(model.RegistrationOpen)
+	if w := doJSON(router, http.MethodPost, "/api/auth/register", registerBody("reader@example.com", "reader", "")); w.Code != http.StatusCreated {
+		t.Fatalf("register: expected 201, got %d", w.Code)
+	}
+	w := doJSON(router, http.MethodPost, "/api/auth/login", `{"email": "reader@example.com", "password": "password123"}`)
+	var resp struct {
+		Token string `json:"token"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+
+	if w := doAuthJSON(router, http.MethodPost, "/api/admin/invitations", resp.Token, `{}`); w.Code != http.StatusForbidden {
+		t.Errorf("non-admin: expected 403, got %d", w.Code)
+	}
+
+	adminToken, _ := loginTokens(t, router)
+	if w := doAuthJSON(router, http.MethodPost, "/api/admin/invitations", adminToken, `{"expires_in_days": 1000}`); w.Code != http.StatusBadRequest {
+		t.Errorf("too long expiry: expected 400, got %d", w.Code)
+	}
+}
+
+// TestRegistrationAllowedDomains - allow-list доменов и приглашение на конкретный адрес в обход него
+func TestRegistrationAllowedDomains(t *testing.T) {
+	router, _ := setupRegistrationTestRouter(model.RegistrationOpen, "corp.example")
+	adminToken, _ := loginTokens(t, router)
+
+	if w := doJSON(router, http.MethodPost, "/api/auth/register", registerBody("dev@Corp.Example", "dev", "")); w.Code != http.StatusCreated {
+		t.Errorf("allowed domain: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(router, http.MethodPost, "/api/auth/register", registerBody("guest@gmail.com", "guest", "")); w.Code != http.StatusForbidden {
+		t.Errorf("foreign domain: expected 403, got %d", w.Code)
+	}
+
+	// Приглашение для любого адреса allow-list не отменяет, на конкретный адрес — отменяет
+	code, _ := createInvitation(t, router, adminToken, `{}`)
+	if w := doJSON(router, http.MethodPost, "/api/auth/register", registerBody("guest@gmail.com", "guest", code)); w.Code != http.StatusForbidden {
+		t.Errorf("open invitation for foreign domain: expected 403, got %d", w.Code)
+	}
+	code, _ = createInvitation(t, router, adminToken, `{"email": "guest@gmail.com"}`)
+	if w := doJSON(router, http.MethodPost, "/api/auth/register", registerBody("guest@gmail.com", "guest", code)); w.Code != http.StatusCreated {
+		t.Errorf("bound invitation for foreign domain: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"blog-backend/pkg/jwt"
+	"net/http"
+)
+
+// JWKSHandler отдает открытые ключи для проверки access токенов другими сервисами
+// GET /.well-known/jwks.json
+func JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	// Клиенты кешируют ключи; новый ключ публикуется заранее (см. JWT_VERIFY_KEY_FILES)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	sendJSONResponse(w, jwt.JWKS(), http.StatusOK)
+}
@@ -0,0 +1,112 @@
+// internal/handlers/jwks_handler_test.go
+package handlers_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"blog-backend/internal/handlers"
+	"blog-backend/internal/model"
+	"blog-backend/pkg/jwt"
+)
+
+// writeKeyFile сохраняет закрытый ключ в PEM (PKCS#8)
+func writeKeyFile(t *testing.T, name string, key any) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	return path
+}
+
+// fetchJWKS запрашивает /.well-known/jwks.json
+func fetchJWKS(t *testing.T) jwt.JWKSet {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	handlers.JWKSHandler(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("jwks: expected 200, got %d", w.Code)
+	}
+	var set jwt.JWKSet
+	if err := json.NewDecoder(w.Body).Decode(&set); err != nil {
+		t.Fatalf("invalid jwks: %v", err)
+	}
+	return set
+}
+
+// TestAsymmetricKeyRotation - RS256 → EdDSA без выхода пользователей
+func TestAsymmetricKeyRotation(t *testing.T) {
+	t.Cleanup(func() { jwt.LoadKeys("", nil) })
+
+	user := model.User{ID: 1, Email: "test@example.com", Username: "testuser", Role: model.RoleAuthor}
+	hsToken, _ := jwt.GenerateToken(user, 0)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	rsaFile := writeKeyFile(t, "previous.pem", rsaKey)
+	edFile := writeKeyFile(t, "current.pem", edKey)
+
+	// 1. Подпись RS256
+	if err := jwt.LoadKeys(rsaFile, nil); err != nil {
+		t.Fatalf("load RS256 key: %v", err)
+	}
+	rsToken, err := jwt.GenerateToken(user, 0)
+	if err != nil {
+		t.Fatalf("generate RS256 token: %v", err)
+	}
+	if _, err := jwt.ValidateToken(rsToken); err != nil {
+		t.Fatalf("RS256 token rejected: %v", err)
+	}
+	if _, err := jwt.ValidateToken(hsToken); err == nil {
+		t.Errorf("HS256 token must be rejected in asymmetric mode")
+	}
+
+	// 2. Ротация: новый ключ EdDSA, старый остается для проверки
+	if err := jwt.LoadKeys(edFile, []string{rsaFile}); err != nil {
+		t.Fatalf("load EdDSA key: %v", err)
+	}
+	edToken, _ := jwt.GenerateToken(user, 0)
+	for name, token := range map[string]string{"RS256": rsToken, "EdDSA": edToken} {
+		if claims, err := jwt.ValidateToken(token); err != nil || claims.UserID != user.ID {
+			t.Errorf("%s token during rotation: %v", name, err)
+		}
+	}
+
+	set := fetchJWKS(t)
+	if len(set.Keys) != 2 {
+		t.Fatalf("expected 2 keys in JWKS, got %d", len(set.Keys))
+	}
+	if set.Keys[0].Alg != "EdDSA" || set.Keys[0].Kty != "OKP" || set.Keys[1].Alg != "RS256" || set.Keys[1].N == "" {
+		t.Errorf("unexpected JWKS: %+v", set.Keys)
+	}
+
+	// 3. Старый ключ удален — выданные им токены больше не принимаются
+	if err := jwt.LoadKeys(edFile, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jwt.ValidateToken(rsToken); err == nil {
+		t.Errorf("token signed by removed key must be rejected")
+	}
+	if _, err := jwt.ValidateToken(edToken); err != nil {
+		t.Errorf("current token rejected: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/internal/model"
+	"blog-backend/service"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// LoginAttemptHandler показывает администраторам журнал неудачных входов
+type LoginAttemptHandler struct {
+	throttle *service.LoginThrottleService
+	log      *log.Logger
+}
+
+// NewLoginAttemptHandler создает новый LoginAttemptHandler
+func NewLoginAttemptHandler(throttle *service.LoginThrottleService, logger *log.Logger) *LoginAttemptHandler {
+	return &LoginAttemptHandler{
+		throttle: throttle,
+		log:      logger,
+	}
+}
+
+// ListFailedAttempts возвращает последние неудачные входы
+// GET /api/admin/login-attempts?email=&ip=&limit=
+func (h *LoginAttemptHandler) ListFailedAttempts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := model.LoginAttemptFilter{
+		Email: query.Get("email"),
+		IP:    query.Get("ip"),
+	}
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 {
+			middleware.AbortError(w, r, "Invalid limit", http.StatusBadRequest, err)
+			return
+		}
+		filter.Limit = value
+	}
+
+	attempts, err := h.throttle.ListFailedAttempts(r.Context(), filter)
+	if err != nil {
+		middleware.AbortError(w, r, "Failed to list login attempts", http.StatusInternalServerError, err)
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"attempts": attempts,
+		"count":    len(attempts),
+	}, http.StatusOK)
+}
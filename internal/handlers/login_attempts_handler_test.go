@@ -0,0 +1,125 @@
+// internal/handlers/login_attempts_handler_test.go
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"blog-backend/internal/handlers"
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository/memory"
+	"blog-backend/service"
+)
+
+// setupLoginThrottleTestRouter - роутер со входом и журналом неудачных входов.
+// Пользователь ID=1 — администратор
+func setupLoginThrottleTestRouter() http.Handler {
+	userRepo := NewMemoryUserRepository()
+	userRepo.UpdateUserRole(context.Background(), 1, model.RoleAdmin)
+
+	cfg := NewTestConfig()
+	cfg.LoginMaxFailures = 3
+	cfg.LoginMaxFailuresPerIP = 5
+	cfg.LoginLockout = time.Minute
+
+	revocations := service.NewRevocationStore(NewMemoryRevokedTokenRepository(), cfg)
+	tokenSvc := service.NewTokenService(NewMemoryRefreshTokenRepository(), NewMemoryPersonalAccessTokenRepository(), userRepo, revocations, service.NewSessionService(NewMemorySessionRepository(), revocations, cfg), cfg)
+	verifier := service.NewEmailVerificationService(userRepo, &RecordingMailer{}, cfg)
+	mfaSvc := service.NewMFAService(userRepo, NewMemoryRecoveryCodeRepository(), tokenSvc, cfg)
+	throttle := service.NewLoginThrottleService(memory.NewMemoryLoginAttemptRepository(), cfg)
+	userSvc := service.NewUserService(userRepo, tokenSvc, verifier, mfaSvc, throttle, service.NewRegistrationService(NewMemoryInvitationRepository(), cfg))
+
+	logger := log.New(io.Discard, "", 0)
+	userHandler := handlers.NewUserHandler(userSvc, logger)
+	loginAttemptHandler := handlers.NewLoginAttemptHandler(throttle, logger)
+	authenticator := middleware.NewAuthenticator(revocations, nil)
+	requireAdmin := middleware.RequireRole(model.RoleAdmin)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/auth/login", userHandler.LoginHandler)
+	mux.HandleFunc("GET /api/admin/login-attempts", authenticator.AuthMiddleware(requireAdmin(loginAttemptHandler.ListFailedAttempts)))
+
+	return mux
+}
+
+// TestLoginLockout - блокировка по email и IP, Retry-After и журнал для администратора
+func TestLoginLockout(t *testing.T) {
+	router := setupLoginThrottleTestRouter()
+	adminToken, _ := loginTokens(t, router)
+
+	wrongPassword := `{"email": "test@example.com", "password": "wrong-password"}`
+
+	// 1. Первые неудачи — обычный 401, на третьей вход блокируется
+	for i := 1; i <= 2; i++ {
+		if w := doJSON(router, http.MethodPost, "/api/auth/login", wrongPassword); w.Code != http.StatusUnauthorized {
+			t.Fatalf("failure %d: expected 401, got %d", i, w.Code)
+		}
+	}
+	w := doJSON(router, http.MethodPost, "/api/auth/login", wrongPassword)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("failure 3: expected 429, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("expected Retry-After 60, got %q", got)
+	}
+
+	// 2. Во время блокировки не принимается даже верный пароль (и регистр email не помогает)
+	w = doJSON(router, http.MethodPost, "/api/auth/login", `{"email": "TEST@example.com", "password": "password123"}`)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("correct password while locked: expected 429, got %d", w.Code)
+	}
+
+	// 3. Перебор разных email с одного IP блокирует IP (лимит 5, три неудачи уже есть)
+	if w := doJSON(router, http.MethodPost, "/api/auth/login", `{"email": "nobody1@example.com", "password": "password123"}`); w.Code != http.StatusUnauthorized {
+		t.Fatalf("unknown email: expected 401, got %d", w.Code)
+	}
+	if w := doJSON(router, http.MethodPost, "/api/auth/login", `{"email": "nobody2@example.com", "password": "password123"}`); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("ip limit: expected 429, got %d", w.Code)
+	}
+
+	// 4. За доверенным прокси IP берется из X-Forwarded-For: другой клиент не заблокирован
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(`{"email": "nobody3@example.com", "password": "password123"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.7")
+	rec := httptest.NewRecorder()
+	middleware.RealIPMiddleware(router).ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("other ip behind proxy: expected 401, got %d", rec.Code)
+	}
+
+	// 5. Журнал неудачных входов
+	w = doAuthJSON(router, http.MethodGet, "/api/admin/login-attempts?email=Test@Example.com", adminToken, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("list attempts: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Attempts []model.LoginAttempt `json:"attempts"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if len(resp.Attempts) != 3 {
+		t.Fatalf("expected 3 attempts for test@example.com, got %d", len(resp.Attempts))
+	}
+	for _, attempt := range resp.Attempts {
+		if attempt.Reason != model.LoginFailureInvalidPassword || attempt.UserID == nil || *attempt.UserID != 1 {
+			t.Errorf("unexpected attempt: %+v", attempt)
+		}
+	}
+
+	w = doAuthJSON(router, http.MethodGet, "/api/admin/login-attempts?ip=203.0.113.7", adminToken, "")
+	json.NewDecoder(w.Body).Decode(&resp)
+	if len(resp.Attempts) != 1 || resp.Attempts[0].Reason != model.LoginFailureUnknownEmail || resp.Attempts[0].UserID != nil {
+		t.Errorf("expected one unknown_email attempt from proxy client, got %+v", resp.Attempts)
+	}
+
+	if w := doAuthJSON(router, http.MethodGet, "/api/admin/login-attempts?limit=x", adminToken, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid limit: expected 400, got %d", w.Code)
+	}
+}
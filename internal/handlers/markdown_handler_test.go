@@ -0,0 +1,95 @@
+// internal/handlers/markdown_handler_test.go
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"blog-backend/internal/model"
+)
+
+// markdownPost - пост с Markdown: заголовки, GFM и попытки внедрить скрипт
+const markdownPost = "# Введение\n\n" +
+	"Текст с **жирным**, ~~зачеркнутым~~ и [ссылкой](https://example.com).\n\n" +
+	"[клик](javascript:alert(1)) <script>alert(1)</script> <img src=x onerror=alert(1)>\n\n" +
+	"## Установка\n\n" +
+	"```go\nfmt.Println(\"<b>\")\n```\n\n" +
+	"| a | b |\n|---|---|\n| 1 | 2 |\n\n" +
+	"- [x] готово\n\n" +
+	"## Установка\n"
+
+// decodePost - пост из ответа создания, обновления или получения
+func decodePost(t *testing.T, body []byte) model.Post {
+	t.Helper()
+
+	var resp struct {
+		Data model.Post `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatalf("invalid post response: %v", err)
+	}
+	return resp.Data
+}
+
+// TestPostMarkdown - content_html без опасной разметки, якоря заголовков и оглавление
+func TestPostMarkdown(t *testing.T) {
+	router := setupSlugTestRouter()
+
+	body, _ := json.Marshal(map[string]string{"title": "Markdown", "content": markdownPost})
+	w := doJSON(router, http.MethodPost, "/api/posts", string(body))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	post := decodePost(t, w.Body.Bytes())
+
+	if post.Content != markdownPost {
+		t.Errorf("expected raw content to be kept")
+	}
+	for _, want := range []string{
+		`<h1 id="vvedenie">Введение</h1>`,
+		`<strong>жирным</strong>`,
+		`<del>зачеркнутым</del>`,
+		`<a href="https://example.com" rel="nofollow">ссылкой</a>`,
+		`<h2 id="ustanovka">Установка</h2>`,
+		`<h2 id="ustanovka-2">Установка</h2>`,
+		`<code class="language-go">fmt.Println(&#34;&lt;b&gt;&#34;)`,
+		`<td>1</td>`,
+		`<input checked="" disabled="" type="checkbox"`,
+	} {
+		if !strings.Contains(post.ContentHTML, want) {
+			t.Errorf("expected %q in content_html:\n%s", want, post.ContentHTML)
+		}
+	}
+	for _, banned := range []string{"<script", "javascript:", "onerror", "<img"} {
+		if strings.Contains(post.ContentHTML, banned) {
+			t.Errorf("unexpected %q in content_html:\n%s", banned, post.ContentHTML)
+		}
+	}
+
+	wantTOC := []model.TOCEntry{
+		{Level: 1, Text: "Введение", ID: "vvedenie"},
+		{Level: 2, Text: "Установка", ID: "ustanovka"},
+		{Level: 2, Text: "Установка", ID: "ustanovka-2"},
+	}
+	if !reflect.DeepEqual(post.TOC, wantTOC) {
+		t.Errorf("expected toc %+v, got %+v", wantTOC, post.TOC)
+	}
+
+	// HTML хранится с постом и пересчитывается при изменении текста
+	w = doJSON(router, http.MethodGet, "/api/posts/by-slug/"+post.Slug, "")
+	if stored := decodePost(t, w.Body.Bytes()); stored.ContentHTML != post.ContentHTML {
+		t.Errorf("expected stored content_html, got %q", stored.ContentHTML)
+	}
+
+	w = doJSON(router, http.MethodPut, "/api/posts/1", `{"title": "Markdown", "content": "Просто *текст*"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	updated := decodePost(t, w.Body.Bytes())
+	if updated.ContentHTML != "<p>Просто <em>текст</em></p>\n" || len(updated.TOC) != 0 {
+		t.Errorf("expected re-rendered content, got %q, toc %+v", updated.ContentHTML, updated.TOC)
+	}
+}
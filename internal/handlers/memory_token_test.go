@@ -0,0 +1,441 @@
+// internal/handlers/memory_token_test.go
+package handlers_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+)
+
+// MemoryRefreshTokenRepository — in-memory хранилище refresh токенов
+type MemoryRefreshTokenRepository struct {
+	tokens map[string]*model.RefreshToken // ключ — хеш токена
+	mu     sync.Mutex
+	nextID int
+}
+
+// NewMemoryRefreshTokenRepository создает пустое хранилище refresh токенов
+func NewMemoryRefreshTokenRepository() repository.RefreshTokenRepository {
+	return &MemoryRefreshTokenRepository{
+		tokens: make(map[string]*model.RefreshToken),
+		nextID: 1,
+	}
+}
+
+// Create сохраняет токен с уникальным ID
+func (r *MemoryRefreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token.ID = r.nextID
+	token.CreatedAt = time.Now()
+	r.tokens[token.TokenHash] = token
+	r.nextID++
+	return nil
+}
+
+// GetByHash возвращает копию токена по хешу (nil, если не найден)
+func (r *MemoryRefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, exists := r.tokens[tokenHash]
+	if !exists {
+		return nil, nil
+	}
+	copied := *token
+	return &copied, nil
+}
+
+// Revoke отзывает активный токен
+func (r *MemoryRefreshTokenRepository) Revoke(ctx context.Context, id int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.ID == id && token.RevokedAt == nil {
+			now := time.Now()
+			token.RevokedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// RevokeFamily отзывает все токены цепочки
+func (r *MemoryRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, token := range r.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// RevokeAllForUser отзывает все токены пользователя
+func (r *MemoryRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// MemoryRevokedTokenRepository — in-memory хранилище отозванных access токенов
+type MemoryRevokedTokenRepository struct {
+	tokens  map[string]*model.RevokedToken
+	cutoffs map[int]time.Time
+	mu      sync.Mutex
+}
+
+// NewMemoryRevokedTokenRepository создает пустое хранилище отозванных токенов
+func NewMemoryRevokedTokenRepository() repository.RevokedTokenRepository {
+	return &MemoryRevokedTokenRepository{
+		tokens:  make(map[string]*model.RevokedToken),
+		cutoffs: make(map[int]time.Time),
+	}
+}
+
+// RevokeToken сохраняет отозванный токен
+func (r *MemoryRevokedTokenRepository) RevokeToken(ctx context.Context, token *model.RevokedToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens[token.JTI] = token
+	return nil
+}
+
+// RevokeAllForUser запоминает момент отзыва всех токенов пользователя
+func (r *MemoryRevokedTokenRepository) RevokeAllForUser(ctx context.Context, userID int, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cutoffs[userID] = before
+	return nil
+}
+
+// ListRevokedTokens возвращает не истекшие отозванные токены
+func (r *MemoryRevokedTokenRepository) ListRevokedTokens(ctx context.Context) ([]*model.RevokedToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var tokens []*model.RevokedToken
+	for _, token := range r.tokens {
+		if token.ExpiresAt.After(time.Now()) {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+// ListUserCutoffs возвращает моменты отзыва после since
+func (r *MemoryRevokedTokenRepository) ListUserCutoffs(ctx context.Context, since time.Time) (map[int]time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cutoffs := make(map[int]time.Time)
+	for userID, before := range r.cutoffs {
+		if before.After(since) {
+			cutoffs[userID] = before
+		}
+	}
+	return cutoffs, nil
+}
+
+// DeleteExpired удаляет истекшие записи
+func (r *MemoryRevokedTokenRepository) DeleteExpired(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for jti, token := range r.tokens {
+		if !token.ExpiresAt.After(time.Now()) {
+			delete(r.tokens, jti)
+		}
+	}
+	return nil
+}
+
+// Интерфейсы реализованы
+var _ repository.RefreshTokenRepository = (*MemoryRefreshTokenRepository)(nil)
+var _ repository.RevokedTokenRepository = (*MemoryRevokedTokenRepository)(nil)
+
+// MemoryPersonalAccessTokenRepository — in-memory хранилище personal access токенов
+type MemoryPersonalAccessTokenRepository struct {
+	tokens []*model.PersonalAccessToken
+	mu     sync.Mutex
+}
+
+// NewMemoryPersonalAccessTokenRepository создает пустое хранилище
+func NewMemoryPersonalAccessTokenRepository() repository.PersonalAccessTokenRepository {
+	return &MemoryPersonalAccessTokenRepository{}
+}
+
+// Create сохраняет токен с уникальным ID
+func (r *MemoryPersonalAccessTokenRepository) Create(ctx context.Context, token *model.PersonalAccessToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token.ID = len(r.tokens) + 1
+	token.CreatedAt = time.Now()
+	r.tokens = append(r.tokens, token)
+	return nil
+}
+
+// GetByHash возвращает копию токена по хешу (nil, если не найден)
+func (r *MemoryPersonalAccessTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*model.PersonalAccessToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+// ListByUser возвращает неотозванные токены пользователя
+func (r *MemoryPersonalAccessTokenRepository) ListByUser(ctx context.Context, userID int) ([]*model.PersonalAccessToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var tokens []*model.PersonalAccessToken
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+// Revoke отзывает токен пользователя
+func (r *MemoryPersonalAccessTokenRepository) Revoke(ctx context.Context, userID, id int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.ID == id && token.UserID == userID && token.RevokedAt == nil {
+			now := time.Now()
+			token.RevokedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// RevokeAllForUser отзывает все токены пользователя
+func (r *MemoryPersonalAccessTokenRepository) RevokeAllForUser(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// TouchLastUsed обновляет время последнего использования
+func (r *MemoryPersonalAccessTokenRepository) TouchLastUsed(ctx context.Context, id int, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.ID == id {
+			token.LastUsedAt = &usedAt
+		}
+	}
+	return nil
+}
+
+// MemoryRecoveryCodeRepository — in-memory хранилище кодов восстановления 2FA
+type MemoryRecoveryCodeRepository struct {
+	codes map[int]map[string]bool // userID → хеш → использован
+	mu    sync.Mutex
+}
+
+// NewMemoryRecoveryCodeRepository создает пустое хранилище кодов восстановления
+func NewMemoryRecoveryCodeRepository() repository.RecoveryCodeRepository {
+	return &MemoryRecoveryCodeRepository{
+		codes: make(map[int]map[string]bool),
+	}
+}
+
+// ReplaceForUser заменяет все коды пользователя
+func (r *MemoryRecoveryCodeRepository) ReplaceForUser(ctx context.Context, userID int, codeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	codes := make(map[string]bool, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes[hash] = false
+	}
+	r.codes[userID] = codes
+	return nil
+}
+
+// Consume помечает код использованным; false — кода нет или он уже использован
+func (r *MemoryRecoveryCodeRepository) Consume(ctx context.Context, userID int, codeHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	used, exists := r.codes[userID][codeHash]
+	if !exists || used {
+		return false, nil
+	}
+	r.codes[userID][codeHash] = true
+	return true, nil
+}
+
+// DeleteForUser удаляет все коды пользователя
+func (r *MemoryRecoveryCodeRepository) DeleteForUser(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.codes, userID)
+	return nil
+}
+
+// MemoryOIDCFlowRepository — in-memory хранилище начатых входов через OIDC
+type MemoryOIDCFlowRepository struct {
+	flows map[string]time.Time // хеш flowID → срок действия
+	mu    sync.Mutex
+}
+
+// NewMemoryOIDCFlowRepository создает пустое хранилище входов
+func NewMemoryOIDCFlowRepository() repository.OIDCFlowRepository {
+	return &MemoryOIDCFlowRepository{
+		flows: make(map[string]time.Time),
+	}
+}
+
+// Create сохраняет начатый вход
+func (r *MemoryOIDCFlowRepository) Create(ctx context.Context, flowHash string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.flows[flowHash] = expiresAt
+	return nil
+}
+
+// Consume удаляет действующий вход; false — входа нет или он истек
+func (r *MemoryOIDCFlowRepository) Consume(ctx context.Context, flowHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	expiresAt, ok := r.flows[flowHash]
+	delete(r.flows, flowHash)
+	return ok && time.Now().Before(expiresAt), nil
+}
+
+// MemoryInvitationRepository — in-memory хранилище приглашений на регистрацию
+type MemoryInvitationRepository struct {
+	invitations map[int]*model.Invitation
+	mu          sync.Mutex
+	nextID      int
+	staleReads  bool // GetByHash не видит отметку об использовании — как Check, выполненный до параллельной регистрации
+}
+
+// NewMemoryInvitationRepository создает пустое хранилище приглашений
+func NewMemoryInvitationRepository() *MemoryInvitationRepository {
+	return &MemoryInvitationRepository{
+		invitations: make(map[int]*model.Invitation),
+		nextID:      1,
+	}
+}
+
+// Create сохраняет приглашение с уникальным ID
+func (r *MemoryInvitationRepository) Create(ctx context.Context, invitation *model.Invitation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	invitation.ID = r.nextID
+	invitation.CreatedAt = time.Now()
+	copied := *invitation
+	r.invitations[invitation.ID] = &copied
+	r.nextID++
+	return nil
+}
+
+// GetByHash возвращает копию приглашения по хешу кода (nil, если не найдено)
+func (r *MemoryInvitationRepository) GetByHash(ctx context.Context, codeHash string) (*model.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, invitation := range r.invitations {
+		if invitation.CodeHash == codeHash {
+			copied := *invitation
+			if r.staleReads {
+				copied.UsedAt, copied.UsedBy = nil, nil
+			}
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+// List возвращает копии приглашений (новые первыми)
+func (r *MemoryInvitationRepository) List(ctx context.Context) ([]*model.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var invitations []*model.Invitation
+	for id := r.nextID - 1; id > 0; id-- {
+		if invitation, exists := r.invitations[id]; exists {
+			copied := *invitation
+			invitations = append(invitations, &copied)
+		}
+	}
+	return invitations, nil
+}
+
+// Delete удаляет неиспользованное приглашение
+func (r *MemoryInvitationRepository) Delete(ctx context.Context, id int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	invitation, exists := r.invitations[id]
+	if !exists || invitation.UsedAt != nil {
+		return false, nil
+	}
+	delete(r.invitations, id)
+	return true, nil
+}
+
+// consume помечает действующее приглашение использованным (для MemoryUserRepository.CreateInvitedUser)
+func (r *MemoryInvitationRepository) consume(id, userID int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	invitation, exists := r.invitations[id]
+	if !exists || invitation.UsedAt != nil || !time.Now().Before(invitation.ExpiresAt) {
+		return false
+	}
+	now := time.Now()
+	invitation.UsedAt = &now
+	invitation.UsedBy = &userID
+	return true
+}
+
+// expire переводит срок действия приглашения в прошлое (для тестов)
+func (r *MemoryInvitationRepository) expire(id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.invitations[id].ExpiresAt = time.Now().Add(-time.Minute)
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/internal/model"
+	"blog-backend/pkg/auth"
+	"blog-backend/service"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// MFAHandler обрабатывает двухфакторную аутентификацию
+type MFAHandler struct {
+	mfaService *service.MFAService
+	log        *log.Logger
+}
+
+// NewMFAHandler создает новый MFAHandler
+func NewMFAHandler(mfaService *service.MFAService, logger *log.Logger) *MFAHandler {
+	return &MFAHandler{
+		mfaService: mfaService,
+		log:        logger,
+	}
+}
+
+// VerifyHandler второй шаг входа: mfa_token из /api/login + код из приложения
+// POST /api/auth/mfa/verify
+func (h *MFAHandler) VerifyHandler(w http.ResponseWriter, r *http.Request) {
+	var req model.MFAVerifyRequest
+	if err := parseJSONRequest(r, &req); err != nil {
+		middleware.AbortError(w, r, "Invalid JSON", http.StatusBadRequest, err)
+		return
+	}
+	if req.MFAToken == "" || req.Code == "" {
+		middleware.AbortError(w, r, "mfa_token and code are required", http.StatusBadRequest, nil)
+		return
+	}
+
+	user, tokens, err := h.mfaService.CompleteLogin(sessionContext(r), req.MFAToken, req.Code)
+	if err != nil {
+		h.abortMFAError(w, r, err, "Failed to verify code")
+		return
+	}
+
+	sendJSONResponse(w, loginResponse(user, tokens), http.StatusOK)
+}
+
+// EnrollHandler начинает подключение приложения-аутентификатора
+// POST /api/profile/mfa/totp
+func (h *MFAHandler) EnrollHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	enrollment, err := h.mfaService.BeginEnrollment(r.Context(), userID)
+	if err != nil {
+		h.abortMFAError(w, r, err, "Failed to start enrollment")
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"message":     "Scan the QR code and confirm with a code from the app",
+		"secret":      enrollment.Secret,
+		"otpauth_uri": enrollment.URI,
+	}, http.StatusOK)
+}
+
+// ConfirmHandler включает 2FA по коду из приложения и возвращает коды восстановления
+// POST /api/profile/mfa/totp/confirm
+func (h *MFAHandler) ConfirmHandler(w http.ResponseWriter, r *http.Request) {
+	userID, req, ok := h.parseCodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.mfaService.ConfirmEnrollment(r.Context(), userID, req.Code)
+	if err != nil {
+		h.abortMFAError(w, r, err, "Failed to enable two-factor authentication")
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"message":        "Two-factor authentication enabled. Store recovery codes in a safe place",
+		"recovery_codes": codes,
+	}, http.StatusOK)
+}
+
+// DisableHandler выключает 2FA (код из приложения или код восстановления)
+// DELETE /api/profile/mfa/totp
+func (h *MFAHandler) DisableHandler(w http.ResponseWriter, r *http.Request) {
+	userID, req, ok := h.parseCodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.mfaService.Disable(r.Context(), userID, req.Code); err != nil {
+		h.abortMFAError(w, r, err, "Failed to disable two-factor authentication")
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"message": "Two-factor authentication disabled",
+	}, http.StatusOK)
+}
+
+// RecoveryCodesHandler выдает новые коды восстановления (старые перестают работать)
+// POST /api/profile/mfa/recovery-codes
+func (h *MFAHandler) RecoveryCodesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, req, ok := h.parseCodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(r.Context(), userID, req.Code)
+	if err != nil {
+		h.abortMFAError(w, r, err, "Failed to regenerate recovery codes")
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"message":        "Recovery codes regenerated",
+		"recovery_codes": codes,
+	}, http.StatusOK)
+}
+
+// parseCodeRequest достает userID и {"code": "..."} из запроса
+func (h *MFAHandler) parseCodeRequest(w http.ResponseWriter, r *http.Request) (int, model.MFACodeRequest, bool) {
+	var req model.MFACodeRequest
+
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return 0, req, false
+	}
+	if err := parseJSONRequest(r, &req); err != nil {
+		middleware.AbortError(w, r, "Invalid JSON", http.StatusBadRequest, err)
+		return 0, req, false
+	}
+	if req.Code == "" {
+		middleware.AbortError(w, r, "code is required", http.StatusBadRequest, nil)
+		return 0, req, false
+	}
+	return userID, req, true
+}
+
+// abortMFAError переводит ошибки MFAService в HTTP коды
+func (h *MFAHandler) abortMFAError(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidMFAToken):
+		middleware.AbortError(w, r, "Invalid or expired mfa_token, log in again", http.StatusUnauthorized, err)
+	case errors.Is(err, service.ErrInvalidMFACode):
+		middleware.AbortError(w, r, "Invalid code", http.StatusUnauthorized, err)
+	case errors.Is(err, service.ErrTooManyMFAAttempts):
+		w.Header().Set("Retry-After", "300")
+		middleware.AbortError(w, r, "Too many invalid codes, try again later", http.StatusTooManyRequests, err)
+	case errors.Is(err, service.ErrMFAAlreadyEnabled):
+		middleware.AbortError(w, r, "Two-factor authentication already enabled", http.StatusConflict, err)
+	case errors.Is(err, service.ErrMFANotEnabled), errors.Is(err, service.ErrMFAEnrollmentNeeded):
+		middleware.AbortError(w, r, err.Error(), http.StatusConflict, err)
+	default:
+		middleware.AbortError(w, r, fallback, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,196 @@
+// internal/handlers/mfa_handler_test.go
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"blog-backend/internal/handlers"
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/internal/repository"
+	"blog-backend/internal/repository/memory"
+	"blog-backend/pkg/totp"
+	"blog-backend/service"
+)
+
+// setupMFATestRouter - роутер со входом и управлением 2FA (и хранилище пользователей)
+func setupMFATestRouter() (http.Handler, repository.UserRepository) {
+	userRepo := NewMemoryUserRepository()
+	cfg := NewTestConfig()
+
+	revocations := service.NewRevocationStore(NewMemoryRevokedTokenRepository(), cfg)
+	tokenSvc := service.NewTokenService(NewMemoryRefreshTokenRepository(), NewMemoryPersonalAccessTokenRepository(), userRepo, revocations, service.NewSessionService(NewMemorySessionRepository(), revocations, cfg), cfg)
+	verifier := service.NewEmailVerificationService(userRepo, &RecordingMailer{}, cfg)
+	mfaSvc := service.NewMFAService(userRepo, NewMemoryRecoveryCodeRepository(), tokenSvc, cfg)
+	throttle := service.NewLoginThrottleService(memory.NewMemoryLoginAttemptRepository(), cfg)
+	userSvc := service.NewUserService(userRepo, tokenSvc, verifier, mfaSvc, throttle, service.NewRegistrationService(NewMemoryInvitationRepository(), cfg))
+
+	logger := log.New(io.Discard, "", 0)
+	userHandler := handlers.NewUserHandler(userSvc, logger)
+	mfaHandler := handlers.NewMFAHandler(mfaSvc, logger)
+	authenticator := middleware.NewAuthenticator(revocations, nil)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/auth/login", userHandler.LoginHandler)
+	mux.HandleFunc("POST /api/auth/mfa/verify", mfaHandler.VerifyHandler)
+	mux.HandleFunc("GET /api/profile", authenticator.AuthMiddleware(userHandler.ProfileHandler))
+	mux.HandleFunc("POST /api/profile/mfa/totp", authenticator.AuthMiddleware(mfaHandler.EnrollHandler))
+	mux.HandleFunc("POST /api/profile/mfa/totp/confirm", authenticator.AuthMiddleware(mfaHandler.ConfirmHandler))
+	mux.HandleFunc("DELETE /api/profile/mfa/totp", authenticator.AuthMiddleware(mfaHandler.DisableHandler))
+
+	return mux, userRepo
+}
+
+// mfaLogin - первый шаг входа при включенной 2FA, возвращает mfa_token
+func mfaLogin(t *testing.T, router http.Handler) string {
+	t.Helper()
+
+	w := doJSON(router, http.MethodPost, "/api/auth/login",
+		`{"email": "test@example.com", "password": "password123"}`)
+	var resp struct {
+		MFARequired bool   `json:"mfa_required"`
+		MFAToken    string `json:"mfa_token"`
+		Token       string `json:"token"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || !resp.MFARequired || resp.MFAToken == "" || resp.Token != "" {
+		t.Fatalf("expected mfa challenge without tokens, got %d: %+v", w.Code, resp)
+	}
+	return resp.MFAToken
+}
+
+// totpCode - код приложения-аутентификатора для шага step
+func totpCode(t *testing.T, secret string, step int64) string {
+	t.Helper()
+
+	code, err := totp.Code(secret, step)
+	if err != nil {
+		t.Fatalf("totp code: %v", err)
+	}
+	return code
+}
+
+// TestTOTPTwoFactor - подключение, вход с кодом, защита от повтора, коды восстановления и лимит попыток
+func TestTOTPTwoFactor(t *testing.T) {
+	router, _ := setupMFATestRouter()
+	accessToken, _ := loginTokens(t, router)
+
+	// 1. Подключение: секрет и otpauth:// URI
+	w := doAuthJSON(router, http.MethodPost, "/api/profile/mfa/totp", accessToken, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("enroll: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var enrollment struct {
+		Secret string `json:"secret"`
+		URI    string `json:"otpauth_uri"`
+	}
+	json.NewDecoder(w.Body).Decode(&enrollment)
+	if enrollment.Secret == "" || enrollment.URI == "" {
+		t.Fatalf("enroll: missing secret or uri: %+v", enrollment)
+	}
+
+	// 2. Неверный код не включает 2FA, верный — включает и выдает коды восстановления
+	step := totp.Step(time.Now())
+	if w := doAuthJSON(router, http.MethodPost, "/api/profile/mfa/totp/confirm", accessToken, `{"code": "000000x"}`); w.Code != http.StatusUnauthorized {
+		t.Fatalf("confirm with bad code: expected 401, got %d", w.Code)
+	}
+	w = doAuthJSON(router, http.MethodPost, "/api/profile/mfa/totp/confirm", accessToken,
+		fmt.Sprintf(`{"code": %q}`, totpCode(t, enrollment.Secret, step)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("confirm: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var confirmed struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	json.NewDecoder(w.Body).Decode(&confirmed)
+	if len(confirmed.RecoveryCodes) != 10 {
+		t.Fatalf("expected 10 recovery codes, got %d", len(confirmed.RecoveryCodes))
+	}
+	if w := doAuthJSON(router, http.MethodPost, "/api/profile/mfa/totp", accessToken, ""); w.Code != http.StatusConflict {
+		t.Errorf("enroll twice: expected 409, got %d", w.Code)
+	}
+
+	// 3. Вход теперь требует код; код из подтверждения повторно не принимается
+	mfaToken := mfaLogin(t, router)
+	if w := doJSON(router, http.MethodPost, "/api/auth/mfa/verify",
+		fmt.Sprintf(`{"mfa_token": %q, "code": %q}`, mfaToken, totpCode(t, enrollment.Secret, step))); w.Code != http.StatusUnauthorized {
+		t.Errorf("replayed code: expected 401, got %d", w.Code)
+	}
+	nextCode := totpCode(t, enrollment.Secret, step+1)
+	w = doJSON(router, http.MethodPost, "/api/auth/mfa/verify", fmt.Sprintf(`{"mfa_token": %q, "code": %q}`, mfaToken, nextCode))
+	if w.Code != http.StatusOK {
+		t.Fatalf("verify: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var tokens struct {
+		Token string `json:"token"`
+	}
+	json.NewDecoder(w.Body).Decode(&tokens)
+	if w := doAuthJSON(router, http.MethodGet, "/api/profile", tokens.Token, ""); w.Code != http.StatusOK {
+		t.Errorf("profile with mfa token: expected 200, got %d", w.Code)
+	}
+
+	// 4. Подделанный mfa_token
+	if w := doJSON(router, http.MethodPost, "/api/auth/mfa/verify", fmt.Sprintf(`{"mfa_token": "forged", "code": %q}`, nextCode)); w.Code != http.StatusUnauthorized {
+		t.Errorf("forged mfa_token: expected 401, got %d", w.Code)
+	}
+
+	// 5. Код восстановления срабатывает один раз
+	recovery := fmt.Sprintf(`{"mfa_token": %q, "code": %q}`, mfaLogin(t, router), confirmed.RecoveryCodes[0])
+	if w := doJSON(router, http.MethodPost, "/api/auth/mfa/verify", recovery); w.Code != http.StatusOK {
+		t.Fatalf("recovery code: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(router, http.MethodPost, "/api/auth/mfa/verify", recovery); w.Code != http.StatusUnauthorized {
+		t.Errorf("reused recovery code: expected 401, got %d", w.Code)
+	}
+
+	// 6. После 5 неверных кодов подряд ввод блокируется даже для верного кода
+	mfaToken = mfaLogin(t, router)
+	// Первая неудача — повторный код восстановления выше
+	for i := 0; i < 4; i++ {
+		doJSON(router, http.MethodPost, "/api/auth/mfa/verify", fmt.Sprintf(`{"mfa_token": %q, "code": "000000"}`, mfaToken))
+	}
+	w = doJSON(router, http.MethodPost, "/api/auth/mfa/verify",
+		fmt.Sprintf(`{"mfa_token": %q, "code": %q}`, mfaToken, confirmed.RecoveryCodes[1]))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("after 5 failures: expected 429, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header")
+	}
+}
+
+// TestTOTPSecretEncryption - секрет в БД зашифрован; секрет, сохраненный открытым текстом
+// до включения шифрования, принимается и при первой проверке кода шифруется
+func TestTOTPSecretEncryption(t *testing.T) {
+	router, userRepo := setupMFATestRouter()
+	accessToken, _ := loginTokens(t, router)
+
+	w := doAuthJSON(router, http.MethodPost, "/api/profile/mfa/totp", accessToken, "")
+	var enrollment struct {
+		Secret string `json:"secret"`
+	}
+	json.NewDecoder(w.Body).Decode(&enrollment)
+	user, _ := userRepo.GetUserByID(t.Context(), 1)
+	if user.TOTPSecret == "" || strings.Contains(user.TOTPSecret, enrollment.Secret) {
+		t.Fatalf("expected encrypted secret in storage, got %q", user.TOTPSecret)
+	}
+
+	// Секрет открытым текстом (сохранен до включения шифрования)
+	const legacySecret = "JBSWY3DPEHPK3PXP"
+	userRepo.SetTOTPSecret(t.Context(), 1, legacySecret)
+	userRepo.EnableTOTP(t.Context(), 1)
+
+	code := fmt.Sprintf(`{"mfa_token": %q, "code": %q}`, mfaLogin(t, router), totpCode(t, legacySecret, totp.Step(time.Now())))
+	if w := doJSON(router, http.MethodPost, "/api/auth/mfa/verify", code); w.Code != http.StatusOK {
+		t.Fatalf("legacy secret: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if user, _ := userRepo.GetUserByID(t.Context(), 1); user.TOTPSecret == legacySecret || !strings.HasPrefix(user.TOTPSecret, "v1:") {
+		t.Errorf("expected legacy secret to be encrypted after verification, got %q", user.TOTPSecret)
+	}
+}
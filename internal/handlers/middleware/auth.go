@@ -1,14 +1,41 @@
 package middleware
 
 import (
+	"blog-backend/internal/model"
+	"blog-backend/pkg/auth"
 	"blog-backend/pkg/jwt"
 	"context"
 	"net/http"
+	"slices"
 	"strings"
 )
 
-// AuthMiddleware проверяет JWT токен и устанавливает контекст пользователя
-func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+// TokenRevocationChecker проверяет, не отозван ли токен (logout)
+type TokenRevocationChecker interface {
+	IsRevoked(ctx context.Context, claims *model.Claims) (bool, error)
+}
+
+// PersonalAccessTokenAuthenticator проверяет personal access токены.
+// nil claims без ошибки — токен недействителен
+type PersonalAccessTokenAuthenticator interface {
+	AuthenticatePAT(ctx context.Context, token string) (*model.Claims, []string, error)
+}
+
+// Authenticator - JWT middleware с проверкой отозванных токенов
+type Authenticator struct {
+	revocations TokenRevocationChecker
+	pats        PersonalAccessTokenAuthenticator // nil — PAT не принимаются
+}
+
+// NewAuthenticator создает Authenticator
+func NewAuthenticator(revocations TokenRevocationChecker, pats PersonalAccessTokenAuthenticator) *Authenticator {
+	return &Authenticator{revocations: revocations, pats: pats}
+}
+
+// AuthMiddleware проверяет JWT или personal access токен и устанавливает контекст пользователя.
+// scopes — области доступа, которые должен иметь PAT для этого маршрута;
+// без scopes маршрут доступен только по JWT (например, управление самими токенами)
+func (a *Authenticator) AuthMiddleware(next http.HandlerFunc, scopes ...string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		// Получаем заголовок Authorization из запроса
@@ -29,6 +56,12 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		// Извлекаем токен
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
+		// Personal access токен для скриптов и CI
+		if strings.HasPrefix(tokenString, model.PersonalAccessTokenPrefix) {
+			a.authenticatePAT(w, r, next, tokenString, scopes)
+			return
+		}
+
 		// Валидируем токен с помощью ValidateToken() из auth.go
 		claims, err := jwt.ValidateToken(tokenString)
 		if err != nil {
@@ -36,10 +69,91 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		// Проверяем, что токен не отозван (logout)
+		revoked, err := a.revocations.IsRevoked(r.Context(), claims)
+		if err != nil {
+			AbortError(w, r, "Failed to verify token", http.StatusInternalServerError, err)
+			return
+		}
+		if revoked {
+			AbortError(w, r, "Token has been revoked", http.StatusUnauthorized, nil)
+			return
+		}
+
 		// 6. Добавьте данные пользователя в контекст запроса
 		ctx := context.WithValue(r.Context(), "userID", claims.UserID)
+		ctx = auth.WithClaims(ctx, claims)
 
 		// 7. Передаем управление следующему обработчику
 		next.ServeHTTP(w, r.WithContext(ctx))
 	}
 }
+
+// OptionalAuthMiddleware - для публичных маршрутов, ответ которых зависит от пользователя
+// (например, скрытые им комментарии). Без заголовка Authorization запрос проходит анонимно,
+// неверный или отозванный токен по-прежнему дает 401
+func (a *Authenticator) OptionalAuthMiddleware(next http.HandlerFunc, scopes ...string) http.HandlerFunc {
+	authenticated := a.AuthMiddleware(next, scopes...)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		authenticated.ServeHTTP(w, r)
+	}
+}
+
+// authenticatePAT проверяет personal access токен и его области доступа
+func (a *Authenticator) authenticatePAT(w http.ResponseWriter, r *http.Request, next http.HandlerFunc, token string, required []string) {
+	if a.pats == nil {
+		AbortError(w, r, "Invalid token", http.StatusUnauthorized, nil)
+		return
+	}
+
+	claims, scopes, err := a.pats.AuthenticatePAT(r.Context(), token)
+	if err != nil {
+		AbortError(w, r, "Failed to verify token", http.StatusInternalServerError, err)
+		return
+	}
+	if claims == nil {
+		AbortError(w, r, "Invalid token", http.StatusUnauthorized, nil)
+		return
+	}
+
+	if len(required) == 0 {
+		AbortError(w, r, "Personal access tokens are not allowed for this endpoint", http.StatusForbidden, nil)
+		return
+	}
+	for _, scope := range required {
+		if !slices.Contains(scopes, scope) {
+			AbortError(w, r, "Insufficient token scope: "+scope+" required", http.StatusForbidden, nil)
+			return
+		}
+	}
+
+	ctx := context.WithValue(r.Context(), "userID", claims.UserID)
+	ctx = auth.WithClaims(ctx, claims)
+	ctx = auth.WithScopes(ctx, scopes)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// RequireRole пропускает только пользователей с одной из ролей.
+// Использовать после AuthMiddleware: authenticator.AuthMiddleware(RequireRole(model.RoleAdmin)(handler))
+func RequireRole(roles ...string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := auth.GetClaimsFromContext(r)
+			if !ok {
+				AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+				return
+			}
+
+			if !slices.Contains(roles, claims.Role) {
+				AbortError(w, r, "Permission denied", http.StatusForbidden, nil)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// RealIPMiddleware — подставляет адрес клиента из заголовков прокси в r.RemoteAddr.
+// Включается только за доверенным прокси (TRUST_PROXY_HEADERS): иначе клиент может подделать свой IP
+func RealIPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ip := proxyClientIP(r); ip != "" {
+			r.RemoteAddr = net.JoinHostPort(ip, "0")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// proxyClientIP берет X-Real-IP или последний адрес X-Forwarded-For
+// (его добавил наш прокси; начало списка клиент может подставить сам)
+func proxyClientIP(r *http.Request) string {
+	candidate := strings.TrimSpace(r.Header.Get("X-Real-IP"))
+	if candidate == "" {
+		forwarded := r.Header.Values("X-Forwarded-For")
+		if len(forwarded) > 0 {
+			list := strings.Split(forwarded[len(forwarded)-1], ",")
+			candidate = strings.TrimSpace(list[len(list)-1])
+		}
+	}
+
+	if ip := net.ParseIP(candidate); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
+// ClientIP возвращает IP адрес клиента без порта
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/service"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// oidcFlowCookie - cookie с flowID входа: связывает callback с браузером, начавшим вход
+const (
+	oidcFlowCookie     = "oidc_flow"
+	oidcFlowCookiePath = "/api/auth/oidc"
+)
+
+// OIDCHandler обрабатывает вход через OpenID Connect (SSO)
+type OIDCHandler struct {
+	oidcService *service.OIDCService
+	log         *log.Logger
+}
+
+// NewOIDCHandler создает новый OIDCHandler
+func NewOIDCHandler(oidcService *service.OIDCService, logger *log.Logger) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+		log:         logger,
+	}
+}
+
+// Login перенаправляет на страницу входа IdP
+// GET /api/auth/oidc/login
+func (h *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	authURL, flowID, err := h.oidcService.LoginURL(r.Context())
+	if err != nil {
+		middleware.AbortError(w, r, "Identity provider is unavailable", http.StatusBadGateway, err)
+		return
+	}
+
+	// SameSite=Lax: cookie уходит при возврате из IdP (переход по ссылке верхнего уровня),
+	// но не с запросами, которые сторонний сайт делает в фоне
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcFlowCookie,
+		Value:    flowID,
+		Path:     oidcFlowCookiePath,
+		MaxAge:   int(h.oidcService.FlowTTL().Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Callback принимает code и state от IdP и выдает пару токенов, как обычный вход.
+// Нужна cookie, выставленная в Login этому же браузеру
+// GET /api/auth/oidc/callback?code=...&state=...
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	// Вход одноразовый: cookie больше не нужна при любом исходе
+	var flowID string
+	if cookie, err := r.Cookie(oidcFlowCookie); err == nil {
+		flowID = cookie.Value
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcFlowCookie,
+		Path:     oidcFlowCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	// Пользователь отказался или IdP не смог его аутентифицировать
+	if idpError := query.Get("error"); idpError != "" {
+		middleware.AbortError(w, r, "Identity provider rejected the login: "+idpError, http.StatusUnauthorized, nil)
+		return
+	}
+	code, state := query.Get("code"), query.Get("state")
+	if code == "" || state == "" {
+		middleware.AbortError(w, r, "code and state are required", http.StatusBadRequest, nil)
+		return
+	}
+
+	user, tokens, err := h.oidcService.Callback(sessionContext(r), code, state, flowID)
+	var mfaRequired *service.MFARequiredError
+	if errors.As(err, &mfaRequired) {
+		sendMFARequired(w, mfaRequired)
+		return
+	}
+	if err != nil {
+		h.abortOIDCError(w, r, err)
+		return
+	}
+
+	sendJSONResponse(w, loginResponse(user, tokens), http.StatusOK)
+}
+
+// abortOIDCError переводит ошибки OIDCService в HTTP коды
+func (h *OIDCHandler) abortOIDCError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidOIDCState):
+		middleware.AbortError(w, r, "Invalid or expired sign-in state, start the login again", http.StatusBadRequest, err)
+	case errors.Is(err, service.ErrOIDCLoginFailed):
+		middleware.AbortError(w, r, "Single sign-on failed", http.StatusUnauthorized, err)
+	case errors.Is(err, service.ErrOIDCEmailNotVerified):
+		middleware.AbortError(w, r, "Identity provider did not confirm your email address", http.StatusForbidden, err)
+	case errors.Is(err, service.ErrOIDCAccountNotFound):
+		middleware.AbortError(w, r, "No account for this email, ask an administrator to create one", http.StatusForbidden, err)
+	case errors.Is(err, service.ErrEmailDomainNotAllowed):
+		middleware.AbortError(w, r, "Registration is not allowed for this email domain", http.StatusForbidden, err)
+	case errors.Is(err, service.ErrOIDCProviderUnavailable):
+		middleware.AbortError(w, r, "Identity provider is unavailable", http.StatusBadGateway, err)
+	default:
+		middleware.AbortError(w, r, "Failed to complete single sign-on", http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,461 @@
+// internal/handlers/oidc_handler_test.go
+package handlers_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"blog-backend/internal/handlers"
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/internal/repository"
+	"blog-backend/internal/repository/memory"
+	"blog-backend/pkg/oidc"
+	"blog-backend/service"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+)
+
+// Параметры клиента, зарегистрированного в тестовом IdP
+const (
+	testOIDCClientID    = "blog"
+	testOIDCSecret      = "idp-secret"
+	testOIDCRedirectURL = "http://blog.test/api/auth/oidc/callback"
+)
+
+// mockIdP - локальный OpenID провайдер: discovery, JWKS и token endpoint с проверкой PKCE
+type mockIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]mockAuthorization // выданные коды (одноразовые)
+	// badNonce - подменить nonce в id_token
+	badNonce bool
+}
+
+// mockAuthorization - то, что IdP запомнил при входе пользователя
+type mockAuthorization struct {
+	challenge   string
+	redirectURI string
+	nonce       string
+	claims      gojwt.MapClaims
+}
+
+// newMockIdP запускает тестовый IdP
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	idp := &mockIdP{key: key, codes: make(map[string]mockAuthorization)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"kid": "idp-key",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", idp.token)
+
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// token - обмен кода на id_token (RFC 6749 4.1.3 + RFC 7636 4.6)
+func (idp *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	invalid := func(code string) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": code})
+	}
+
+	if id, secret, ok := r.BasicAuth(); !ok || id != testOIDCClientID || secret != testOIDCSecret {
+		invalid("invalid_client")
+		return
+	}
+	if r.PostFormValue("grant_type") != "authorization_code" {
+		invalid("unsupported_grant_type")
+		return
+	}
+
+	idp.mu.Lock()
+	auth, ok := idp.codes[r.PostFormValue("code")]
+	delete(idp.codes, r.PostFormValue("code"))
+	badNonce := idp.badNonce
+	idp.mu.Unlock()
+
+	if !ok || auth.redirectURI != r.PostFormValue("redirect_uri") ||
+		oidc.CodeChallenge(r.PostFormValue("code_verifier")) != auth.challenge {
+		invalid("invalid_grant")
+		return
+	}
+
+	claims := gojwt.MapClaims{
+		"iss":   idp.server.URL,
+		"aud":   testOIDCClientID,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(5 * time.Minute).Unix(),
+		"nonce": auth.nonce,
+	}
+	if badNonce {
+		claims["nonce"] = "other"
+	}
+	for name, value := range auth.claims {
+		claims[name] = value
+	}
+	token := gojwt.NewWithClaims(gojwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "idp-key"
+	signed, _ := token.SignedString(idp.key)
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"access_token": "idp-access-token",
+		"token_type":   "Bearer",
+		"id_token":     signed,
+	})
+}
+
+// authorize имитирует вход пользователя на странице IdP: проверяет параметры
+// запроса авторизации и возвращает код и state для callback
+func (idp *mockIdP) authorize(t *testing.T, location string, claims gojwt.MapClaims) (string, string) {
+	t.Helper()
+
+	authURL, err := url.Parse(location)
+	if err != nil || !strings.HasPrefix(location, idp.server.URL+"/authorize?") {
+		t.Fatalf("unexpected authorization url %q", location)
+	}
+	query := authURL.Query()
+	if query.Get("response_type") != "code" || query.Get("client_id") != testOIDCClientID ||
+		query.Get("redirect_uri") != testOIDCRedirectURL || query.Get("code_challenge_method") != "S256" ||
+		query.Get("code_challenge") == "" || query.Get("nonce") == "" || !strings.Contains(query.Get("scope"), "openid") {
+		t.Fatalf("invalid authorization request: %v", query)
+	}
+	if query.Has("code_verifier") {
+		t.Fatal("code_verifier must not leave the server")
+	}
+
+	code := "code-" + query.Get("nonce")[:12]
+	idp.mu.Lock()
+	idp.codes[code] = mockAuthorization{
+		challenge:   query.Get("code_challenge"),
+		redirectURI: query.Get("redirect_uri"),
+		nonce:       query.Get("nonce"),
+		claims:      claims,
+	}
+	idp.mu.Unlock()
+	return code, query.Get("state")
+}
+
+// oidcBrowser - браузер пользователя: запоминает cookie из ответов и отправляет их с запросами
+type oidcBrowser struct {
+	handler http.Handler
+	mu      sync.Mutex
+	cookies map[string]*http.Cookie
+}
+
+// newOIDCBrowser - браузер без cookie, работающий с тем же сервером
+func newOIDCBrowser(handler http.Handler) *oidcBrowser {
+	return &oidcBrowser{handler: handler, cookies: make(map[string]*http.Cookie)}
+}
+
+func (b *oidcBrowser) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	for _, cookie := range b.cookies {
+		r.AddCookie(cookie)
+	}
+	b.mu.Unlock()
+
+	b.handler.ServeHTTP(w, r)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, cookie := range (&http.Response{Header: w.Header()}).Cookies() {
+		if cookie.MaxAge < 0 {
+			delete(b.cookies, cookie.Name)
+		} else {
+			b.cookies[cookie.Name] = cookie
+		}
+	}
+}
+
+// setupOIDCTestRouter - роутер с входом через тестовый IdP (запросы идут через браузер с cookie)
+func setupOIDCTestRouter(t *testing.T, autoProvision bool) (*oidcBrowser, *mockIdP, repository.UserRepository) {
+	t.Helper()
+
+	idp := newMockIdP(t)
+	cfg := NewTestConfig()
+	cfg.OIDCAutoProvision = autoProvision
+	cfg.RegistrationAllowedDomains = []string{"example.com", "corp.example"}
+
+	userRepo := NewMemoryUserRepository()
+	revocations := service.NewRevocationStore(NewMemoryRevokedTokenRepository(), cfg)
+	sessionSvc := service.NewSessionService(NewMemorySessionRepository(), revocations, cfg)
+	tokenSvc := service.NewTokenService(NewMemoryRefreshTokenRepository(), NewMemoryPersonalAccessTokenRepository(), userRepo, revocations, sessionSvc, cfg)
+	mfaSvc := service.NewMFAService(userRepo, NewMemoryRecoveryCodeRepository(), tokenSvc, cfg)
+	policy := service.NewRegistrationService(NewMemoryInvitationRepository(), cfg)
+	provider := oidc.NewProvider(oidc.Config{
+		IssuerURL:    idp.server.URL,
+		ClientID:     testOIDCClientID,
+		ClientSecret: testOIDCSecret,
+		RedirectURL:  testOIDCRedirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+		HTTPClient:   idp.server.Client(),
+	})
+	oidcSvc := service.NewOIDCService(provider, NewMemoryOIDCFlowRepository(), userRepo, tokenSvc, mfaSvc, policy, cfg)
+	verifier := service.NewEmailVerificationService(userRepo, &RecordingMailer{}, cfg)
+	throttle := service.NewLoginThrottleService(memory.NewMemoryLoginAttemptRepository(), cfg)
+	userSvc := service.NewUserService(userRepo, tokenSvc, verifier, mfaSvc, throttle, policy)
+
+	logger := log.New(io.Discard, "", 0)
+	oidcHandler := handlers.NewOIDCHandler(oidcSvc, logger)
+	userHandler := handlers.NewUserHandler(userSvc, logger)
+	authenticator := middleware.NewAuthenticator(sessionSvc, nil)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/auth/oidc/login", oidcHandler.Login)
+	mux.HandleFunc("GET /api/auth/oidc/callback", oidcHandler.Callback)
+	mux.HandleFunc("GET /api/profile", authenticator.AuthMiddleware(userHandler.ProfileHandler))
+	return newOIDCBrowser(mux), idp, userRepo
+}
+
+// startOIDCLogin - GET /api/auth/oidc/login, возвращает адрес страницы IdP
+func startOIDCLogin(t *testing.T, router http.Handler) string {
+	t.Helper()
+
+	w := doJSON(router, http.MethodGet, "/api/auth/oidc/login", "")
+	if w.Code != http.StatusFound {
+		t.Fatalf("oidc login: expected 302, got %d: %s", w.Code, w.Body.String())
+	}
+	return w.Header().Get("Location")
+}
+
+// oidcCallback - возврат из IdP с кодом и state
+func oidcCallback(router http.Handler, code, state string) *httptest.ResponseRecorder {
+	query := url.Values{"code": {code}, "state": {state}}
+	return doJSON(router, http.MethodGet, "/api/auth/oidc/callback?"+query.Encode(), "")
+}
+
+// oidcLoginResponse - ответ callback при успешном входе
+type oidcLoginResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	MFARequired  bool   `json:"mfa_required"`
+	User         struct {
+		ID            int    `json:"id"`
+		Email         string `json:"email"`
+		Username      string `json:"username"`
+		EmailVerified bool   `json:"email_verified"`
+	} `json:"user"`
+}
+
+// TestOIDCLogin - вход существующего пользователя по email и создание нового
+func TestOIDCLogin(t *testing.T) {
+	router, idp, _ := setupOIDCTestRouter(t, true)
+
+	// Существующий пользователь находится по подтвержденному email
+	code, state := idp.authorize(t, startOIDCLogin(t, router), gojwt.MapClaims{
+		"sub": "idp-user-1", "email": "test@example.com", "email_verified": true,
+	})
+	w := oidcCallback(router, code, state)
+	if w.Code != http.StatusOK {
+		t.Fatalf("existing user: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var existing oidcLoginResponse
+	json.NewDecoder(w.Body).Decode(&existing)
+	if existing.User.ID != 1 || !existing.User.EmailVerified || existing.Token == "" || existing.RefreshToken == "" {
+		t.Fatalf("expected tokens for user 1 with verified email, got %+v", existing)
+	}
+	if w := doAuthJSON(router, http.MethodGet, "/api/profile", existing.Token, ""); w.Code != http.StatusOK {
+		t.Errorf("issued token: expected 200 on profile, got %d", w.Code)
+	}
+
+	// Вход одноразовый: cookie входа удалена, повтор отклоняется еще до обмена кода
+	if w := oidcCallback(router, code, state); w.Code != http.StatusBadRequest {
+		t.Errorf("reused code: expected 400, got %d", w.Code)
+	}
+
+	// Новый пользователь создается; занятый username получает суффикс
+	code, state = idp.authorize(t, startOIDCLogin(t, router), gojwt.MapClaims{
+		"sub": "idp-user-2", "email": "new.user@corp.example", "email_verified": "true", "preferred_username": "testuser",
+	})
+	w = oidcCallback(router, code, state)
+	if w.Code != http.StatusOK {
+		t.Fatalf("new user: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var created oidcLoginResponse
+	json.NewDecoder(w.Body).Decode(&created)
+	if created.User.ID == 1 || created.User.Email != "new.user@corp.example" ||
+		created.User.Username != "testuser2" || !created.User.EmailVerified {
+		t.Fatalf("unexpected provisioned user: %+v", created.User)
+	}
+
+	// Повторный вход попадает в тот же аккаунт
+	code, state = idp.authorize(t, startOIDCLogin(t, router), gojwt.MapClaims{
+		"sub": "idp-user-2", "email": "new.user@corp.example", "email_verified": true,
+	})
+	w = oidcCallback(router, code, state)
+	var again oidcLoginResponse
+	json.NewDecoder(w.Body).Decode(&again)
+	if w.Code != http.StatusOK || again.User.ID != created.User.ID {
+		t.Errorf("second login: expected user %d, got %d %+v", created.User.ID, w.Code, again.User)
+	}
+}
+
+// TestOIDCLoginRejected - неподтвержденный email, подмена state, чужой verifier и nonce
+func TestOIDCLoginRejected(t *testing.T) {
+	router, idp, _ := setupOIDCTestRouter(t, true)
+	verified := gojwt.MapClaims{"sub": "idp-user-1", "email": "test@example.com", "email_verified": true}
+
+	t.Run("unverified email", func(t *testing.T) {
+		code, state := idp.authorize(t, startOIDCLogin(t, router), gojwt.MapClaims{
+			"sub": "idp-user-3", "email": "test@example.com", "email_verified": false,
+		})
+		if w := oidcCallback(router, code, state); w.Code != http.StatusForbidden {
+			t.Errorf("expected 403, got %d", w.Code)
+		}
+	})
+
+	t.Run("domain not allowed", func(t *testing.T) {
+		code, state := idp.authorize(t, startOIDCLogin(t, router), gojwt.MapClaims{
+			"sub": "idp-user-4", "email": "someone@other.example", "email_verified": true,
+		})
+		if w := oidcCallback(router, code, state); w.Code != http.StatusForbidden {
+			t.Errorf("expected 403, got %d", w.Code)
+		}
+	})
+
+	t.Run("without login cookie", func(t *testing.T) {
+		// code и state перехвачены (из редиректа или лога) и отправлены с другого устройства
+		code, state := idp.authorize(t, startOIDCLogin(t, router), verified)
+		if w := oidcCallback(router.handler, code, state); w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("attacker login in victim browser", func(t *testing.T) {
+		// Login CSRF: злоумышленник начинает вход у себя и подсовывает жертве свои code и state
+		attacker := newOIDCBrowser(router.handler)
+		code, state := idp.authorize(t, startOIDCLogin(t, attacker), verified)
+		victim := newOIDCBrowser(router.handler)
+		startOIDCLogin(t, victim)
+		if w := oidcCallback(victim, code, state); w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("replayed cookie", func(t *testing.T) {
+		// Даже с сохраненной cookie вход завершается только один раз
+		browser := newOIDCBrowser(router.handler)
+		code, state := idp.authorize(t, startOIDCLogin(t, browser), verified)
+		cookie := browser.cookies["oidc_flow"]
+		if cookie == nil || !cookie.HttpOnly || !cookie.Secure || cookie.SameSite != http.SameSiteLaxMode {
+			t.Fatalf("expected HttpOnly, Secure, SameSite=Lax login cookie, got %+v", cookie)
+		}
+		if w := oidcCallback(browser, code, state); w.Code != http.StatusOK {
+			t.Fatalf("first callback: expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		browser.cookies["oidc_flow"] = cookie
+		if w := oidcCallback(browser, code, state); w.Code != http.StatusBadRequest {
+			t.Errorf("replay: expected 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("tampered state", func(t *testing.T) {
+		code, state := idp.authorize(t, startOIDCLogin(t, router), verified)
+		if w := oidcCallback(router, code, state+"x"); w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("code from another login", func(t *testing.T) {
+		// Код выдан под code_challenge первого входа, а state (и verifier) — второго
+		code, _ := idp.authorize(t, startOIDCLogin(t, router), verified)
+		_, otherState := idp.authorize(t, startOIDCLogin(t, router), verified)
+		if w := oidcCallback(router, code, otherState); w.Code != http.StatusUnauthorized {
+			t.Errorf("expected 401, got %d", w.Code)
+		}
+	})
+
+	t.Run("nonce mismatch", func(t *testing.T) {
+		idp.badNonce = true
+		defer func() { idp.badNonce = false }()
+		code, state := idp.authorize(t, startOIDCLogin(t, router), verified)
+		if w := oidcCallback(router, code, state); w.Code != http.StatusUnauthorized {
+			t.Errorf("expected 401, got %d", w.Code)
+		}
+	})
+
+	t.Run("denied by idp", func(t *testing.T) {
+		w := doJSON(router, http.MethodGet, "/api/auth/oidc/callback?error=access_denied&state=x", "")
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("expected 401, got %d", w.Code)
+		}
+	})
+
+	t.Run("missing code", func(t *testing.T) {
+		if w := oidcCallback(router, "", "state"); w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", w.Code)
+		}
+	})
+}
+
+// TestOIDCWithoutAutoProvision - входят только существующие пользователи
+func TestOIDCWithoutAutoProvision(t *testing.T) {
+	router, idp, _ := setupOIDCTestRouter(t, false)
+
+	code, state := idp.authorize(t, startOIDCLogin(t, router), gojwt.MapClaims{
+		"sub": "idp-user-2", "email": "new.user@corp.example", "email_verified": true,
+	})
+	if w := oidcCallback(router, code, state); w.Code != http.StatusForbidden {
+		t.Errorf("unknown user: expected 403, got %d", w.Code)
+	}
+
+	code, state = idp.authorize(t, startOIDCLogin(t, router), gojwt.MapClaims{
+		"sub": "idp-user-1", "email": "test@example.com", "email_verified": true,
+	})
+	if w := oidcCallback(router, code, state); w.Code != http.StatusOK {
+		t.Errorf("existing user: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestOIDCLoginWithMFA - при включенной 2FA SSO проходит только первый шаг входа
+func TestOIDCLoginWithMFA(t *testing.T) {
+	router, idp, userRepo := setupOIDCTestRouter(t, true)
+	userRepo.SetTOTPSecret(t.Context(), 1, "JBSWY3DPEHPK3PXP")
+	userRepo.EnableTOTP(t.Context(), 1)
+
+	code, state := idp.authorize(t, startOIDCLogin(t, router), gojwt.MapClaims{
+		"sub": "idp-user-1", "email": "test@example.com", "email_verified": true,
+	})
+	w := oidcCallback(router, code, state)
+	var resp oidcLoginResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || !resp.MFARequired || resp.Token != "" {
+		t.Errorf("expected mfa challenge, got %d %+v", w.Code, resp)
+	}
+}
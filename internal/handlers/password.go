@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/internal/model"
+	"blog-backend/service"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// PasswordHandler обрабатывает сброс забытого пароля
+type PasswordHandler struct {
+	resetService *service.PasswordResetService
+	log          *log.Logger
+}
+
+// NewPasswordHandler создает новый PasswordHandler
+func NewPasswordHandler(resetService *service.PasswordResetService, logger *log.Logger) *PasswordHandler {
+	return &PasswordHandler{
+		resetService: resetService,
+		log:          logger,
+	}
+}
+
+// ForgotPasswordHandler отправляет письмо со ссылкой для сброса пароля
+// POST /api/password/forgot
+func (h *PasswordHandler) ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var req model.ForgotPasswordRequest
+	if err := parseJSONRequest(r, &req); err != nil {
+		middleware.AbortError(w, r, "Invalid JSON", http.StatusBadRequest, err)
+		return
+	}
+	if req.Email == "" {
+		middleware.AbortError(w, r, "email is required", http.StatusBadRequest, nil)
+		return
+	}
+
+	// Письмо отправляется в фоне: одинаковый ответ за одинаковое время
+	// для существующих и несуществующих email, в том числе когда отправка пропущена лимитами
+	h.resetService.RequestReset(r.Context(), req.Email, middleware.ClientIP(r))
+
+	sendJSONResponse(w, map[string]interface{}{
+		"message": "If the email is registered, a password reset link has been sent",
+	}, http.StatusOK)
+}
+
+// ResetPasswordHandler устанавливает новый пароль по токену из письма
+// POST /api/password/reset
+func (h *PasswordHandler) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var req model.ResetPasswordRequest
+	if err := parseJSONRequest(r, &req); err != nil {
+		middleware.AbortError(w, r, "Invalid JSON", http.StatusBadRequest, err)
+		return
+	}
+	if req.Token == "" || req.Password == "" {
+		middleware.AbortError(w, r, "token and password are required", http.StatusBadRequest, nil)
+		return
+	}
+
+	if err := h.resetService.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPassword):
+			middleware.AbortError(w, r, err.Error(), http.StatusBadRequest, err)
+		case errors.Is(err, service.ErrInvalidResetToken):
+			middleware.AbortError(w, r, "Invalid or expired reset token", http.StatusBadRequest, err)
+		default:
+			middleware.AbortError(w, r, "Failed to reset password", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"message": "Password has been reset, please log in again",
+	}, http.StatusOK)
+}
@@ -0,0 +1,261 @@
+// internal/handlers/password_handler_test.go
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"blog-backend/internal/handlers"
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+	"blog-backend/internal/repository/memory"
+	"blog-backend/pkg/mailer"
+	"blog-backend/service"
+)
+
+// MemoryPasswordResetRepository — in-memory хранилище токенов сброса пароля
+type MemoryPasswordResetRepository struct {
+	tokens []*model.PasswordResetToken
+	mu     sync.Mutex
+}
+
+// NewMemoryPasswordResetRepository создает пустое хранилище
+func NewMemoryPasswordResetRepository() repository.PasswordResetRepository {
+	return &MemoryPasswordResetRepository{}
+}
+
+// Create сохраняет токен
+func (r *MemoryPasswordResetRepository) Create(ctx context.Context, token *model.PasswordResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token.ID = len(r.tokens) + 1
+	token.CreatedAt = time.Now()
+	r.tokens = append(r.tokens, token)
+	return nil
+}
+
+// Consume помечает действующий токен использованным
+func (r *MemoryPasswordResetRepository) Consume(ctx context.Context, tokenHash string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash && token.UsedAt == nil && token.ExpiresAt.After(time.Now()) {
+			now := time.Now()
+			token.UsedAt = &now
+			return token.UserID, nil
+		}
+	}
+	return 0, nil
+}
+
+// InvalidateForUser помечает все токены пользователя использованными
+func (r *MemoryPasswordResetRepository) InvalidateForUser(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.UsedAt == nil {
+			token.UsedAt = &now
+		}
+	}
+	return nil
+}
+
+// RecordingMailer — сохраняет отправленные письма для проверки в тестах
+type RecordingMailer struct {
+	messages []mailer.Message
+	mu       sync.Mutex
+}
+
+// Send запоминает письмо
+func (m *RecordingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// FailingMailer — почтовый сервер недоступен
+type FailingMailer struct{}
+
+// Send всегда возвращает ошибку
+func (FailingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	return errors.New("smtp: connection refused")
+}
+
+// Count возвращает число отправленных писем
+func (m *RecordingMailer) Count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.messages)
+}
+
+// Last возвращает последнее письмо
+func (m *RecordingMailer) Last() (mailer.Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.messages) == 0 {
+		return mailer.Message{}, false
+	}
+	return m.messages[len(m.messages)-1], true
+}
+
+// setupPasswordTestRouter - роутер со сбросом пароля и входом.
+// Письма уходят в фоне: перед проверкой почты нужно дождаться resetSvc.Wait()
+func setupPasswordTestRouter(mail mailer.Mailer) (http.Handler, *service.PasswordResetService) {
+	userRepo := NewMemoryUserRepository()
+	cfg := NewTestConfig()
+	cfg.AppBaseURL = "http://blog.test"
+
+	revocations := service.NewRevocationStore(NewMemoryRevokedTokenRepository(), cfg)
+	tokenSvc := service.NewTokenService(NewMemoryRefreshTokenRepository(), NewMemoryPersonalAccessTokenRepository(), userRepo, revocations, service.NewSessionService(NewMemorySessionRepository(), revocations, cfg), cfg)
+	verifier := service.NewEmailVerificationService(userRepo, mail, cfg)
+	mfaSvc := service.NewMFAService(userRepo, NewMemoryRecoveryCodeRepository(), tokenSvc, cfg)
+	throttle := service.NewLoginThrottleService(memory.NewMemoryLoginAttemptRepository(), cfg)
+	userSvc := service.NewUserService(userRepo, tokenSvc, verifier, mfaSvc, throttle, service.NewRegistrationService(NewMemoryInvitationRepository(), cfg))
+
+	resetSvc := service.NewPasswordResetService(userRepo, NewMemoryPasswordResetRepository(), tokenSvc, throttle, mail, cfg)
+
+	logger := log.New(io.Discard, "", 0)
+	userHandler := handlers.NewUserHandler(userSvc, logger)
+	passwordHandler := handlers.NewPasswordHandler(resetSvc, logger)
+	authenticator := middleware.NewAuthenticator(revocations, nil)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/auth/login", userHandler.LoginHandler)
+	mux.HandleFunc("GET /api/profile", authenticator.AuthMiddleware(userHandler.ProfileHandler))
+	mux.HandleFunc("POST /api/password/forgot", passwordHandler.ForgotPasswordHandler)
+	mux.HandleFunc("POST /api/password/reset", passwordHandler.ResetPasswordHandler)
+
+	return mux, resetSvc
+}
+
+var resetTokenRe = regexp.MustCompile(`token=([A-Za-z0-9_\-]+)`)
+
+// TestPasswordResetFlow - забытый пароль → письмо → новый пароль → вход
+func TestPasswordResetFlow(t *testing.T) {
+	mail := &RecordingMailer{}
+	router, resetSvc := setupPasswordTestRouter(mail)
+	oldAccess, _ := loginTokens(t, router)
+
+	// 1. Неизвестный email — тот же ответ, письмо не отправляется
+	w := doJSON(router, http.MethodPost, "/api/password/forgot", `{"email": "unknown@example.com"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unknown email: expected 200, got %d", w.Code)
+	}
+	unknownBody := w.Body.String()
+	resetSvc.Wait()
+	if _, sent := mail.Last(); sent {
+		t.Fatalf("no email expected for unknown address")
+	}
+
+	// 2. Запрос сброса для существующего пользователя
+	w = doJSON(router, http.MethodPost, "/api/password/forgot", `{"email": "test@example.com"}`)
+	if w.Code != http.StatusOK || w.Body.String() != unknownBody {
+		t.Fatalf("forgot: expected 200 with the same body, got %d: %s", w.Code, w.Body.String())
+	}
+	resetSvc.Wait()
+	msg, sent := mail.Last()
+	if !sent || msg.To != "test@example.com" {
+		t.Fatalf("expected reset email to test@example.com, got %+v", msg)
+	}
+	match := resetTokenRe.FindStringSubmatch(msg.Body)
+	if match == nil {
+		t.Fatalf("reset link not found in email: %s", msg.Body)
+	}
+	token := match[1]
+
+	// 3. Слишком короткий пароль
+	if w := doJSON(router, http.MethodPost, "/api/password/reset", `{"token": "`+token+`", "password": "short"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("short password: expected 400, got %d", w.Code)
+	}
+
+	// 4. Успешный сброс
+	if w := doJSON(router, http.MethodPost, "/api/password/reset", `{"token": "`+token+`", "password": "newpassword123"}`); w.Code != http.StatusOK {
+		t.Fatalf("reset: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// 5. Токен одноразовый
+	if w := doJSON(router, http.MethodPost, "/api/password/reset", `{"token": "`+token+`", "password": "anotherpass123"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("reused token: expected 400, got %d", w.Code)
+	}
+
+	// 6. Старые сессии завершены, старый пароль не подходит, новый — подходит
+	if w := doAuthJSON(router, http.MethodGet, "/api/profile", oldAccess, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("old session: expected 401, got %d", w.Code)
+	}
+	if w := doJSON(router, http.MethodPost, "/api/auth/login", `{"email": "test@example.com", "password": "password123"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("old password: expected 401, got %d", w.Code)
+	}
+	if w := doJSON(router, http.MethodPost, "/api/auth/login", `{"email": "test@example.com", "password": "newpassword123"}`); w.Code != http.StatusOK {
+		t.Errorf("new password: expected 200, got %d", w.Code)
+	}
+}
+
+// TestPasswordResetMailFailure - ошибка почты не меняет ответ (иначе по ней виден существующий email)
+func TestPasswordResetMailFailure(t *testing.T) {
+	router, resetSvc := setupPasswordTestRouter(FailingMailer{})
+
+	for _, email := range []string{"test@example.com", "unknown@example.com"} {
+		if w := doJSON(router, http.MethodPost, "/api/password/forgot", `{"email": "`+email+`"}`); w.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d: %s", email, w.Code, w.Body.String())
+		}
+	}
+	resetSvc.Wait()
+}
+
+// TestPasswordResetLimits - повторные письма одному пользователю и запросы с одного IP ограничены,
+// ответ при этом не меняется
+func TestPasswordResetLimits(t *testing.T) {
+	mail := &RecordingMailer{}
+	router, resetSvc := setupPasswordTestRouter(mail)
+	forgot := `{"email": "test@example.com"}`
+
+	// 1. Второе письмо в течение минуты не отправляется
+	first := doJSON(router, http.MethodPost, "/api/password/forgot", forgot)
+	second := doJSON(router, http.MethodPost, "/api/password/forgot", forgot)
+	if second.Code != first.Code || second.Body.String() != first.Body.String() {
+		t.Errorf("cooldown: expected the same response, got %d: %s", second.Code, second.Body.String())
+	}
+	resetSvc.Wait()
+	if sent := mail.Count(); sent != 1 {
+		t.Errorf("cooldown: expected 1 email, got %d", sent)
+	}
+
+	// 2. После серии запросов IP блокируется (счетчик общий с неудачными входами)
+	router, resetSvc = setupPasswordTestRouter(mail)
+	for i := 0; i < 20; i++ {
+		doJSON(router, http.MethodPost, "/api/password/forgot", fmt.Sprintf(`{"email": "user%d@example.com"}`, i))
+	}
+	w := doJSON(router, http.MethodPost, "/api/password/forgot", forgot)
+	if w.Code != first.Code || w.Body.String() != first.Body.String() {
+		t.Errorf("ip limit: expected the same response, got %d: %s", w.Code, w.Body.String())
+	}
+	resetSvc.Wait()
+	if sent := mail.Count(); sent != 1 {
+		t.Errorf("ip limit: expected no new emails, got %d total", sent)
+	}
+	if w := doJSON(router, http.MethodPost, "/api/auth/login", `{"email": "test@example.com", "password": "password123"}`); w.Code != http.StatusTooManyRequests {
+		t.Errorf("login from blocked IP: expected 429, got %d", w.Code)
+	}
+}
+
+// Интерфейсы реализованы
+var _ repository.PasswordResetRepository = (*MemoryPasswordResetRepository)(nil)
+var _ mailer.Mailer = (*RecordingMailer)(nil)
+var _ mailer.Mailer = FailingMailer{}
@@ -6,12 +6,18 @@ import (
 	"blog-backend/pkg/auth"
 	"blog-backend/service"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 )
 
+// maxPostBodyBytes - предел тела запроса создания и обновления поста
+// (с запасом на JSON экранирование текста, длину самого текста проверяет сервис)
+const maxPostBodyBytes = 1 << 20
+
 // Response - единый JSON формат ответа API
 type Response struct {
 	Data    interface{} `json:"data,omitempty"`
@@ -43,13 +49,30 @@ func (h *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var post model.Post
+	r.Body = http.MaxBytesReader(w, r.Body, maxPostBodyBytes)
 	if err := json.NewDecoder(r.Body).Decode(&post); err != nil {
-		middleware.AbortError(w, r, "Invalid JSON", http.StatusBadRequest, err)
+		abortPostBodyError(w, r, err)
 		return
 	}
 
 	createdPost, err := h.postService.CreatePost(r.Context(), userID, &post)
 	if err != nil {
+		if errors.Is(err, service.ErrPermissionDenied) {
+			middleware.AbortError(w, r, "Permission denied", http.StatusForbidden, err)
+			return
+		}
+		if errors.Is(err, service.ErrEmailNotVerified) {
+			middleware.AbortError(w, r, "Email not verified", http.StatusForbidden, err)
+			return
+		}
+		if errors.Is(err, service.ErrInvalidTag) {
+			middleware.AbortError(w, r, err.Error(), http.StatusBadRequest, err)
+			return
+		}
+		if errors.Is(err, service.ErrPostTooLarge) {
+			middleware.AbortError(w, r, err.Error(), http.StatusRequestEntityTooLarge, err)
+			return
+		}
 		middleware.AbortError(w, r, "Failed to create post", http.StatusInternalServerError, err)
 		return
 	}
@@ -85,7 +108,36 @@ func (h *PostHandler) GetPost(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// UpdatePost обновляет пост (только автор)
+// GetPostBySlug возвращает пост по слагу (публичный доступ).
+// Прежний слаг поста (заголовок меняли) перенаправляет на текущий адрес (301)
+// GET /api/posts/by-slug/{slug}
+func (h *PostHandler) GetPostBySlug(w http.ResponseWriter, r *http.Request) {
+	postSlug := r.PathValue("slug")
+	if postSlug == "" {
+		middleware.AbortError(w, r, "Post slug required", http.StatusBadRequest, nil)
+		return
+	}
+
+	post, moved, err := h.postService.GetPostBySlug(r.Context(), postSlug)
+	if err != nil {
+		if errors.Is(err, service.ErrPostNotFound) {
+			middleware.AbortError(w, r, "Post not found", http.StatusNotFound, err)
+			return
+		}
+		middleware.AbortError(w, r, "Failed to get post", http.StatusInternalServerError, err)
+		return
+	}
+	if moved {
+		http.Redirect(w, r, "/api/posts/by-slug/"+url.PathEscape(post.Slug), http.StatusMovedPermanently)
+		return
+	}
+
+	h.successResponse(w, http.StatusOK, Response{
+		Data: post,
+	})
+}
+
+// UpdatePost обновляет пост (автор или editor/admin)
 func (h *PostHandler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 	userID, ok := auth.GetUserIDFromContext(r)
 	if !ok {
@@ -109,13 +161,15 @@ func (h *PostHandler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 
 	// Парсим поля для обновления (кроме ID)
 	var updateData struct {
-		Title   string `json:"title"`
-		Content string `json:"content"`
-		Status  string `json:"status,omitempty"`
+		Title   string   `json:"title"`
+		Content string   `json:"content"`
+		Status  string   `json:"status,omitempty"`
+		Tags    []string `json:"tags"` // без поля теги не меняются, [] убирает все
 	}
 
+	r.Body = http.MaxBytesReader(w, r.Body, maxPostBodyBytes)
 	if err := json.NewDecoder(r.Body).Decode(&updateData); err != nil {
-		middleware.AbortError(w, r, "Invalid JSON", http.StatusBadRequest, err)
+		abortPostBodyError(w, r, err)
 		return
 	}
 
@@ -125,6 +179,7 @@ func (h *PostHandler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 		Title:   updateData.Title,
 		Content: updateData.Content,
 		Status:  updateData.Status,
+		Tags:    updateData.Tags,
 	}
 
 	updatedPost, err := h.postService.UpdatePost(r.Context(), userID, id, postToUpdate)
@@ -135,6 +190,10 @@ func (h *PostHandler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 			middleware.AbortError(w, r, "Post not found", http.StatusNotFound, err)
 		case strings.Contains(err.Error(), "permission denied"):
 			middleware.AbortError(w, r, "Permission denied", http.StatusForbidden, err)
+		case errors.Is(err, service.ErrInvalidTag):
+			middleware.AbortError(w, r, err.Error(), http.StatusBadRequest, err)
+		case errors.Is(err, service.ErrPostTooLarge):
+			middleware.AbortError(w, r, err.Error(), http.StatusRequestEntityTooLarge, err)
 		default:
 			middleware.AbortError(w, r, "Failed to update post", http.StatusInternalServerError, err)
 		}
@@ -147,7 +206,17 @@ func (h *PostHandler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// DeletePost удаляет пост (только автор)
+// abortPostBodyError - тело запроса поста не прочитано: слишком большое или не JSON
+func abortPostBodyError(w http.ResponseWriter, r *http.Request, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		middleware.AbortError(w, r, "Request body is too large", http.StatusRequestEntityTooLarge, err)
+		return
+	}
+	middleware.AbortError(w, r, "Invalid JSON", http.StatusBadRequest, err)
+}
+
+// DeletePost удаляет пост (автор или editor/admin)
 func (h *PostHandler) DeletePost(w http.ResponseWriter, r *http.Request) {
 
 	userID, ok := auth.GetUserIDFromContext(r)
@@ -198,7 +267,28 @@ func (h *PostHandler) ListPosts(w http.ResponseWriter, r *http.Request) {
 		offset = 0
 	}
 
-	posts, total, err := h.postService.GetAllPosts(r.Context(), limit, offset)
+	// ?tag=go&tag=postgres — опубликованные посты с тегами; match=all требует все теги сразу
+	var posts []*model.Post
+	var total int
+	var err error
+	if tags := r.URL.Query()["tag"]; len(tags) > 0 {
+		var matchAll bool
+		switch r.URL.Query().Get("match") {
+		case "", "any":
+		case "all":
+			matchAll = true
+		default:
+			middleware.AbortError(w, r, "Invalid match (any, all)", http.StatusBadRequest, nil)
+			return
+		}
+		posts, total, err = h.postService.ListPostsByTags(r.Context(), tags, matchAll, limit, offset)
+	} else {
+		posts, total, err = h.postService.GetAllPosts(r.Context(), limit, offset)
+	}
+	if errors.Is(err, service.ErrInvalidTag) {
+		middleware.AbortError(w, r, err.Error(), http.StatusBadRequest, err)
+		return
+	}
 	if err != nil {
 		h.log.Printf("list posts failed: %v", err)
 		middleware.AbortError(w, r, "Failed to list posts", http.StatusInternalServerError, err)
@@ -211,6 +301,28 @@ func (h *PostHandler) ListPosts(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// SearchPosts ищет опубликованные посты по словам из q (публичный доступ).
+// Результаты отсортированы по релевантности, найденные слова выделены <mark>
+// GET /api/posts/search?q=
+func (h *PostHandler) SearchPosts(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePagination(r)
+
+	results, total, err := h.postService.SearchPosts(r.Context(), r.URL.Query().Get("q"), limit, offset)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSearchQuery) {
+			middleware.AbortError(w, r, err.Error(), http.StatusBadRequest, err)
+			return
+		}
+		middleware.AbortError(w, r, "Failed to search posts", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.successResponse(w, http.StatusOK, Response{
+		Data:  results,
+		Total: total,
+	})
+}
+
 // successResponse отправляет успешный JSON ответ
 func (h *PostHandler) successResponse(w http.ResponseWriter, status int, resp Response) {
 	w.Header().Set("Content-Type", "application/json")
@@ -5,11 +5,16 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"html"
 	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -30,9 +35,14 @@ var (
 
 // MemoryPostStorage — потокобезопасное in-memory хранилище постов
 type MemoryPostStorage struct {
-	posts  []*model.Post
-	mu     sync.RWMutex
-	nextID int
+	posts   []*model.Post
+	mu      sync.RWMutex
+	nextID  int
+	slugs   map[string]int          // прежние слаги → ID поста
+	follows *MemoryFollowRepository // подписки для ListFeed (nil = подписок нет)
+	mutes   *MemoryBlockRepository  // скрытые авторы для ListFeed (nil = никто не скрыт)
+
+	revisions map[int][]*model.PostRevision // прежние версии постов по ID поста, старые первыми
 }
 
 // NewMemoryPostStorage создает новое хранилище постов с автоинкрементом ID=1
@@ -45,6 +55,10 @@ func (s *MemoryPostStorage) CreatePost(ctx context.Context, post *model.Post) (*
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if post.Tags == nil {
+		post.Tags = []string{} // как в postgres: пост без тегов — пустой список
+	}
+
 	post.ID = s.nextID              // Устанавливаем уникальный ID
 	post.CreatedAt = time.Now()     // Метка создания
 	s.posts = append(s.posts, post) // Добавляем в хранилище
@@ -97,6 +111,31 @@ func (s *MemoryPostStorage) UpdatePost(ctx context.Context, id int, post *model.
 
 	for i, p := range s.posts {
 		if p.ID == id {
+			// Как в postgres: автор и дата создания не меняются, пустые слаг, заголовок
+			// и текст тоже, а прежний слаг попадает в историю
+			post.AuthorID, post.CreatedAt = p.AuthorID, p.CreatedAt
+			if post.Tags == nil {
+				post.Tags = p.Tags
+			}
+			if post.Slug == "" {
+				post.Slug = p.Slug
+			}
+			if post.Title == "" {
+				post.Title = p.Title
+			}
+			if post.Content == "" {
+				post.Content, post.ContentHTML, post.TOC = p.Content, p.ContentHTML, p.TOC
+			}
+			if post.Title != p.Title || post.Content != p.Content {
+				s.saveRevision(p)
+			}
+			if post.Slug != p.Slug {
+				if s.slugs == nil {
+					s.slugs = map[string]int{}
+				}
+				s.slugs[p.Slug] = p.ID
+				delete(s.slugs, post.Slug)
+			}
 			s.posts[i] = post
 			return post, nil
 		}
@@ -132,6 +171,96 @@ func (s *MemoryPostStorage) CountPosts(ctx context.Context) (int, error) {
 	return count, nil
 }
 
+// ListPostsByUser возвращает опубликованные посты автора с пагинацией
+func (s *MemoryPostStorage) ListPostsByUser(ctx context.Context, userID, limit, offset int) ([]*model.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var posts []*model.Post
+	for _, post := range s.posts {
+		if post.AuthorID == userID && post.Status == "published" {
+			posts = append(posts, post)
+		}
+	}
+	if offset >= len(posts) {
+		return nil, nil
+	}
+	end := offset + limit
+	if end > len(posts) {
+		end = len(posts)
+	}
+	return posts[offset:end], nil
+}
+
+// ListAllPostsByUser возвращает все посты автора, включая черновики
+func (s *MemoryPostStorage) ListAllPostsByUser(ctx context.Context, userID int) ([]*model.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var posts []*model.Post
+	for _, post := range s.posts {
+		if post.AuthorID == userID {
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
+
+// ListFeed возвращает опубликованные посты авторов из подписок после cursor (новые первыми)
+func (s *MemoryPostStorage) ListFeed(ctx context.Context, followerID int, cursor *model.FeedCursor, limit int) ([]*model.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.follows == nil {
+		return nil, nil
+	}
+	followees := s.follows.followees(followerID)
+	muted := map[int]bool{}
+	if s.mutes != nil {
+		muted = s.mutes.muted(followerID)
+	}
+
+	var posts []*model.Post
+	for _, post := range s.posts {
+		if post.Status != "published" || !followees[post.AuthorID] || muted[post.AuthorID] {
+			continue
+		}
+		if cursor != nil && !feedBefore(post, cursor) {
+			continue
+		}
+		posts = append(posts, post)
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		return feedBefore(posts[j], &model.FeedCursor{CreatedAt: posts[i].CreatedAt, ID: posts[i].ID})
+	})
+	if len(posts) > limit {
+		posts = posts[:limit]
+	}
+	return posts, nil
+}
+
+// feedBefore - пост идет в ленте после позиции cursor: (created_at, id) < cursor
+func feedBefore(post *model.Post, cursor *model.FeedCursor) bool {
+	if post.CreatedAt.Equal(cursor.CreatedAt) {
+		return post.ID < cursor.ID
+	}
+	return post.CreatedAt.Before(cursor.CreatedAt)
+}
+
+// CountPostsByUser возвращает количество опубликованных постов автора
+func (s *MemoryPostStorage) CountPostsByUser(ctx context.Context, userID int) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, post := range s.posts {
+		if post.AuthorID == userID && post.Status == "published" {
+			count++
+		}
+	}
+	return count, nil
+}
+
 // GetReadyToPublish возвращает черновики готовые к публикации (publish_at <= now)
 func (s *MemoryPostStorage) GetReadyToPublish(ctx context.Context, batchSize int) ([]*model.Post, error) {
 	s.mu.RLock()
@@ -174,17 +303,21 @@ func (s *MemoryPostStorage) PublishPost(ctx context.Context, id int) error {
 
 // MemoryUserRepository - in-memory хранилище пользователей
 type MemoryUserRepository struct {
-	users  map[int]*model.User
-	emails map[string]int
-	nextID int
+	users     map[int]*model.User
+	emails    map[string]int
+	totpSteps map[int]int64 // userID → последний использованный шаг TOTP
+	nextID    int
+
+	invitations *MemoryInvitationRepository // для CreateInvitedUser
 }
 
 // NewMemoryUserRepository создает хранилище с тестовым пользователем ID=1
 func NewMemoryUserRepository() repository.UserRepository {
 	r := &MemoryUserRepository{
-		users:  make(map[int]*model.User),
-		emails: make(map[string]int),
-		nextID: 1,
+		users:     make(map[int]*model.User),
+		emails:    make(map[string]int),
+		totpSteps: make(map[int]int64),
+		nextID:    1,
 	}
 	// Создаем тестового пользователя ID=1
 	hash, _ := jwt.HashPassword("password123")
@@ -201,7 +334,7 @@ func NewMemoryUserRepository() repository.UserRepository {
 func (r *MemoryUserRepository) GetUserByID(ctx context.Context, id int) (*model.User, error) {
 	user, exists := r.users[id]
 	if !exists {
-		return nil, ErrUserNotFound
+		return nil, nil // как в PostgreSQL: не найден — не ошибка
 	}
 	return user, nil
 }
@@ -210,7 +343,7 @@ func (r *MemoryUserRepository) GetUserByID(ctx context.Context, id int) (*model.
 func (r *MemoryUserRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
 	userID, exists := r.emails[email]
 	if !exists {
-		return nil, ErrUserNotFound
+		return nil, nil // как в PostgreSQL: не найден — не ошибка
 	}
 	return r.users[userID], nil
 }
@@ -225,6 +358,7 @@ func (r *MemoryUserRepository) CreateUser(ctx context.Context, email, username,
 		ID:           r.nextID,
 		Email:        email,
 		Username:     username,
+		Role:         model.RoleAuthor, // как DEFAULT в БД
 		PasswordHash: passwordHash,
 		CreatedAt:    time.Now(),
 	}
@@ -235,19 +369,200 @@ func (r *MemoryUserRepository) CreateUser(ctx context.Context, email, username,
 	return user, nil
 }
 
+// CreateInvitedUser создает пользователя, только если приглашение удалось израсходовать
+func (r *MemoryUserRepository) CreateInvitedUser(ctx context.Context, email, username, passwordHash string, invitationID int) (*model.User, error) {
+	if r.invitations == nil {
+		return nil, errors.New("invitations not configured")
+	}
+	if _, exists := r.emails[email]; exists {
+		return nil, errors.New("user exists")
+	}
+	if !r.invitations.consume(invitationID, r.nextID) {
+		return nil, repository.ErrInvitationUsed
+	}
+	return r.CreateUser(ctx, email, username, passwordHash)
+}
+
 // UserExistsByEmail проверяет существование пользователя по email
 func (r *MemoryUserRepository) UserExistsByEmail(ctx context.Context, email string) (bool, error) {
 	_, exists := r.emails[email]
 	return exists, nil
 }
 
+// UpdateUserRole меняет роль пользователя
+func (r *MemoryUserRepository) UpdateUserRole(ctx context.Context, id int, role string) error {
+	user, exists := r.users[id]
+	if !exists {
+		return ErrUserNotFound
+	}
+	user.Role = role
+	return nil
+}
+
+// UpdatePassword сохраняет новый хеш пароля
+func (r *MemoryUserRepository) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	user, exists := r.users[id]
+	if !exists {
+		return ErrUserNotFound
+	}
+	user.PasswordHash = passwordHash
+	return nil
+}
+
+// MarkEmailVerified подтверждает email, если он не менялся
+func (r *MemoryUserRepository) MarkEmailVerified(ctx context.Context, id int, email string) (bool, error) {
+	user, exists := r.users[id]
+	if !exists || user.Email != email {
+		return false, nil
+	}
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	return true, nil
+}
+
+// SetTOTPSecret сохраняет секрет, 2FA еще не включена
+func (r *MemoryUserRepository) SetTOTPSecret(ctx context.Context, id int, secret string) error {
+	user, exists := r.users[id]
+	if !exists {
+		return fmt.Errorf("user not found: %d", id)
+	}
+	user.TOTPSecret = secret
+	user.TOTPEnabledAt = nil
+	delete(r.totpSteps, id)
+	return nil
+}
+
+// ReplaceTOTPSecret перезаписывает секрет, если он не изменился
+func (r *MemoryUserRepository) ReplaceTOTPSecret(ctx context.Context, id int, oldSecret, newSecret string) error {
+	if user, exists := r.users[id]; exists && user.TOTPSecret == oldSecret {
+		user.TOTPSecret = newSecret
+	}
+	return nil
+}
+
+// EnableTOTP включает 2FA
+func (r *MemoryUserRepository) EnableTOTP(ctx context.Context, id int) error {
+	user, exists := r.users[id]
+	if !exists {
+		return fmt.Errorf("user not found: %d", id)
+	}
+	now := time.Now()
+	user.TOTPEnabledAt = &now
+	return nil
+}
+
+// DisableTOTP выключает 2FA и удаляет секрет
+func (r *MemoryUserRepository) DisableTOTP(ctx context.Context, id int) error {
+	user, exists := r.users[id]
+	if !exists {
+		return fmt.Errorf("user not found: %d", id)
+	}
+	user.TOTPSecret = ""
+	user.TOTPEnabledAt = nil
+	delete(r.totpSteps, id)
+	return nil
+}
+
+// UseTOTPStep запоминает шаг TOTP; false — код этого шага уже использован
+func (r *MemoryUserRepository) UseTOTPStep(ctx context.Context, id int, step int64) (bool, error) {
+	if last, ok := r.totpSteps[id]; ok && last >= step {
+		return false, nil
+	}
+	r.totpSteps[id] = step
+	return true, nil
+}
+
+// GetUserByUsername возвращает пользователя по имени без учета регистра, как в PostgreSQL (nil — не найден)
+func (r *MemoryUserRepository) GetUserByUsername(ctx context.Context, username string) (*model.User, error) {
+	for _, user := range r.users {
+		if strings.EqualFold(user.Username, username) {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+// UpdateProfile сохраняет username, email, bio и статус подтверждения email
+func (r *MemoryUserRepository) UpdateProfile(ctx context.Context, user *model.User) error {
+	stored, exists := r.users[user.ID]
+	if !exists || stored.DeletedAt != nil {
+		return ErrUserNotFound
+	}
+	for email, id := range r.emails {
+		if id == user.ID {
+			delete(r.emails, email)
+		}
+	}
+	r.emails[user.Email] = user.ID
+
+	stored.Username = user.Username
+	stored.Email = user.Email
+	stored.Bio = user.Bio
+	stored.EmailVerifiedAt = user.EmailVerifiedAt
+	return nil
+}
+
+// UpdateAvatar сохраняет ссылки на аватар
+func (r *MemoryUserRepository) UpdateAvatar(ctx context.Context, id int, avatarURL, avatarSmallURL *string) error {
+	user, exists := r.users[id]
+	if !exists || user.DeletedAt != nil {
+		return ErrUserNotFound
+	}
+	user.AvatarURL = avatarURL
+	user.AvatarSmallURL = avatarSmallURL
+	return nil
+}
+
+// DeleteUser удаляет пользователя
+func (r *MemoryUserRepository) DeleteUser(ctx context.Context, id int) error {
+	user, exists := r.users[id]
+	if !exists {
+		return ErrUserNotFound
+	}
+	delete(r.emails, user.Email)
+	delete(r.users, id)
+	delete(r.totpSteps, id)
+	return nil
+}
+
+// AnonymizeUser стирает личные данные, пользователь остается "deleted-<id>"
+func (r *MemoryUserRepository) AnonymizeUser(ctx context.Context, id int) error {
+	user, exists := r.users[id]
+	if !exists {
+		return ErrUserNotFound
+	}
+	delete(r.emails, user.Email)
+
+	now := time.Now()
+	user.Email = fmt.Sprintf("deleted-%d@deleted.invalid", id)
+	user.Username = fmt.Sprintf("deleted-%d", id)
+	user.PasswordHash = "!"
+	user.Role = model.RoleReader
+	user.Bio = ""
+	user.AvatarURL = nil
+	user.AvatarSmallURL = nil
+	user.EmailVerifiedAt = nil
+	user.TOTPSecret = ""
+	user.TOTPEnabledAt = nil
+	user.DeletedAt = &now
+	r.emails[user.Email] = id
+	delete(r.totpSteps, id)
+	return nil
+}
+
 // Test config с отключенным scheduler
 func NewTestConfig() *config.Config {
 	return &config.Config{
 		SchedulerEnabled: false,
+		MFAEncryptionKey: testMFAEncryptionKey,
 	}
 }
 
+// testMFAEncryptionKey - ключ шифрования секретов TOTP в тестах (32 байта в base64)
+const testMFAEncryptionKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+
 // Mock middleware для тестов - имитирует JWT middleware и всегда пропускает с userID=1
 func mockAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -542,5 +857,308 @@ func TestListPosts(t *testing.T) {
 }
 
 // Интерфейсы реализованы
+// SearchPosts - упрощенный поиск: опубликованные посты, где есть все слова запроса
+// (без учета регистра, без стемминга); совпадение в заголовке весит больше
+func (s *MemoryPostStorage) SearchPosts(ctx context.Context, query string, limit, offset int) ([]*model.PostSearchResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := s.search(query)
+	if offset >= len(results) {
+		return nil, nil
+	}
+	return results[offset:min(offset+limit, len(results))], nil
+}
+
+// CountSearchPosts возвращает количество найденных постов
+func (s *MemoryPostStorage) CountSearchPosts(ctx context.Context, query string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.search(query)), nil
+}
+
+// search находит посты и подсвечивает слова (вызывается под блокировкой)
+func (s *MemoryPostStorage) search(query string) []*model.PostSearchResult {
+	words := strings.Fields(strings.ToLower(query))
+	var results []*model.PostSearchResult
+	for i := len(s.posts) - 1; i >= 0; i-- {
+		post := s.posts[i]
+		if post.Status != "published" || len(words) == 0 {
+			continue
+		}
+		title, content := strings.ToLower(post.Title), strings.ToLower(post.Content)
+		rank := 0.0
+		for _, word := range words {
+			inTitle, inContent := strings.Contains(title, word), strings.Contains(content, word)
+			if !inTitle && !inContent {
+				rank = 0
+				break
+			}
+			if inTitle {
+				rank += 1
+			}
+			if inContent {
+				rank += 0.4
+			}
+		}
+		if rank > 0 {
+			results = append(results, &model.PostSearchResult{
+				Post:           *post,
+				Rank:           rank,
+				TitleHighlight: markWords(post.Title, words),
+				Snippet:        markWords(post.Content, words),
+			})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Rank > results[j].Rank })
+	return results
+}
+
+// markWords экранирует текст и оборачивает вхождения слов в <mark>
+func markWords(text string, words []string) string {
+	escaped := html.EscapeString(text)
+	for _, word := range words {
+		word = html.EscapeString(word)
+		var b strings.Builder
+		rest := escaped
+		for {
+			i := strings.Index(strings.ToLower(rest), word)
+			if i < 0 {
+				break
+			}
+			b.WriteString(rest[:i] + "<mark>" + rest[i:i+len(word)] + "</mark>")
+			rest = rest[i+len(word):]
+		}
+		escaped = b.String() + rest
+	}
+	return escaped
+}
+
+// ListPostsByTags возвращает опубликованные посты хотя бы с одним или со всеми тегами
+func (s *MemoryPostStorage) ListPostsByTags(ctx context.Context, tags []string, matchAll bool, limit, offset int) ([]*model.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched := s.postsByTags(tags, matchAll)
+	if offset >= len(matched) {
+		return nil, nil
+	}
+	return matched[offset:min(offset+limit, len(matched))], nil
+}
+
+// CountPostsByTags возвращает количество опубликованных постов с тегами
+func (s *MemoryPostStorage) CountPostsByTags(ctx context.Context, tags []string, matchAll bool) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.postsByTags(tags, matchAll)), nil
+}
+
+// postsByTags - опубликованные посты с тегами, новые первыми (вызывается под блокировкой)
+func (s *MemoryPostStorage) postsByTags(tags []string, matchAll bool) []*model.Post {
+	var matched []*model.Post
+	for i := len(s.posts) - 1; i >= 0; i-- {
+		post := s.posts[i]
+		if post.Status != "published" {
+			continue
+		}
+		count := 0
+		for _, tag := range tags {
+			if slices.Contains(post.Tags, tag) {
+				count++
+			}
+		}
+		if (matchAll && count == len(tags)) || (!matchAll && count > 0) {
+			matched = append(matched, post)
+		}
+	}
+	return matched
+}
+
+// ListTags возвращает теги опубликованных постов с количеством, популярные первыми
+func (s *MemoryPostStorage) ListTags(ctx context.Context) ([]model.Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := map[string]int{}
+	for _, post := range s.posts {
+		if post.Status == "published" {
+			for _, tag := range post.Tags {
+				counts[tag]++
+			}
+		}
+	}
+	tags := []model.Tag{}
+	for name, count := range counts {
+		tags = append(tags, model.Tag{Name: name, PostCount: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].PostCount != tags[j].PostCount {
+			return tags[i].PostCount > tags[j].PostCount
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
+
+// GetTag возвращает тег, если он есть хотя бы у одного поста (nil, если нет)
+func (s *MemoryPostStorage) GetTag(ctx context.Context, name string) (*model.Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tag *model.Tag
+	for _, post := range s.posts {
+		if slices.Contains(post.Tags, name) {
+			if tag == nil {
+				tag = &model.Tag{Name: name}
+			}
+			if post.Status == "published" {
+				tag.PostCount++
+			}
+		}
+	}
+	return tag, nil
+}
+
+// RenameTag переименовывает тег у всех постов
+func (s *MemoryPostStorage) RenameTag(ctx context.Context, name, newName string) error {
+	return s.replaceTag(name, newName)
+}
+
+// MergeTags заменяет тег source на target (без повторов)
+func (s *MemoryPostStorage) MergeTags(ctx context.Context, source, target string) error {
+	return s.replaceTag(source, target)
+}
+
+// replaceTag заменяет тег у всех постов, сохраняя теги по алфавиту
+func (s *MemoryPostStorage) replaceTag(from, to string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, post := range s.posts {
+		i := slices.Index(post.Tags, from)
+		if i < 0 {
+			continue
+		}
+		tags := slices.Delete(slices.Clone(post.Tags), i, i+1)
+		if !slices.Contains(tags, to) {
+			tags = append(tags, to)
+		}
+		sort.Strings(tags)
+		post.Tags = tags
+	}
+	return nil
+}
+
+// saveRevision сохраняет прежнюю версию поста (вызывается под блокировкой)
+func (s *MemoryPostStorage) saveRevision(p *model.Post) {
+	if s.revisions == nil {
+		s.revisions = map[int][]*model.PostRevision{}
+	}
+	createdAt := p.UpdatedAt
+	if createdAt.IsZero() {
+		createdAt = p.CreatedAt
+	}
+	s.revisions[p.ID] = append(s.revisions[p.ID], &model.PostRevision{
+		Revision:  len(s.revisions[p.ID]) + 1,
+		Title:     p.Title,
+		Content:   p.Content,
+		CreatedAt: createdAt,
+	})
+}
+
+// ListRevisions возвращает прежние версии поста без текста, новые первыми
+func (s *MemoryPostStorage) ListRevisions(ctx context.Context, postID int) ([]*model.PostRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revisions := []*model.PostRevision{}
+	for _, rev := range slices.Backward(s.revisions[postID]) {
+		listed := *rev
+		listed.Content = ""
+		revisions = append(revisions, &listed)
+	}
+	return revisions, nil
+}
+
+// GetRevision возвращает версию поста с текстом (nil, если нет)
+func (s *MemoryPostStorage) GetRevision(ctx context.Context, postID, revision int) (*model.PostRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, rev := range s.revisions[postID] {
+		if rev.Revision == revision {
+			found := *rev
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetPostBySlug возвращает пост по текущему слагу (nil, если нет)
+func (s *MemoryPostStorage) GetPostBySlug(ctx context.Context, slug string) (*model.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, p := range s.posts {
+		if p.Slug == slug {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+// FindSlugRedirect возвращает ID поста, которому принадлежал слаг (0, если нет)
+func (s *MemoryPostStorage) FindSlugRedirect(ctx context.Context, slug string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.slugs[slug], nil
+}
+
+// SlugTaken проверяет, занят ли слаг другим постом (текущий или прежний слаг)
+func (s *MemoryPostStorage) SlugTaken(ctx context.Context, slug string, exceptPostID int) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, p := range s.posts {
+		if p.Slug == slug && p.ID != exceptPostID {
+			return true, nil
+		}
+	}
+	if postID, ok := s.slugs[slug]; ok && postID != exceptPostID {
+		return true, nil
+	}
+	return false, nil
+}
+
+// ListUnrenderedPosts возвращает посты с текстом, но без HTML, по возрастанию ID после afterID
+func (s *MemoryPostStorage) ListUnrenderedPosts(ctx context.Context, afterID, limit int) ([]*model.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var posts []*model.Post
+	for _, p := range s.posts {
+		if p.ID > afterID && p.Content != "" && p.ContentHTML == "" && len(posts) < limit {
+			posts = append(posts, &model.Post{ID: p.ID, Content: p.Content})
+		}
+	}
+	return posts, nil
+}
+
+// SetRenderedContent записывает HTML и оглавление поста
+func (s *MemoryPostStorage) SetRenderedContent(ctx context.Context, post *model.Post) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.posts {
+		if p.ID == post.ID && p.Content == post.Content {
+			p.ContentHTML, p.TOC = post.ContentHTML, post.TOC
+		}
+	}
+	return nil
+}
+
 var _ repository.PostRepository = (*MemoryPostStorage)(nil)
 var _ repository.UserRepository = (*MemoryUserRepository)(nil)
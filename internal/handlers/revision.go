@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/pkg/auth"
+	"blog-backend/service"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// RevisionHandler обрабатывает историю версий постов
+type RevisionHandler struct {
+	revisionService *service.RevisionService
+	log             *log.Logger
+}
+
+// NewRevisionHandler создает новый RevisionHandler
+func NewRevisionHandler(revisionService *service.RevisionService, logger *log.Logger) *RevisionHandler {
+	return &RevisionHandler{
+		revisionService: revisionService,
+		log:             logger,
+	}
+}
+
+// ListRevisions возвращает версии поста, новые первыми (автор или editor/admin)
+// GET /api/posts/{postId}/revisions
+func (h *RevisionHandler) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	postID, err := strconv.Atoi(r.PathValue("postId"))
+	if err != nil {
+		middleware.AbortError(w, r, "Invalid post ID", http.StatusBadRequest, err)
+		return
+	}
+
+	revisions, err := h.revisionService.ListRevisions(r.Context(), userID, postID)
+	if err != nil {
+		h.abortRevisionError(w, r, err, "Failed to list revisions")
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"revisions": revisions,
+	}, http.StatusOK)
+}
+
+// Diff сравнивает две версии поста построчно (автор или editor/admin)
+// GET /api/posts/{postId}/revisions/diff?from=1&to=3
+func (h *RevisionHandler) Diff(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	postID, err := strconv.Atoi(r.PathValue("postId"))
+	if err != nil {
+		middleware.AbortError(w, r, "Invalid post ID", http.StatusBadRequest, err)
+		return
+	}
+	from, errFrom := strconv.Atoi(r.URL.Query().Get("from"))
+	to, errTo := strconv.Atoi(r.URL.Query().Get("to"))
+	if err := errors.Join(errFrom, errTo); err != nil {
+		middleware.AbortError(w, r, "Query parameters from and to must be revision numbers", http.StatusBadRequest, err)
+		return
+	}
+
+	diff, err := h.revisionService.Diff(r.Context(), userID, postID, from, to)
+	if err != nil {
+		h.abortRevisionError(w, r, err, "Failed to compare revisions")
+		return
+	}
+
+	sendJSONResponse(w, diff, http.StatusOK)
+}
+
+// Restore возвращает посту заголовок и текст прежней версии (только автор)
+// POST /api/posts/{postId}/revisions/{revision}/restore
+func (h *RevisionHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	postID, err := strconv.Atoi(r.PathValue("postId"))
+	if err != nil {
+		middleware.AbortError(w, r, "Invalid post ID", http.StatusBadRequest, err)
+		return
+	}
+	revision, err := strconv.Atoi(r.PathValue("revision"))
+	if err != nil {
+		middleware.AbortError(w, r, "Invalid revision number", http.StatusBadRequest, err)
+		return
+	}
+
+	post, err := h.revisionService.Restore(r.Context(), userID, postID, revision)
+	if err != nil {
+		h.abortRevisionError(w, r, err, "Failed to restore revision")
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"message": "Revision restored",
+		"post":    post,
+	}, http.StatusOK)
+}
+
+// abortRevisionError переводит ошибки RevisionService в HTTP коды
+func (h *RevisionHandler) abortRevisionError(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrPostNotFound), strings.Contains(err.Error(), "post not found"):
+		middleware.AbortError(w, r, "Post not found", http.StatusNotFound, err)
+	case errors.Is(err, service.ErrPermissionDenied):
+		middleware.AbortError(w, r, "Permission denied", http.StatusForbidden, err)
+	case errors.Is(err, service.ErrRevisionNotFound):
+		middleware.AbortError(w, r, "Revision not found", http.StatusNotFound, err)
+	case errors.Is(err, service.ErrInvalidRevision):
+		middleware.AbortError(w, r, err.Error(), http.StatusBadRequest, err)
+	default:
+		middleware.AbortError(w, r, fallback, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,269 @@
+// internal/handlers/revision_handler_test.go
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"blog-backend/internal/handlers"
+	"blog-backend/internal/model"
+	"blog-backend/service"
+)
+
+// otherAuthorID - еще один автор в setupRevisionTestRouter (ID=1 — автор поста, ID=2 — редактор)
+const otherAuthorID = 3
+
+// setupRevisionTestRouter - роутер постов и истории версий с автором, редактором и вторым автором
+func setupRevisionTestRouter(t *testing.T) http.Handler {
+	t.Helper()
+
+	postRepo := NewMemoryPostStorage()
+	userRepo := NewMemoryUserRepository()
+	ctx := context.Background()
+	editor, _ := userRepo.CreateUser(ctx, "editor@example.com", "editor", "hash")
+	if err := userRepo.UpdateUserRole(ctx, editor.ID, model.RoleEditor); err != nil || editor.ID != editorID {
+		t.Fatalf("failed to create editor: %v", err)
+	}
+	if other, _ := userRepo.CreateUser(ctx, "other@example.com", "other", "hash"); other == nil || other.ID != otherAuthorID {
+		t.Fatalf("failed to create second author")
+	}
+
+	logger := log.New(io.Discard, "", 0)
+	postSvc := service.NewPostService(postRepo, userRepo, NewTestConfig())
+	postHandler := handlers.NewPostHandler(postSvc, logger)
+	revisionHandler := handlers.NewRevisionHandler(service.NewRevisionService(postRepo, userRepo, postSvc), logger)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/posts", userHeaderMiddleware(postHandler.CreatePost))
+	mux.HandleFunc("PUT /api/posts/{postid}", userHeaderMiddleware(postHandler.UpdatePost))
+	mux.HandleFunc("GET /api/posts/{postId}/revisions", userHeaderMiddleware(revisionHandler.ListRevisions))
+	mux.HandleFunc("GET /api/posts/{postId}/revisions/diff", userHeaderMiddleware(revisionHandler.Diff))
+	mux.HandleFunc("POST /api/posts/{postId}/revisions/{revision}/restore", userHeaderMiddleware(revisionHandler.Restore))
+	return mux
+}
+
+// createRevisedPost - пост автора ID=1 с двумя правками: три версии, текущая — третья
+func createRevisedPost(t *testing.T, router http.Handler) string {
+	t.Helper()
+
+	w := doAsUser(router, http.MethodPost, "/api/posts", 1, `{"title": "Первая версия", "content": "один\nдва\nтри"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create post: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	postURL := "/api/posts/" + strconv.Itoa(decodePost(t, w.Body.Bytes()).ID)
+
+	for _, body := range []string{
+		`{"title": "Вторая версия", "content": "один\nдва с половиной\nтри"}`,
+		`{"title": "Вторая версия", "content": "один\nдва с половиной\nтри", "tags": ["go"]}`, // только теги — без новой версии
+		`{"title": "Третья версия", "content": "один\nдва с половиной\nтри\nчетыре"}`,
+	} {
+		if w := doAsUser(router, http.MethodPut, postURL, 1, body); w.Code != http.StatusOK {
+			t.Fatalf("update post: expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	return postURL
+}
+
+// listRevisions - версии поста из ответа
+func listRevisions(t *testing.T, router http.Handler, postURL string, userID int) []model.PostRevision {
+	t.Helper()
+
+	w := doAsUser(router, http.MethodGet, postURL+"/revisions", userID, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("list revisions: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Revisions []model.PostRevision `json:"revisions"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	return resp.Revisions
+}
+
+// TestPostRevisions - прежние версии сохраняются при изменении заголовка или текста
+func TestPostRevisions(t *testing.T) {
+	router := setupRevisionTestRouter(t)
+	postURL := createRevisedPost(t, router)
+
+	revisions := listRevisions(t, router, postURL, 1)
+	var titles []string
+	for _, rev := range revisions {
+		titles = append(titles, strconv.Itoa(rev.Revision)+" "+rev.Title)
+		if rev.Content != "" {
+			t.Errorf("revision %d: expected list without content", rev.Revision)
+		}
+	}
+	if want := []string{"3 Третья версия", "2 Вторая версия", "1 Первая версия"}; !reflect.DeepEqual(titles, want) {
+		t.Fatalf("expected revisions %v, got %v", want, titles)
+	}
+	if !revisions[0].Current || revisions[1].Current {
+		t.Errorf("expected only the newest revision to be current: %+v", revisions)
+	}
+
+	// Историю видит и редактор, но не другой автор
+	if got := listRevisions(t, router, postURL, editorID); len(got) != 3 {
+		t.Errorf("editor: expected 3 revisions, got %d", len(got))
+	}
+	if w := doAsUser(router, http.MethodGet, postURL+"/revisions", otherAuthorID, ""); w.Code != http.StatusForbidden {
+		t.Errorf("other author: expected 403, got %d", w.Code)
+	}
+	if w := doAsUser(router, http.MethodGet, "/api/posts/999/revisions", 1, ""); w.Code != http.StatusNotFound {
+		t.Errorf("unknown post: expected 404, got %d", w.Code)
+	}
+}
+
+// TestTagsOnlyUpdateCreatesNoRevision - правка без заголовка и текста не создает пустую версию
+func TestTagsOnlyUpdateCreatesNoRevision(t *testing.T) {
+	router := setupRevisionTestRouter(t)
+	postURL := createRevisedPost(t, router)
+
+	for _, body := range []string{`{"tags": ["web"]}`, `{"title": "", "content": "", "tags": []}`} {
+		if w := doAsUser(router, http.MethodPut, postURL, 1, body); w.Code != http.StatusOK {
+			t.Fatalf("update %s: expected 200, got %d: %s", body, w.Code, w.Body.String())
+		}
+	}
+
+	revisions := listRevisions(t, router, postURL, 1)
+	if len(revisions) != 3 || revisions[0].Title != "Третья версия" {
+		t.Fatalf("expected 3 revisions with the current one on top, got %+v", revisions)
+	}
+	for _, rev := range revisions {
+		if rev.Title == "" {
+			t.Errorf("revision %d: expected no empty revisions", rev.Revision)
+		}
+	}
+}
+
+// TestRevisionDiff - построчное сравнение любых двух версий, включая текущую
+func TestRevisionDiff(t *testing.T) {
+	router := setupRevisionTestRouter(t)
+	postURL := createRevisedPost(t, router)
+
+	w := doAsUser(router, http.MethodGet, postURL+"/revisions/diff?from=1&to=3", 1, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("diff: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var diff model.RevisionDiff
+	json.NewDecoder(w.Body).Decode(&diff)
+
+	want := []model.DiffLine{
+		{Op: "equal", Text: "один"},
+		{Op: "delete", Text: "два"},
+		{Op: "insert", Text: "два с половиной"},
+		{Op: "equal", Text: "три"},
+		{Op: "insert", Text: "четыре"},
+	}
+	if !reflect.DeepEqual(diff.Lines, want) {
+		t.Errorf("expected lines %+v, got %+v", want, diff.Lines)
+	}
+	if diff.TitleFrom != "Первая версия" || diff.TitleTo != "Третья версия" || diff.Inserted != 2 || diff.Deleted != 1 {
+		t.Errorf("unexpected diff summary: %+v", diff)
+	}
+
+	// Сравнение в обратную сторону меняет добавления и удаления местами
+	w = doAsUser(router, http.MethodGet, postURL+"/revisions/diff?from=3&to=2", 1, "")
+	json.NewDecoder(w.Body).Decode(&diff)
+	if diff.Inserted != 0 || diff.Deleted != 1 {
+		t.Errorf("reverse diff: expected 0 inserted and 1 deleted, got %+v", diff)
+	}
+
+	cases := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{"unknown revision", "?from=1&to=9", http.StatusNotFound},
+		{"missing to", "?from=1", http.StatusBadRequest},
+		{"not a number", "?from=first&to=2", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if w := doAsUser(router, http.MethodGet, postURL+"/revisions/diff"+tc.query, 1, ""); w.Code != tc.want {
+				t.Errorf("expected %d, got %d: %s", tc.want, w.Code, w.Body.String())
+			}
+		})
+	}
+	if w := doAsUser(router, http.MethodGet, postURL+"/revisions/diff?from=1&to=2", otherAuthorID, ""); w.Code != http.StatusForbidden {
+		t.Errorf("other author: expected 403, got %d", w.Code)
+	}
+}
+
+// TestRestoreRevision - восстановление доступно только автору и само сохраняется в истории
+func TestRestoreRevision(t *testing.T) {
+	router := setupRevisionTestRouter(t)
+	postURL := createRevisedPost(t, router)
+
+	// Даже редактор, который может править пост, не восстанавливает версии
+	if w := doAsUser(router, http.MethodPost, postURL+"/revisions/1/restore", editorID, ""); w.Code != http.StatusForbidden {
+		t.Errorf("editor: expected 403, got %d", w.Code)
+	}
+
+	w := doAsUser(router, http.MethodPost, postURL+"/revisions/1/restore", 1, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("restore: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Post model.Post `json:"post"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Post.Title != "Первая версия" || resp.Post.Content != "один\nдва\nтри" {
+		t.Errorf("expected first revision to be restored, got %q %q", resp.Post.Title, resp.Post.Content)
+	}
+	if !strings.Contains(resp.Post.ContentHTML, "два") || strings.Contains(resp.Post.ContentHTML, "четыре") {
+		t.Errorf("expected content_html to be rendered from restored content, got %q", resp.Post.ContentHTML)
+	}
+	if !reflect.DeepEqual(resp.Post.Tags, []string{"go"}) {
+		t.Errorf("expected tags to stay unchanged, got %v", resp.Post.Tags)
+	}
+
+	// Замененная версия попадает в историю — восстановление можно отменить
+	revisions := listRevisions(t, router, postURL, 1)
+	if len(revisions) != 4 || revisions[0].Title != "Первая версия" || revisions[1].Title != "Третья версия" {
+		t.Fatalf("expected restored version on top of history, got %+v", revisions)
+	}
+
+	cases := []struct {
+		name     string
+		revision string
+		want     int
+	}{
+		{"current revision", "4", http.StatusBadRequest},
+		{"unknown revision", "99", http.StatusNotFound},
+		{"not a number", "latest", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if w := doAsUser(router, http.MethodPost, postURL+"/revisions/"+tc.revision+"/restore", 1, ""); w.Code != tc.want {
+				t.Errorf("expected %d, got %d: %s", tc.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestPostContentLimit - длина текста и тело запроса ограничены (от них зависит сравнение версий)
+func TestPostContentLimit(t *testing.T) {
+	router := setupRevisionTestRouter(t)
+	postURL := createRevisedPost(t, router)
+
+	tooLong := `{"title": "Длинный пост", "content": "` + strings.Repeat("я", 100_001) + `"}`
+	tooLarge := `{"title": "Огромный пост", "content": "` + strings.Repeat("x\\n", 400_000) + `"}`
+	for _, body := range []string{tooLong, tooLarge} {
+		if w := doAsUser(router, http.MethodPost, "/api/posts", 1, body); w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("create: expected 413, got %d", w.Code)
+		}
+		if w := doAsUser(router, http.MethodPut, postURL, 1, body); w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("update: expected 413, got %d", w.Code)
+		}
+	}
+
+	limit := `{"title": "Длинный пост", "content": "` + strings.Repeat("я", 100_000) + `"}`
+	if w := doAsUser(router, http.MethodPut, postURL, 1, limit); w.Code != http.StatusOK {
+		t.Errorf("content at the limit: expected 200, got %d", w.Code)
+	}
+}
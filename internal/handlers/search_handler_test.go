@@ -0,0 +1,105 @@
+// internal/handlers/search_handler_test.go
+package handlers_test
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"blog-backend/internal/handlers"
+	"blog-backend/internal/model"
+	"blog-backend/service"
+)
+
+// setupSearchTestRouter - роутер с поиском и тремя постами (третий — отложенный черновик)
+func setupSearchTestRouter(t *testing.T) http.Handler {
+	t.Helper()
+
+	postSvc := service.NewPostService(NewMemoryPostStorage(), NewMemoryUserRepository(), NewTestConfig())
+	postHandler := handlers.NewPostHandler(postSvc, log.New(io.Discard, "", 0))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/posts", mockAuthMiddleware(postHandler.CreatePost))
+	mux.HandleFunc("GET /api/posts/search", postHandler.SearchPosts)
+	mux.HandleFunc("GET /api/posts/{postid}", postHandler.GetPost)
+
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	for _, body := range []string{
+		`{"title": "Горутины в Go", "content": "Каналы, <script> и индексы в map"}`,
+		`{"title": "Индексы в PostgreSQL", "content": "GIN индексы ускоряют поиск"}`,
+		`{"title": "Индексы: черновик", "content": "Еще не опубликован", "publish_at": "` + future + `"}`,
+	} {
+		if w := doJSON(mux, http.MethodPost, "/api/posts", body); w.Code != http.StatusCreated {
+			t.Fatalf("create post: expected 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	return mux
+}
+
+// searchPosts - результаты и total из GET /api/posts/search
+func searchPosts(t *testing.T, router http.Handler, query string) ([]model.PostSearchResult, int) {
+	t.Helper()
+
+	w := doJSON(router, http.MethodGet, "/api/posts/search?"+query, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("search %q: expected 200, got %d: %s", query, w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data  []model.PostSearchResult `json:"data"`
+		Total int                      `json:"total"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	return resp.Data, resp.Total
+}
+
+// TestSearchPosts - релевантность, подсветка, черновики и пагинация
+func TestSearchPosts(t *testing.T) {
+	router := setupSearchTestRouter(t)
+
+	results, total := searchPosts(t, router, "q="+url.QueryEscape("индексы"))
+	if total != 2 || len(results) != 2 {
+		t.Fatalf("expected 2 published posts, got %d (total %d)", len(results), total)
+	}
+	// Совпадение в заголовке важнее совпадения в тексте
+	if results[0].ID != 2 || results[1].ID != 1 || results[0].Rank <= results[1].Rank {
+		t.Errorf("expected post 2 ranked above post 1, got %d (%v), %d (%v)",
+			results[0].ID, results[0].Rank, results[1].ID, results[1].Rank)
+	}
+	if results[0].TitleHighlight != "<mark>Индексы</mark> в PostgreSQL" {
+		t.Errorf("unexpected title highlight %q", results[0].TitleHighlight)
+	}
+	// Текст поста экранируется, разметка — только подсветка
+	if snippet := results[1].Snippet; !strings.Contains(snippet, "&lt;script&gt;") || !strings.Contains(snippet, "<mark>индексы</mark>") {
+		t.Errorf("unexpected snippet %q", snippet)
+	}
+
+	results, total = searchPosts(t, router, "q="+url.QueryEscape("индексы")+"&limit=1&offset=1")
+	if total != 2 || len(results) != 1 || results[0].ID != 1 {
+		t.Errorf("expected second page with post 1, got %+v (total %d)", results, total)
+	}
+
+	if results, total := searchPosts(t, router, "q=rust"); len(results) != 0 || total != 0 {
+		t.Errorf("expected no results, got %d", total)
+	}
+
+	// /api/posts/search не перехватывается маршрутом /api/posts/{postid}
+	if w := doJSON(router, http.MethodGet, "/api/posts/2", ""); w.Code != http.StatusOK {
+		t.Errorf("get post: expected 200, got %d", w.Code)
+	}
+}
+
+// TestSearchPostsValidation - пустой и слишком длинный запрос
+func TestSearchPostsValidation(t *testing.T) {
+	router := setupSearchTestRouter(t)
+
+	for _, query := range []string{"", "q=", "q=%20%20", "q=" + strings.Repeat("a", 201)} {
+		if w := doJSON(router, http.MethodGet, "/api/posts/search?"+query, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("query %q: expected 400, got %d", query, w.Code)
+		}
+	}
+}
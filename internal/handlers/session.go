@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/internal/model"
+	"blog-backend/pkg/auth"
+	"blog-backend/service"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// SessionHandler показывает и завершает сессии (входы с устройств)
+type SessionHandler struct {
+	sessionService *service.SessionService
+	log            *log.Logger
+}
+
+// NewSessionHandler создает новый SessionHandler
+func NewSessionHandler(sessionService *service.SessionService, logger *log.Logger) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+		log:            logger,
+	}
+}
+
+// ListSessions возвращает активные сессии текущего пользователя (текущая помечена current)
+// GET /api/sessions
+func (h *SessionHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.GetClaimsFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	sessions, err := h.sessionService.List(r.Context(), claims.UserID, claims.SessionID)
+	if err != nil {
+		middleware.AbortError(w, r, "Failed to list sessions", http.StatusInternalServerError, err)
+		return
+	}
+	if sessions == nil {
+		sessions = []*model.Session{}
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"sessions": sessions,
+	}, http.StatusOK)
+}
+
+// TerminateSession завершает сессию: ее токены перестают приниматься
+// DELETE /api/sessions/{id}
+func (h *SessionHandler) TerminateSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	sessionID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		middleware.AbortError(w, r, "Invalid session ID", http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.sessionService.Terminate(r.Context(), userID, sessionID); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			middleware.AbortError(w, r, "Session not found", http.StatusNotFound, err)
+			return
+		}
+		middleware.AbortError(w, r, "Failed to terminate session", http.StatusInternalServerError, err)
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"message": "Session terminated",
+	}, http.StatusOK)
+}
+
+// sessionContext - контекст запроса с IP и User-Agent клиента для сессии, создаваемой при входе
+func sessionContext(r *http.Request) context.Context {
+	return auth.WithClientInfo(r.Context(), middleware.ClientIP(r), r.UserAgent())
+}
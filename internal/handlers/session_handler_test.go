@@ -0,0 +1,351 @@
+// internal/handlers/session_handler_test.go
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"blog-backend/internal/handlers"
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+	"blog-backend/internal/repository/memory"
+	"blog-backend/pkg/jwt"
+	"blog-backend/service"
+)
+
+// MemorySessionRepository — in-memory хранилище сессий
+type MemorySessionRepository struct {
+	sessions map[int]*model.Session
+	mu       sync.Mutex
+	nextID   int
+}
+
+// NewMemorySessionRepository создает пустое хранилище сессий
+func NewMemorySessionRepository() repository.SessionRepository {
+	return &MemorySessionRepository{
+		sessions: make(map[int]*model.Session),
+		nextID:   1,
+	}
+}
+
+// Create сохраняет сессию с уникальным ID
+func (r *MemorySessionRepository) Create(ctx context.Context, session *model.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session.ID = r.nextID
+	session.CreatedAt = time.Now()
+	session.LastSeenAt = session.CreatedAt
+	copied := *session
+	r.sessions[session.ID] = &copied
+	r.nextID++
+	return nil
+}
+
+// GetByID возвращает копию сессии (nil, если не найдена)
+func (r *MemorySessionRepository) GetByID(ctx context.Context, id int) (*model.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, exists := r.sessions[id]
+	if !exists {
+		return nil, nil
+	}
+	copied := *session
+	return &copied, nil
+}
+
+// GetByFamily возвращает копию сессии цепочки refresh токенов
+func (r *MemorySessionRepository) GetByFamily(ctx context.Context, familyID string) (*model.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, session := range r.sessions {
+		if session.FamilyID == familyID {
+			copied := *session
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+// ListActive возвращает активные сессии пользователя (новые первыми)
+func (r *MemorySessionRepository) ListActive(ctx context.Context, userID int) ([]*model.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sessions []*model.Session
+	for id := r.nextID - 1; id > 0; id-- {
+		session, exists := r.sessions[id]
+		if exists && session.UserID == userID && session.RevokedAt == nil && time.Now().Before(session.ExpiresAt) {
+			copied := *session
+			sessions = append(sessions, &copied)
+		}
+	}
+	return sessions, nil
+}
+
+// ListForUser возвращает до limit последних сессий пользователя, включая завершенные
+func (r *MemorySessionRepository) ListForUser(ctx context.Context, userID, limit int) ([]*model.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sessions []*model.Session
+	for id := r.nextID - 1; id > 0 && len(sessions) < limit; id-- {
+		if session, exists := r.sessions[id]; exists && session.UserID == userID {
+			copied := *session
+			sessions = append(sessions, &copied)
+		}
+	}
+	return sessions, nil
+}
+
+// TouchLastSeen обновляет время последней активности
+func (r *MemorySessionRepository) TouchLastSeen(ctx context.Context, id int, seenAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if session, exists := r.sessions[id]; exists && session.RevokedAt == nil {
+		session.LastSeenAt = seenAt
+	}
+	return nil
+}
+
+// Extend продлевает активную сессию
+func (r *MemorySessionRepository) Extend(ctx context.Context, id int, seenAt, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if session, exists := r.sessions[id]; exists && session.RevokedAt == nil {
+		session.LastSeenAt, session.ExpiresAt = seenAt, expiresAt
+	}
+	return nil
+}
+
+// Revoke завершает активную сессию
+func (r *MemorySessionRepository) Revoke(ctx context.Context, id int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, exists := r.sessions[id]
+	if !exists || session.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	session.RevokedAt = &now
+	return true, nil
+}
+
+// RevokeAllForUser завершает все сессии пользователя
+func (r *MemorySessionRepository) RevokeAllForUser(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// setupSessionTestRouter - роутер, в котором AuthMiddleware проверяет сессии
+func setupSessionTestRouter(t *testing.T) http.Handler {
+	t.Helper()
+
+	cfg := NewTestConfig()
+	userRepo := NewMemoryUserRepository()
+	hash, _ := jwt.HashPassword("password123")
+	userRepo.CreateUser(context.Background(), "alice@example.com", "alice", hash)
+
+	revocations := service.NewRevocationStore(NewMemoryRevokedTokenRepository(), cfg)
+	sessionSvc := service.NewSessionService(NewMemorySessionRepository(), revocations, cfg)
+	tokenSvc := service.NewTokenService(NewMemoryRefreshTokenRepository(), NewMemoryPersonalAccessTokenRepository(), userRepo, revocations, sessionSvc, cfg)
+	verifier := service.NewEmailVerificationService(userRepo, &RecordingMailer{}, cfg)
+	mfaSvc := service.NewMFAService(userRepo, NewMemoryRecoveryCodeRepository(), tokenSvc, cfg)
+	throttle := service.NewLoginThrottleService(memory.NewMemoryLoginAttemptRepository(), cfg)
+	userSvc := service.NewUserService(userRepo, tokenSvc, verifier, mfaSvc, throttle, service.NewRegistrationService(NewMemoryInvitationRepository(), cfg))
+
+	logger := log.New(io.Discard, "", 0)
+	userHandler := handlers.NewUserHandler(userSvc, logger)
+	sessionHandler := handlers.NewSessionHandler(sessionSvc, logger)
+	authenticator := middleware.NewAuthenticator(sessionSvc, nil)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/auth/login", userHandler.LoginHandler)
+	mux.HandleFunc("POST /api/auth/refresh", userHandler.RefreshHandler)
+	mux.HandleFunc("POST /api/logout", authenticator.AuthMiddleware(userHandler.LogoutHandler))
+	mux.HandleFunc("GET /api/profile", authenticator.AuthMiddleware(userHandler.ProfileHandler))
+	mux.HandleFunc("GET /api/sessions", authenticator.AuthMiddleware(sessionHandler.ListSessions))
+	mux.HandleFunc("DELETE /api/sessions/{id}", authenticator.AuthMiddleware(sessionHandler.TerminateSession))
+	return mux
+}
+
+// loginFrom - входит тестовым пользователем с заданного устройства
+func loginFrom(t *testing.T, router http.Handler, email, userAgent string) (string, string) {
+	t.Helper()
+
+	body := fmt.Sprintf(`{"email": %q, "password": "password123"}`, email)
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("login failed: %d %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	return resp.Token, resp.RefreshToken
+}
+
+// listSessions - список сессий по access токену
+func listSessions(t *testing.T, router http.Handler, token string) []model.Session {
+	t.Helper()
+
+	w := doAuthJSON(router, http.MethodGet, "/api/sessions", token, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("list sessions: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Sessions []model.Session `json:"sessions"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	return resp.Sessions
+}
+
+// TestListSessions - каждый вход виден как отдельная сессия, текущая помечена
+func TestListSessions(t *testing.T) {
+	router := setupSessionTestRouter(t)
+	laptop, _ := loginFrom(t, router, "test@example.com", "Firefox on Linux")
+	phone, _ := loginFrom(t, router, "test@example.com", "Safari on iPhone")
+	loginFrom(t, router, "alice@example.com", "Chrome on Windows")
+
+	sessions := listSessions(t, router, laptop)
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %+v", sessions)
+	}
+	agents := map[string]bool{}
+	for _, session := range sessions {
+		agents[session.UserAgent] = session.Current
+		if session.IP == "" || session.CreatedAt.IsZero() || session.LastSeenAt.IsZero() {
+			t.Errorf("expected ip and timestamps, got %+v", session)
+		}
+	}
+	if current, ok := agents["Firefox on Linux"]; !ok || !current {
+		t.Errorf("expected laptop session to be current, got %+v", sessions)
+	}
+	if current, ok := agents["Safari on iPhone"]; !ok || current {
+		t.Errorf("expected phone session not to be current, got %+v", sessions)
+	}
+
+	// С телефона текущей видна уже его сессия
+	for _, session := range listSessions(t, router, phone) {
+		if session.Current != (session.UserAgent == "Safari on iPhone") {
+			t.Errorf("wrong current flag from phone: %+v", session)
+		}
+	}
+
+	if w := doJSON(router, http.MethodGet, "/api/sessions", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("unauthenticated: expected 401, got %d", w.Code)
+	}
+}
+
+// TestTerminateSession - завершенная сессия перестает принимать access и refresh токены
+func TestTerminateSession(t *testing.T) {
+	router := setupSessionTestRouter(t)
+	laptop, _ := loginFrom(t, router, "test@example.com", "Firefox on Linux")
+	phone, phoneRefresh := loginFrom(t, router, "test@example.com", "Safari on iPhone")
+	aliceToken, _ := loginFrom(t, router, "alice@example.com", "Chrome on Windows")
+
+	var phoneSession int
+	for _, session := range listSessions(t, router, laptop) {
+		if session.UserAgent == "Safari on iPhone" {
+			phoneSession = session.ID
+		}
+	}
+	aliceSession := listSessions(t, router, aliceToken)[0].ID
+	phoneURL := fmt.Sprintf("/api/sessions/%d", phoneSession)
+
+	// Чужую сессию завершить нельзя
+	if w := doAuthJSON(router, http.MethodDelete, fmt.Sprintf("/api/sessions/%d", aliceSession), laptop, ""); w.Code != http.StatusNotFound {
+		t.Errorf("foreign session: expected 404, got %d", w.Code)
+	}
+	if w := doAuthJSON(router, http.MethodDelete, "/api/sessions/999", laptop, ""); w.Code != http.StatusNotFound {
+		t.Errorf("unknown session: expected 404, got %d", w.Code)
+	}
+	if w := doAuthJSON(router, http.MethodDelete, "/api/sessions/abc", laptop, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid id: expected 400, got %d", w.Code)
+	}
+
+	if w := doAuthJSON(router, http.MethodDelete, phoneURL, laptop, ""); w.Code != http.StatusOK {
+		t.Fatalf("terminate: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Токены телефона больше не работают
+	if w := doAuthJSON(router, http.MethodGet, "/api/profile", phone, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("terminated access token: expected 401, got %d", w.Code)
+	}
+	if w := doJSON(router, http.MethodPost, "/api/auth/refresh", `{"refresh_token": "`+phoneRefresh+`"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("terminated refresh token: expected 401, got %d", w.Code)
+	}
+
+	// Ноутбук и другой пользователь не затронуты
+	if w := doAuthJSON(router, http.MethodGet, "/api/profile", laptop, ""); w.Code != http.StatusOK {
+		t.Errorf("other session: expected 200, got %d", w.Code)
+	}
+	if w := doAuthJSON(router, http.MethodGet, "/api/profile", aliceToken, ""); w.Code != http.StatusOK {
+		t.Errorf("other user: expected 200, got %d", w.Code)
+	}
+	if sessions := listSessions(t, router, laptop); len(sessions) != 1 || !sessions[0].Current {
+		t.Errorf("expected only current session left, got %+v", sessions)
+	}
+
+	// Повторное завершение — 404
+	if w := doAuthJSON(router, http.MethodDelete, phoneURL, laptop, ""); w.Code != http.StatusNotFound {
+		t.Errorf("repeat terminate: expected 404, got %d", w.Code)
+	}
+}
+
+// TestSessionSurvivesRefresh - обновление токенов продолжает ту же сессию, logout ее завершает
+func TestSessionSurvivesRefresh(t *testing.T) {
+	router := setupSessionTestRouter(t)
+	_, refresh := loginFrom(t, router, "test@example.com", "Firefox on Linux")
+
+	w := doJSON(router, http.MethodPost, "/api/auth/refresh", `{"refresh_token": "`+refresh+`"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("refresh: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var pair struct {
+		Token string `json:"token"`
+	}
+	json.NewDecoder(w.Body).Decode(&pair)
+
+	sessions := listSessions(t, router, pair.Token)
+	if len(sessions) != 1 || !sessions[0].Current || sessions[0].UserAgent != "Firefox on Linux" {
+		t.Fatalf("expected the same session after refresh, got %+v", sessions)
+	}
+
+	if w := doAuthJSON(router, http.MethodPost, "/api/logout", pair.Token, "{}"); w.Code != http.StatusOK {
+		t.Fatalf("logout: expected 200, got %d", w.Code)
+	}
+	other, _ := loginFrom(t, router, "test@example.com", "Safari on iPhone")
+	if sessions := listSessions(t, router, other); len(sessions) != 1 || sessions[0].UserAgent != "Safari on iPhone" {
+		t.Errorf("expected logout to end the session, got %+v", sessions)
+	}
+}
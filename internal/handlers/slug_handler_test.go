@@ -0,0 +1,123 @@
+// internal/handlers/slug_handler_test.go
+package handlers_test
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"testing"
+
+	"blog-backend/internal/handlers"
+	"blog-backend/internal/model"
+	"blog-backend/service"
+)
+
+// setupSlugTestRouter - роутер постов с адресами по слагу
+func setupSlugTestRouter() http.Handler {
+	postSvc := service.NewPostService(NewMemoryPostStorage(), NewMemoryUserRepository(), NewTestConfig())
+	postHandler := handlers.NewPostHandler(postSvc, log.New(io.Discard, "", 0))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/posts", mockAuthMiddleware(postHandler.CreatePost))
+	mux.HandleFunc("PUT /api/posts/{postid}", mockAuthMiddleware(postHandler.UpdatePost))
+	mux.HandleFunc("GET /api/posts/by-slug/{slug}", postHandler.GetPostBySlug)
+	return mux
+}
+
+// createSlugPost - создает пост и возвращает его из ответа
+func createSlugPost(t *testing.T, router http.Handler, title string) model.Post {
+	t.Helper()
+
+	w := doJSON(router, http.MethodPost, "/api/posts", `{"title": "`+title+`", "content": "Текст поста"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create post: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data model.Post `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	return resp.Data
+}
+
+// TestPostSlugs - слаг из заголовка с транслитерацией и суффиксом при совпадении
+func TestPostSlugs(t *testing.T) {
+	router := setupSlugTestRouter()
+
+	cases := []struct {
+		title string
+		want  string
+	}{
+		{"Привет, мир!", "privet-mir"},
+		{"Привет, мир?", "privet-mir-2"},
+		{"Щука и ёж: Café", "shchuka-i-ezh-cafe"},
+		{"!!!", "post"},
+	}
+	for _, tc := range cases {
+		if post := createSlugPost(t, router, tc.title); post.Slug != tc.want {
+			t.Errorf("title %q: expected slug %q, got %q", tc.title, tc.want, post.Slug)
+		}
+	}
+
+	w := doJSON(router, http.MethodGet, "/api/posts/by-slug/privet-mir-2", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("get by slug: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data model.Post `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Data.ID != 2 || resp.Data.Title != "Привет, мир?" {
+		t.Errorf("expected post 2, got %+v", resp.Data)
+	}
+
+	if w := doJSON(router, http.MethodGet, "/api/posts/by-slug/unknown", ""); w.Code != http.StatusNotFound {
+		t.Errorf("unknown slug: expected 404, got %d", w.Code)
+	}
+}
+
+// TestPostSlugRedirect - после смены заголовка прежний адрес перенаправляет на новый
+func TestPostSlugRedirect(t *testing.T) {
+	router := setupSlugTestRouter()
+	post := createSlugPost(t, router, "Первый заголовок")
+
+	// Правка без смены слага адрес не меняет
+	w := doJSON(router, http.MethodPut, "/api/posts/1", `{"title": "Первый  заголовок!", "content": "Текст"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(router, http.MethodGet, "/api/posts/by-slug/"+post.Slug, ""); w.Code != http.StatusOK {
+		t.Fatalf("expected slug to stay, got %d", w.Code)
+	}
+
+	w = doJSON(router, http.MethodPut, "/api/posts/1", `{"title": "Новый заголовок", "content": "Текст"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doJSON(router, http.MethodGet, "/api/posts/by-slug/pervyi-zagolovok", "")
+	if w.Code != http.StatusMovedPermanently {
+		t.Fatalf("old slug: expected 301, got %d: %s", w.Code, w.Body.String())
+	}
+	if location := w.Header().Get("Location"); location != "/api/posts/by-slug/novyi-zagolovok" {
+		t.Errorf("unexpected redirect location %q", location)
+	}
+	if w := doJSON(router, http.MethodGet, "/api/posts/by-slug/novyi-zagolovok", ""); w.Code != http.StatusOK {
+		t.Errorf("new slug: expected 200, got %d", w.Code)
+	}
+
+	// Прежний слаг остается за постом: новый пост с тем же заголовком его не займет
+	if other := createSlugPost(t, router, "Первый заголовок"); other.Slug != "pervyi-zagolovok-2" {
+		t.Errorf("expected old slug to stay reserved, got %q", other.Slug)
+	}
+
+	// Возврат заголовка возвращает и адрес
+	doJSON(router, http.MethodPut, "/api/posts/1", `{"title": "Первый заголовок", "content": "Текст"}`)
+	if w := doJSON(router, http.MethodGet, "/api/posts/by-slug/pervyi-zagolovok", ""); w.Code != http.StatusOK {
+		t.Errorf("restored slug: expected 200, got %d", w.Code)
+	}
+	w = doJSON(router, http.MethodGet, "/api/posts/by-slug/novyi-zagolovok", "")
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/api/posts/by-slug/pervyi-zagolovok" {
+		t.Errorf("expected redirect back, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/internal/model"
+	"blog-backend/pkg/auth"
+	"blog-backend/service"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// TagHandler обрабатывает список тегов и управление ими
+type TagHandler struct {
+	tagService *service.TagService
+	log        *log.Logger
+}
+
+// NewTagHandler создает новый TagHandler
+func NewTagHandler(tagService *service.TagService, logger *log.Logger) *TagHandler {
+	return &TagHandler{
+		tagService: tagService,
+		log:        logger,
+	}
+}
+
+// ListTags возвращает теги опубликованных постов с количеством постов (публичный доступ)
+// GET /api/tags
+func (h *TagHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.tagService.ListTags(r.Context())
+	if err != nil {
+		middleware.AbortError(w, r, "Failed to list tags", http.StatusInternalServerError, err)
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"tags": tags,
+	}, http.StatusOK)
+}
+
+// RenameTag переименовывает тег у всех постов (editor/admin)
+// PUT /api/tags/{name}
+func (h *TagHandler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	var req model.RenameTagRequest
+	if err := parseJSONRequest(r, &req); err != nil {
+		middleware.AbortError(w, r, "Invalid JSON", http.StatusBadRequest, err)
+		return
+	}
+
+	tag, err := h.tagService.RenameTag(r.Context(), actorID, r.PathValue("name"), req.Name)
+	if err != nil {
+		h.abortTagError(w, r, err, "Failed to rename tag")
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"message": "Tag renamed",
+		"tag":     tag,
+	}, http.StatusOK)
+}
+
+// MergeTags переносит посты тега в другой тег и удаляет его (editor/admin)
+// POST /api/tags/{name}/merge
+func (h *TagHandler) MergeTags(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	var req model.MergeTagsRequest
+	if err := parseJSONRequest(r, &req); err != nil {
+		middleware.AbortError(w, r, "Invalid JSON", http.StatusBadRequest, err)
+		return
+	}
+
+	tag, err := h.tagService.MergeTags(r.Context(), actorID, r.PathValue("name"), req.Into)
+	if err != nil {
+		h.abortTagError(w, r, err, "Failed to merge tags")
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"message": "Tags merged",
+		"tag":     tag,
+	}, http.StatusOK)
+}
+
+// abortTagError переводит ошибки TagService в HTTP коды
+func (h *TagHandler) abortTagError(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrPermissionDenied):
+		middleware.AbortError(w, r, "Permission denied", http.StatusForbidden, err)
+	case errors.Is(err, service.ErrTagNotFound):
+		middleware.AbortError(w, r, "Tag not found", http.StatusNotFound, err)
+	case errors.Is(err, service.ErrTagExists):
+		middleware.AbortError(w, r, "Tag already exists, merge the tags instead", http.StatusConflict, err)
+	case errors.Is(err, service.ErrInvalidTag):
+		middleware.AbortError(w, r, err.Error(), http.StatusBadRequest, err)
+	default:
+		middleware.AbortError(w, r, fallback, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,259 @@
+// internal/handlers/tag_handler_test.go
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"blog-backend/internal/handlers"
+	"blog-backend/internal/model"
+	"blog-backend/service"
+)
+
+// editorID - ID редактора в setupTagTestRouter (ID=1 — автор)
+const editorID = 2
+
+// userHeaderMiddleware - тестовая авторизация: ID пользователя из заголовка X-User-ID
+func userHeaderMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := strconv.Atoi(r.Header.Get("X-User-ID"))
+		ctx := context.WithValue(r.Context(), "userID", userID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
+
+// setupTagTestRouter - роутер постов и тегов с автором ID=1 и редактором ID=2
+func setupTagTestRouter(t *testing.T) http.Handler {
+	t.Helper()
+
+	postRepo := NewMemoryPostStorage()
+	userRepo := NewMemoryUserRepository()
+	ctx := context.Background()
+	editor, _ := userRepo.CreateUser(ctx, "editor@example.com", "editor", "hash")
+	if err := userRepo.UpdateUserRole(ctx, editor.ID, model.RoleEditor); err != nil || editor.ID != editorID {
+		t.Fatalf("failed to create editor: %v", err)
+	}
+
+	logger := log.New(io.Discard, "", 0)
+	postHandler := handlers.NewPostHandler(service.NewPostService(postRepo, userRepo, NewTestConfig()), logger)
+	tagHandler := handlers.NewTagHandler(service.NewTagService(postRepo, userRepo), logger)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/posts", postHandler.ListPosts)
+	mux.HandleFunc("POST /api/posts", userHeaderMiddleware(postHandler.CreatePost))
+	mux.HandleFunc("PUT /api/posts/{postid}", userHeaderMiddleware(postHandler.UpdatePost))
+	mux.HandleFunc("GET /api/tags", tagHandler.ListTags)
+	mux.HandleFunc("PUT /api/tags/{name}", userHeaderMiddleware(tagHandler.RenameTag))
+	mux.HandleFunc("POST /api/tags/{name}/merge", userHeaderMiddleware(tagHandler.MergeTags))
+	return mux
+}
+
+// doAsUser - запрос от имени пользователя userID
+func doAsUser(router http.Handler, method, url string, userID int, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-ID", strconv.Itoa(userID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// postTags - теги поста из ответа создания или обновления
+func postTags(t *testing.T, w *httptest.ResponseRecorder) []string {
+	t.Helper()
+
+	var resp struct {
+		Data model.Post `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("invalid post response: %v", err)
+	}
+	return resp.Data.Tags
+}
+
+// postIDsByTags - ID постов и total из GET /api/posts с фильтром по тегам
+func postIDsByTags(t *testing.T, router http.Handler, query string) ([]int, int) {
+	t.Helper()
+
+	w := doJSON(router, http.MethodGet, "/api/posts?"+query, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("list %q: expected 200, got %d: %s", query, w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data  []model.Post `json:"data"`
+		Total int          `json:"total"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	ids := []int{}
+	for _, post := range resp.Data {
+		ids = append(ids, post.ID)
+	}
+	return ids, resp.Total
+}
+
+// listTags - теги из GET /api/tags
+func listTags(t *testing.T, router http.Handler) []model.Tag {
+	t.Helper()
+
+	var resp struct {
+		Tags []model.Tag `json:"tags"`
+	}
+	json.NewDecoder(doJSON(router, http.MethodGet, "/api/tags", "").Body).Decode(&resp)
+	return resp.Tags
+}
+
+// TestPostTags - теги при создании и обновлении, фильтр any/all и список тегов
+func TestPostTags(t *testing.T) {
+	router := setupTagTestRouter(t)
+
+	w := doAsUser(router, http.MethodPost, "/api/posts", 1, `{"title": "Go и Postgres", "content": "...", "tags": ["Go", " PostgreSQL ", "go"]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if tags := postTags(t, w); !reflect.DeepEqual(tags, []string{"go", "postgresql"}) {
+		t.Errorf("expected normalized tags, got %v", tags)
+	}
+	doAsUser(router, http.MethodPost, "/api/posts", 1, `{"title": "Веб на Go", "content": "...", "tags": ["go", "Web Dev"]}`)
+	doAsUser(router, http.MethodPost, "/api/posts", 1, `{"title": "Без тегов", "content": "..."}`)
+
+	cases := []struct {
+		query string
+		want  []int
+	}{
+		{"tag=go", []int{2, 1}},
+		{"tag=GO", []int{2, 1}},
+		{"tag=postgresql&tag=web-dev", []int{2, 1}},
+		{"tag=postgresql&tag=web-dev&match=all", []int{}},
+		{"tag=go&tag=web-dev&match=all", []int{2}},
+		{"tag=rust", []int{}},
+	}
+	for _, tc := range cases {
+		if ids, total := postIDsByTags(t, router, tc.query); !reflect.DeepEqual(ids, tc.want) || total != len(tc.want) {
+			t.Errorf("%s: expected %v, got %v (total %d)", tc.query, tc.want, ids, total)
+		}
+	}
+	if _, total := postIDsByTags(t, router, ""); total != 3 {
+		t.Errorf("expected all 3 posts without filter, got %d", total)
+	}
+	if w := doJSON(router, http.MethodGet, "/api/posts?tag=go&match=some", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid match: expected 400, got %d", w.Code)
+	}
+
+	want := []model.Tag{{Name: "go", PostCount: 2}, {Name: "postgresql", PostCount: 1}, {Name: "web-dev", PostCount: 1}}
+	if tags := listTags(t, router); !reflect.DeepEqual(tags, want) {
+		t.Errorf("expected %v, got %v", want, tags)
+	}
+
+	// Без поля tags теги не меняются, пустой список убирает все
+	w = doAsUser(router, http.MethodPut, "/api/posts/2", 1, `{"title": "Веб на Go", "content": "новый текст"}`)
+	if tags := postTags(t, w); !reflect.DeepEqual(tags, []string{"go", "web-dev"}) {
+		t.Errorf("expected tags to stay, got %v", tags)
+	}
+	w = doAsUser(router, http.MethodPut, "/api/posts/2", 1, `{"title": "Веб на Go", "content": "...", "tags": []}`)
+	if tags := postTags(t, w); len(tags) != 0 {
+		t.Errorf("expected tags to be removed, got %v", tags)
+	}
+	if ids, _ := postIDsByTags(t, router, "tag=web-dev"); len(ids) != 0 {
+		t.Errorf("expected no posts with removed tag, got %v", ids)
+	}
+
+	// Правка только тегов не трогает заголовок и текст
+	w = doAsUser(router, http.MethodPut, "/api/posts/2", 1, `{"tags": ["rust"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("tags-only update: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data model.Post `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Data.Title != "Веб на Go" || resp.Data.Content != "..." || !reflect.DeepEqual(resp.Data.Tags, []string{"rust"}) {
+		t.Errorf("expected title and content to stay, got %q / %q / %v", resp.Data.Title, resp.Data.Content, resp.Data.Tags)
+	}
+}
+
+// TestPostTagsValidation - недопустимые теги и слишком много тегов
+func TestPostTagsValidation(t *testing.T) {
+	router := setupTagTestRouter(t)
+
+	for _, tags := range []string{
+		`["bad/tag"]`,
+		`[""]`,
+		`["` + strings.Repeat("a", 33) + `"]`,
+		`["t1", "t2", "t3", "t4", "t5", "t6", "t7", "t8", "t9", "t10", "t11"]`,
+	} {
+		w := doAsUser(router, http.MethodPost, "/api/posts", 1, `{"title": "Пост", "content": "...", "tags": `+tags+`}`)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("tags %s: expected 400, got %d: %s", tags, w.Code, w.Body.String())
+		}
+	}
+
+	w := doAsUser(router, http.MethodPost, "/api/posts", 1, `{"title": "Пост", "content": "...", "tags": ["C++", "c#", "Node.js", "машинное обучение"]}`)
+	if tags := postTags(t, w); !reflect.DeepEqual(tags, []string{"c#", "c++", "node.js", "машинное-обучение"}) {
+		t.Errorf("unexpected tags %v", tags)
+	}
+	if w := doJSON(router, http.MethodGet, "/api/posts?tag=bad/tag", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid tag filter: expected 400, got %d", w.Code)
+	}
+}
+
+// TestRenameAndMergeTags - переименование и объединение тегов редактором
+func TestRenameAndMergeTags(t *testing.T) {
+	router := setupTagTestRouter(t)
+	doAsUser(router, http.MethodPost, "/api/posts", 1, `{"title": "Первый", "content": "...", "tags": ["golang", "postgresql"]}`)
+	doAsUser(router, http.MethodPost, "/api/posts", 1, `{"title": "Второй", "content": "...", "tags": ["go", "golang"]}`)
+
+	// Автор не управляет тегами
+	if w := doAsUser(router, http.MethodPut, "/api/tags/postgresql", 1, `{"name": "postgres"}`); w.Code != http.StatusForbidden {
+		t.Errorf("author rename: expected 403, got %d", w.Code)
+	}
+
+	w := doAsUser(router, http.MethodPut, "/api/tags/PostgreSQL", editorID, `{"name": "Postgres"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("rename: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ids, _ := postIDsByTags(t, router, "tag=postgres"); !reflect.DeepEqual(ids, []int{1}) {
+		t.Errorf("expected renamed tag on post 1, got %v", ids)
+	}
+
+	// Занятое имя — конфликт, такие теги объединяются
+	if w := doAsUser(router, http.MethodPut, "/api/tags/golang", editorID, `{"name": "go"}`); w.Code != http.StatusConflict {
+		t.Errorf("rename to existing: expected 409, got %d", w.Code)
+	}
+	if w := doAsUser(router, http.MethodPut, "/api/tags/rust", editorID, `{"name": "rustlang"}`); w.Code != http.StatusNotFound {
+		t.Errorf("rename unknown: expected 404, got %d", w.Code)
+	}
+	if w := doAsUser(router, http.MethodPut, "/api/tags/postgres", editorID, `{"name": "bad tag!"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid new name: expected 400, got %d", w.Code)
+	}
+
+	w = doAsUser(router, http.MethodPost, "/api/tags/golang/merge", editorID, `{"into": "go"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("merge: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Tag model.Tag `json:"tag"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Tag != (model.Tag{Name: "go", PostCount: 2}) {
+		t.Errorf("expected merged tag with 2 posts, got %+v", resp.Tag)
+	}
+	want := []model.Tag{{Name: "go", PostCount: 2}, {Name: "postgres", PostCount: 1}}
+	if tags := listTags(t, router); !reflect.DeepEqual(tags, want) {
+		t.Errorf("expected %v after merge, got %v", want, tags)
+	}
+
+	if w := doAsUser(router, http.MethodPost, "/api/tags/go/merge", editorID, `{"into": "go"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("merge into itself: expected 400, got %d", w.Code)
+	}
+	if w := doAsUser(router, http.MethodPost, "/api/tags/golang/merge", editorID, `{"into": "go"}`); w.Code != http.StatusNotFound {
+		t.Errorf("merge removed tag: expected 404, got %d", w.Code)
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/internal/model"
+	"blog-backend/pkg/auth"
+	"blog-backend/service"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// TokenHandler управляет personal access токенами
+type TokenHandler struct {
+	patService *service.PersonalAccessTokenService
+	log        *log.Logger
+}
+
+// NewTokenHandler создает новый TokenHandler
+func NewTokenHandler(patService *service.PersonalAccessTokenService, logger *log.Logger) *TokenHandler {
+	return &TokenHandler{
+		patService: patService,
+		log:        logger,
+	}
+}
+
+// CreateToken выпускает новый personal access токен (показывается один раз)
+// POST /api/tokens
+func (h *TokenHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	var req model.CreatePersonalAccessTokenRequest
+	if err := parseJSONRequest(r, &req); err != nil {
+		middleware.AbortError(w, r, "Invalid JSON", http.StatusBadRequest, err)
+		return
+	}
+
+	token, raw, err := h.patService.Create(r.Context(), userID, req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPersonalAccessToken) {
+			middleware.AbortError(w, r, err.Error(), http.StatusBadRequest, err)
+			return
+		}
+		middleware.AbortError(w, r, "Failed to create token", http.StatusInternalServerError, err)
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"message":               "Token created. Copy it now, it will not be shown again",
+		"token":                 raw,
+		"personal_access_token": token,
+	}, http.StatusCreated)
+}
+
+// ListTokens возвращает активные токены текущего пользователя
+// GET /api/tokens
+func (h *TokenHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	tokens, err := h.patService.List(r.Context(), userID)
+	if err != nil {
+		middleware.AbortError(w, r, "Failed to list tokens", http.StatusInternalServerError, err)
+		return
+	}
+	if tokens == nil {
+		tokens = []*model.PersonalAccessToken{}
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"tokens": tokens,
+	}, http.StatusOK)
+}
+
+// RevokeToken отзывает токен текущего пользователя
+// DELETE /api/tokens/{id}
+func (h *TokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	tokenID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		middleware.AbortError(w, r, "Invalid token ID", http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.patService.Revoke(r.Context(), userID, tokenID); err != nil {
+		if errors.Is(err, service.ErrPersonalAccessTokenNotFound) {
+			middleware.AbortError(w, r, "Token not found", http.StatusNotFound, err)
+			return
+		}
+		middleware.AbortError(w, r, "Failed to revoke token", http.StatusInternalServerError, err)
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"message": "Token revoked",
+	}, http.StatusOK)
+}
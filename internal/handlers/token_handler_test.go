@@ -0,0 +1,151 @@
+// internal/handlers/token_handler_test.go
+package handlers_test
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"blog-backend/internal/handlers"
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/internal/model"
+	"blog-backend/service"
+)
+
+// setupTokenTestRouter - роутер с входом, профилем, лентой и управлением personal access токенами
+func setupTokenTestRouter() http.Handler {
+	userRepo := NewMemoryUserRepository()
+	patRepo := NewMemoryPersonalAccessTokenRepository()
+	userSvc, revocations := newTestUserServiceWithPATs(userRepo, patRepo)
+	patSvc := service.NewPersonalAccessTokenService(patRepo, userRepo)
+
+	followRepo := NewMemoryFollowRepository(userRepo)
+	postRepo := NewMemoryPostStorage().(*MemoryPostStorage)
+	postRepo.follows = followRepo
+	followSvc := service.NewFollowService(followRepo, NewMemoryBlockRepository(userRepo), userRepo, postRepo)
+
+	logger := log.New(io.Discard, "", 0)
+	userHandler := handlers.NewUserHandler(userSvc, logger)
+	tokenHandler := handlers.NewTokenHandler(patSvc, logger)
+	followHandler := handlers.NewFollowHandler(followSvc, logger)
+	authenticator := middleware.NewAuthenticator(revocations, patSvc)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/auth/login", userHandler.LoginHandler)
+	mux.HandleFunc("POST /api/logout/all", authenticator.AuthMiddleware(userHandler.LogoutAllHandler))
+	mux.HandleFunc("GET /api/profile", authenticator.AuthMiddleware(userHandler.ProfileHandler, model.ScopeProfileRead))
+	mux.HandleFunc("GET /api/feed", authenticator.AuthMiddleware(followHandler.Feed, model.ScopePostsRead))
+	mux.HandleFunc("POST /api/tokens", authenticator.AuthMiddleware(tokenHandler.CreateToken))
+	mux.HandleFunc("GET /api/tokens", authenticator.AuthMiddleware(tokenHandler.ListTokens))
+	mux.HandleFunc("DELETE /api/tokens/{id}", authenticator.AuthMiddleware(tokenHandler.RevokeToken))
+
+	return mux
+}
+
+// createPAT выпускает токен и возвращает его значение и ID
+func createPAT(t *testing.T, router http.Handler, jwtToken, body string) (string, int) {
+	t.Helper()
+
+	w := doAuthJSON(router, http.MethodPost, "/api/tokens", jwtToken, body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create token: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Token string                    `json:"token"`
+		PAT   model.PersonalAccessToken `json:"personal_access_token"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !strings.HasPrefix(resp.Token, model.PersonalAccessTokenPrefix) {
+		t.Fatalf("unexpected token format: %q", resp.Token)
+	}
+	return resp.Token, resp.PAT.ID
+}
+
+// TestPersonalAccessTokens - выпуск, области доступа и отзыв
+func TestPersonalAccessTokens(t *testing.T) {
+	router := setupTokenTestRouter()
+	jwtToken, _ := loginTokens(t, router)
+
+	// 1. Валидация запроса
+	for name, body := range map[string]string{
+		"no_name":       `{"name": "", "scopes": ["profile:read"]}`,
+		"no_scopes":     `{"name": "ci", "scopes": []}`,
+		"unknown_scope": `{"name": "ci", "scopes": ["admin:all"]}`,
+		"bad_expiry":    `{"name": "ci", "scopes": ["profile:read"], "expires_in_days": 1000}`,
+	} {
+		if w := doAuthJSON(router, http.MethodPost, "/api/tokens", jwtToken, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, w.Code)
+		}
+	}
+
+	profileToken, profileTokenID := createPAT(t, router, jwtToken, `{"name": "ci", "scopes": ["profile:read"], "expires_in_days": 30}`)
+	postsToken, _ := createPAT(t, router, jwtToken, `{"name": "publisher", "scopes": ["posts:write"]}`)
+
+	// 2. Токен с нужной областью доступа принимается
+	if w := doAuthJSON(router, http.MethodGet, "/api/profile", profileToken, ""); w.Code != http.StatusOK {
+		t.Errorf("profile with profile:read: expected 200, got %d", w.Code)
+	}
+	// Без нужной области — 403
+	if w := doAuthJSON(router, http.MethodGet, "/api/profile", postsToken, ""); w.Code != http.StatusForbidden {
+		t.Errorf("profile with posts:write: expected 403, got %d", w.Code)
+	}
+	// Чтение по авторизации (лента) требует posts:read
+	if w := doAuthJSON(router, http.MethodGet, "/api/feed", postsToken, ""); w.Code != http.StatusForbidden {
+		t.Errorf("feed with posts:write: expected 403, got %d", w.Code)
+	}
+	readerToken, _ := createPAT(t, router, jwtToken, `{"name": "reader", "scopes": ["posts:read"]}`)
+	if w := doAuthJSON(router, http.MethodGet, "/api/feed", readerToken, ""); w.Code != http.StatusOK {
+		t.Errorf("feed with posts:read: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	// Управление токенами — только по JWT
+	if w := doAuthJSON(router, http.MethodPost, "/api/tokens", profileToken, `{"name": "x", "scopes": ["profile:read"]}`); w.Code != http.StatusForbidden {
+		t.Errorf("create token with PAT: expected 403, got %d", w.Code)
+	}
+	// Неизвестный токен
+	if w := doAuthJSON(router, http.MethodGet, "/api/profile", model.PersonalAccessTokenPrefix+"unknown", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("unknown PAT: expected 401, got %d", w.Code)
+	}
+
+	// 3. Список без секретов
+	w := doAuthJSON(router, http.MethodGet, "/api/tokens", jwtToken, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("list: expected 200, got %d", w.Code)
+	}
+	if body := w.Body.String(); strings.Contains(body, model.PersonalAccessTokenPrefix) || !strings.Contains(body, `"last_used_at":"`) {
+		t.Errorf("unexpected list response: %s", body)
+	}
+
+	// 4. Отзыв
+	url := "/api/tokens/" + strconv.Itoa(profileTokenID)
+	if w := doAuthJSON(router, http.MethodDelete, url, jwtToken, ""); w.Code != http.StatusOK {
+		t.Fatalf("revoke: expected 200, got %d", w.Code)
+	}
+	if w := doAuthJSON(router, http.MethodDelete, url, jwtToken, ""); w.Code != http.StatusNotFound {
+		t.Errorf("revoke twice: expected 404, got %d", w.Code)
+	}
+	if w := doAuthJSON(router, http.MethodGet, "/api/profile", profileToken, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("revoked PAT: expected 401, got %d", w.Code)
+	}
+}
+
+// TestLogoutAllRevokesPATs - выход со всех устройств отзывает и personal access токены
+func TestLogoutAllRevokesPATs(t *testing.T) {
+	router := setupTokenTestRouter()
+	jwtToken, _ := loginTokens(t, router)
+	patToken, _ := createPAT(t, router, jwtToken, `{"name": "ci", "scopes": ["profile:read"]}`)
+
+	// Выход со всех устройств — только по JWT
+	if w := doAuthJSON(router, http.MethodPost, "/api/logout/all", patToken, ""); w.Code != http.StatusForbidden {
+		t.Errorf("logout all with PAT: expected 403, got %d", w.Code)
+	}
+	if w := doAuthJSON(router, http.MethodPost, "/api/logout/all", jwtToken, ""); w.Code != http.StatusOK {
+		t.Fatalf("logout all: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doAuthJSON(router, http.MethodGet, "/api/profile", patToken, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("PAT after logout all: expected 401, got %d", w.Code)
+	}
+}
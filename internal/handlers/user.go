@@ -7,9 +7,13 @@ import (
 	"blog-backend/pkg/jwt"
 	"blog-backend/service"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 // UserHandler обрабатывает HTTP запросы для пользователей
@@ -33,7 +37,8 @@ func (h *UserHandler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		middleware.AbortError(w, r, "Method not allowed", http.StatusMethodNotAllowed, nil)
 		return
 	}
-	ctx := r.Context()
+	// IP и User-Agent нужны для сессии, которая создается вместе с токенами
+	ctx := sessionContext(r)
 
 	// Реализуем регистрацию пользователя
 	//
@@ -66,7 +71,14 @@ func (h *UserHandler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 3. Проверяем существование email
+	// 3. Политика регистрации (закрыта, по приглашениям, домены email) —
+	// до проверки email, чтобы закрытая регистрация не раскрывала занятые адреса
+	if err := h.userService.CheckRegistration(ctx, req.Email, req.InviteCode); err != nil {
+		abortRegistrationError(w, r, err)
+		return
+	}
+
+	// Проверяем существование email
 	if exists, err := h.userService.UserExistsByEmail(ctx, req.Email); err != nil {
 		middleware.AbortError(w, r, "Database error", http.StatusInternalServerError, err)
 		return
@@ -82,22 +94,26 @@ func (h *UserHandler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 5. Создаем пользователя и токен
-	user, token, err := h.userService.Register(ctx, req.Email, req.Username, passwordHash)
+	// 5. Создаем пользователя и токены
+	user, tokens, err := h.userService.Register(ctx, req.Email, req.Username, passwordHash, req.InviteCode)
 	if err != nil {
-		middleware.AbortError(w, r, "Failed to create user", http.StatusInternalServerError, err)
+		abortRegistrationError(w, r, err)
 		return
 	}
 
-	// 7. Возвращаем ответ с токеном и данными пользователя
+	// 7. Возвращаем ответ с токенами и данными пользователя
 	response := map[string]interface{}{
 		"message": "User registered successfully",
 		"user": map[string]interface{}{
-			"id":       user.ID,
-			"email":    user.Email,
-			"username": user.Username,
+			"id":             user.ID,
+			"email":          user.Email,
+			"username":       user.Username,
+			"role":           user.Role,
+			"email_verified": user.IsEmailVerified(),
 		},
-		"token": token,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
 	}
 	sendJSONResponse(w, response, http.StatusCreated)
 }
@@ -109,7 +125,7 @@ func (h *UserHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx := r.Context()
+	ctx := sessionContext(r)
 	// Авторизация пользователя
 	//
 	// Пошаговый план:
@@ -140,25 +156,150 @@ func (h *UserHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 3. Вызываем сервис
-	user, token, err := h.userService.Login(ctx, req.Email, req.Password)
+	user, tokens, err := h.userService.Login(ctx, req.Email, req.Password, middleware.ClientIP(r))
+	// Слишком много неудачных попыток — вход временно заблокирован
+	var locked *service.LoginLockedError
+	if errors.As(err, &locked) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+		middleware.AbortError(w, r, "Too many failed login attempts, try again later", http.StatusTooManyRequests, err)
+		return
+	}
+	// Пароль верный, но включена 2FA — нужен второй шаг POST /api/auth/mfa/verify
+	var mfaRequired *service.MFARequiredError
+	if errors.As(err, &mfaRequired) {
+		sendMFARequired(w, mfaRequired)
+		return
+	}
 	if err != nil {
 		middleware.AbortError(w, r, "Invalid email or password", http.StatusUnauthorized, err)
 		return
 	}
 
 	// 4. Успешный ответ
-	response := map[string]interface{}{
+	sendJSONResponse(w, loginResponse(user, tokens), http.StatusOK)
+}
+
+// loginResponse - ответ успешного входа (пароль, 2FA, SSO)
+func loginResponse(user *model.User, tokens *model.TokenPair) map[string]interface{} {
+	return map[string]interface{}{
 		"message": "Login successful",
 		"user": map[string]interface{}{
-			"id":       user.ID,
-			"email":    user.Email,
-			"username": user.Username,
+			"id":             user.ID,
+			"email":          user.Email,
+			"username":       user.Username,
+			"role":           user.Role,
+			"email_verified": user.IsEmailVerified(),
 		},
-		"token": token,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	}
+}
+
+// sendMFARequired - первый шаг входа пройден, нужен код 2FA (POST /api/auth/mfa/verify)
+func sendMFARequired(w http.ResponseWriter, mfaRequired *service.MFARequiredError) {
+	sendJSONResponse(w, map[string]interface{}{
+		"message":      "Two-factor authentication code required",
+		"mfa_required": true,
+		"mfa_token":    mfaRequired.Token,
+		"expires_in":   int(mfaRequired.ExpiresIn.Seconds()),
+	}, http.StatusOK)
+}
+
+// RefreshHandler обменивает refresh токен на новую пару токенов
+// Старый refresh токен после обмена становится недействительным
+func (h *UserHandler) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		middleware.AbortError(w, r, "Method not allowed", http.StatusMethodNotAllowed, nil)
+		return
+	}
+
+	var req model.RefreshRequest
+	if err := parseJSONRequest(r, &req); err != nil {
+		middleware.AbortError(w, r, "Invalid JSON", http.StatusBadRequest, err)
+		return
+	}
+	if req.RefreshToken == "" {
+		middleware.AbortError(w, r, "refresh_token is required", http.StatusBadRequest, nil)
+		return
+	}
+
+	user, tokens, err := h.userService.RefreshTokens(sessionContext(r), req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRefreshTokenReused):
+			middleware.AbortError(w, r, "Refresh token reuse detected, all sessions revoked", http.StatusUnauthorized, err)
+		case errors.Is(err, service.ErrInvalidRefreshToken):
+			middleware.AbortError(w, r, "Invalid refresh token", http.StatusUnauthorized, err)
+		default:
+			middleware.AbortError(w, r, "Failed to refresh token", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	response := map[string]interface{}{
+		"message": "Token refreshed",
+		"user": map[string]interface{}{
+			"id":             user.ID,
+			"email":          user.Email,
+			"username":       user.Username,
+			"role":           user.Role,
+			"email_verified": user.IsEmailVerified(),
+		},
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
 	}
 	sendJSONResponse(w, response, http.StatusOK)
 }
 
+// LogoutHandler отзывает текущий access токен и refresh токен из тела запроса
+// Этот обработчик должен быть вызван только после AuthMiddleware
+func (h *UserHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.GetClaimsFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	// Тело запроса необязательно: {"refresh_token": "..."}
+	var req model.LogoutRequest
+	if r.ContentLength != 0 {
+		if err := parseJSONRequest(r, &req); err != nil {
+			middleware.AbortError(w, r, "Invalid JSON", http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if err := h.userService.Logout(r.Context(), claims, req.RefreshToken); err != nil {
+		middleware.AbortError(w, r, "Failed to logout", http.StatusInternalServerError, err)
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"message": "Logged out successfully",
+	}, http.StatusOK)
+}
+
+// LogoutAllHandler отзывает все токены пользователя (выход со всех устройств)
+// Этот обработчик должен быть вызван только после AuthMiddleware
+func (h *UserHandler) LogoutAllHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	if err := h.userService.LogoutAll(r.Context(), userID); err != nil {
+		middleware.AbortError(w, r, "Failed to logout", http.StatusInternalServerError, err)
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"message": "All sessions have been logged out",
+	}, http.StatusOK)
+}
+
 // ProfileHandler возвращает профиль текущего пользователя
 // Этот обработчик должен быть вызван только после AuthMiddleware
 func (h *UserHandler) ProfileHandler(w http.ResponseWriter, r *http.Request) {
@@ -190,15 +331,73 @@ func (h *UserHandler) ProfileHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Отправляем профиль (без password_hash)
-	response := map[string]interface{}{
-		"id":         user.ID,
-		"email":      user.Email,
-		"username":   user.Username,
-		"created_at": user.CreatedAt,
-	}
 	// Возвращаем данные пользователя в JSON формате
-	sendJSONResponse(w, response, http.StatusOK)
+	sendJSONResponse(w, profileResponse(user), http.StatusOK)
+}
+
+// profileResponse профиль текущего пользователя (без password_hash)
+func profileResponse(user *model.User) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                user.ID,
+		"email":             user.Email,
+		"username":          user.Username,
+		"role":              user.Role,
+		"bio":               user.Bio,
+		"avatar_url":        user.AvatarURL,
+		"avatar_small_url":  user.AvatarSmallURL,
+		"created_at":        user.CreatedAt,
+		"email_verified":    user.IsEmailVerified(),
+		"email_verified_at": user.EmailVerifiedAt,
+		"mfa_enabled":       user.IsMFAEnabled(),
+	}
+}
+
+// ChangeRoleHandler меняет роль пользователя (только admin)
+// PUT /api/admin/users/{id}/role
+func (h *UserHandler) ChangeRoleHandler(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	targetID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		middleware.AbortError(w, r, "Invalid user ID", http.StatusBadRequest, err)
+		return
+	}
+
+	var req model.ChangeRoleRequest
+	if err := parseJSONRequest(r, &req); err != nil {
+		middleware.AbortError(w, r, "Invalid JSON", http.StatusBadRequest, err)
+		return
+	}
+
+	user, err := h.userService.ChangeRole(r.Context(), actorID, targetID, req.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidRole):
+			middleware.AbortError(w, r, "Invalid role (reader, author, editor, admin)", http.StatusBadRequest, err)
+		case errors.Is(err, service.ErrPermissionDenied):
+			middleware.AbortError(w, r, "Permission denied", http.StatusForbidden, err)
+		case strings.Contains(err.Error(), "user not found"):
+			middleware.AbortError(w, r, "User not found", http.StatusNotFound, err)
+		default:
+			middleware.AbortError(w, r, "Failed to change role", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"message": "Role updated",
+		"user": map[string]interface{}{
+			"id":             user.ID,
+			"email":          user.Email,
+			"username":       user.Username,
+			"role":           user.Role,
+			"email_verified": user.IsEmailVerified(),
+		},
+	}, http.StatusOK)
 }
 
 // sendJSONResponse отправляет JSON ответ (вспомогательная функция)
@@ -224,7 +423,28 @@ func parseJSONRequest(r *http.Request, v interface{}) error {
 	return decoder.Decode(v)
 }
 
-// validateRegisterRequest валидирует данные регистрации
+// abortRegistrationError переводит ошибки политики регистрации в HTTP коды
+func abortRegistrationError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrRegistrationClosed):
+		middleware.AbortError(w, r, "Registration is closed", http.StatusForbidden, err)
+	case errors.Is(err, service.ErrInvitationRequired):
+		middleware.AbortError(w, r, "Registration requires an invitation code", http.StatusForbidden, err)
+	case errors.Is(err, service.ErrInvalidInvitation):
+		middleware.AbortError(w, r, "Invalid or expired invitation code", http.StatusForbidden, err)
+	case errors.Is(err, service.ErrEmailDomainNotAllowed):
+		middleware.AbortError(w, r, "Registration is not allowed for this email domain", http.StatusForbidden, err)
+	case errors.Is(err, service.ErrInvalidUsername):
+		middleware.AbortError(w, r, err.Error(), http.StatusBadRequest, err)
+	case errors.Is(err, service.ErrUsernameTaken), errors.Is(err, service.ErrUsernameConfusable):
+		middleware.AbortError(w, r, err.Error(), http.StatusConflict, err)
+	default:
+		middleware.AbortError(w, r, "Failed to create user", http.StatusInternalServerError, err)
+	}
+}
+
+// validateRegisterRequest валидирует данные регистрации и нормализует email
+// (правила для username проверяет UserService.Register)
 func validateRegisterRequest(req *model.RegisterRequest) error {
 	if req.Email == "" {
 		return fmt.Errorf("email is required")
@@ -236,10 +456,11 @@ func validateRegisterRequest(req *model.RegisterRequest) error {
 		return fmt.Errorf("password is required")
 	}
 
-	// TODO: Добавить дополнительные проверки
-	// - Используйте ValidateEmail() и ValidatePassword() из auth.go
-	// - Проверьте длину username (например, минимум 3 символа)
-	// - Проверьте что username содержит только допустимые символы
+	email, err := jwt.NormalizeEmail(req.Email)
+	if err != nil {
+		return err
+	}
+	req.Email = email
 
 	return nil
 }
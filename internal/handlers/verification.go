@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/pkg/auth"
+	"blog-backend/service"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// VerificationHandler обрабатывает подтверждение email
+type VerificationHandler struct {
+	verifier *service.EmailVerificationService
+	log      *log.Logger
+}
+
+// NewVerificationHandler создает новый VerificationHandler
+func NewVerificationHandler(verifier *service.EmailVerificationService, logger *log.Logger) *VerificationHandler {
+	return &VerificationHandler{
+		verifier: verifier,
+		log:      logger,
+	}
+}
+
+// VerifyEmailHandler подтверждает email по ссылке из письма
+// GET /api/verify-email?token=...
+func (h *VerificationHandler) VerifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		middleware.AbortError(w, r, "token is required", http.StatusBadRequest, nil)
+		return
+	}
+
+	user, err := h.verifier.Verify(r.Context(), token)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidVerificationToken) {
+			middleware.AbortError(w, r, "Invalid or expired verification link", http.StatusBadRequest, err)
+			return
+		}
+		middleware.AbortError(w, r, "Failed to verify email", http.StatusInternalServerError, err)
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"message": "Email verified",
+		"user": map[string]interface{}{
+			"id":             user.ID,
+			"email":          user.Email,
+			"username":       user.Username,
+			"email_verified": user.IsEmailVerified(),
+		},
+	}, http.StatusOK)
+}
+
+// ResendVerificationHandler повторно отправляет письмо с подтверждением
+// POST /api/verify-email/resend (только после AuthMiddleware)
+func (h *VerificationHandler) ResendVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r)
+	if !ok {
+		middleware.AbortError(w, r, "User not authenticated", http.StatusUnauthorized, nil)
+		return
+	}
+
+	if err := h.verifier.Resend(r.Context(), userID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrEmailAlreadyVerified):
+			middleware.AbortError(w, r, "Email already verified", http.StatusConflict, err)
+		case errors.Is(err, service.ErrVerificationResendTooSoon):
+			w.Header().Set("Retry-After", "60")
+			middleware.AbortError(w, r, "Verification email was sent recently, try again later", http.StatusTooManyRequests, err)
+		default:
+			middleware.AbortError(w, r, "Failed to send verification email", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	sendJSONResponse(w, map[string]interface{}{
+		"message": "Verification email sent",
+	}, http.StatusAccepted)
+}
@@ -0,0 +1,111 @@
+// internal/handlers/verification_handler_test.go
+package handlers_test
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"blog-backend/internal/handlers"
+	"blog-backend/internal/handlers/middleware"
+	"blog-backend/internal/repository/memory"
+	"blog-backend/service"
+)
+
+// setupVerificationTestRouter - роутер с регистрацией и подтверждением email
+func setupVerificationTestRouter() (http.Handler, *RecordingMailer) {
+	userRepo := NewMemoryUserRepository()
+	cfg := NewTestConfig()
+	cfg.AppBaseURL = "http://blog.test"
+
+	revocations := service.NewRevocationStore(NewMemoryRevokedTokenRepository(), cfg)
+	tokenSvc := service.NewTokenService(NewMemoryRefreshTokenRepository(), NewMemoryPersonalAccessTokenRepository(), userRepo, revocations, service.NewSessionService(NewMemorySessionRepository(), revocations, cfg), cfg)
+	mail := &RecordingMailer{}
+	verifier := service.NewEmailVerificationService(userRepo, mail, cfg)
+	mfaSvc := service.NewMFAService(userRepo, NewMemoryRecoveryCodeRepository(), tokenSvc, cfg)
+	throttle := service.NewLoginThrottleService(memory.NewMemoryLoginAttemptRepository(), cfg)
+	userSvc := service.NewUserService(userRepo, tokenSvc, verifier, mfaSvc, throttle, service.NewRegistrationService(NewMemoryInvitationRepository(), cfg))
+
+	logger := log.New(io.Discard, "", 0)
+	userHandler := handlers.NewUserHandler(userSvc, logger)
+	verificationHandler := handlers.NewVerificationHandler(verifier, logger)
+	authenticator := middleware.NewAuthenticator(revocations, nil)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/auth/register", userHandler.RegisterHandler)
+	mux.HandleFunc("GET /api/profile", authenticator.AuthMiddleware(userHandler.ProfileHandler))
+	mux.HandleFunc("GET /api/verify-email", verificationHandler.VerifyEmailHandler)
+	mux.HandleFunc("POST /api/verify-email/resend", authenticator.AuthMiddleware(verificationHandler.ResendVerificationHandler))
+
+	return mux, mail
+}
+
+var verifyLinkRe = regexp.MustCompile(`/api/verify-email\?token=(\S+)`)
+
+// TestEmailVerificationFlow - регистрация → письмо → подтверждение
+func TestEmailVerificationFlow(t *testing.T) {
+	router, mail := setupVerificationTestRouter()
+
+	// 1. Регистрация: аккаунт не подтвержден, письмо отправлено
+	w := doJSON(router, http.MethodPost, "/api/auth/register",
+		`{"email": "new@example.com", "username": "newuser", "password": "password123"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var reg struct {
+		Token string `json:"token"`
+		User  struct {
+			EmailVerified bool `json:"email_verified"`
+		} `json:"user"`
+	}
+	json.NewDecoder(w.Body).Decode(&reg)
+	if reg.User.EmailVerified {
+		t.Errorf("new account must be unverified")
+	}
+
+	msg, sent := mail.Last()
+	if !sent || msg.To != "new@example.com" {
+		t.Fatalf("expected verification email, got %+v", msg)
+	}
+	match := verifyLinkRe.FindStringSubmatch(msg.Body)
+	if match == nil {
+		t.Fatalf("verification link not found in email: %s", msg.Body)
+	}
+	token, _ := url.QueryUnescape(match[1])
+
+	// 2. Повторная отправка сразу после регистрации — слишком часто
+	if w := doAuthJSON(router, http.MethodPost, "/api/verify-email/resend", reg.Token, ""); w.Code != http.StatusTooManyRequests {
+		t.Errorf("resend too soon: expected 429, got %d", w.Code)
+	}
+
+	// 3. Подделанный и пустой токены
+	if w := doJSON(router, http.MethodGet, "/api/verify-email?token="+url.QueryEscape(token+"x"), ""); w.Code != http.StatusBadRequest {
+		t.Errorf("tampered token: expected 400, got %d", w.Code)
+	}
+	if w := doJSON(router, http.MethodGet, "/api/verify-email", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("missing token: expected 400, got %d", w.Code)
+	}
+
+	// 4. Подтверждение
+	if w := doJSON(router, http.MethodGet, "/api/verify-email?token="+url.QueryEscape(token), ""); w.Code != http.StatusOK {
+		t.Fatalf("verify: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doAuthJSON(router, http.MethodGet, "/api/profile", reg.Token, "")
+	var profile struct {
+		EmailVerified bool `json:"email_verified"`
+	}
+	json.NewDecoder(w.Body).Decode(&profile)
+	if !profile.EmailVerified {
+		t.Errorf("profile: expected email_verified=true")
+	}
+
+	// 5. Уже подтвержденный email
+	if w := doAuthJSON(router, http.MethodPost, "/api/verify-email/resend", reg.Token, ""); w.Code != http.StatusConflict {
+		t.Errorf("resend verified: expected 409, got %d", w.Code)
+	}
+}
@@ -6,20 +6,153 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Роли пользователей
+const (
+	RoleReader = "reader" // читает и комментирует
+	RoleAuthor = "author" // + пишет свои посты (роль по умолчанию)
+	RoleEditor = "editor" // + модерирует любые посты и комментарии
+	RoleAdmin  = "admin"  // + управляет пользователями
+)
+
+// IsValidRole проверяет, что роль известна системе
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleReader, RoleAuthor, RoleEditor, RoleAdmin:
+		return true
+	}
+	return false
+}
+
 // User представляет пользователя в системе
 type User struct {
 	ID           int       `json:"id"`
 	Email        string    `json:"email"`
 	Username     string    `json:"username"`
+	Role         string    `json:"role"`
 	PasswordHash string    `json:"-"` // "-" исключает поле из JSON
+	Bio          string    `json:"bio"`
 	CreatedAt    time.Time `json:"created_at"`
+
+	// Аватар: ссылки на квадратные копии 256x256 и 64x64 (nil = аватар не загружен)
+	AvatarURL      *string `json:"avatar_url,omitempty"`
+	AvatarSmallURL *string `json:"avatar_small_url,omitempty"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"` // NULL = email не подтвержден
+
+	// Двухфакторная аутентификация (TOTP)
+	TOTPSecret    string     `json:"-"` // зашифрован (secretbox), задается при начале подключения
+	TOTPEnabledAt *time.Time `json:"-"` // NULL = 2FA не включена
+
+	DeletedAt *time.Time `json:"-"` // аккаунт удален с анонимизацией (посты и комментарии остались)
+}
+
+// IsMFAEnabled сообщает, включена ли двухфакторная аутентификация
+func (u *User) IsMFAEnabled() bool {
+	return u.TOTPEnabledAt != nil
+}
+
+// IsEmailVerified сообщает, подтвердил ли пользователь email
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// PublicProfile публичный профиль автора (без email, роли и данных входа)
+type PublicProfile struct {
+	ID        int       `json:"id"`
+	Username  string    `json:"username"`
+	Bio       string    `json:"bio"`
+	AvatarURL *string   `json:"avatar_url"` // nil = аватар не загружен
+	PostCount int       `json:"post_count"` // только опубликованные посты
+	JoinedAt  time.Time `json:"joined_at"`
+
+	AvatarSmallURL *string `json:"avatar_small_url"` // 64x64 для списков и комментариев
+
+	FollowerCount  int `json:"follower_count"`
+	FollowingCount int `json:"following_count"`
+}
+
+// FollowUser пользователь в списке подписчиков или подписок (только публичные поля)
+type FollowUser struct {
+	ID         int       `json:"id"`
+	Username   string    `json:"username"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// RestrictedUser пользователь в списке заблокированных или скрытых (mute)
+type RestrictedUser struct {
+	ID       int       `json:"id"`
+	Username string    `json:"username"`
+	Since    time.Time `json:"since"`
+}
+
+// FeedCursor позиция в ленте — последний показанный пост (created_at, id).
+// Следующая страница начинается строго после него, поэтому новые посты не сдвигают выдачу
+type FeedCursor struct {
+	CreatedAt time.Time
+	ID        int
+}
+
+// UpdateProfileRequest структура для PATCH /api/profile (nil = поле не меняется)
+type UpdateProfileRequest struct {
+	Username        *string `json:"username"`
+	Email           *string `json:"email"`
+	Bio             *string `json:"bio"`
+	CurrentPassword string  `json:"current_password,omitempty"` // обязателен при смене email
+}
+
+// ChangePasswordRequest структура для смены пароля
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// DeleteAccountRequest структура для удаления аккаунта
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+// Режимы удаления аккаунта (ACCOUNT_DELETION_MODE)
+const (
+	AccountDeletionAnonymize = "anonymize" // посты и комментарии остаются от "deleted-<id>"
+	AccountDeletionHard      = "delete"    // пользователь удаляется вместе с постами и комментариями
+)
+
+// ChangeRoleRequest структура для смены роли пользователя (только admin)
+type ChangeRoleRequest struct {
+	Role string `json:"role"`
 }
 
 // RegisterRequest структура для запроса регистрации
 type RegisterRequest struct {
-	Email    string `json:"email"`
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Email      string `json:"email"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	InviteCode string `json:"invite_code,omitempty"` // обязателен при REGISTRATION_MODE=invite-only
+}
+
+// Режимы регистрации (REGISTRATION_MODE)
+const (
+	RegistrationOpen       = "open"        // любой желающий
+	RegistrationInviteOnly = "invite-only" // только по приглашению администратора
+	RegistrationClosed     = "closed"      // регистрация выключена
+)
+
+// Invitation одноразовое приглашение на регистрацию (в БД только хеш кода)
+type Invitation struct {
+	ID        int        `json:"id"`
+	CodeHash  string     `json:"-"`
+	Email     string     `json:"email,omitempty"` // "" = для любого адреса
+	CreatedBy *int       `json:"created_by"`      // NULL = администратор удален
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	UsedBy    *int       `json:"used_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// CreateInvitationRequest структура для создания приглашения (только admin)
+type CreateInvitationRequest struct {
+	Email         string `json:"email,omitempty"`           // привязать приглашение к адресу
+	ExpiresInDays int    `json:"expires_in_days,omitempty"` // 0 = INVITATION_TTL
 }
 
 // CreateUserRequest для парсинга JSON
@@ -41,23 +174,277 @@ type AuthResponse struct {
 	User  User   `json:"user"`
 }
 
+// RefreshRequest структура для обновления токенов
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenPair пара токенов, выдаваемая при входе и обновлении
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // Время жизни access токена в секундах
+}
+
+// RefreshToken хранимый на сервере refresh токен (в БД только хеш)
+type RefreshToken struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	TokenHash string     `json:"-"`
+	FamilyID  string     `json:"family_id"` // Все токены одной цепочки ротации
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"` // nil = токен активен
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Session вход пользователя с конкретного устройства.
+// Одна сессия соответствует одной цепочке refresh токенов
+type Session struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"-"`
+	FamilyID   string     `json:"-"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"` // Продлевается при каждом обновлении токенов
+	RevokedAt  *time.Time `json:"-"`          // nil = сессия активна
+	Current    bool       `json:"current"`    // Сессия токена, с которым сделан запрос
+}
+
+// ForgotPasswordRequest структура для запроса сброса пароля
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest структура для установки нового пароля по токену из письма
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// PasswordResetToken одноразовый токен сброса пароля (в БД только хеш)
+type PasswordResetToken struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// LogoutRequest структура для выхода (refresh токен необязателен)
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RevokedToken отозванный access токен (хранится до истечения срока)
+type RevokedToken struct {
+	JTI       string    `json:"jti"`
+	UserID    int       `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// MFAVerifyRequest второй шаг входа: код из приложения или код восстановления
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+// MFACodeRequest подтверждение действия кодом TOTP (или кодом восстановления)
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
+
+// TOTPEnrollment данные для подключения приложения-аутентификатора
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"` // для QR-кода
+}
+
+// Personal access токены для скриптов и CI
+const PersonalAccessTokenPrefix = "blog_pat_" // отличает PAT от JWT в заголовке Authorization
+
+// Области доступа (scopes) personal access токенов
+const (
+	ScopePostsRead     = "posts:read"
+	ScopePostsWrite    = "posts:write"
+	ScopeCommentsRead  = "comments:read"
+	ScopeCommentsWrite = "comments:write"
+	ScopeProfileRead   = "profile:read"
+)
+
+// IsValidScope проверяет, что область доступа известна системе
+func IsValidScope(scope string) bool {
+	switch scope {
+	case ScopePostsRead, ScopePostsWrite, ScopeCommentsRead, ScopeCommentsWrite, ScopeProfileRead:
+		return true
+	}
+	return false
+}
+
+// PersonalAccessToken именованный токен с ограниченными правами (в БД только хеш)
+type PersonalAccessToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"-"`
+	Name       string     `json:"name"`
+	TokenHash  string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"` // NULL = бессрочный
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreatePersonalAccessTokenRequest структура для выпуска personal access токена
+type CreatePersonalAccessTokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days,omitempty"` // 0 = бессрочный
+}
+
+// Причины неудачного входа (журнал login_attempts)
+const (
+	LoginFailureUnknownEmail    = "unknown_email"
+	LoginFailureInvalidPassword = "invalid_password"
+)
+
+// LoginThrottle счетчик неудачных входов по ключу ("email:..." или "ip:...")
+type LoginThrottle struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time // NULL = не заблокирован
+}
+
+// IsLocked сообщает, заблокирован ли вход на момент now
+func (t *LoginThrottle) IsLocked(now time.Time) bool {
+	return t.LockedUntil != nil && now.Before(*t.LockedUntil)
+}
+
+// LoginAttempt запись журнала неудачных входов (видна администраторам)
+type LoginAttempt struct {
+	ID        int       `json:"id"`
+	Email     string    `json:"email"`
+	UserID    *int      `json:"user_id"` // NULL = такого пользователя нет
+	IP        string    `json:"ip"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LoginAttemptFilter фильтр журнала неудачных входов
+type LoginAttemptFilter struct {
+	Email string
+	IP    string
+	Limit int
+}
+
+// Статусы выгрузки персональных данных
+const (
+	ExportStatusPending = "pending" // архив собирается
+	ExportStatusReady   = "ready"   // можно скачать до expires_at
+	ExportStatusFailed  = "failed"
+)
+
+// DataExport выгрузка персональных данных пользователя (ZIP архив)
+type DataExport struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"-"`
+	Status      string     `json:"status"`
+	FilePath    string     `json:"-"`
+	SizeBytes   int64      `json:"size_bytes,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
 // Claims структура для JWT токена
+// Уникальный ID токена хранится в RegisteredClaims.ID (claim "jti")
 type Claims struct {
 	UserID   int    `json:"user_id"`
 	Email    string `json:"email"`
 	Username string `json:"username"`
+	Role     string `json:"role"`
+	// SessionID - сессия входа (0 у токенов без сессии)
+	SessionID int `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
 type Post struct {
 	ID        int        `json:"id"`
 	Title     string     `json:"title"`      // Заголовок поста
+	Slug      string     `json:"slug"`       // Адрес поста из заголовка (/api/posts/by-slug/{slug})
 	Content   string     `json:"content"`    // Текст поста
 	AuthorID  int        `json:"author_id"`  // ID автора комментария
 	Status    string     `json:"status"`     // "draft" или "published"
 	PublishAt *time.Time `json:"publish_at"` // через указатель, который может быть nil (для представления SQL NULL)
+	Tags      []string   `json:"tags"`       // Теги по алфавиту (при обновлении nil = не менять)
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
+
+	// Content в HTML (Markdown: CommonMark + GFM, после очистки) и оглавление по заголовкам.
+	// Считаются при сохранении поста, якоря оглавления — id заголовков в ContentHTML
+	ContentHTML string     `json:"content_html"`
+	TOC         []TOCEntry `json:"toc"`
+}
+
+// TOCEntry - пункт оглавления поста: уровень заголовка, текст и якорь (id заголовка в ContentHTML)
+type TOCEntry struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	ID    string `json:"id"`
+}
+
+// PostSearchResult - найденный пост с релевантностью и подсветкой найденных слов.
+// TitleHighlight и Snippet - экранированный HTML, в котором найденные слова обернуты в <mark>
+type PostSearchResult struct {
+	Post
+	Rank           float64 `json:"rank"`
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"` // фрагменты текста вокруг найденных слов
+}
+
+// Tag - тег постов с количеством опубликованных постов
+type Tag struct {
+	Name      string `json:"name"`
+	PostCount int    `json:"post_count"`
+}
+
+// RenameTagRequest - новое имя тега (editor/admin)
+type RenameTagRequest struct {
+	Name string `json:"name"`
+}
+
+// MergeTagsRequest - тег, в который переносятся посты объединяемого тега (editor/admin)
+type MergeTagsRequest struct {
+	Into string `json:"into"`
+}
+
+// PostRevision - версия заголовка и текста поста. Прежние версии сохраняются при каждом изменении,
+// последняя в истории (Current) — текущее состояние поста
+type PostRevision struct {
+	Revision  int       `json:"revision"` // номер по порядку, начиная с 1
+	Title     string    `json:"title"`
+	Content   string    `json:"content,omitempty"` // в списке версий не передается
+	Current   bool      `json:"current"`
+	CreatedAt time.Time `json:"created_at"` // когда версия была сохранена
+}
+
+// RevisionDiff - построчное сравнение двух версий поста
+type RevisionDiff struct {
+	From      int        `json:"from"`
+	To        int        `json:"to"`
+	TitleFrom string     `json:"title_from"`
+	TitleTo   string     `json:"title_to"`
+	Inserted  int        `json:"inserted"` // добавлено строк
+	Deleted   int        `json:"deleted"`  // удалено строк
+	Lines     []DiffLine `json:"lines"`
+}
+
+// DiffLine - строка сравнения версий: Op — "equal", "insert" или "delete"
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
 }
 
 type Comment struct {
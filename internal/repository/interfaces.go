@@ -2,10 +2,22 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"blog-backend/internal/model"
 )
 
+// Ошибки гонок между проверкой в сервисе и записью в хранилище
+var (
+	// ErrSlugTaken - слаг занял другой пост между проверкой SlugTaken и записью
+	ErrSlugTaken = errors.New("slug already taken")
+	// ErrInvitationUsed - приглашение использовали или оно истекло между проверкой и регистрацией
+	ErrInvitationUsed = errors.New("invitation already used")
+	// ErrExportPending - у пользователя уже собирается выгрузка (параллельный запрос успел раньше)
+	ErrExportPending = errors.New("data export already pending")
+)
+
 // Отдельный интерфейс для health checks
 type HealthChecker interface {
 	HealthCheck(ctx context.Context) error
@@ -19,25 +31,251 @@ type PostRepository interface {
 	UpdatePost(ctx context.Context, id int, post *model.Post) (*model.Post, error)
 	DeletePost(ctx context.Context, id int) error
 
+	// Слаги. UpdatePost при смене слага сохраняет прежний в истории.
+	// CreatePost и UpdatePost возвращают ErrSlugTaken, если слаг успел занять другой пост.
+	// GetPostBySlug ищет по текущему слагу (nil, если не найден),
+	// FindSlugRedirect — ID поста, которому слаг принадлежал раньше (0, если не найден)
+	GetPostBySlug(ctx context.Context, slug string) (*model.Post, error)
+	FindSlugRedirect(ctx context.Context, slug string) (int, error)
+	// SlugTaken — слаг занят другим постом: текущий или из истории (старые ссылки должны работать)
+	SlugTaken(ctx context.Context, slug string, exceptPostID int) (bool, error)
+
 	// Список + пагинация
 	ListPosts(ctx context.Context, limit, offset int) ([]*model.Post, error)
 	CountPosts(ctx context.Context) (int, error)
 
+	// Полнотекстовый поиск по опубликованным постам (русский и английский), релевантные первыми
+	SearchPosts(ctx context.Context, query string, limit, offset int) ([]*model.PostSearchResult, error)
+	CountSearchPosts(ctx context.Context, query string) (int, error)
+
+	// Теги. CreatePost и UpdatePost сохраняют post.Tags (при обновлении nil = теги не меняются).
+	// ListPostsByTags — опубликованные посты хотя бы с одним из тегов или, при matchAll, со всеми
+	ListPostsByTags(ctx context.Context, tags []string, matchAll bool, limit, offset int) ([]*model.Post, error)
+	CountPostsByTags(ctx context.Context, tags []string, matchAll bool) (int, error)
+	// ListTags — теги опубликованных постов с количеством постов, популярные первыми
+	ListTags(ctx context.Context) ([]model.Tag, error)
+	// GetTag ищет тег по имени (nil, если не найден)
+	GetTag(ctx context.Context, name string) (*model.Tag, error)
+	RenameTag(ctx context.Context, name, newName string) error
+	// MergeTags переносит посты тега source в target и удаляет source
+	MergeTags(ctx context.Context, source, target string) error
+
+	// История версий. UpdatePost в той же транзакции сохраняет прежние заголовок и текст,
+	// если они меняются. ListRevisions — сохраненные версии без текста, новые первыми;
+	// GetRevision — версия с текстом (nil, если не найдена)
+	ListRevisions(ctx context.Context, postID int) ([]*model.PostRevision, error)
+	GetRevision(ctx context.Context, postID, revision int) (*model.PostRevision, error)
+
+	// Опубликованные посты автора (публичная страница автора)
+	ListPostsByUser(ctx context.Context, userID, limit, offset int) ([]*model.Post, error)
+	CountPostsByUser(ctx context.Context, userID int) (int, error)
+	// Все посты автора, включая черновики (выгрузка данных)
+	ListAllPostsByUser(ctx context.Context, userID int) ([]*model.Post, error)
+
+	// Лента: опубликованные посты авторов, на которых подписан followerID, после cursor
+	// (nil = с начала), новые первыми. Скрытые (mute) авторы не попадают в ленту
+	ListFeed(ctx context.Context, followerID int, cursor *model.FeedCursor, limit int) ([]*model.Post, error)
+
 	// Методы планировщика
 	GetReadyToPublish(ctx context.Context, batchSize int) ([]*model.Post, error)
 	PublishPost(ctx context.Context, postID int) error
+
+	// Посты, сохраненные до появления content_html: непустой текст без HTML, по возрастанию ID
+	// начиная после afterID. SetRenderedContent записывает HTML и оглавление, не меняя updated_at
+	ListUnrenderedPosts(ctx context.Context, afterID, limit int) ([]*model.Post, error)
+	SetRenderedContent(ctx context.Context, post *model.Post) error
 }
 
 // UserRepository — интерфейс для работы с пользователями
 type UserRepository interface {
 	GetUserByID(ctx context.Context, id int) (*model.User, error)
+	// GetUserByEmail и GetUserByUsername ищут без учета регистра (nil, если не найден)
 	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*model.User, error)
 	CreateUser(ctx context.Context, email string, username string, passwordHash string) (*model.User, error)
+	// CreateInvitedUser создает пользователя и расходует приглашение invitationID в одной транзакции.
+	// ErrInvitationUsed — приглашение уже использовано или истекло, пользователь не создается
+	CreateInvitedUser(ctx context.Context, email, username, passwordHash string, invitationID int) (*model.User, error)
 	UserExistsByEmail(ctx context.Context, email string) (bool, error)
+	UpdateUserRole(ctx context.Context, id int, role string) error
+	UpdatePassword(ctx context.Context, id int, passwordHash string) error
+	// MarkEmailVerified подтверждает email, только если он не менялся с момента отправки ссылки.
+	// Возвращает false, если пользователь не найден или email уже другой
+	MarkEmailVerified(ctx context.Context, id int, email string) (bool, error)
+	// SetTOTPSecret сохраняет секрет нового подключения (2FA остается выключенной до подтверждения)
+	SetTOTPSecret(ctx context.Context, id int, secret string) error
+	// ReplaceTOTPSecret перезаписывает секрет (старый → зашифрованный), не меняя состояние 2FA.
+	// Ничего не делает, если секрет уже другой
+	ReplaceTOTPSecret(ctx context.Context, id int, oldSecret, newSecret string) error
+	EnableTOTP(ctx context.Context, id int) error
+	DisableTOTP(ctx context.Context, id int) error
+	// UseTOTPStep атомарно запоминает шаг использованного кода, false — код уже использовался
+	UseTOTPStep(ctx context.Context, id int, step int64) (bool, error)
+	// UpdateProfile сохраняет username, email, bio и email_verified_at пользователя
+	UpdateProfile(ctx context.Context, user *model.User) error
+	// UpdateAvatar сохраняет ссылки на аватар (nil — аватар удален)
+	UpdateAvatar(ctx context.Context, id int, avatarURL, avatarSmallURL *string) error
+	// DeleteUser удаляет пользователя (посты, комментарии и токены удаляются каскадно)
+	DeleteUser(ctx context.Context, id int) error
+	// AnonymizeUser обезличивает пользователя: посты и комментарии остаются,
+	// личные данные, пароль, 2FA и все токены удаляются
+	AnonymizeUser(ctx context.Context, id int) error
 }
 
 // CommentRepository — интерфейс для работы с комментариями
 type CommentRepository interface {
 	Create(ctx context.Context, comment *model.Comment) (int, error)
+	GetByID(ctx context.Context, id int) (*model.Comment, error)
 	GetByPostID(ctx context.Context, postID int) ([]*model.Comment, error)
+	// GetVisibleByPostID — комментарии поста без авторов, скрытых (mute) пользователем viewerID
+	GetVisibleByPostID(ctx context.Context, postID, viewerID int) ([]*model.Comment, error)
+	GetByAuthorID(ctx context.Context, authorID int) ([]*model.Comment, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// RefreshTokenRepository — интерфейс для работы с refresh токенами
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *model.RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error)
+	// Revoke отзывает активный токен, false — токен уже был отозван
+	Revoke(ctx context.Context, id int) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAllForUser(ctx context.Context, userID int) error
+}
+
+// SessionRepository — интерфейс для работы с сессиями (входами с устройств)
+type SessionRepository interface {
+	Create(ctx context.Context, session *model.Session) error
+	// GetByID и GetByFamily возвращают nil, если сессия не найдена
+	GetByID(ctx context.Context, id int) (*model.Session, error)
+	GetByFamily(ctx context.Context, familyID string) (*model.Session, error)
+	// ListActive возвращает незавершенные и не истекшие сессии пользователя
+	ListActive(ctx context.Context, userID int) ([]*model.Session, error)
+	// ListForUser возвращает до limit последних сессий пользователя, включая завершенные (история входов)
+	ListForUser(ctx context.Context, userID, limit int) ([]*model.Session, error)
+	TouchLastSeen(ctx context.Context, id int, seenAt time.Time) error
+	// Extend продлевает активную сессию при обновлении токенов
+	Extend(ctx context.Context, id int, seenAt, expiresAt time.Time) error
+	// Revoke завершает активную сессию, false — сессия уже завершена
+	Revoke(ctx context.Context, id int) (bool, error)
+	RevokeAllForUser(ctx context.Context, userID int) error
+}
+
+// RevokedTokenRepository — интерфейс для хранения отозванных access токенов
+type RevokedTokenRepository interface {
+	RevokeToken(ctx context.Context, token *model.RevokedToken) error
+	// RevokeAllForUser отзывает все токены пользователя, выданные до момента before
+	RevokeAllForUser(ctx context.Context, userID int, before time.Time) error
+	ListRevokedTokens(ctx context.Context) ([]*model.RevokedToken, error)
+	// ListUserCutoffs возвращает userID → момент отзыва (только после since)
+	ListUserCutoffs(ctx context.Context, since time.Time) (map[int]time.Time, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+// PasswordResetRepository — интерфейс для одноразовых токенов сброса пароля
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *model.PasswordResetToken) error
+	// Consume атомарно помечает действующий токен использованным и возвращает userID (0 — токен недействителен)
+	Consume(ctx context.Context, tokenHash string) (int, error)
+	InvalidateForUser(ctx context.Context, userID int) error
+}
+
+// RecoveryCodeRepository — интерфейс для кодов восстановления 2FA (хранятся хеши)
+type RecoveryCodeRepository interface {
+	// ReplaceForUser заменяет все коды пользователя новыми
+	ReplaceForUser(ctx context.Context, userID int, codeHashes []string) error
+	// Consume помечает код использованным, false — код не найден или уже использован
+	Consume(ctx context.Context, userID int, codeHash string) (bool, error)
+	DeleteForUser(ctx context.Context, userID int) error
+}
+
+// PersonalAccessTokenRepository — интерфейс для personal access токенов
+type PersonalAccessTokenRepository interface {
+	Create(ctx context.Context, token *model.PersonalAccessToken) error
+	// GetByHash находит токен по хешу (nil, если не найден)
+	GetByHash(ctx context.Context, tokenHash string) (*model.PersonalAccessToken, error)
+	// ListByUser возвращает неотозванные токены пользователя
+	ListByUser(ctx context.Context, userID int) ([]*model.PersonalAccessToken, error)
+	// Revoke отзывает токен пользователя, false — токен не найден или уже отозван
+	Revoke(ctx context.Context, userID, id int) (bool, error)
+	RevokeAllForUser(ctx context.Context, userID int) error
+	TouchLastUsed(ctx context.Context, id int, usedAt time.Time) error
+}
+
+// LoginAttemptRepository — счетчики неудачных входов и журнал неудачных попыток
+type LoginAttemptRepository interface {
+	// GetThrottle возвращает счетчик ключа (nil, если неудач не было)
+	GetThrottle(ctx context.Context, key string) (*model.LoginThrottle, error)
+	// RegisterFailure атомарно увеличивает счетчик и возвращает новое значение.
+	// Если последняя неудача была раньше resetBefore, счет начинается заново
+	RegisterFailure(ctx context.Context, key string, at, resetBefore time.Time) (int, error)
+	// LockUntil запрещает вход по ключу до until
+	LockUntil(ctx context.Context, key string, until time.Time) error
+	// ResetThrottle удаляет счетчик (после успешного входа)
+	ResetThrottle(ctx context.Context, key string) error
+	RecordAttempt(ctx context.Context, attempt *model.LoginAttempt) error
+	// ListAttempts возвращает последние неудачные попытки (новые первыми)
+	ListAttempts(ctx context.Context, filter model.LoginAttemptFilter) ([]*model.LoginAttempt, error)
+}
+
+// DataExportRepository — выгрузки персональных данных
+type DataExportRepository interface {
+	// Create возвращает ErrExportPending, если у пользователя уже есть выгрузка в статусе pending
+	Create(ctx context.Context, export *model.DataExport) error
+	// GetByID возвращает выгрузку (nil, если не найдена)
+	GetByID(ctx context.Context, id int) (*model.DataExport, error)
+	// GetLatestForUser возвращает последнюю выгрузку пользователя (nil, если их не было)
+	GetLatestForUser(ctx context.Context, userID int) (*model.DataExport, error)
+	MarkReady(ctx context.Context, id int, filePath string, sizeBytes int64, expiresAt time.Time) error
+	MarkFailed(ctx context.Context, id int) error
+	// DeleteExpired удаляет записи с истекшим сроком и возвращает пути их архивов
+	DeleteExpired(ctx context.Context, now time.Time) ([]string, error)
+}
+
+// InvitationRepository — приглашения на регистрацию (хранятся хеши кодов)
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation *model.Invitation) error
+	// GetByHash находит приглашение по хешу кода (nil, если не найдено)
+	GetByHash(ctx context.Context, codeHash string) (*model.Invitation, error)
+	// List возвращает все приглашения (новые первыми)
+	List(ctx context.Context) ([]*model.Invitation, error)
+	// Delete удаляет неиспользованное приглашение, false — не найдено или уже использовано
+	Delete(ctx context.Context, id int) (bool, error)
+}
+
+// FollowRepository — подписки пользователей на авторов
+type FollowRepository interface {
+	// Follow подписывает followerID на followeeID (повторная подписка не ошибка)
+	Follow(ctx context.Context, followerID, followeeID int) error
+	// Unfollow отменяет подписку, false — подписки не было
+	Unfollow(ctx context.Context, followerID, followeeID int) (bool, error)
+	// ListFollowers / ListFollowing — новые подписки первыми, удаленные аккаунты не показываются
+	ListFollowers(ctx context.Context, userID, limit, offset int) ([]*model.FollowUser, error)
+	ListFollowing(ctx context.Context, userID, limit, offset int) ([]*model.FollowUser, error)
+	CountFollowers(ctx context.Context, userID int) (int, error)
+	CountFollowing(ctx context.Context, userID int) (int, error)
+}
+
+// BlockRepository — блокировки и скрытие (mute) пользователей
+type BlockRepository interface {
+	// Block блокирует blockedID для blockerID (повторная блокировка не ошибка)
+	Block(ctx context.Context, blockerID, blockedID int) error
+	// Unblock снимает блокировку, false — блокировки не было
+	Unblock(ctx context.Context, blockerID, blockedID int) (bool, error)
+	// IsBlocked — blockerID заблокировал userID
+	IsBlocked(ctx context.Context, blockerID, userID int) (bool, error)
+	ListBlocked(ctx context.Context, blockerID int) ([]*model.RestrictedUser, error)
+	// Mute скрывает посты и комментарии mutedID от muterID (повтор не ошибка)
+	Mute(ctx context.Context, muterID, mutedID int) error
+	// Unmute, false — пользователь не был скрыт
+	Unmute(ctx context.Context, muterID, mutedID int) (bool, error)
+	ListMuted(ctx context.Context, muterID int) ([]*model.RestrictedUser, error)
+}
+
+// OIDCFlowRepository — начатые входы через OIDC (хранятся хеши flowID): каждый завершается один раз
+type OIDCFlowRepository interface {
+	// Create сохраняет начатый вход (заодно удаляются просроченные)
+	Create(ctx context.Context, flowHash string, expiresAt time.Time) error
+	// Consume атомарно удаляет действующий вход, false — входа нет, он истек или уже завершен
+	Consume(ctx context.Context, flowHash string) (bool, error)
 }
@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"blog-backend/internal/model"
+)
+
+// Журнал в памяти ограничен: при переполнении удаляются самые старые записи
+const maxStoredLoginAttempts = 10000
+
+// Реализация LoginAttemptRepository в памяти процесса.
+// Подходит для одного экземпляра сервиса; при нескольких экземплярах используйте PostgreSQL
+type MemoryLoginAttemptRepository struct {
+	mu        sync.Mutex
+	throttles map[string]*model.LoginThrottle
+	attempts  []*model.LoginAttempt // от старых к новым
+	nextID    int
+	sweepAt   int // размер карты, при котором удаляются устаревшие счетчики
+}
+
+// Создаем хранилище счетчиков входа в памяти
+func NewMemoryLoginAttemptRepository() *MemoryLoginAttemptRepository {
+	return &MemoryLoginAttemptRepository{
+		throttles: make(map[string]*model.LoginThrottle),
+		nextID:    1,
+		sweepAt:   1024,
+	}
+}
+
+// GetThrottle возвращает копию счетчика (nil, если неудач не было)
+func (r *MemoryLoginAttemptRepository) GetThrottle(ctx context.Context, key string) (*model.LoginThrottle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	throttle, exists := r.throttles[key]
+	if !exists {
+		return nil, nil
+	}
+	copied := *throttle
+	return &copied, nil
+}
+
+// RegisterFailure увеличивает счетчик ключа
+func (r *MemoryLoginAttemptRepository) RegisterFailure(ctx context.Context, key string, at, resetBefore time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	throttle, exists := r.throttles[key]
+	if !exists || throttle.LastFailureAt.Before(resetBefore) {
+		throttle = &model.LoginThrottle{Key: key}
+		r.throttles[key] = throttle
+	}
+	throttle.Failures++
+	throttle.LastFailureAt = at
+
+	// Перебор ключей (например, с разных IP) не должен расти бесконечно
+	if len(r.throttles) >= r.sweepAt {
+		r.sweep(at, resetBefore)
+		r.sweepAt = 2*len(r.throttles) + 1024
+	}
+	return throttle.Failures, nil
+}
+
+// LockUntil блокирует ключ до until
+func (r *MemoryLoginAttemptRepository) LockUntil(ctx context.Context, key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if throttle, exists := r.throttles[key]; exists {
+		throttle.LockedUntil = &until
+	}
+	return nil
+}
+
+// ResetThrottle удаляет счетчик ключа
+func (r *MemoryLoginAttemptRepository) ResetThrottle(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.throttles, key)
+	return nil
+}
+
+// RecordAttempt добавляет запись в журнал
+func (r *MemoryLoginAttemptRepository) RecordAttempt(ctx context.Context, attempt *model.LoginAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt.ID = r.nextID
+	r.nextID++
+	if attempt.CreatedAt.IsZero() {
+		attempt.CreatedAt = time.Now()
+	}
+
+	copied := *attempt
+	r.attempts = append(r.attempts, &copied)
+	if len(r.attempts) > maxStoredLoginAttempts {
+		r.attempts = r.attempts[len(r.attempts)-maxStoredLoginAttempts:]
+	}
+	return nil
+}
+
+// ListAttempts возвращает последние попытки по фильтру (новые первыми)
+func (r *MemoryLoginAttemptRepository) ListAttempts(ctx context.Context, filter model.LoginAttemptFilter) ([]*model.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]*model.LoginAttempt, 0)
+	for i := len(r.attempts) - 1; i >= 0 && len(result) < filter.Limit; i-- {
+		attempt := r.attempts[i]
+		if filter.Email != "" && !strings.EqualFold(attempt.Email, filter.Email) {
+			continue
+		}
+		if filter.IP != "" && attempt.IP != filter.IP {
+			continue
+		}
+		copied := *attempt
+		result = append(result, &copied)
+	}
+	return result, nil
+}
+
+// sweep удаляет счетчики без свежих неудач и без действующей блокировки
+func (r *MemoryLoginAttemptRepository) sweep(now, resetBefore time.Time) {
+	for key, throttle := range r.throttles {
+		if throttle.LastFailureAt.Before(resetBefore) && !throttle.IsLocked(now) {
+			delete(r.throttles, key)
+		}
+	}
+}
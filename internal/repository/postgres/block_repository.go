@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"blog-backend/internal/model"
+)
+
+// Реализация BlockRepository для PostgreSQL
+type PostgresBlockRepository struct {
+	db *sql.DB
+}
+
+// Создаем новый репозиторий блокировок и скрытых пользователей
+func NewPostgresBlockRepository(db *sql.DB) *PostgresBlockRepository {
+	return &PostgresBlockRepository{db: db}
+}
+
+// Block блокирует пользователя (повторная блокировка не ошибка)
+func (r *PostgresBlockRepository) Block(ctx context.Context, blockerID, blockedID int) error {
+	query := `
+        INSERT INTO user_blocks (blocker_id, blocked_id)
+        VALUES ($1, $2)
+        ON CONFLICT (blocker_id, blocked_id) DO NOTHING`
+
+	if _, err := r.db.ExecContext(ctx, query, blockerID, blockedID); err != nil {
+		return fmt.Errorf("failed to block user %d: %w", blockedID, err)
+	}
+	return nil
+}
+
+// Unblock снимает блокировку (false — блокировки не было)
+func (r *PostgresBlockRepository) Unblock(ctx context.Context, blockerID, blockedID int) (bool, error) {
+	query := `DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2`
+	return r.deleteRelation(ctx, query, blockerID, blockedID)
+}
+
+// IsBlocked проверяет, заблокировал ли blockerID пользователя userID
+func (r *PostgresBlockRepository) IsBlocked(ctx context.Context, blockerID, userID int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2)`
+
+	var blocked bool
+	if err := r.db.QueryRowContext(ctx, query, blockerID, userID).Scan(&blocked); err != nil {
+		return false, fmt.Errorf("failed to check block: %w", err)
+	}
+	return blocked, nil
+}
+
+// ListBlocked возвращает заблокированных пользователей (новые первыми)
+func (r *PostgresBlockRepository) ListBlocked(ctx context.Context, blockerID int) ([]*model.RestrictedUser, error) {
+	query := `
+        SELECT u.id, u.username, b.created_at
+        FROM user_blocks b
+        JOIN users u ON u.id = b.blocked_id
+        WHERE b.blocker_id = $1
+        ORDER BY b.created_at DESC, u.id DESC`
+
+	return r.listRestricted(ctx, query, blockerID)
+}
+
+// Mute скрывает посты и комментарии пользователя (повтор не ошибка)
+func (r *PostgresBlockRepository) Mute(ctx context.Context, muterID, mutedID int) error {
+	query := `
+        INSERT INTO user_mutes (muter_id, muted_id)
+        VALUES ($1, $2)
+        ON CONFLICT (muter_id, muted_id) DO NOTHING`
+
+	if _, err := r.db.ExecContext(ctx, query, muterID, mutedID); err != nil {
+		return fmt.Errorf("failed to mute user %d: %w", mutedID, err)
+	}
+	return nil
+}
+
+// Unmute снова показывает пользователя (false — он не был скрыт)
+func (r *PostgresBlockRepository) Unmute(ctx context.Context, muterID, mutedID int) (bool, error) {
+	query := `DELETE FROM user_mutes WHERE muter_id = $1 AND muted_id = $2`
+	return r.deleteRelation(ctx, query, muterID, mutedID)
+}
+
+// ListMuted возвращает скрытых пользователей (новые первыми)
+func (r *PostgresBlockRepository) ListMuted(ctx context.Context, muterID int) ([]*model.RestrictedUser, error) {
+	query := `
+        SELECT u.id, u.username, m.created_at
+        FROM user_mutes m
+        JOIN users u ON u.id = m.muted_id
+        WHERE m.muter_id = $1
+        ORDER BY m.created_at DESC, u.id DESC`
+
+	return r.listRestricted(ctx, query, muterID)
+}
+
+// deleteRelation удаляет блокировку или скрытие, false — записи не было
+func (r *PostgresBlockRepository) deleteRelation(ctx context.Context, query string, userID, targetID int) (bool, error) {
+	result, err := r.db.ExecContext(ctx, query, userID, targetID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete relation with user %d: %w", targetID, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete relation with user %d: %w", targetID, err)
+	}
+	return rows > 0, nil
+}
+
+// listRestricted выполняет запрос списка заблокированных/скрытых
+func (r *PostgresBlockRepository) listRestricted(ctx context.Context, query string, userID int) ([]*model.RestrictedUser, error) {
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list restricted users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*model.RestrictedUser
+	for rows.Next() {
+		user := &model.RestrictedUser{}
+		if err := rows.Scan(&user.ID, &user.Username, &user.Since); err != nil {
+			return nil, fmt.Errorf("failed to scan restricted user: %w", err)
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
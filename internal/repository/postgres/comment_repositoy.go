@@ -39,6 +39,31 @@ func (r *CommentRepository) Create(ctx context.Context, comment *model.Comment)
 	return id, nil
 }
 
+// GetByID возвращает комментарий по ID
+func (r *CommentRepository) GetByID(ctx context.Context, id int) (*model.Comment, error) {
+	query := `
+        SELECT id, post_id, author_id, content, created_at
+        FROM comments
+        WHERE id = $1`
+
+	comment := &model.Comment{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&comment.ID,
+		&comment.PostID,
+		&comment.AuthorID,
+		&comment.Content,
+		&comment.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("comment not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comment %d: %w", id, err)
+	}
+
+	return comment, nil
+}
+
 // GetByPostID возвращает все комментарии поста
 func (r *CommentRepository) GetByPostID(ctx context.Context, postID int) ([]*model.Comment, error) {
 	query := `
@@ -75,3 +100,96 @@ func (r *CommentRepository) GetByPostID(ctx context.Context, postID int) ([]*mod
 
 	return comments, nil
 }
+
+// GetVisibleByPostID возвращает комментарии поста без авторов, скрытых пользователем viewerID
+func (r *CommentRepository) GetVisibleByPostID(ctx context.Context, postID, viewerID int) ([]*model.Comment, error) {
+	query := `
+        SELECT c.id, c.post_id, c.author_id, c.content, c.created_at
+        FROM comments c
+        WHERE c.post_id = $1
+          AND NOT EXISTS (SELECT 1 FROM user_mutes m WHERE m.muter_id = $2 AND m.muted_id = c.author_id)
+        ORDER BY c.created_at ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, postID, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments by post_id %d: %w", postID, err)
+	}
+	defer rows.Close()
+
+	var comments []*model.Comment
+	for rows.Next() {
+		comment := &model.Comment{}
+		err := rows.Scan(
+			&comment.ID,
+			&comment.PostID,
+			&comment.AuthorID,
+			&comment.Content,
+			&comment.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		comments = append(comments, comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return comments, nil
+}
+
+// GetByAuthorID возвращает все комментарии пользователя (для выгрузки данных)
+func (r *CommentRepository) GetByAuthorID(ctx context.Context, authorID int) ([]*model.Comment, error) {
+	query := `
+        SELECT id, post_id, author_id, content, created_at 
+        FROM comments 
+        WHERE author_id = $1 
+        ORDER BY created_at ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, authorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments by author_id %d: %w", authorID, err)
+	}
+	defer rows.Close()
+
+	var comments []*model.Comment
+	for rows.Next() {
+		comment := &model.Comment{}
+		err := rows.Scan(
+			&comment.ID,
+			&comment.PostID,
+			&comment.AuthorID,
+			&comment.Content,
+			&comment.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		comments = append(comments, comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return comments, nil
+}
+
+// Delete удаляет комментарий по ID
+func (r *CommentRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM comments WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("comment not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+)
+
+// Реализация DataExportRepository для PostgreSQL
+type PostgresDataExportRepository struct {
+	db *sql.DB
+}
+
+// Создаем новый репозиторий выгрузок данных
+func NewPostgresDataExportRepository(db *sql.DB) *PostgresDataExportRepository {
+	return &PostgresDataExportRepository{db: db}
+}
+
+// Create сохраняет новую выгрузку в статусе pending.
+// Вторую pending выгрузку пользователя не пропускает уникальный индекс idx_data_exports_one_pending
+func (r *PostgresDataExportRepository) Create(ctx context.Context, export *model.DataExport) error {
+	query := `
+        INSERT INTO data_exports (user_id, status)
+        VALUES ($1, $2)
+        RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, query, export.UserID, export.Status).Scan(&export.ID, &export.CreatedAt)
+	if isUniqueViolation(err, "idx_data_exports_one_pending") {
+		return repository.ErrExportPending
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create data export: %w", err)
+	}
+	return nil
+}
+
+// GetByID возвращает выгрузку (nil, если не найдена)
+func (r *PostgresDataExportRepository) GetByID(ctx context.Context, id int) (*model.DataExport, error) {
+	query := `
+        SELECT id, user_id, status, file_path, size_bytes, created_at, completed_at, expires_at
+        FROM data_exports
+        WHERE id = $1`
+
+	export, err := scanDataExport(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data export: %w", err)
+	}
+	return export, nil
+}
+
+// GetLatestForUser возвращает последнюю выгрузку пользователя (nil, если их не было)
+func (r *PostgresDataExportRepository) GetLatestForUser(ctx context.Context, userID int) (*model.DataExport, error) {
+	query := `
+        SELECT id, user_id, status, file_path, size_bytes, created_at, completed_at, expires_at
+        FROM data_exports
+        WHERE user_id = $1
+        ORDER BY created_at DESC, id DESC
+        LIMIT 1`
+
+	export, err := scanDataExport(r.db.QueryRowContext(ctx, query, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest data export: %w", err)
+	}
+	return export, nil
+}
+
+// MarkReady сохраняет путь к готовому архиву и срок действия ссылки
+func (r *PostgresDataExportRepository) MarkReady(ctx context.Context, id int, filePath string, sizeBytes int64, expiresAt time.Time) error {
+	query := `
+        UPDATE data_exports
+        SET status = 'ready', file_path = $1, size_bytes = $2, completed_at = CURRENT_TIMESTAMP, expires_at = $3
+        WHERE id = $4`
+
+	if _, err := r.db.ExecContext(ctx, query, filePath, sizeBytes, expiresAt, id); err != nil {
+		return fmt.Errorf("failed to mark data export %d ready: %w", id, err)
+	}
+	return nil
+}
+
+// MarkFailed помечает выгрузку неудачной
+func (r *PostgresDataExportRepository) MarkFailed(ctx context.Context, id int) error {
+	query := `
+        UPDATE data_exports
+        SET status = 'failed', completed_at = CURRENT_TIMESTAMP
+        WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to mark data export %d failed: %w", id, err)
+	}
+	return nil
+}
+
+// DeleteExpired удаляет записи с истекшим сроком и возвращает пути их архивов
+func (r *PostgresDataExportRepository) DeleteExpired(ctx context.Context, now time.Time) ([]string, error) {
+	query := `
+        DELETE FROM data_exports
+        WHERE expires_at < $1
+        RETURNING file_path`
+
+	rows, err := r.db.QueryContext(ctx, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete expired data exports: %w", err)
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, fmt.Errorf("failed to scan data export: %w", err)
+		}
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, rows.Err()
+}
+
+// scanDataExport читает строку data_exports
+func scanDataExport(row rowScanner) (*model.DataExport, error) {
+	export := &model.DataExport{}
+	err := row.Scan(
+		&export.ID,
+		&export.UserID,
+		&export.Status,
+		&export.FilePath,
+		&export.SizeBytes,
+		&export.CreatedAt,
+		&export.CompletedAt,
+		&export.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
@@ -3,9 +3,10 @@ package postgres
 import (
 	"blog-backend/internal/config"
 	"database/sql"
+	"errors"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 func NewDB(cfg *config.Config) (*sql.DB, error) {
@@ -25,3 +26,9 @@ func NewDB(cfg *config.Config) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// isUniqueViolation - нарушено ограничение уникальности (или уникальный индекс) constraint
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"blog-backend/internal/model"
+)
+
+// Реализация FollowRepository для PostgreSQL
+type PostgresFollowRepository struct {
+	db *sql.DB
+}
+
+// Создаем новый репозиторий подписок
+func NewPostgresFollowRepository(db *sql.DB) *PostgresFollowRepository {
+	return &PostgresFollowRepository{db: db}
+}
+
+// Follow подписывает followerID на followeeID (повторная подписка не ошибка)
+func (r *PostgresFollowRepository) Follow(ctx context.Context, followerID, followeeID int) error {
+	query := `
+        INSERT INTO follows (follower_id, followee_id)
+        VALUES ($1, $2)
+        ON CONFLICT (follower_id, followee_id) DO NOTHING`
+
+	if _, err := r.db.ExecContext(ctx, query, followerID, followeeID); err != nil {
+		return fmt.Errorf("failed to follow user %d: %w", followeeID, err)
+	}
+	return nil
+}
+
+// Unfollow отменяет подписку (false — подписки не было)
+func (r *PostgresFollowRepository) Unfollow(ctx context.Context, followerID, followeeID int) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2`, followerID, followeeID)
+	if err != nil {
+		return false, fmt.Errorf("failed to unfollow user %d: %w", followeeID, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to unfollow user %d: %w", followeeID, err)
+	}
+	return rows > 0, nil
+}
+
+// ListFollowers возвращает подписчиков пользователя (новые первыми)
+func (r *PostgresFollowRepository) ListFollowers(ctx context.Context, userID, limit, offset int) ([]*model.FollowUser, error) {
+	query := `
+        SELECT u.id, u.username, f.created_at
+        FROM follows f
+        JOIN users u ON u.id = f.follower_id
+        WHERE f.followee_id = $1 AND u.deleted_at IS NULL
+        ORDER BY f.created_at DESC, u.id DESC
+        LIMIT $2 OFFSET $3`
+
+	return r.listFollowUsers(ctx, query, userID, limit, offset)
+}
+
+// ListFollowing возвращает авторов, на которых подписан пользователь (новые первыми)
+func (r *PostgresFollowRepository) ListFollowing(ctx context.Context, userID, limit, offset int) ([]*model.FollowUser, error) {
+	query := `
+        SELECT u.id, u.username, f.created_at
+        FROM follows f
+        JOIN users u ON u.id = f.followee_id
+        WHERE f.follower_id = $1 AND u.deleted_at IS NULL
+        ORDER BY f.created_at DESC, u.id DESC
+        LIMIT $2 OFFSET $3`
+
+	return r.listFollowUsers(ctx, query, userID, limit, offset)
+}
+
+// CountFollowers количество подписчиков пользователя
+func (r *PostgresFollowRepository) CountFollowers(ctx context.Context, userID int) (int, error) {
+	query := `
+        SELECT COUNT(*)
+        FROM follows f
+        JOIN users u ON u.id = f.follower_id
+        WHERE f.followee_id = $1 AND u.deleted_at IS NULL`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count followers: %w", err)
+	}
+	return count, nil
+}
+
+// CountFollowing количество подписок пользователя
+func (r *PostgresFollowRepository) CountFollowing(ctx context.Context, userID int) (int, error) {
+	query := `
+        SELECT COUNT(*)
+        FROM follows f
+        JOIN users u ON u.id = f.followee_id
+        WHERE f.follower_id = $1 AND u.deleted_at IS NULL`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count following: %w", err)
+	}
+	return count, nil
+}
+
+// listFollowUsers выполняет запрос списка подписчиков/подписок
+func (r *PostgresFollowRepository) listFollowUsers(ctx context.Context, query string, args ...interface{}) ([]*model.FollowUser, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list follows: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*model.FollowUser
+	for rows.Next() {
+		user := &model.FollowUser{}
+		if err := rows.Scan(&user.ID, &user.Username, &user.FollowedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan follow: %w", err)
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"blog-backend/internal/model"
+)
+
+// Реализация InvitationRepository для PostgreSQL
+type PostgresInvitationRepository struct {
+	db *sql.DB
+}
+
+// Создаем новый репозиторий приглашений
+func NewPostgresInvitationRepository(db *sql.DB) *PostgresInvitationRepository {
+	return &PostgresInvitationRepository{db: db}
+}
+
+// Create сохраняет хеш нового приглашения
+func (r *PostgresInvitationRepository) Create(ctx context.Context, invitation *model.Invitation) error {
+	query := `
+        INSERT INTO invitations (code_hash, email, created_by, expires_at)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		invitation.CodeHash,
+		invitation.Email,
+		invitation.CreatedBy,
+		invitation.ExpiresAt,
+	).Scan(&invitation.ID, &invitation.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+	return nil
+}
+
+// GetByHash находит приглашение по хешу кода (nil, если не найдено)
+func (r *PostgresInvitationRepository) GetByHash(ctx context.Context, codeHash string) (*model.Invitation, error) {
+	query := `
+        SELECT id, code_hash, email, created_by, expires_at, used_at, used_by, created_at
+        FROM invitations
+        WHERE code_hash = $1`
+
+	invitation, err := scanInvitation(r.db.QueryRowContext(ctx, query, codeHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	return invitation, nil
+}
+
+// List возвращает все приглашения (новые первыми)
+func (r *PostgresInvitationRepository) List(ctx context.Context) ([]*model.Invitation, error) {
+	query := `
+        SELECT id, code_hash, email, created_by, expires_at, used_at, used_by, created_at
+        FROM invitations
+        ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+	defer rows.Close()
+
+	var invitations []*model.Invitation
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invitation: %w", err)
+		}
+		invitations = append(invitations, invitation)
+	}
+	return invitations, rows.Err()
+}
+
+// Delete удаляет неиспользованное приглашение (false — не найдено или уже использовано)
+func (r *PostgresInvitationRepository) Delete(ctx context.Context, id int) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM invitations WHERE id = $1 AND used_at IS NULL`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete invitation %d: %w", id, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete invitation %d: %w", id, err)
+	}
+	return rows > 0, nil
+}
+
+// scanInvitation читает строку invitations
+func scanInvitation(row rowScanner) (*model.Invitation, error) {
+	invitation := &model.Invitation{}
+	err := row.Scan(
+		&invitation.ID,
+		&invitation.CodeHash,
+		&invitation.Email,
+		&invitation.CreatedBy,
+		&invitation.ExpiresAt,
+		&invitation.UsedAt,
+		&invitation.UsedBy,
+		&invitation.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"blog-backend/internal/model"
+)
+
+// Реализация LoginAttemptRepository для PostgreSQL (общие счетчики для всех экземпляров сервиса)
+type PostgresLoginAttemptRepository struct {
+	db *sql.DB
+}
+
+// Создаем новый репозиторий счетчиков и журнала входов
+func NewPostgresLoginAttemptRepository(db *sql.DB) *PostgresLoginAttemptRepository {
+	return &PostgresLoginAttemptRepository{db: db}
+}
+
+// GetThrottle возвращает счетчик ключа (nil, если неудач не было)
+func (r *PostgresLoginAttemptRepository) GetThrottle(ctx context.Context, key string) (*model.LoginThrottle, error) {
+	query := `
+        SELECT key, failures, last_failure_at, locked_until
+        FROM login_throttles
+        WHERE key = $1`
+
+	var throttle model.LoginThrottle
+	err := r.db.QueryRowContext(ctx, query, key).Scan(
+		&throttle.Key,
+		&throttle.Failures,
+		&throttle.LastFailureAt,
+		&throttle.LockedUntil,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login throttle: %w", err)
+	}
+
+	return &throttle, nil
+}
+
+// RegisterFailure увеличивает счетчик одним запросом (без гонок между экземплярами)
+func (r *PostgresLoginAttemptRepository) RegisterFailure(ctx context.Context, key string, at, resetBefore time.Time) (int, error) {
+	query := `
+        INSERT INTO login_throttles (key, failures, last_failure_at)
+        VALUES ($1, 1, $2)
+        ON CONFLICT (key) DO UPDATE SET
+            failures = CASE WHEN login_throttles.last_failure_at < $3
+                            THEN 1 ELSE login_throttles.failures + 1 END,
+            locked_until = CASE WHEN login_throttles.last_failure_at < $3
+                                THEN NULL ELSE login_throttles.locked_until END,
+            last_failure_at = $2
+        RETURNING failures`
+
+	var failures int
+	if err := r.db.QueryRowContext(ctx, query, key, at, resetBefore).Scan(&failures); err != nil {
+		return 0, fmt.Errorf("failed to register login failure: %w", err)
+	}
+
+	return failures, nil
+}
+
+// LockUntil блокирует ключ до until
+func (r *PostgresLoginAttemptRepository) LockUntil(ctx context.Context, key string, until time.Time) error {
+	query := `UPDATE login_throttles SET locked_until = $1 WHERE key = $2`
+	if _, err := r.db.ExecContext(ctx, query, until, key); err != nil {
+		return fmt.Errorf("failed to lock login: %w", err)
+	}
+	return nil
+}
+
+// ResetThrottle удаляет счетчик ключа
+func (r *PostgresLoginAttemptRepository) ResetThrottle(ctx context.Context, key string) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM login_throttles WHERE key = $1", key); err != nil {
+		return fmt.Errorf("failed to reset login throttle: %w", err)
+	}
+	return nil
+}
+
+// RecordAttempt сохраняет неудачную попытку в журнал
+func (r *PostgresLoginAttemptRepository) RecordAttempt(ctx context.Context, attempt *model.LoginAttempt) error {
+	query := `
+        INSERT INTO login_attempts (email, user_id, ip, reason)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		attempt.Email,
+		attempt.UserID,
+		attempt.IP,
+		attempt.Reason,
+	).Scan(&attempt.ID, &attempt.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record login attempt: %w", err)
+	}
+
+	return nil
+}
+
+// ListAttempts возвращает последние попытки по фильтру (новые первыми)
+func (r *PostgresLoginAttemptRepository) ListAttempts(ctx context.Context, filter model.LoginAttemptFilter) ([]*model.LoginAttempt, error) {
+	query := `
+        SELECT id, email, user_id, ip, reason, created_at
+        FROM login_attempts
+        WHERE ($1 = '' OR email = $1) AND ($2 = '' OR ip = $2)
+        ORDER BY created_at DESC, id DESC
+        LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, filter.Email, filter.IP, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list login attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := make([]*model.LoginAttempt, 0)
+	for rows.Next() {
+		var attempt model.LoginAttempt
+		if err := rows.Scan(
+			&attempt.ID,
+			&attempt.Email,
+			&attempt.UserID,
+			&attempt.IP,
+			&attempt.Reason,
+			&attempt.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan login attempt: %w", err)
+		}
+		attempts = append(attempts, &attempt)
+	}
+
+	return attempts, rows.Err()
+}
@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Реализация OIDCFlowRepository для PostgreSQL
+type PostgresOIDCFlowRepository struct {
+	db *sql.DB
+}
+
+// Создаем новый репозиторий входов через OIDC
+func NewPostgresOIDCFlowRepository(db *sql.DB) *PostgresOIDCFlowRepository {
+	return &PostgresOIDCFlowRepository{db: db}
+}
+
+// Create сохраняет хеш начатого входа. Брошенные входы (пользователь не вернулся из IdP)
+// удаляются здесь же, отдельная очистка не нужна
+func (r *PostgresOIDCFlowRepository) Create(ctx context.Context, flowHash string, expiresAt time.Time) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM oidc_login_flows WHERE expires_at <= NOW()"); err != nil {
+		return fmt.Errorf("failed to delete expired oidc flows: %w", err)
+	}
+
+	query := `
+        INSERT INTO oidc_login_flows (flow_hash, expires_at)
+        VALUES ($1, $2)`
+
+	if _, err := r.db.ExecContext(ctx, query, flowHash, expiresAt); err != nil {
+		return fmt.Errorf("failed to create oidc flow: %w", err)
+	}
+	return nil
+}
+
+// Consume удаляет вход одним запросом: из двух одновременных callback пройдет только один
+func (r *PostgresOIDCFlowRepository) Consume(ctx context.Context, flowHash string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM oidc_login_flows WHERE flow_hash = $1 AND expires_at > NOW()", flowHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume oidc flow: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	return rows > 0, nil
+}
@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"blog-backend/internal/model"
+)
+
+// Реализация PasswordResetRepository для PostgreSQL
+type PostgresPasswordResetRepository struct {
+	db *sql.DB
+}
+
+// Создаем новый репозиторий токенов сброса пароля
+func NewPostgresPasswordResetRepository(db *sql.DB) *PostgresPasswordResetRepository {
+	return &PostgresPasswordResetRepository{db: db}
+}
+
+// Create сохраняет хеш нового токена сброса пароля
+func (r *PostgresPasswordResetRepository) Create(ctx context.Context, token *model.PasswordResetToken) error {
+	query := `
+        INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+        VALUES ($1, $2, $3)
+        RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, query, token.UserID, token.TokenHash, token.ExpiresAt).
+		Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create password reset token: %w", err)
+	}
+
+	return nil
+}
+
+// Consume помечает токен использованным одним запросом (защита от повторного использования)
+func (r *PostgresPasswordResetRepository) Consume(ctx context.Context, tokenHash string) (int, error) {
+	query := `
+        UPDATE password_reset_tokens
+        SET used_at = NOW()
+        WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+        RETURNING user_id`
+
+	var userID int
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to consume password reset token: %w", err)
+	}
+
+	return userID, nil
+}
+
+// InvalidateForUser делает недействительными все неиспользованные токены пользователя
+func (r *PostgresPasswordResetRepository) InvalidateForUser(ctx context.Context, userID int) error {
+	query := `
+        UPDATE password_reset_tokens
+        SET used_at = NOW()
+        WHERE user_id = $1 AND used_at IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to invalidate password reset tokens: %w", err)
+	}
+
+	return nil
+}
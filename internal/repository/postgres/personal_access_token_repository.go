@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"blog-backend/internal/model"
+
+	"github.com/lib/pq"
+)
+
+// Реализация PersonalAccessTokenRepository для PostgreSQL
+type PostgresPersonalAccessTokenRepository struct {
+	db *sql.DB
+}
+
+// Создаем новый репозиторий personal access токенов
+func NewPostgresPersonalAccessTokenRepository(db *sql.DB) *PostgresPersonalAccessTokenRepository {
+	return &PostgresPersonalAccessTokenRepository{db: db}
+}
+
+// Create сохраняет хеш нового токена
+func (r *PostgresPersonalAccessTokenRepository) Create(ctx context.Context, token *model.PersonalAccessToken) error {
+	query := `
+        INSERT INTO personal_access_tokens (user_id, name, token_hash, scopes, expires_at)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		token.UserID,
+		token.Name,
+		token.TokenHash,
+		pq.Array(token.Scopes),
+		token.ExpiresAt,
+	).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create personal access token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByHash находит токен по хешу (nil, если не найден)
+func (r *PostgresPersonalAccessTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*model.PersonalAccessToken, error) {
+	query := `
+        SELECT id, user_id, name, token_hash, scopes, last_used_at, expires_at, revoked_at, created_at
+        FROM personal_access_tokens
+        WHERE token_hash = $1`
+
+	token, err := scanPersonalAccessToken(r.db.QueryRowContext(ctx, query, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get personal access token: %w", err)
+	}
+
+	return token, nil
+}
+
+// ListByUser возвращает неотозванные токены пользователя (новые первыми)
+func (r *PostgresPersonalAccessTokenRepository) ListByUser(ctx context.Context, userID int) ([]*model.PersonalAccessToken, error) {
+	query := `
+        SELECT id, user_id, name, token_hash, scopes, last_used_at, expires_at, revoked_at, created_at
+        FROM personal_access_tokens
+        WHERE user_id = $1 AND revoked_at IS NULL
+        ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list personal access tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*model.PersonalAccessToken
+	for rows.Next() {
+		token, err := scanPersonalAccessToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan personal access token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// Revoke отзывает токен, только если он принадлежит пользователю
+func (r *PostgresPersonalAccessTokenRepository) Revoke(ctx context.Context, userID, id int) (bool, error) {
+	query := `
+        UPDATE personal_access_tokens
+        SET revoked_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke personal access token: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check affected rows: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// RevokeAllForUser отзывает все токены пользователя
+func (r *PostgresPersonalAccessTokenRepository) RevokeAllForUser(ctx context.Context, userID int) error {
+	query := `
+        UPDATE personal_access_tokens
+        SET revoked_at = CURRENT_TIMESTAMP
+        WHERE user_id = $1 AND revoked_at IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to revoke personal access tokens of user %d: %w", userID, err)
+	}
+
+	return nil
+}
+
+// TouchLastUsed обновляет время последнего использования
+func (r *PostgresPersonalAccessTokenRepository) TouchLastUsed(ctx context.Context, id int, usedAt time.Time) error {
+	query := `UPDATE personal_access_tokens SET last_used_at = $1 WHERE id = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, usedAt, id); err != nil {
+		return fmt.Errorf("failed to update last_used_at: %w", err)
+	}
+
+	return nil
+}
+
+// rowScanner — общий интерфейс *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanPersonalAccessToken читает строку personal_access_tokens
+func scanPersonalAccessToken(row rowScanner) (*model.PersonalAccessToken, error) {
+	token := &model.PersonalAccessToken{}
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.TokenHash,
+		pq.Array(&token.Scopes),
+		&token.LastUsedAt,
+		&token.ExpiresAt,
+		&token.RevokedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
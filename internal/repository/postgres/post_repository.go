@@ -3,10 +3,16 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"html"
+	"strings"
 	"time"
 
 	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+
+	"github.com/lib/pq"
 )
 
 // Реализация PostRepository для PostgreSQL (с CRUD методами)
@@ -23,9 +29,9 @@ func NewPostgresPostRepository(db *sql.DB) *PostgresPostRepository {
 func (r *PostgresPostRepository) CreatePost(ctx context.Context, post *model.Post) (*model.Post, error) {
 	// INSERT с RETURNING возвращает все поля созданной записи
 	query := `
-        INSERT INTO posts (author_id, title, content, status, publish_at) 
-        VALUES ($1, $2, $3, $4, $5) 
-        RETURNING id, author_id, title, content, status, publish_at, created_at, updated_at`
+        INSERT INTO posts (author_id, title, slug, content, content_html, toc, status, publish_at) 
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8) 
+        RETURNING id, author_id, title, slug, content, status, publish_at, created_at, updated_at`
 
 	// Инициализируем структуру createdPost
 	createdPost := &model.Post{}
@@ -45,22 +51,33 @@ func (r *PostgresPostRepository) CreatePost(ctx context.Context, post *model.Pos
 		publishAtParam.Valid = true
 	}
 
+	// Пост и его теги сохраняются вместе
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	// Выполняем INSERT, передаем только данные (author_id, title, content, publish_at)
-	row := r.db.QueryRowContext(
+	row := tx.QueryRowContext(
 		ctx,
 		query,
 		post.AuthorID,
 		post.Title,
+		post.Slug,
 		post.Content,
+		post.ContentHTML,
+		tocJSON(post.TOC),
 		post.Status,
 		publishAtParam,
 	)
 
 	// БД заполняет все поля (ID генерируется автоматически)
-	err := row.Scan(
+	err = row.Scan(
 		&createdPost.ID,        // Автогенерированный ID
 		&createdPost.AuthorID,  // Из параметров INSERT
 		&createdPost.Title,     // Из параметров INSERT
+		&createdPost.Slug,      // Из параметров INSERT
 		&createdPost.Content,   // Из параметров INSERT
 		&createdPost.Status,    // Из параметров INSERT
 		&publishAtNull,         // CURRENT_TIMESTAMP
@@ -72,12 +89,26 @@ func (r *PostgresPostRepository) CreatePost(ctx context.Context, post *model.Pos
 	if publishAtNull.Valid {
 		createdPost.PublishAt = &publishAtNull.Time
 	}
+	createdPost.ContentHTML, createdPost.TOC = post.ContentHTML, post.TOC
 
 	// Обрабатываем ошибки
+	if isUniqueViolation(err, "posts_slug_key") {
+		return nil, fmt.Errorf("%w: %s", repository.ErrSlugTaken, post.Slug)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create post: %w", err)
 	}
 
+	if err := setPostTags(ctx, tx, createdPost.ID, post.Tags); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to create post: %w", err)
+	}
+
+	if err := r.attachTags(ctx, createdPost); err != nil {
+		return nil, err
+	}
 	return createdPost, nil
 }
 
@@ -88,7 +119,7 @@ func (r *PostgresPostRepository) GetPostByID(ctx context.Context, id int) (*mode
 
 	// SELECT одной записи по первичному ключу
 	query := `
-        SELECT id, author_id, title, content, status, publish_at, created_at, updated_at 
+        SELECT id, author_id, title, slug, content, content_html, toc, status, publish_at, created_at, updated_at 
         FROM posts 
         WHERE id = $1`
 
@@ -100,7 +131,10 @@ func (r *PostgresPostRepository) GetPostByID(ctx context.Context, id int) (*mode
 		&post.ID,
 		&post.AuthorID,
 		&post.Title,
+		&post.Slug,
 		&post.Content,
+		&post.ContentHTML,
+		tocColumn{&post.TOC},
 		&post.Status,
 		&post.PublishAt,
 		&post.CreatedAt,
@@ -115,30 +149,74 @@ func (r *PostgresPostRepository) GetPostByID(ctx context.Context, id int) (*mode
 		return nil, fmt.Errorf("failed to get post: %w", err)
 	}
 
+	if err := r.attachTags(ctx, post); err != nil {
+		return nil, err
+	}
 	return post, nil
 }
 
-// Обновляем пост и возвращает актуальную версию с updated_at
+// Обновляем пост и возвращает актуальную версию с updated_at.
+// Если слаг меняется, прежний сохраняется в истории (старые ссылки ведут на пост)
 func (r *PostgresPostRepository) UpdatePost(ctx context.Context, id int, post *model.Post) (*model.Post, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Блокируем строку: параллельное обновление не должно потерять прежний слаг и прежнюю версию
+	var oldSlug, oldTitle, oldContent string
+	var oldUpdatedAt time.Time
+	err = tx.QueryRowContext(ctx, "SELECT slug, title, content, updated_at FROM posts WHERE id = $1 FOR UPDATE", id).
+		Scan(&oldSlug, &oldTitle, &oldContent, &oldUpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("post not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+	slug := post.Slug
+	if slug == "" {
+		slug = oldSlug
+	}
+
+	// Прежняя версия попадает в историю, только если заголовок или текст действительно меняются;
+	// пустые поля не меняются, поэтому правка только тегов или даты публикации версию не создает
+	if (post.Title != "" && post.Title != oldTitle) || (post.Content != "" && post.Content != oldContent) {
+		revision := `
+            INSERT INTO post_revisions (post_id, revision, title, content, created_at)
+            SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4 FROM post_revisions WHERE post_id = $1`
+		if _, err := tx.ExecContext(ctx, revision, id, oldTitle, oldContent, oldUpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to save revision: %w", err)
+		}
+	}
+
 	// UPDATE с автоматическим updated_at и RETURNING всех полей
 	query := `
         UPDATE posts 
-        SET title = $1, content = $2, publish_at = $3, updated_at = CURRENT_TIMESTAMP
-        WHERE id = $4
-        RETURNING id, author_id, title, content, status, publish_at, created_at, updated_at`
+        SET title = COALESCE(NULLIF($1, ''), title), slug = $2,
+            content = COALESCE(NULLIF($3, ''), content),
+            content_html = CASE WHEN $3 = '' THEN content_html ELSE $4 END,
+            toc = CASE WHEN $3 = '' THEN toc ELSE $5 END,
+            publish_at = $6, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $7
+        RETURNING id, author_id, title, slug, content, content_html, toc, status, publish_at, created_at, updated_at`
 
 	// Инициализируем структуру post
 	updatedPost := &model.Post{}
 
 	// Выполняем UPDATE
-	row := r.db.QueryRowContext(ctx, query, post.Title, post.Content, post.PublishAt, id)
+	row := tx.QueryRowContext(ctx, query, post.Title, slug, post.Content, post.ContentHTML, tocJSON(post.TOC), post.PublishAt, id)
 
 	// Заполняем структуру данными из БД
-	err := row.Scan(
+	err = row.Scan(
 		&updatedPost.ID,
 		&updatedPost.AuthorID,
 		&updatedPost.Title,
+		&updatedPost.Slug,
 		&updatedPost.Content,
+		&updatedPost.ContentHTML,
+		tocColumn{&updatedPost.TOC},
 		&updatedPost.Status,
 		&updatedPost.PublishAt,
 		&updatedPost.CreatedAt,
@@ -146,13 +224,103 @@ func (r *PostgresPostRepository) UpdatePost(ctx context.Context, id int, post *m
 	)
 
 	// Обрабатываем ошибки
+	if isUniqueViolation(err, "posts_slug_key") {
+		return nil, fmt.Errorf("%w: %s", repository.ErrSlugTaken, slug)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to update post: %w", err)
 	}
 
+	if slug != oldSlug {
+		// Прежний слаг становится редиректом, а вернувшийся (заголовок вернули назад) — снова адресом
+		history := `
+            INSERT INTO post_slug_history (slug, post_id) VALUES ($1, $2)
+            ON CONFLICT (slug) DO UPDATE SET post_id = EXCLUDED.post_id, created_at = CURRENT_TIMESTAMP`
+		if _, err := tx.ExecContext(ctx, history, oldSlug, id); err != nil {
+			return nil, fmt.Errorf("failed to save slug history: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM post_slug_history WHERE slug = $1", slug); err != nil {
+			return nil, fmt.Errorf("failed to save slug history: %w", err)
+		}
+	}
+
+	// nil — теги не передавались и не меняются
+	if post.Tags != nil {
+		if err := setPostTags(ctx, tx, id, post.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to update post: %w", err)
+	}
+
+	if err := r.attachTags(ctx, updatedPost); err != nil {
+		return nil, err
+	}
 	return updatedPost, nil
 }
 
+// Получаем пост по текущему слагу (nil — не найден)
+func (r *PostgresPostRepository) GetPostBySlug(ctx context.Context, slug string) (*model.Post, error) {
+	query := `
+        SELECT id, author_id, title, slug, content, content_html, toc, status, publish_at, created_at, updated_at 
+        FROM posts 
+        WHERE slug = $1`
+
+	post := &model.Post{}
+	err := r.db.QueryRowContext(ctx, query, slug).Scan(
+		&post.ID,
+		&post.AuthorID,
+		&post.Title,
+		&post.Slug,
+		&post.Content,
+		&post.ContentHTML,
+		tocColumn{&post.TOC},
+		&post.Status,
+		&post.PublishAt,
+		&post.CreatedAt,
+		&post.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post by slug: %w", err)
+	}
+
+	if err := r.attachTags(ctx, post); err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+// Ищем пост, которому слаг принадлежал раньше (0 — не найден)
+func (r *PostgresPostRepository) FindSlugRedirect(ctx context.Context, slug string) (int, error) {
+	var postID int
+	err := r.db.QueryRowContext(ctx, "SELECT post_id FROM post_slug_history WHERE slug = $1", slug).Scan(&postID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find slug redirect: %w", err)
+	}
+	return postID, nil
+}
+
+// Проверяем, занят ли слаг другим постом (текущим слагом или в истории)
+func (r *PostgresPostRepository) SlugTaken(ctx context.Context, slug string, exceptPostID int) (bool, error) {
+	query := `
+        SELECT EXISTS(SELECT 1 FROM posts WHERE slug = $1 AND id <> $2)
+            OR EXISTS(SELECT 1 FROM post_slug_history WHERE slug = $1 AND post_id <> $2)`
+
+	var taken bool
+	if err := r.db.QueryRowContext(ctx, query, slug, exceptPostID).Scan(&taken); err != nil {
+		return false, fmt.Errorf("failed to check slug: %w", err)
+	}
+	return taken, nil
+}
+
 // Удаляем пост по ID, проверяем что запись существовала
 func (r *PostgresPostRepository) DeletePost(ctx context.Context, id int) error {
 	result, err := r.db.ExecContext(ctx, "DELETE FROM posts WHERE id = $1", id)
@@ -175,7 +343,7 @@ func (r *PostgresPostRepository) DeletePost(ctx context.Context, id int) error {
 // Возвращаем список постов с пагинацией (limit/offset)
 func (r *PostgresPostRepository) ListPosts(ctx context.Context, limit, offset int) ([]*model.Post, error) {
 	query := `
-        SELECT id, author_id, title, content, status, publish_at, created_at, updated_at
+        SELECT id, author_id, title, slug, content, content_html, toc, status, publish_at, created_at, updated_at
         FROM posts 
         ORDER BY created_at DESC 
         LIMIT $1 OFFSET $2`
@@ -194,7 +362,10 @@ func (r *PostgresPostRepository) ListPosts(ctx context.Context, limit, offset in
 			&post.ID,
 			&post.AuthorID,
 			&post.Title,
+			&post.Slug,
 			&post.Content,
+			&post.ContentHTML,
+			tocColumn{&post.TOC},
 			&post.Status,
 			&post.PublishAt,
 			&post.CreatedAt,
@@ -205,6 +376,12 @@ func (r *PostgresPostRepository) ListPosts(ctx context.Context, limit, offset in
 		posts = append(posts, post)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan post: %w", err)
+	}
+	if err := r.attachTags(ctx, posts...); err != nil {
+		return nil, err
+	}
 	return posts, nil
 }
 
@@ -218,12 +395,12 @@ func (r *PostgresPostRepository) CountPosts(ctx context.Context) (int, error) {
 	return count, nil
 }
 
-// Список постов конкретного пользователя с пагинацией (по заданию пока не требуется)
+// Опубликованные посты конкретного пользователя с пагинацией (черновики не показываются)
 func (r *PostgresPostRepository) ListPostsByUser(ctx context.Context, userID, limit, offset int) ([]*model.Post, error) {
 	query := `
-        SELECT id, author_id, title, content, status, publish_at, created_at, updated_at
+        SELECT id, author_id, title, slug, content, content_html, toc, status, publish_at, created_at, updated_at
         FROM posts 
-        WHERE author_id = $1
+        WHERE author_id = $1 AND status = 'published'
         ORDER BY created_at DESC 
         LIMIT $2 OFFSET $3`
 
@@ -240,7 +417,54 @@ func (r *PostgresPostRepository) ListPostsByUser(ctx context.Context, userID, li
 			&post.ID,
 			&post.AuthorID,
 			&post.Title,
+			&post.Slug,
+			&post.Content,
+			&post.ContentHTML,
+			tocColumn{&post.TOC},
+			&post.Status,
+			&post.PublishAt,
+			&post.CreatedAt,
+			&post.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan post: %w", err)
+		}
+		posts = append(posts, post)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan post: %w", err)
+	}
+	if err := r.attachTags(ctx, posts...); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// Все посты пользователя, включая черновики (для выгрузки данных)
+func (r *PostgresPostRepository) ListAllPostsByUser(ctx context.Context, userID int) ([]*model.Post, error) {
+	query := `
+        SELECT id, author_id, title, slug, content, content_html, toc, status, publish_at, created_at, updated_at
+        FROM posts 
+        WHERE author_id = $1
+        ORDER BY created_at ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user posts: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []*model.Post
+	for rows.Next() {
+		post := &model.Post{}
+		if err := rows.Scan(
+			&post.ID,
+			&post.AuthorID,
+			&post.Title,
+			&post.Slug,
 			&post.Content,
+			&post.ContentHTML,
+			tocColumn{&post.TOC},
 			&post.Status,
 			&post.PublishAt,
 			&post.CreatedAt,
@@ -251,13 +475,90 @@ func (r *PostgresPostRepository) ListPostsByUser(ctx context.Context, userID, li
 		posts = append(posts, post)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan post: %w", err)
+	}
+	if err := r.attachTags(ctx, posts...); err != nil {
+		return nil, err
+	}
 	return posts, nil
 }
 
+// Лента подписок: keyset пагинация по (created_at, id) вместо OFFSET —
+// глубокие страницы не дорожают, новые посты не сдвигают выдачу
+func (r *PostgresPostRepository) ListFeed(ctx context.Context, followerID int, cursor *model.FeedCursor, limit int) ([]*model.Post, error) {
+	query := `
+        SELECT p.id, p.author_id, p.title, p.slug, p.content, p.content_html, p.toc, p.status, p.publish_at, p.created_at, p.updated_at
+        FROM posts p
+        JOIN follows f ON f.followee_id = p.author_id
+        WHERE f.follower_id = $1 AND p.status = 'published'
+          AND NOT EXISTS (SELECT 1 FROM user_mutes m WHERE m.muter_id = $1 AND m.muted_id = p.author_id)
+        ORDER BY p.created_at DESC, p.id DESC
+        LIMIT $2`
+	args := []interface{}{followerID, limit}
+	if cursor != nil {
+		query = `
+        SELECT p.id, p.author_id, p.title, p.slug, p.content, p.content_html, p.toc, p.status, p.publish_at, p.created_at, p.updated_at
+        FROM posts p
+        JOIN follows f ON f.followee_id = p.author_id
+        WHERE f.follower_id = $1 AND p.status = 'published'
+          AND NOT EXISTS (SELECT 1 FROM user_mutes m WHERE m.muter_id = $1 AND m.muted_id = p.author_id)
+          AND (p.created_at, p.id) < ($3, $4)
+        ORDER BY p.created_at DESC, p.id DESC
+        LIMIT $2`
+		args = append(args, cursor.CreatedAt, cursor.ID)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feed: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []*model.Post
+	for rows.Next() {
+		post := &model.Post{}
+		if err := rows.Scan(
+			&post.ID,
+			&post.AuthorID,
+			&post.Title,
+			&post.Slug,
+			&post.Content,
+			&post.ContentHTML,
+			tocColumn{&post.TOC},
+			&post.Status,
+			&post.PublishAt,
+			&post.CreatedAt,
+			&post.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan post: %w", err)
+		}
+		posts = append(posts, post)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan post: %w", err)
+	}
+	if err := r.attachTags(ctx, posts...); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// Количество опубликованных постов пользователя
+func (r *PostgresPostRepository) CountPostsByUser(ctx context.Context, userID int) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM posts WHERE author_id = $1 AND status = 'published'`
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count user posts: %w", err)
+	}
+	return count, nil
+}
+
 // Посты готовые к публикации (publish_at <= NOW())
 func (r *PostgresPostRepository) GetReadyToPublish(ctx context.Context, batchSize int) ([]*model.Post, error) {
 	query := `
-        SELECT id, author_id, title, content, status, publish_at, created_at, updated_at 
+        SELECT id, author_id, title, slug, content, content_html, toc, status, publish_at, created_at, updated_at 
         FROM posts 
         WHERE status = 'draft' AND publish_at <= NOW()
         ORDER BY publish_at ASC
@@ -277,7 +578,10 @@ func (r *PostgresPostRepository) GetReadyToPublish(ctx context.Context, batchSiz
 			&post.ID,
 			&post.AuthorID,
 			&post.Title,
+			&post.Slug,
 			&post.Content,
+			&post.ContentHTML,
+			tocColumn{&post.TOC},
 			&post.Status,
 			&publishAtNull,
 			&post.CreatedAt,
@@ -312,3 +616,425 @@ func (r *PostgresPostRepository) PublishPost(ctx context.Context, postID int) er
 
 	return nil
 }
+
+// Посты с текстом, но без HTML (сохранены до появления content_html)
+func (r *PostgresPostRepository) ListUnrenderedPosts(ctx context.Context, afterID, limit int) ([]*model.Post, error) {
+	query := `
+        SELECT id, content
+        FROM posts
+        WHERE content_html = '' AND content <> '' AND id > $1
+        ORDER BY id
+        LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unrendered posts: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []*model.Post
+	for rows.Next() {
+		post := &model.Post{}
+		if err := rows.Scan(&post.ID, &post.Content); err != nil {
+			return nil, fmt.Errorf("failed to scan post: %w", err)
+		}
+		posts = append(posts, post)
+	}
+	return posts, rows.Err()
+}
+
+// Записываем HTML и оглавление (текст не изменился — ни updated_at, ни новой версии)
+func (r *PostgresPostRepository) SetRenderedContent(ctx context.Context, post *model.Post) error {
+	query := `UPDATE posts SET content_html = $1, toc = $2 WHERE id = $3 AND content = $4`
+	if _, err := r.db.ExecContext(ctx, query, post.ContentHTML, tocJSON(post.TOC), post.ID, post.Content); err != nil {
+		return fmt.Errorf("failed to save rendered content: %w", err)
+	}
+	return nil
+}
+
+// Маркеры ts_headline: фрагмент экранируется целиком, после чего маркеры становятся <mark>
+// (в тексте поста может быть HTML, а подсветку клиенты вставляют как разметку)
+const (
+	headlineStart = "\x02"
+	headlineStop  = "\x03"
+)
+
+// Запрос ищет одновременно в русской и английской конфигурации: русские слова приводятся
+// к основе русским стеммером, английские — английским. Заголовок весит больше текста (вес A)
+const searchQuery = `websearch_to_tsquery('russian', $1) || websearch_to_tsquery('english', $1)`
+
+// Ищем опубликованные посты: релевантные первыми, с подсветкой в заголовке и фрагментах текста
+func (r *PostgresPostRepository) SearchPosts(ctx context.Context, query string, limit, offset int) ([]*model.PostSearchResult, error) {
+	sqlQuery := `
+        SELECT p.id, p.author_id, p.title, p.slug, p.content, p.content_html, p.toc, p.status, p.publish_at, p.created_at, p.updated_at,
+               ts_rank(p.search_vector, q.query) AS rank,
+               ts_headline('russian', p.title, q.query, $4),
+               ts_headline('russian', p.content, q.query, $5)
+        FROM posts p, (SELECT ` + searchQuery + ` AS query) q
+        WHERE p.status = 'published' AND p.search_vector @@ q.query
+        ORDER BY rank DESC, p.created_at DESC
+        LIMIT $2 OFFSET $3`
+
+	markers := fmt.Sprintf(`StartSel="%s", StopSel="%s"`, headlineStart, headlineStop)
+	titleOptions := markers + ", HighlightAll=true"
+	snippetOptions := markers + `, MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=" … "`
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, query, limit, offset, titleOptions, snippetOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search posts: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*model.PostSearchResult
+	var posts []*model.Post
+	for rows.Next() {
+		result := &model.PostSearchResult{}
+		if err := rows.Scan(
+			&result.ID,
+			&result.AuthorID,
+			&result.Title,
+			&result.Slug,
+			&result.Content,
+			&result.ContentHTML,
+			tocColumn{&result.TOC},
+			&result.Status,
+			&result.PublishAt,
+			&result.CreatedAt,
+			&result.UpdatedAt,
+			&result.Rank,
+			&result.TitleHighlight,
+			&result.Snippet,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan post: %w", err)
+		}
+		result.TitleHighlight = markHeadline(result.TitleHighlight)
+		result.Snippet = markHeadline(result.Snippet)
+		results = append(results, result)
+		posts = append(posts, &result.Post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan post: %w", err)
+	}
+	if err := r.attachTags(ctx, posts...); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Количество найденных опубликованных постов (для пагинации)
+func (r *PostgresPostRepository) CountSearchPosts(ctx context.Context, query string) (int, error) {
+	sqlQuery := `
+        SELECT COUNT(*)
+        FROM posts p, (SELECT ` + searchQuery + ` AS query) q
+        WHERE p.status = 'published' AND p.search_vector @@ q.query`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, sqlQuery, query).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count search results: %w", err)
+	}
+	return count, nil
+}
+
+// markHeadline экранирует фрагмент ts_headline и заменяет маркеры на <mark>
+func markHeadline(headline string) string {
+	return strings.NewReplacer(headlineStart, "<mark>", headlineStop, "</mark>").Replace(html.EscapeString(headline))
+}
+
+// Опубликованные посты с тегами: хотя бы с одним из tags или, при matchAll, со всеми.
+// Теги уже нормализованы и без повторов
+func (r *PostgresPostRepository) ListPostsByTags(ctx context.Context, tags []string, matchAll bool, limit, offset int) ([]*model.Post, error) {
+	query := `
+        SELECT id, author_id, title, slug, content, content_html, toc, status, publish_at, created_at, updated_at
+        FROM posts 
+        WHERE status = 'published' AND id IN (
+            SELECT pt.post_id FROM post_tags pt JOIN tags t ON t.id = pt.tag_id
+            WHERE t.name = ANY($1)
+            GROUP BY pt.post_id
+            HAVING COUNT(*) >= $2)
+        ORDER BY created_at DESC 
+        LIMIT $3 OFFSET $4`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(tags), requiredTagMatches(tags, matchAll), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list posts by tags: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []*model.Post
+	for rows.Next() {
+		post := &model.Post{}
+		if err := rows.Scan(
+			&post.ID,
+			&post.AuthorID,
+			&post.Title,
+			&post.Slug,
+			&post.Content,
+			&post.ContentHTML,
+			tocColumn{&post.TOC},
+			&post.Status,
+			&post.PublishAt,
+			&post.CreatedAt,
+			&post.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan post: %w", err)
+		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan post: %w", err)
+	}
+	if err := r.attachTags(ctx, posts...); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// Количество опубликованных постов с тегами (для пагинации)
+func (r *PostgresPostRepository) CountPostsByTags(ctx context.Context, tags []string, matchAll bool) (int, error) {
+	query := `
+        SELECT COUNT(*) FROM posts 
+        WHERE status = 'published' AND id IN (
+            SELECT pt.post_id FROM post_tags pt JOIN tags t ON t.id = pt.tag_id
+            WHERE t.name = ANY($1)
+            GROUP BY pt.post_id
+            HAVING COUNT(*) >= $2)`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, pq.Array(tags), requiredTagMatches(tags, matchAll)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count posts by tags: %w", err)
+	}
+	return count, nil
+}
+
+// Теги опубликованных постов с количеством постов
+func (r *PostgresPostRepository) ListTags(ctx context.Context) ([]model.Tag, error) {
+	query := `
+        SELECT t.name, COUNT(*)
+        FROM tags t
+        JOIN post_tags pt ON pt.tag_id = t.id
+        JOIN posts p ON p.id = pt.post_id AND p.status = 'published'
+        GROUP BY t.id, t.name
+        ORDER BY COUNT(*) DESC, t.name`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []model.Tag{}
+	for rows.Next() {
+		var tag model.Tag
+		if err := rows.Scan(&tag.Name, &tag.PostCount); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// Получаем тег по имени с количеством опубликованных постов (nil — не найден)
+func (r *PostgresPostRepository) GetTag(ctx context.Context, name string) (*model.Tag, error) {
+	query := `
+        SELECT t.name, COUNT(p.id)
+        FROM tags t
+        LEFT JOIN post_tags pt ON pt.tag_id = t.id
+        LEFT JOIN posts p ON p.id = pt.post_id AND p.status = 'published'
+        WHERE t.name = $1
+        GROUP BY t.id, t.name`
+
+	tag := &model.Tag{}
+	err := r.db.QueryRowContext(ctx, query, name).Scan(&tag.Name, &tag.PostCount)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+	return tag, nil
+}
+
+// Переименовываем тег (имя newName должно быть свободно)
+func (r *PostgresPostRepository) RenameTag(ctx context.Context, name, newName string) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE tags SET name = $2 WHERE name = $1", name, newName)
+	if err != nil {
+		return fmt.Errorf("failed to rename tag: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("tag not found")
+	}
+	return nil
+}
+
+// Объединяем теги: посты source получают тег target, source удаляется (связи — каскадно)
+func (r *PostgresPostRepository) MergeTags(ctx context.Context, source, target string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Посты с обоими тегами уже связаны с target — их пропускаем
+	query := `
+        INSERT INTO post_tags (post_id, tag_id)
+        SELECT pt.post_id, (SELECT id FROM tags WHERE name = $2)
+        FROM post_tags pt JOIN tags t ON t.id = pt.tag_id
+        WHERE t.name = $1
+        ON CONFLICT DO NOTHING`
+	if _, err := tx.ExecContext(ctx, query, source, target); err != nil {
+		return fmt.Errorf("failed to merge tags: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM tags WHERE name = $1", source); err != nil {
+		return fmt.Errorf("failed to merge tags: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to merge tags: %w", err)
+	}
+	return nil
+}
+
+// Сохраненные версии поста без текста, новые первыми
+func (r *PostgresPostRepository) ListRevisions(ctx context.Context, postID int) ([]*model.PostRevision, error) {
+	query := `
+        SELECT revision, title, created_at
+        FROM post_revisions
+        WHERE post_id = $1
+        ORDER BY revision DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []*model.PostRevision{}
+	for rows.Next() {
+		revision := &model.PostRevision{}
+		if err := rows.Scan(&revision.Revision, &revision.Title, &revision.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan revision: %w", err)
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+// Получаем версию поста с текстом (nil — не найдена)
+func (r *PostgresPostRepository) GetRevision(ctx context.Context, postID, revision int) (*model.PostRevision, error) {
+	query := `
+        SELECT revision, title, content, created_at
+        FROM post_revisions
+        WHERE post_id = $1 AND revision = $2`
+
+	rev := &model.PostRevision{}
+	err := r.db.QueryRowContext(ctx, query, postID, revision).Scan(&rev.Revision, &rev.Title, &rev.Content, &rev.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revision: %w", err)
+	}
+	return rev, nil
+}
+
+// setPostTags заменяет теги поста, недостающие теги создаются
+func setPostTags(ctx context.Context, tx *sql.Tx, postID int, tags []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM post_tags WHERE post_id = $1", postID); err != nil {
+		return fmt.Errorf("failed to save post tags: %w", err)
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	query := `INSERT INTO tags (name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING`
+	if _, err := tx.ExecContext(ctx, query, pq.Array(tags)); err != nil {
+		return fmt.Errorf("failed to save post tags: %w", err)
+	}
+	query = `INSERT INTO post_tags (post_id, tag_id) SELECT $1, id FROM tags WHERE name = ANY($2)`
+	if _, err := tx.ExecContext(ctx, query, postID, pq.Array(tags)); err != nil {
+		return fmt.Errorf("failed to save post tags: %w", err)
+	}
+	return nil
+}
+
+// attachTags загружает теги постов одним запросом (по алфавиту, у поста без тегов — пустой список)
+func (r *PostgresPostRepository) attachTags(ctx context.Context, posts ...*model.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(posts))
+	byID := make(map[int]*model.Post, len(posts))
+	for _, post := range posts {
+		post.Tags = []string{}
+		ids = append(ids, int64(post.ID))
+		byID[post.ID] = post
+	}
+
+	query := `
+        SELECT pt.post_id, t.name
+        FROM post_tags pt JOIN tags t ON t.id = pt.tag_id
+        WHERE pt.post_id = ANY($1)
+        ORDER BY t.name`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to load post tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID int
+		var name string
+		if err := rows.Scan(&postID, &name); err != nil {
+			return fmt.Errorf("failed to scan post tag: %w", err)
+		}
+		if post := byID[postID]; post != nil {
+			post.Tags = append(post.Tags, name)
+		}
+	}
+	return rows.Err()
+}
+
+// requiredTagMatches - сколько тегов из фильтра должно быть у поста
+func requiredTagMatches(tags []string, matchAll bool) int {
+	if matchAll {
+		return len(tags)
+	}
+	return 1
+}
+
+// tocColumn читает оглавление поста из JSONB
+type tocColumn struct {
+	toc *[]model.TOCEntry
+}
+
+// Scan реализует sql.Scanner
+func (c tocColumn) Scan(src interface{}) error {
+	var data []byte
+	switch value := src.(type) {
+	case []byte:
+		data = value
+	case string:
+		data = []byte(value)
+	case nil:
+		*c.toc = []model.TOCEntry{}
+		return nil
+	default:
+		return fmt.Errorf("unexpected toc type %T", src)
+	}
+	return json.Unmarshal(data, c.toc)
+}
+
+// tocJSON - оглавление для колонки JSONB (пост без заголовков — пустой список)
+func tocJSON(toc []model.TOCEntry) string {
+	if toc == nil {
+		return "[]"
+	}
+	data, _ := json.Marshal(toc)
+	return string(data)
+}
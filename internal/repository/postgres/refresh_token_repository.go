@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"blog-backend/internal/model"
+)
+
+// Реализация RefreshTokenRepository для PostgreSQL
+type PostgresRefreshTokenRepository struct {
+	db *sql.DB
+}
+
+// Создаем новый репозиторий refresh токенов
+func NewPostgresRefreshTokenRepository(db *sql.DB) *PostgresRefreshTokenRepository {
+	return &PostgresRefreshTokenRepository{db: db}
+}
+
+// Create сохраняет хеш нового refresh токена
+func (r *PostgresRefreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	query := `
+        INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		token.UserID,
+		token.TokenHash,
+		token.FamilyID,
+		token.ExpiresAt,
+	).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByHash находит refresh токен по хешу (nil, если не найден)
+func (r *PostgresRefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	query := `
+        SELECT id, user_id, token_hash, family_id, expires_at, revoked_at, created_at
+        FROM refresh_tokens
+        WHERE token_hash = $1`
+
+	token := &model.RefreshToken{}
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.FamilyID,
+		&token.ExpiresAt,
+		&token.RevokedAt,
+		&token.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	return token, nil
+}
+
+// Revoke атомарно отзывает активный токен.
+// Если токен уже отозван (например, параллельный запрос) — возвращает false
+func (r *PostgresRefreshTokenRepository) Revoke(ctx context.Context, id int) (bool, error) {
+	query := `
+        UPDATE refresh_tokens
+        SET revoked_at = NOW()
+        WHERE id = $1 AND revoked_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke refresh token %d: %w", id, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check affected rows: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// RevokeFamily отзывает всю цепочку токенов (при обнаружении повторного использования)
+func (r *PostgresRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	query := `
+        UPDATE refresh_tokens
+        SET revoked_at = NOW()
+        WHERE family_id = $1 AND revoked_at IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, familyID); err != nil {
+		return fmt.Errorf("failed to revoke token family %s: %w", familyID, err)
+	}
+
+	return nil
+}
+
+// RevokeAllForUser отзывает все активные refresh токены пользователя
+func (r *PostgresRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID int) error {
+	query := `
+        UPDATE refresh_tokens
+        SET revoked_at = NOW()
+        WHERE user_id = $1 AND revoked_at IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens of user %d: %w", userID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"blog-backend/internal/model"
+)
+
+// Реализация RevokedTokenRepository для PostgreSQL
+type PostgresRevokedTokenRepository struct {
+	db *sql.DB
+}
+
+// Создаем новый репозиторий отозванных токенов
+func NewPostgresRevokedTokenRepository(db *sql.DB) *PostgresRevokedTokenRepository {
+	return &PostgresRevokedTokenRepository{db: db}
+}
+
+// RevokeToken сохраняет jti отозванного токена (повторный отзыв игнорируется)
+func (r *PostgresRevokedTokenRepository) RevokeToken(ctx context.Context, token *model.RevokedToken) error {
+	query := `
+        INSERT INTO revoked_tokens (jti, user_id, expires_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (jti) DO NOTHING`
+
+	if _, err := r.db.ExecContext(ctx, query, token.JTI, token.UserID, token.ExpiresAt); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAllForUser запоминает момент, до которого все токены пользователя недействительны
+func (r *PostgresRevokedTokenRepository) RevokeAllForUser(ctx context.Context, userID int, before time.Time) error {
+	query := `
+        INSERT INTO user_token_revocations (user_id, revoked_before)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id) DO UPDATE SET revoked_before = EXCLUDED.revoked_before`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, before); err != nil {
+		return fmt.Errorf("failed to revoke tokens of user %d: %w", userID, err)
+	}
+
+	return nil
+}
+
+// ListRevokedTokens возвращает все еще не истекшие отозванные токены
+func (r *PostgresRevokedTokenRepository) ListRevokedTokens(ctx context.Context) ([]*model.RevokedToken, error) {
+	query := `
+        SELECT jti, user_id, expires_at
+        FROM revoked_tokens
+        WHERE expires_at > NOW()`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revoked tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*model.RevokedToken
+	for rows.Next() {
+		token := &model.RevokedToken{}
+		if err := rows.Scan(&token.JTI, &token.UserID, &token.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan revoked token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// ListUserCutoffs возвращает моменты отзыва всех токенов пользователей, сделанные после since
+func (r *PostgresRevokedTokenRepository) ListUserCutoffs(ctx context.Context, since time.Time) (map[int]time.Time, error) {
+	query := `
+        SELECT user_id, revoked_before
+        FROM user_token_revocations
+        WHERE revoked_before > $1`
+
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user revocations: %w", err)
+	}
+	defer rows.Close()
+
+	cutoffs := make(map[int]time.Time)
+	for rows.Next() {
+		var userID int
+		var before time.Time
+		if err := rows.Scan(&userID, &before); err != nil {
+			return nil, fmt.Errorf("failed to scan user revocation: %w", err)
+		}
+		cutoffs[userID] = before
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return cutoffs, nil
+}
+
+// DeleteExpired удаляет записи об отозванных токенах, срок которых уже истек
+func (r *PostgresRevokedTokenRepository) DeleteExpired(ctx context.Context) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at <= NOW()"); err != nil {
+		return fmt.Errorf("failed to delete expired revoked tokens: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"blog-backend/internal/model"
+)
+
+// Реализация SessionRepository для PostgreSQL
+type PostgresSessionRepository struct {
+	db *sql.DB
+}
+
+// Создаем новый репозиторий сессий
+func NewPostgresSessionRepository(db *sql.DB) *PostgresSessionRepository {
+	return &PostgresSessionRepository{db: db}
+}
+
+// scanSession читает строку sessions
+func scanSession(row rowScanner) (*model.Session, error) {
+	session := &model.Session{}
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.FamilyID,
+		&session.IP,
+		&session.UserAgent,
+		&session.CreatedAt,
+		&session.LastSeenAt,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Create сохраняет новую сессию
+func (r *PostgresSessionRepository) Create(ctx context.Context, session *model.Session) error {
+	query := `
+        INSERT INTO sessions (user_id, family_id, ip, user_agent, expires_at)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at, last_seen_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		session.UserID,
+		session.FamilyID,
+		session.IP,
+		session.UserAgent,
+		session.ExpiresAt,
+	).Scan(&session.ID, &session.CreatedAt, &session.LastSeenAt)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID находит сессию по ID (nil, если не найдена)
+func (r *PostgresSessionRepository) GetByID(ctx context.Context, id int) (*model.Session, error) {
+	query := `
+        SELECT id, user_id, family_id, ip, user_agent, created_at, last_seen_at, expires_at, revoked_at
+        FROM sessions
+        WHERE id = $1`
+
+	session, err := scanSession(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session %d: %w", id, err)
+	}
+
+	return session, nil
+}
+
+// GetByFamily находит сессию цепочки refresh токенов (nil, если не найдена)
+func (r *PostgresSessionRepository) GetByFamily(ctx context.Context, familyID string) (*model.Session, error) {
+	query := `
+        SELECT id, user_id, family_id, ip, user_agent, created_at, last_seen_at, expires_at, revoked_at
+        FROM sessions
+        WHERE family_id = $1`
+
+	session, err := scanSession(r.db.QueryRowContext(ctx, query, familyID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session of family %s: %w", familyID, err)
+	}
+
+	return session, nil
+}
+
+// ListActive возвращает активные сессии пользователя, последние использованные первыми
+func (r *PostgresSessionRepository) ListActive(ctx context.Context, userID int) ([]*model.Session, error) {
+	query := `
+        SELECT id, user_id, family_id, ip, user_agent, created_at, last_seen_at, expires_at, revoked_at
+        FROM sessions
+        WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+        ORDER BY last_seen_at DESC, id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*model.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// ListForUser возвращает последние сессии пользователя, включая завершенные, новые первыми
+func (r *PostgresSessionRepository) ListForUser(ctx context.Context, userID, limit int) ([]*model.Session, error) {
+	query := `
+        SELECT id, user_id, family_id, ip, user_agent, created_at, last_seen_at, expires_at, revoked_at
+        FROM sessions
+        WHERE user_id = $1
+        ORDER BY created_at DESC, id DESC
+        LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*model.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// TouchLastSeen обновляет время последней активности
+func (r *PostgresSessionRepository) TouchLastSeen(ctx context.Context, id int, seenAt time.Time) error {
+	query := `UPDATE sessions SET last_seen_at = $2 WHERE id = $1 AND revoked_at IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, id, seenAt); err != nil {
+		return fmt.Errorf("failed to touch session %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// Extend продлевает сессию при ротации refresh токена
+func (r *PostgresSessionRepository) Extend(ctx context.Context, id int, seenAt, expiresAt time.Time) error {
+	query := `
+        UPDATE sessions
+        SET last_seen_at = $2, expires_at = $3
+        WHERE id = $1 AND revoked_at IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, id, seenAt, expiresAt); err != nil {
+		return fmt.Errorf("failed to extend session %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// Revoke атомарно завершает активную сессию, false — сессия уже завершена
+func (r *PostgresSessionRepository) Revoke(ctx context.Context, id int) (bool, error) {
+	query := `
+        UPDATE sessions
+        SET revoked_at = NOW()
+        WHERE id = $1 AND revoked_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke session %d: %w", id, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check affected rows: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// RevokeAllForUser завершает все активные сессии пользователя
+func (r *PostgresSessionRepository) RevokeAllForUser(ctx context.Context, userID int) error {
+	query := `
+        UPDATE sessions
+        SET revoked_at = NOW()
+        WHERE user_id = $1 AND revoked_at IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to revoke sessions of user %d: %w", userID, err)
+	}
+
+	return nil
+}
@@ -2,8 +2,10 @@ package postgres
 
 import (
 	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 )
 
@@ -42,13 +44,13 @@ func (r *PostgresUserRepository) CreateUser(ctx context.Context, email, username
 	query := `
         INSERT INTO users (email, username, password_hash) 
         VALUES ($1, $2, $3) 
-        RETURNING id, created_at
+        RETURNING id, role, created_at, email_verified_at
     `
 	// Инициализируем структуру User
 	user := &model.User{}
 	// 2. Выполняем запрос с db.QueryRow(query, email, username, passwordHash)
 	// 3. Считываем результат в переменные user.ID и user.CreatedAt
-	err := r.db.QueryRowContext(ctx, query, email, username, passwordHash).Scan(&user.ID, &user.CreatedAt)
+	err := r.db.QueryRowContext(ctx, query, email, username, passwordHash).Scan(&user.ID, &user.Role, &user.CreatedAt, &user.EmailVerifiedAt)
 	// 5. Обрабатываем ошибки
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
@@ -61,6 +63,48 @@ func (r *PostgresUserRepository) CreateUser(ctx context.Context, email, username
 	return user, nil
 }
 
+// CreateInvitedUser создает пользователя и расходует приглашение в одной транзакции.
+// UPDATE берет блокировку строки: из двух параллельных регистраций с одним кодом
+// вторая после фиксации первой не найдет строку с used_at IS NULL и откатится
+func (r *PostgresUserRepository) CreateInvitedUser(ctx context.Context, email, username, passwordHash string, invitationID int) (*model.User, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+        INSERT INTO users (email, username, password_hash)
+        VALUES ($1, $2, $3)
+        RETURNING id, role, created_at, email_verified_at
+    `
+	user := &model.User{Email: email, Username: username}
+	err = tx.QueryRowContext(ctx, query, email, username, passwordHash).Scan(&user.ID, &user.Role, &user.CreatedAt, &user.EmailVerifiedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	query = `
+        UPDATE invitations
+        SET used_at = CURRENT_TIMESTAMP, used_by = $1
+        WHERE id = $2 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+        RETURNING id
+    `
+	var consumedID int
+	err = tx.QueryRowContext(ctx, query, user.ID, invitationID).Scan(&consumedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrInvitationUsed
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume invitation %d: %w", invitationID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return user, nil
+}
+
 // GetUserByEmail находит пользователя по email
 func (r *PostgresUserRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
 	// TODO: Реализуйте поиск пользователя по email
@@ -77,9 +121,11 @@ func (r *PostgresUserRepository) GetUserByEmail(ctx context.Context, email strin
 
 	// 1. Создаем SQL запрос с плейсхолдером $1
 	query := `
-        SELECT id, email, username, password_hash, created_at 
+        SELECT id, email, username, role, password_hash, created_at, email_verified_at,
+               COALESCE(totp_secret, ''), totp_enabled_at, bio, deleted_at,
+               avatar_url, avatar_small_url
         FROM users 
-        WHERE email = $1
+        WHERE LOWER(email) = LOWER($1)
     `
 	// Инициализируем структуру User
 	user := &model.User{}
@@ -90,8 +136,16 @@ func (r *PostgresUserRepository) GetUserByEmail(ctx context.Context, email strin
 		&user.ID,
 		&user.Email,
 		&user.Username,
+		&user.Role,
 		&user.PasswordHash,
 		&user.CreatedAt,
+		&user.EmailVerifiedAt,
+		&user.TOTPSecret,
+		&user.TOTPEnabledAt,
+		&user.Bio,
+		&user.DeletedAt,
+		&user.AvatarURL,
+		&user.AvatarSmallURL,
 	)
 
 	if err != nil {
@@ -119,19 +173,16 @@ func (r *PostgresUserRepository) GetUserByID(ctx context.Context, userID int) (*
 
 	// 1. Создаем SQL запрос для поиска по ID
 	query := `
-        SELECT id, email, username, created_at 
+        SELECT id, email, username, role, created_at, email_verified_at,
+               COALESCE(totp_secret, ''), totp_enabled_at, bio, deleted_at,
+               avatar_url, avatar_small_url
         FROM users 
         WHERE id = $1
     `
 
 	user := &model.User{}
 	// 3. Выполняем запрос
-	err := r.db.QueryRowContext(ctx, query, userID).Scan(
-		&user.ID,
-		&user.Email,
-		&user.Username,
-		&user.CreatedAt,
-	)
+	err := scanProfile(r.db.QueryRowContext(ctx, query, userID), user)
 
 	// Проверить !!!
 	// if errors.Is(err, sql.ErrNoRows) {
@@ -149,6 +200,46 @@ func (r *PostgresUserRepository) GetUserByID(ctx context.Context, userID int) (*
 	return user, nil
 }
 
+// GetUserByUsername находит пользователя по имени без учета регистра (без password_hash)
+func (r *PostgresUserRepository) GetUserByUsername(ctx context.Context, username string) (*model.User, error) {
+	query := `
+        SELECT id, email, username, role, created_at, email_verified_at,
+               COALESCE(totp_secret, ''), totp_enabled_at, bio, deleted_at,
+               avatar_url, avatar_small_url
+        FROM users
+        WHERE LOWER(username) = LOWER($1)
+    `
+
+	user := &model.User{}
+	err := scanProfile(r.db.QueryRowContext(ctx, query, username), user)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by username: %w", err)
+	}
+
+	return user, nil
+}
+
+// scanProfile считывает пользователя без password_hash
+func scanProfile(row *sql.Row, user *model.User) error {
+	return row.Scan(
+		&user.ID,
+		&user.Email,
+		&user.Username,
+		&user.Role,
+		&user.CreatedAt,
+		&user.EmailVerifiedAt,
+		&user.TOTPSecret,
+		&user.TOTPEnabledAt,
+		&user.Bio,
+		&user.DeletedAt,
+		&user.AvatarURL,
+		&user.AvatarSmallURL,
+	)
+}
+
 // UserExistsByEmail проверяет, существует ли пользователь с данным email
 func (r *PostgresUserRepository) UserExistsByEmail(ctx context.Context, email string) (bool, error) {
 	// TODO: Реализуйте проверку существования пользователя
@@ -163,7 +254,7 @@ func (r *PostgresUserRepository) UserExistsByEmail(ctx context.Context, email st
 	// Это эффективнее чем получать полную запись пользователя
 
 	query := `
-        SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)
+        SELECT EXISTS(SELECT 1 FROM users WHERE LOWER(email) = LOWER($1))
     `
 
 	var exists bool
@@ -175,3 +266,203 @@ func (r *PostgresUserRepository) UserExistsByEmail(ctx context.Context, email st
 
 	return exists, nil
 }
+
+// UpdateUserRole меняет роль пользователя
+func (r *PostgresUserRepository) UpdateUserRole(ctx context.Context, id int, role string) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE users SET role = $1 WHERE id = $2", role, id)
+	if err != nil {
+		return fmt.Errorf("failed to update role of user %d: %w", id, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// UpdatePassword сохраняет новый хеш пароля
+func (r *PostgresUserRepository) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE users SET password_hash = $1 WHERE id = $2", passwordHash, id)
+	if err != nil {
+		return fmt.Errorf("failed to update password of user %d: %w", id, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// MarkEmailVerified подтверждает email пользователя (повторное подтверждение не меняет дату)
+func (r *PostgresUserRepository) MarkEmailVerified(ctx context.Context, id int, email string) (bool, error) {
+	query := `
+        UPDATE users
+        SET email_verified_at = COALESCE(email_verified_at, CURRENT_TIMESTAMP)
+        WHERE id = $1 AND email = $2
+    `
+	result, err := r.db.ExecContext(ctx, query, id, email)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify email of user %d: %w", id, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check affected rows: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// SetTOTPSecret сохраняет секрет нового подключения 2FA (до подтверждения 2FA выключена)
+func (r *PostgresUserRepository) SetTOTPSecret(ctx context.Context, id int, secret string) error {
+	query := `UPDATE users SET totp_secret = $1, totp_enabled_at = NULL, totp_last_step = NULL WHERE id = $2`
+	return r.execUserUpdate(ctx, query, secret, id)
+}
+
+// ReplaceTOTPSecret перезаписывает секрет, если он не изменился (2FA остается в том же состоянии)
+func (r *PostgresUserRepository) ReplaceTOTPSecret(ctx context.Context, id int, oldSecret, newSecret string) error {
+	query := `UPDATE users SET totp_secret = $1 WHERE id = $2 AND totp_secret = $3`
+	if _, err := r.db.ExecContext(ctx, query, newSecret, id, oldSecret); err != nil {
+		return fmt.Errorf("failed to replace totp secret: %w", err)
+	}
+	return nil
+}
+
+// EnableTOTP включает 2FA после подтверждения кодом
+func (r *PostgresUserRepository) EnableTOTP(ctx context.Context, id int) error {
+	query := `UPDATE users SET totp_enabled_at = CURRENT_TIMESTAMP WHERE id = $1 AND totp_secret IS NOT NULL`
+	return r.execUserUpdate(ctx, query, id)
+}
+
+// DisableTOTP выключает 2FA и удаляет секрет
+func (r *PostgresUserRepository) DisableTOTP(ctx context.Context, id int) error {
+	query := `UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL WHERE id = $1`
+	return r.execUserUpdate(ctx, query, id)
+}
+
+// UseTOTPStep запоминает шаг кода: повторно тот же (или более ранний) код не примется
+func (r *PostgresUserRepository) UseTOTPStep(ctx context.Context, id int, step int64) (bool, error) {
+	query := `
+        UPDATE users SET totp_last_step = $1
+        WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)
+    `
+	result, err := r.db.ExecContext(ctx, query, step, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to save TOTP step of user %d: %w", id, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check affected rows: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// execUserUpdate выполняет UPDATE пользователя и проверяет, что он найден
+func (r *PostgresUserRepository) execUserUpdate(ctx context.Context, query string, args ...any) error {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// UpdateProfile сохраняет изменяемые пользователем поля профиля
+func (r *PostgresUserRepository) UpdateProfile(ctx context.Context, user *model.User) error {
+	query := `
+        UPDATE users SET username = $1, email = $2, bio = $3, email_verified_at = $4
+        WHERE id = $5 AND deleted_at IS NULL
+    `
+	return r.execUserUpdate(ctx, query, user.Username, user.Email, user.Bio, user.EmailVerifiedAt, user.ID)
+}
+
+// UpdateAvatar сохраняет ссылки на аватар (nil — аватар удален)
+func (r *PostgresUserRepository) UpdateAvatar(ctx context.Context, id int, avatarURL, avatarSmallURL *string) error {
+	query := `UPDATE users SET avatar_url = $1, avatar_small_url = $2 WHERE id = $3 AND deleted_at IS NULL`
+	return r.execUserUpdate(ctx, query, avatarURL, avatarSmallURL, id)
+}
+
+// DeleteUser удаляет пользователя; посты, комментарии и токены удаляются по ON DELETE CASCADE
+func (r *PostgresUserRepository) DeleteUser(ctx context.Context, id int) error {
+	return r.execUserUpdate(ctx, "DELETE FROM users WHERE id = $1", id)
+}
+
+// AnonymizeUser обезличивает пользователя в одной транзакции
+func (r *PostgresUserRepository) AnonymizeUser(ctx context.Context, id int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Email в зоне .invalid никому не принадлежит, пароль "!" не совпадет ни с одним хешем
+	query := `
+        UPDATE users SET
+            email = 'deleted-' || id || '@deleted.invalid',
+            username = 'deleted-' || id,
+            password_hash = '!',
+            role = 'reader',
+            bio = '',
+            avatar_url = NULL,
+            avatar_small_url = NULL,
+            email_verified_at = NULL,
+            totp_secret = NULL,
+            totp_enabled_at = NULL,
+            totp_last_step = NULL,
+            deleted_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND deleted_at IS NULL
+    `
+	result, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to anonymize user %d: %w", id, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	// Токены, коды восстановления и выгрузки данных больше не нужны
+	// (файлы архивов удаляются очисткой EXPORT_DIR по сроку хранения)
+	for _, table := range []string{"refresh_tokens", "personal_access_tokens", "password_reset_tokens", "mfa_recovery_codes", "data_exports", "sessions"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE user_id = $1", id); err != nil {
+			return fmt.Errorf("failed to delete %s of user %d: %w", table, id, err)
+		}
+	}
+
+	// Обезличенный аккаунт не должен оставаться в чужих списках подписок, блокировок и скрытых
+	relations := []string{
+		"DELETE FROM follows WHERE follower_id = $1 OR followee_id = $1",
+		"DELETE FROM user_blocks WHERE blocker_id = $1 OR blocked_id = $1",
+		"DELETE FROM user_mutes WHERE muter_id = $1 OR muted_id = $1",
+	}
+	for _, query := range relations {
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return fmt.Errorf("failed to delete relations of user %d: %w", id, err)
+		}
+	}
+
+	return tx.Commit()
+}
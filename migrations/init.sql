@@ -1,6 +1,10 @@
 -- =====================================================
 -- Инициализация базы данных блога
--- Таблицы: users, posts, comments
+-- Таблицы: users, posts, comments, refresh_tokens, revoked_tokens,
+--          user_token_revocations, password_reset_tokens, personal_access_tokens,
+--          mfa_recovery_codes, login_throttles, login_attempts, data_exports,
+--          invitations, follows, user_blocks, user_mutes, sessions,
+--          post_slug_history, tags, post_tags, post_revisions, oidc_login_flows
 -- =====================================================
 
 -- 1. Создание таблицы пользователей
@@ -9,6 +13,15 @@ CREATE TABLE IF NOT EXISTS users (
     email VARCHAR(255) UNIQUE NOT NULL,
     username VARCHAR(30) UNIQUE NOT NULL,
     password_hash VARCHAR(255) NOT NULL,
+    role VARCHAR(20) NOT NULL DEFAULT 'author' CHECK (role IN ('reader', 'author', 'editor', 'admin')),
+    email_verified_at TIMESTAMP, -- NULL = email не подтвержден
+    totp_secret VARCHAR(255),    -- секрет TOTP (зашифрован AES-GCM)
+    totp_enabled_at TIMESTAMP,   -- NULL = 2FA выключена
+    totp_last_step BIGINT,       -- шаг последнего принятого кода (защита от повтора)
+    bio VARCHAR(500) NOT NULL DEFAULT '',
+    avatar_url VARCHAR(500),       -- аватар 256x256 (NULL = не загружен)
+    avatar_small_url VARCHAR(500), -- аватар 64x64
+    deleted_at TIMESTAMP,        -- аккаунт удален с анонимизацией
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
@@ -17,11 +30,21 @@ CREATE TABLE IF NOT EXISTS posts (
     id SERIAL PRIMARY KEY,
     author_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     title VARCHAR(255) NOT NULL,
+    slug VARCHAR(100) UNIQUE NOT NULL, -- адрес поста из заголовка (латиница, цифры, дефисы)
     content TEXT NOT NULL,
+    content_html TEXT NOT NULL DEFAULT '',  -- content в HTML (Markdown после очистки), считается при записи
+    toc JSONB NOT NULL DEFAULT '[]',        -- оглавление: [{"level", "text", "id"}]
     status VARCHAR(20) DEFAULT 'draft' CHECK (status IN ('draft', 'published')), -- статус поста
     publish_at TIMESTAMP,  -- Время публикации (NULL = опубликован сейчас)
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    -- Поисковый индекс: заголовок (вес A) и текст (вес B) в русской и английской конфигурации
+    search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('russian', title), 'A') ||
+        setweight(to_tsvector('english', title), 'A') ||
+        setweight(to_tsvector('russian', content), 'B') ||
+        setweight(to_tsvector('english', content), 'B')
+    ) STORED
 );
 
 -- 3. Таблица комментариев
@@ -33,32 +56,397 @@ CREATE TABLE IF NOT EXISTS comments (
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+-- 4. Таблица refresh токенов (хранятся только хеши)
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    family_id VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 5. Отозванные access токены (хранятся до истечения срока действия)
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 6. Выход со всех устройств: токены, выданные до revoked_before, недействительны
+-- Без внешнего ключа: отзыв должен пережить удаление пользователя (его токены еще не истекли)
+CREATE TABLE IF NOT EXISTS user_token_revocations (
+    user_id INTEGER PRIMARY KEY,
+    revoked_before TIMESTAMP NOT NULL
+);
+
+-- 7. Одноразовые токены сброса пароля (хранятся только хеши)
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 8. Personal access токены для скриптов и CI (хранятся только хеши)
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    scopes TEXT[] NOT NULL,
+    last_used_at TIMESTAMP,
+    expires_at TIMESTAMP,  -- NULL = бессрочный
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 9. Одноразовые коды восстановления 2FA (хранятся только хеши)
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 10. Счетчики неудачных входов (LOGIN_ATTEMPTS_STORE=postgres)
+CREATE TABLE IF NOT EXISTS login_throttles (
+    key VARCHAR(300) PRIMARY KEY,  -- 'email:<email>' или 'ip:<адрес>'
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP         -- NULL = вход разрешен
+);
+
+-- 11. Журнал неудачных входов (для администраторов)
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    ip VARCHAR(45) NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 12. Выгрузки персональных данных (ZIP архивы собираются в фоне)
+CREATE TABLE IF NOT EXISTS data_exports (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    file_path TEXT NOT NULL DEFAULT '',
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    CONSTRAINT data_exports_status_check CHECK (status IN ('pending', 'ready', 'failed'))
+);
+
+-- 13. Приглашения на регистрацию (REGISTRATION_MODE=invite-only)
+CREATE TABLE IF NOT EXISTS invitations (
+    id SERIAL PRIMARY KEY,
+    code_hash VARCHAR(64) UNIQUE NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    used_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 14. Подписки на авторов (лента GET /api/feed)
+CREATE TABLE IF NOT EXISTS follows (
+    follower_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (follower_id, followee_id),
+    CONSTRAINT follows_not_self CHECK (follower_id <> followee_id)
+);
+
+-- 15. Блокировки: blocked_id не может комментировать посты blocker_id и подписываться на него
+CREATE TABLE IF NOT EXISTS user_blocks (
+    blocker_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CONSTRAINT user_blocks_not_self CHECK (blocker_id <> blocked_id)
+);
+
+-- 16. Скрытые пользователи: посты и комментарии muted_id не показываются muter_id
+CREATE TABLE IF NOT EXISTS user_mutes (
+    muter_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    muted_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (muter_id, muted_id),
+    CONSTRAINT user_mutes_not_self CHECK (muter_id <> muted_id)
+);
+
+-- 17. Сессии: вход с устройства, к которому привязана цепочка refresh токенов
+CREATE TABLE IF NOT EXISTS sessions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id VARCHAR(64) UNIQUE NOT NULL,
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+);
+
+-- 18. Прежние слаги постов: старые ссылки после смены заголовка перенаправляются (301)
+CREATE TABLE IF NOT EXISTS post_slug_history (
+    slug VARCHAR(100) PRIMARY KEY,
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 19. Теги (хранятся нормализованными: нижний регистр, пробелы заменены дефисом)
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(32) UNIQUE NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 20. Теги постов (многие ко многим)
+CREATE TABLE IF NOT EXISTS post_tags (
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (post_id, tag_id)
+);
+
+-- 21. Прежние версии постов: сохраняются при каждом изменении заголовка или текста
+CREATE TABLE IF NOT EXISTS post_revisions (
+    id SERIAL PRIMARY KEY,
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (post_id, revision)
+);
+
+-- 22. Начатые входы через OIDC: callback принимается один раз и только от браузера с cookie входа
+CREATE TABLE IF NOT EXISTS oidc_login_flows (
+    flow_hash VARCHAR(64) PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Обновление существующей базы: CREATE TABLE IF NOT EXISTS не меняет уже созданные таблицы,
+-- поэтому столбцы, добавленные позже, добавляются отдельно (повторный запуск ничего не меняет).
+-- docker-entrypoint-initdb.d выполняет файл только на пустом томе — на существующей базе
+-- его запускают вручную (см. README, "Обновление существующей базы")
+
+-- Роли пользователей: существующие аккаунты получают роль author, как при регистрации
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'author'
+    CHECK (role IN ('reader', 'author', 'editor', 'admin'));
+
+-- Подтверждение email. Аккаунты, созданные до его появления, считаются подтвержденными,
+-- иначе REQUIRE_VERIFIED_EMAIL заблокирует их вход. Заполняется только при добавлении столбца:
+-- повторный запуск не подтверждает аккаунты, зарегистрированные позже
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT FROM information_schema.columns
+        WHERE table_name = 'users' AND column_name = 'email_verified_at'
+    ) THEN
+        ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+        UPDATE users SET email_verified_at = COALESCE(created_at, CURRENT_TIMESTAMP);
+    END IF;
+END $$;
+
+-- Профиль и удаление аккаунта
+ALTER TABLE users ADD COLUMN IF NOT EXISTS bio VARCHAR(500) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- Двухфакторная аутентификация. Секрет TOTP хранится зашифрованным и длиннее base32
+-- (столбец из первой версии 2FA расширяется). Секреты, сохраненные открытым текстом,
+-- сервер шифрует при следующей проверке кода
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT;
+ALTER TABLE users ALTER COLUMN totp_secret TYPE VARCHAR(255);
+
+-- Аватары
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_url VARCHAR(500);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_small_url VARCHAR(500);
+
+-- Слаги постов. Существующим постам слаг строится из заголовка приближенно к pkg/slug
+-- (кириллица транслитерируется, остальное — дефисы) с ID поста в конце: так слаги не совпадают.
+-- Уникальность проверяется после заполнения
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS slug VARCHAR(100);
+UPDATE posts SET slug = COALESCE(NULLIF(trim(BOTH '-' FROM left(trim(BOTH '-' FROM regexp_replace(
+        translate(
+            replace(replace(replace(replace(replace(replace(replace(replace(replace(
+                lower(title), 'щ', 'shch'), 'ж', 'zh'), 'х', 'kh'), 'ц', 'ts'), 'ч', 'ch'), 'ш', 'sh'),
+                'ю', 'iu'), 'я', 'ia'), 'є', 'ie'),
+            'абвгдезийклмнопрстуфыэёіїґъь', 'abvgdeziiklmnoprstufyeeiig'),
+        '[^a-z0-9]+', '-', 'g')), 70)), ''), 'post') || '-' || id
+WHERE slug IS NULL;
+ALTER TABLE posts ALTER COLUMN slug SET NOT NULL;
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT FROM pg_constraint WHERE conname = 'posts_slug_key') THEN
+        ALTER TABLE posts ADD CONSTRAINT posts_slug_key UNIQUE (slug);
+    END IF;
+END $$;
+
+-- HTML версия и оглавление. Markdown в SQL не разобрать: существующие посты получают HTML
+-- при запуске сервера (PostService.RenderStoredContent обрабатывает посты с пустым content_html)
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS content_html TEXT NOT NULL DEFAULT '';
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS toc JSONB NOT NULL DEFAULT '[]';
+
+-- Поисковый индекс (генерируемый столбец заполняется для существующих постов при добавлении)
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('russian', title), 'A') ||
+    setweight(to_tsvector('english', title), 'A') ||
+    setweight(to_tsvector('russian', content), 'B') ||
+    setweight(to_tsvector('english', content), 'B')
+) STORED;
+
+-- Одна собираемая выгрузка на пользователя (индекс ниже). Лишние pending выгрузки,
+-- оставшиеся до его появления, считаются неудачными — кроме последней
+UPDATE data_exports SET status = 'failed', completed_at = CURRENT_TIMESTAMP
+WHERE status = 'pending' AND id NOT IN (
+    SELECT MAX(id) FROM data_exports WHERE status = 'pending' GROUP BY user_id
+);
+
 -- Индексы для оптимизации поиска
-CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
+-- Email и username уникальны без учета регистра (Bob@Example.com и bob@example.com — один адрес).
+-- На существующей базе перед созданием индексов объедините аккаунты, отличающиеся только регистром
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users(LOWER(email));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_lower ON users(LOWER(username));
 CREATE INDEX IF NOT EXISTS idx_posts_author_id ON posts(author_id);
+CREATE INDEX IF NOT EXISTS idx_posts_search_vector ON posts USING GIN (search_vector);
 CREATE INDEX IF NOT EXISTS idx_comments_post_id ON comments(post_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user_id ON personal_access_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user_id ON mfa_recovery_codes(user_id);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_email ON login_attempts(email, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_ip ON login_attempts(ip, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_created_at ON login_attempts(created_at);
+CREATE INDEX IF NOT EXISTS idx_data_exports_user_id ON data_exports(user_id, created_at DESC);
+-- Два одновременных POST /api/profile/export не создадут две выгрузки: вторая вставка нарушит индекс
+CREATE UNIQUE INDEX IF NOT EXISTS idx_data_exports_one_pending ON data_exports(user_id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_comments_author_id ON comments(author_id);
+CREATE INDEX IF NOT EXISTS idx_follows_followee_id ON follows(followee_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id, last_seen_at DESC) WHERE revoked_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_post_slug_history_post_id ON post_slug_history(post_id);
+-- Фильтр по тегам идет от тега к постам (первичный ключ post_tags — от поста к тегам)
+CREATE INDEX IF NOT EXISTS idx_post_tags_tag_id ON post_tags(tag_id, post_id);
+CREATE INDEX IF NOT EXISTS idx_oidc_login_flows_expires_at ON oidc_login_flows(expires_at);
+-- Лента: для каждого автора из подписок посты читаются по индексу уже в порядке выдачи
+CREATE INDEX IF NOT EXISTS idx_posts_author_published ON posts(author_id, created_at DESC, id DESC) WHERE status = 'published';
 
 -- Добавим комментарии к таблице для документации
 COMMENT ON TABLE users IS 'Таблица пользователей системы';
-COMMENT ON COLUMN users.email IS 'Email пользователя (уникальный)';
-COMMENT ON COLUMN users.username IS 'Имя пользователя (уникальное)';
-COMMENT ON COLUMN users.password_hash IS 'Хеш пароля (bcrypt)';
+COMMENT ON COLUMN users.email IS 'Email пользователя (уникальный без учета регистра, хранится нормализованным: нижний регистр, NFC, домен в punycode)';
+COMMENT ON COLUMN users.username IS 'Имя пользователя (уникальное без учета регистра, NFC)';
+COMMENT ON COLUMN users.password_hash IS 'Хеш пароля (argon2id или bcrypt, алгоритм указан в префиксе)';
+COMMENT ON COLUMN users.role IS 'reader=читатель, author=автор, editor=модератор, admin=администратор';
+COMMENT ON COLUMN users.email_verified_at IS 'Дата подтверждения email (NULL = не подтвержден)';
+COMMENT ON COLUMN users.totp_secret IS 'Секрет TOTP (RFC 6238), зашифрован ключом MFA_ENCRYPTION_KEY (AES-256-GCM)';
+COMMENT ON COLUMN users.totp_enabled_at IS 'Дата включения 2FA (NULL = выключена)';
+COMMENT ON COLUMN users.totp_last_step IS 'Шаг последнего принятого кода: повторно код не принимается';
+COMMENT ON COLUMN users.bio IS 'О себе (до 500 символов)';
+COMMENT ON COLUMN users.avatar_url IS 'Ссылка на аватар 256x256 (NULL = не загружен)';
+COMMENT ON COLUMN users.avatar_small_url IS 'Ссылка на аватар 64x64';
+COMMENT ON COLUMN users.deleted_at IS 'Дата удаления аккаунта с анонимизацией (NULL = активен)';
 COMMENT ON COLUMN users.created_at IS 'Дата и время регистрации';
 
 COMMENT ON TABLE posts IS 'Таблица постов блога';
 COMMENT ON COLUMN posts.author_id IS 'ID автора поста (внешниий ключ → users)';
 COMMENT ON COLUMN posts.title IS 'Заголовок поста';
+COMMENT ON COLUMN posts.slug IS 'Слаг для GET /api/posts/by-slug/{slug}: транслитерация заголовка, при совпадении с суффиксом -2, -3...';
 COMMENT ON COLUMN posts.content IS 'Содержимое поста';
+COMMENT ON COLUMN posts.content_html IS 'Содержимое в HTML: CommonMark + GFM, только разрешенные теги и атрибуты';
+COMMENT ON COLUMN posts.toc IS 'Оглавление из заголовков: уровень, текст и якорь (id заголовка в content_html)';
 COMMENT ON COLUMN posts.status IS 'draft=черновик, published=опубликован';
 COMMENT ON COLUMN posts.publish_at IS 'Время публикации (NULL=сейчас, > now = отложено)';
 COMMENT ON COLUMN posts.created_at IS 'Дата создания поста';
 COMMENT ON COLUMN posts.updated_at IS 'Дата последнего изменения';
+COMMENT ON COLUMN posts.search_vector IS 'Полнотекстовый индекс для GET /api/posts/search (вычисляется из title и content)';
 
 COMMENT ON TABLE comments IS 'Таблица комментариев к постам';
 COMMENT ON COLUMN comments.post_id IS 'ID поста (внешниий ключ → posts)';
 COMMENT ON COLUMN comments.author_id IS 'ID автора комментария (внешниий ключ → users)';
 COMMENT ON COLUMN comments.content IS 'Текст комментария';
 
+COMMENT ON TABLE refresh_tokens IS 'Refresh токены с ротацией';
+COMMENT ON COLUMN refresh_tokens.token_hash IS 'SHA-256 хеш токена (сам токен не хранится)';
+COMMENT ON COLUMN refresh_tokens.family_id IS 'Цепочка ротации: при повторном использовании отзывается целиком';
+COMMENT ON COLUMN refresh_tokens.revoked_at IS 'Время отзыва (NULL = активен)';
+
+COMMENT ON TABLE revoked_tokens IS 'Отозванные access токены (logout)';
+COMMENT ON COLUMN revoked_tokens.jti IS 'Уникальный ID токена (claim jti)';
+COMMENT ON COLUMN revoked_tokens.expires_at IS 'Срок действия токена (после него запись можно удалить)';
+
+COMMENT ON TABLE user_token_revocations IS 'Выход со всех устройств';
+COMMENT ON COLUMN user_token_revocations.revoked_before IS 'Токены, выданные до этого момента, недействительны';
+
+COMMENT ON TABLE password_reset_tokens IS 'Одноразовые токены сброса пароля';
+COMMENT ON COLUMN password_reset_tokens.token_hash IS 'SHA-256 хеш токена из письма';
+COMMENT ON COLUMN password_reset_tokens.used_at IS 'Время использования (NULL = не использован)';
+
+COMMENT ON TABLE mfa_recovery_codes IS 'Коды восстановления 2FA (на случай потери телефона)';
+COMMENT ON COLUMN mfa_recovery_codes.code_hash IS 'SHA-256 хеш кода';
+COMMENT ON COLUMN mfa_recovery_codes.used_at IS 'Время использования (NULL = не использован)';
+
+COMMENT ON TABLE personal_access_tokens IS 'Именованные токены для скриптов и CI';
+COMMENT ON COLUMN personal_access_tokens.token_hash IS 'SHA-256 хеш токена (показывается пользователю один раз)';
+COMMENT ON COLUMN personal_access_tokens.scopes IS 'Области доступа: posts:read, posts:write, comments:read, comments:write, profile:read';
+COMMENT ON COLUMN personal_access_tokens.revoked_at IS 'Время отзыва (NULL = активен)';
+
+COMMENT ON TABLE login_throttles IS 'Счетчики неудачных входов по email и IP (блокировка перебора)';
+COMMENT ON COLUMN login_throttles.failures IS 'Неудач подряд (сбрасывается при успешном входе)';
+COMMENT ON COLUMN login_throttles.locked_until IS 'Вход запрещен до этого момента';
+
+COMMENT ON TABLE login_attempts IS 'Журнал неудачных входов';
+COMMENT ON COLUMN login_attempts.user_id IS 'Пользователь с этим email (NULL = не найден)';
+COMMENT ON COLUMN login_attempts.reason IS 'unknown_email=нет такого email, invalid_password=неверный пароль';
+
+COMMENT ON TABLE data_exports IS 'Выгрузки персональных данных (POST /api/profile/export)';
+COMMENT ON COLUMN data_exports.status IS 'pending=собирается, ready=готов к скачиванию, failed=ошибка';
+COMMENT ON COLUMN data_exports.file_path IS 'Путь к ZIP архиву в EXPORT_DIR';
+COMMENT ON COLUMN data_exports.expires_at IS 'После этого момента ссылка недействительна, архив удаляется';
+
+COMMENT ON TABLE invitations IS 'Одноразовые приглашения на регистрацию (создает admin)';
+COMMENT ON COLUMN invitations.code_hash IS 'SHA-256 хеш кода (код показывается администратору один раз)';
+COMMENT ON COLUMN invitations.email IS 'Адрес, для которого выдано приглашение (пустая строка = любой)';
+COMMENT ON COLUMN invitations.used_at IS 'Время регистрации по приглашению (NULL = не использовано)';
+
+COMMENT ON TABLE follows IS 'Подписки: follower_id читает посты followee_id в ленте';
+
+COMMENT ON TABLE user_blocks IS 'Блокировки: запрет комментариев и подписки для blocked_id';
+COMMENT ON TABLE user_mutes IS 'Скрытые пользователи: их посты и комментарии не видны muter_id';
+
+COMMENT ON TABLE sessions IS 'Сессии входа (устройства), список в GET /api/sessions';
+COMMENT ON COLUMN sessions.family_id IS 'Цепочка refresh токенов этого входа (refresh_tokens.family_id)';
+COMMENT ON COLUMN sessions.last_seen_at IS 'Последняя активность (обновляется не чаще раза в REVOCATION_SYNC_INTERVAL)';
+COMMENT ON COLUMN sessions.expires_at IS 'Срок действия, продлевается при обновлении токенов';
+COMMENT ON COLUMN sessions.revoked_at IS 'Время завершения (NULL = активна): access токены сессии отклоняются';
+
+COMMENT ON TABLE post_slug_history IS 'Прежние слаги постов: запрос по ним перенаправляется на текущий слаг';
+COMMENT ON COLUMN post_slug_history.post_id IS 'Пост, которому принадлежал слаг (внешний ключ → posts)';
+
+COMMENT ON TABLE tags IS 'Теги постов, список с количеством постов в GET /api/tags';
+COMMENT ON COLUMN tags.name IS 'Имя тега: до 32 символов, нижний регистр, буквы, цифры и - _ . + #';
+COMMENT ON TABLE post_tags IS 'Связь постов и тегов (не больше 10 тегов у поста)';
+
+COMMENT ON TABLE post_revisions IS 'Прежние версии постов, история в GET /api/posts/{id}/revisions';
+COMMENT ON COLUMN post_revisions.revision IS 'Номер версии поста по порядку с 1 (текущее состояние поста — следующий номер)';
+COMMENT ON COLUMN post_revisions.created_at IS 'Когда версия была сохранена (posts.updated_at на момент замены)';
+
+COMMENT ON TABLE oidc_login_flows IS 'Начатые входы через OIDC, удаляются при завершении (вход одноразовый)';
+COMMENT ON COLUMN oidc_login_flows.flow_hash IS 'SHA-256 хеш flowID из cookie oidc_flow (сам flowID не хранится)';
+
 -- Проверка создания таблиц
 DO $$
 BEGIN
@@ -71,4 +459,58 @@ BEGIN
     IF EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'comments') THEN
         RAISE NOTICE '✅ Таблица comments создана';
     END IF;
+    IF EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'refresh_tokens') THEN
+        RAISE NOTICE '✅ Таблица refresh_tokens создана';
+    END IF;
+    IF EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'revoked_tokens') THEN
+        RAISE NOTICE '✅ Таблица revoked_tokens создана';
+    END IF;
+    IF EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'password_reset_tokens') THEN
+        RAISE NOTICE '✅ Таблица password_reset_tokens создана';
+    END IF;
+    IF EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'personal_access_tokens') THEN
+        RAISE NOTICE '✅ Таблица personal_access_tokens создана';
+    END IF;
+    IF EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'mfa_recovery_codes') THEN
+        RAISE NOTICE '✅ Таблица mfa_recovery_codes создана';
+    END IF;
+    IF EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'login_throttles') THEN
+        RAISE NOTICE '✅ Таблица login_throttles создана';
+    END IF;
+    IF EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'login_attempts') THEN
+        RAISE NOTICE '✅ Таблица login_attempts создана';
+    END IF;
+    IF EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'data_exports') THEN
+        RAISE NOTICE '✅ Таблица data_exports создана';
+    END IF;
+    IF EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'invitations') THEN
+        RAISE NOTICE '✅ Таблица invitations создана';
+    END IF;
+    IF EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'follows') THEN
+        RAISE NOTICE '✅ Таблица follows создана';
+    END IF;
+    IF EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'user_blocks') THEN
+        RAISE NOTICE '✅ Таблица user_blocks создана';
+    END IF;
+    IF EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'user_mutes') THEN
+        RAISE NOTICE '✅ Таблица user_mutes создана';
+    END IF;
+    IF EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'sessions') THEN
+        RAISE NOTICE '✅ Таблица sessions создана';
+    END IF;
+    IF EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'post_slug_history') THEN
+        RAISE NOTICE '✅ Таблица post_slug_history создана';
+    END IF;
+    IF EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'tags') THEN
+        RAISE NOTICE '✅ Таблица tags создана';
+    END IF;
+    IF EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'post_tags') THEN
+        RAISE NOTICE '✅ Таблица post_tags создана';
+    END IF;
+    IF EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'post_revisions') THEN
+        RAISE NOTICE '✅ Таблица post_revisions создана';
+    END IF;
+    IF EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'oidc_login_flows') THEN
+        RAISE NOTICE '✅ Таблица oidc_login_flows создана';
+    END IF;
 END $$;
\ No newline at end of file
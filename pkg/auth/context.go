@@ -1,13 +1,59 @@
 package auth
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+
+	"blog-backend/internal/model"
+)
 
 type contextKey string
 
 const (
-	contextKeyUser = contextKey("user")
+	contextKeyUser   = contextKey("user")
+	contextKeyScopes = contextKey("scopes")
+	contextKeyClient = contextKey("client")
 )
 
+// ClientInfo - откуда выполнен вход (сохраняется в сессии)
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// WithClaims сохраняет claims токена в контексте запроса
+func WithClaims(ctx context.Context, claims *model.Claims) context.Context {
+	return context.WithValue(ctx, contextKeyUser, claims)
+}
+
+// GetClaimsFromContext извлекает claims токена из контекста
+func GetClaimsFromContext(r *http.Request) (*model.Claims, bool) {
+	claims, ok := r.Context().Value(contextKeyUser).(*model.Claims)
+	return claims, ok
+}
+
+// WithScopes сохраняет области доступа personal access токена
+func WithScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, contextKeyScopes, scopes)
+}
+
+// GetScopesFromContext извлекает области доступа; ok=false — запрос с JWT (полный доступ)
+func GetScopesFromContext(r *http.Request) ([]string, bool) {
+	scopes, ok := r.Context().Value(contextKeyScopes).([]string)
+	return scopes, ok
+}
+
+// WithClientInfo сохраняет IP и User-Agent клиента для новой сессии
+func WithClientInfo(ctx context.Context, ip, userAgent string) context.Context {
+	return context.WithValue(ctx, contextKeyClient, ClientInfo{IP: ip, UserAgent: userAgent})
+}
+
+// ClientInfoFromContext извлекает IP и User-Agent клиента (пустые, если не заданы)
+func ClientInfoFromContext(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(contextKeyClient).(ClientInfo)
+	return info
+}
+
 // GetUserIDFromContext извлекает ID пользователя из контекста
 func GetUserIDFromContext(r *http.Request) (int, bool) {
 	// Используем r.Context().Value("userID")
@@ -3,17 +3,23 @@ package jwt
 import (
 	"blog-backend/internal/model"
 	"fmt"
+	"net/mail"
 	"os"
+	"strings"
 
 	// TODO: Добавьте необходимые импорты:
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/net/idna"
+	"golang.org/x/text/unicode/norm"
 )
 
 var jwtSecret []byte
 
+// accessTokenTTL - время жизни access токена (по умолчанию 15 минут)
+var accessTokenTTL = 15 * time.Minute
+
 // InitAuth инициализирует секретный ключ для JWT
 func InitAuth() {
 	jwtSecret = []byte(os.Getenv("JWT_SECRET"))
@@ -22,63 +28,75 @@ func InitAuth() {
 	}
 }
 
-// HashPassword хеширует пароль с использованием bcrypt
+// SetAccessTokenTTL задает время жизни access токена
+func SetAccessTokenTTL(ttl time.Duration) {
+	if ttl > 0 {
+		accessTokenTTL = ttl
+	}
+}
+
+// AccessTokenTTL возвращает время жизни access токена
+func AccessTokenTTL() time.Duration {
+	return accessTokenTTL
+}
+
+// HashPassword хеширует пароль текущим алгоритмом (см. SetPasswordHasher)
 func HashPassword(password string) (string, error) {
-	// TODO: Реализуйте хеширование пароля
-	//
-	// Что нужно сделать:
-	// 1. Импортируйте "golang.org/x/crypto/bcrypt"
-	// 2. Используйте bcrypt.GenerateFromPassword()
-	// 3. Передайте []byte(password) и bcrypt.DefaultCost
-	// 4. Обработайте ошибку и верните результат как string
-	//
-	// Документация: https://pkg.go.dev/golang.org/x/crypto/bcrypt#GenerateFromPassword
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
-	// return "", fmt.Errorf("not implemented - реализуйте хеширование пароля с bcrypt")
+	return currentPasswordHasher().Hash(password)
 }
 
-// CheckPassword проверяет пароль против хеша
+// CheckPassword проверяет пароль против хеша любого поддерживаемого алгоритма
+// (алгоритм определяется по префиксу хеша: bcrypt или argon2id)
 func CheckPassword(password, hash string) bool {
-	// TODO: Реализуйте проверку пароля
-	//
-	// Что нужно сделать:
-	// 1. Используйте bcrypt.CompareHashAndPassword()
-	// 2. Передайте []byte(hash) и []byte(password)
-	// 3. Верните true если ошибки нет, false если есть
-	//
-	// Документация: https://pkg.go.dev/golang.org/x/crypto/bcrypt#CompareHashAndPassword
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
-	// return false // Временная заглушка
+	hasher := hasherFor(hash)
+	if hasher == nil {
+		return false
+	}
+	return hasher.Verify(password, hash)
 }
 
-// GenerateToken создает JWT токен для пользователя
-func GenerateToken(user model.User) (string, error) {
+// GenerateToken создает JWT токен для пользователя в сессии sessionID (0 — без сессии)
+func GenerateToken(user model.User, sessionID int) (string, error) {
 	// TODO: Реализуйте генерацию JWT токена
 	//
 	// Что нужно сделать:
 	// 1. Импортируйте "time" и "github.com/golang-jwt/jwt/v5"
 	// 2. Создайте Claims структуру с данными пользователя
 	//    - Заполните UserID, Email, Username
-	//    - Установите ExpiresAt на 24 часа вперед: jwt.NewNumericDate(time.Now().Add(24 * time.Hour))
+	//    - Установите ExpiresAt на accessTokenTTL вперед: jwt.NewNumericDate(time.Now().Add(accessTokenTTL))
 	//    - Установите IssuedAt на текущее время: jwt.NewNumericDate(time.Now())
 	// 3. Создайте токен с помощью jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	// 4. Подпишите токен с помощью token.SignedString(jwtSecret)
 	//
 	// Документация: https://pkg.go.dev/github.com/golang-jwt/jwt/v5
+	// Уникальный ID токена (jti) — нужен для отзыва конкретного токена
+	jti, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
 	claims := model.Claims{
-		UserID:   user.ID,
-		Email:    user.Email,
-		Username: user.Username,
+		UserID:    user.ID,
+		Email:     user.Email,
+		Username:  user.Username,
+		Role:      user.Role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(accessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtSecret)
+	// Асимметричный ключ (RS256/EdDSA) с kid в заголовке, иначе HS256 с JWT_SECRET
+	var tokenString string
+	if key := currentSigningKey(); key != nil {
+		token := jwt.NewWithClaims(key.method, claims)
+		token.Header["kid"] = key.kid
+		tokenString, err = token.SignedString(key.private)
+	} else {
+		tokenString, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSecret)
+	}
 	if err != nil {
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -95,8 +113,22 @@ func ValidateToken(tokenString string) (*model.Claims, error) {
 	// 1. Создайте пустую структуру claims := &Claims{}
 	claims := &model.Claims{}
 
-	// 3. В keyFunc проверьте, что алгоритм подписи HMAC (*jwt.SigningMethodHMAC)
+	// 3. В keyFunc выбираем ключ проверки по режиму подписи
 	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		// Асимметричный режим: ключ ищем по kid, алгоритм должен совпадать с ключом.
+		// HS256 токены не принимаются — иначе их мог бы выпустить любой, кто знает секрет
+		if currentSigningKey() != nil {
+			kid, _ := token.Header["kid"].(string)
+			key, ok := lookupVerificationKey(kid)
+			if !ok {
+				return nil, fmt.Errorf("unknown signing key: %q", kid)
+			}
+			if token.Method.Alg() != key.method.Alg() {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return key.public, nil
+		}
+
 		// Проверяем алгоритм подписи
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -137,14 +169,49 @@ func ValidatePassword(password string) error {
 	return nil
 }
 
-// ValidateEmail проверяет формат email (базовая проверка)
+// Ограничения длины адреса (RFC 5321, 4.5.3.1)
+const (
+	maxEmailLength      = 254
+	maxEmailLocalLength = 64
+)
+
+// ValidateEmail проверяет формат email (см. NormalizeEmail)
 func ValidateEmail(email string) error {
+	_, err := NormalizeEmail(email)
+	return err
+}
+
+// NormalizeEmail проверяет адрес по RFC 5322 и приводит его к виду, в котором он хранится:
+// Unicode NFC, нижний регистр, домен в ASCII (punycode). Так Bob@Example.com и bob@example.com —
+// один адрес. Отображаемое имя ("Bob <bob@example.com>"), комментарии, local-part в кавычках
+// и домены без точки (localhost, [127.0.0.1]) не принимаются
+func NormalizeEmail(email string) (string, error) {
+	email = norm.NFC.String(strings.TrimSpace(email))
 	if email == "" {
-		return fmt.Errorf("email is required")
+		return "", fmt.Errorf("email is required")
 	}
 
-	// TODO: Добавьте более строгую валидацию email если необходимо
-	// Можно использовать regexp.MatchString() для проверки формата
+	// ParseAddress разбирает и name-addr; принимаем только голый addr-spec
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Name != "" || addr.Address != email {
+		return "", fmt.Errorf("invalid email format")
+	}
 
-	return nil
+	at := strings.LastIndex(email, "@")
+	local, domain := email[:at], email[at+1:]
+	if len(local) > maxEmailLocalLength {
+		return "", fmt.Errorf("invalid email format: local part is too long")
+	}
+
+	// IDNA переводит домен в ASCII и проверяет метки (нижний регистр, дефисы, длина)
+	domain, err = idna.Lookup.ToASCII(domain)
+	if err != nil || !strings.Contains(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", fmt.Errorf("invalid email format: invalid domain")
+	}
+
+	email = norm.NFC.String(strings.ToLower(local)) + "@" + domain
+	if len(email) > maxEmailLength {
+		return "", fmt.Errorf("invalid email format: address is too long")
+	}
+	return email, nil
 }
@@ -0,0 +1,274 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Минимальный размер RSA ключа
+const minRSAKeyBits = 2048
+
+// signingKey - закрытый ключ, которым подписываются новые токены
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer
+}
+
+// verificationKey - открытый ключ для проверки токенов (текущий и предыдущие)
+type verificationKey struct {
+	kid    string
+	method jwt.SigningMethod
+	public crypto.PublicKey
+}
+
+// Асимметричные ключи. Если signing == nil — используется HS256 с JWT_SECRET
+var keys = struct {
+	mu      sync.RWMutex
+	signing *signingKey
+	verify  map[string]*verificationKey
+}{}
+
+// LoadKeys загружает ключи подписи из PEM файлов (RS256 или EdDSA).
+// signingKeyFile — закрытый ключ для новых токенов; verifyKeyFiles — ключи,
+// которыми подписаны еще действующие токены (открытые или закрытые), например
+// предыдущий ключ во время ротации. Пустой signingKeyFile возвращает режим HS256
+func LoadKeys(signingKeyFile string, verifyKeyFiles []string) error {
+	if signingKeyFile == "" {
+		keys.mu.Lock()
+		keys.signing = nil
+		keys.verify = nil
+		keys.mu.Unlock()
+		return nil
+	}
+
+	private, err := readPrivateKey(signingKeyFile)
+	if err != nil {
+		return err
+	}
+	current, err := newVerificationKey(private.Public())
+	if err != nil {
+		return fmt.Errorf("%s: %w", signingKeyFile, err)
+	}
+
+	verify := map[string]*verificationKey{current.kid: current}
+	for _, file := range verifyKeyFiles {
+		public, err := readPublicKey(file)
+		if err != nil {
+			return err
+		}
+		key, err := newVerificationKey(public)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		verify[key.kid] = key
+	}
+
+	keys.mu.Lock()
+	keys.signing = &signingKey{kid: current.kid, method: current.method, private: private}
+	keys.verify = verify
+	keys.mu.Unlock()
+	return nil
+}
+
+// currentSigningKey возвращает ключ подписи (nil в режиме HS256)
+func currentSigningKey() *signingKey {
+	keys.mu.RLock()
+	defer keys.mu.RUnlock()
+	return keys.signing
+}
+
+// lookupVerificationKey ищет ключ проверки по kid из заголовка токена
+func lookupVerificationKey(kid string) (*verificationKey, bool) {
+	keys.mu.RLock()
+	defer keys.mu.RUnlock()
+	key, ok := keys.verify[kid]
+	return key, ok
+}
+
+// newVerificationKey определяет алгоритм по типу ключа и вычисляет kid
+func newVerificationKey(public crypto.PublicKey) (*verificationKey, error) {
+	key := &verificationKey{public: public}
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key must be at least %d bits", minRSAKeyBits)
+		}
+		key.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T (use RSA or Ed25519)", public)
+	}
+
+	kid, err := thumbprint(toJWK(key))
+	if err != nil {
+		return nil, err
+	}
+	key.kid = kid
+	return key, nil
+}
+
+// readPrivateKey читает закрытый ключ (PKCS#8 или PKCS#1 для RSA)
+func readPrivateKey(file string) (crypto.Signer, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: expected private key, got %q", file, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to parse private key: %w", file, err)
+	}
+
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported private key type %T", file, parsed)
+	}
+	return signer, nil
+}
+
+// readPublicKey читает открытый ключ; для закрытого ключа берется его открытая часть
+func readPublicKey(file string) (crypto.PublicKey, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to parse public key: %w", file, err)
+		}
+		return public, nil
+	case "RSA PUBLIC KEY":
+		public, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to parse public key: %w", file, err)
+		}
+		return public, nil
+	default:
+		private, err := readPrivateKey(file)
+		if err != nil {
+			return nil, err
+		}
+		return private.Public(), nil
+	}
+}
+
+// readPEM читает первый PEM блок из файла
+func readPEM(file string) (*pem.Block, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", file)
+	}
+	return block, nil
+}
+
+// JWK - открытый ключ в формате RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519 (OKP)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet - набор ключей для /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS возвращает все открытые ключи проверки (пустой набор в режиме HS256)
+func JWKS() JWKSet {
+	keys.mu.RLock()
+	defer keys.mu.RUnlock()
+
+	set := JWKSet{Keys: make([]JWK, 0, len(keys.verify))}
+	// Текущий ключ первым
+	if keys.signing != nil {
+		set.Keys = append(set.Keys, toJWK(keys.verify[keys.signing.kid]))
+	}
+	for kid, key := range keys.verify {
+		if keys.signing == nil || kid != keys.signing.kid {
+			set.Keys = append(set.Keys, toJWK(key))
+		}
+	}
+	return set
+}
+
+// toJWK конвертирует открытый ключ в JWK
+func toJWK(key *verificationKey) JWK {
+	jwk := JWK{Use: "sig", Alg: key.method.Alg(), Kid: key.kid}
+	switch pub := key.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
+
+// thumbprint вычисляет kid как JWK Thumbprint (RFC 7638):
+// одинаковый ключ дает одинаковый kid на всех экземплярах сервиса
+func thumbprint(jwk JWK) (string, error) {
+	// Обязательные поля в лексикографическом порядке
+	var members any
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	default:
+		return "", fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
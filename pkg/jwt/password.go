@@ -0,0 +1,170 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher - алгоритм хеширования паролей.
+// Хеш содержит идентификатор алгоритма и параметры ($2a$..., $argon2id$...),
+// поэтому старые хеши проверяются и после смены алгоритма
+type PasswordHasher interface {
+	// Hash хеширует пароль со случайной солью
+	Hash(password string) (string, error)
+	// Verify проверяет пароль; false — хеш не этого алгоритма или пароль неверный
+	Verify(password, encoded string) bool
+	// Matches сообщает, что хеш создан этим алгоритмом с текущими параметрами
+	Matches(encoded string) bool
+}
+
+// Хешер для новых паролей. По умолчанию bcrypt — как до появления выбора алгоритма
+var passwordHashers = struct {
+	mu      sync.RWMutex
+	current PasswordHasher
+}{current: BcryptHasher{Cost: bcrypt.DefaultCost}}
+
+// SetPasswordHasher задает алгоритм для новых хешей (и для пересчета старых при входе)
+func SetPasswordHasher(h PasswordHasher) {
+	passwordHashers.mu.Lock()
+	defer passwordHashers.mu.Unlock()
+	passwordHashers.current = h
+}
+
+// currentPasswordHasher возвращает алгоритм для новых хешей
+func currentPasswordHasher() PasswordHasher {
+	passwordHashers.mu.RLock()
+	defer passwordHashers.mu.RUnlock()
+	return passwordHashers.current
+}
+
+// hasherFor выбирает алгоритм по префиксу сохраненного хеша
+func hasherFor(encoded string) PasswordHasher {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return Argon2idHasher{}
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		return BcryptHasher{}
+	default:
+		return nil
+	}
+}
+
+// NeedsRehash сообщает, что хеш создан другим алгоритмом или с другими параметрами
+// и его стоит пересчитать, пока известен пароль (после успешного входа)
+func NeedsRehash(encoded string) bool {
+	return !currentPasswordHasher().Matches(encoded)
+}
+
+// BcryptHasher - bcrypt (хеши $2a$...)
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	return string(bytes), err
+}
+
+func (h BcryptHasher) Verify(password, encoded string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)) == nil
+}
+
+func (h BcryptHasher) Matches(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err == nil && cost == h.Cost
+}
+
+// Argon2Params - параметры argon2id (RFC 9106)
+type Argon2Params struct {
+	Memory      uint32 // КиБ
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params - 64 МиБ, 3 прохода, 2 потока
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Argon2idHasher - argon2id в формате PHC: $argon2id$v=19$m=65536,t=3,p=2$<соль>$<хеш>
+type Argon2idHasher struct {
+	Params Argon2Params
+}
+
+var errInvalidArgon2Hash = errors.New("invalid argon2id hash")
+
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.Params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	p := h.Params
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify проверяет пароль с параметрами из самого хеша
+func (h Argon2idHasher) Verify(password, encoded string) bool {
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false
+	}
+	other := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return subtle.ConstantTimeCompare(key, other) == 1
+}
+
+func (h Argon2idHasher) Matches(encoded string) bool {
+	p, _, _, err := decodeArgon2id(encoded)
+	return err == nil && p == h.Params
+}
+
+// decodeArgon2id разбирает хеш в формате PHC
+func decodeArgon2id(encoded string) (Argon2Params, []byte, []byte, error) {
+	var p Argon2Params
+
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", соль, хеш
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, errInvalidArgon2Hash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, errInvalidArgon2Hash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, errInvalidArgon2Hash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, errInvalidArgon2Hash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, errInvalidArgon2Hash
+	}
+
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+	return p, salt, key, nil
+}
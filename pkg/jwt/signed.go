@@ -0,0 +1,72 @@
+package jwt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ошибки проверки подписанных значений
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrSignatureExpired = errors.New("signature expired")
+)
+
+// SignValue подписывает значение секретом приложения для ссылок в письмах.
+// purpose разделяет назначения: подпись для одной цели не подходит для другой.
+// Формат: base64url(expiresUnix "." value) "." base64url(HMAC-SHA256)
+func SignValue(purpose, value string, ttl time.Duration) string {
+	payload := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10) + "." + value
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(signValue(purpose, encoded))
+}
+
+// VerifySignedValue проверяет подпись и срок действия, возвращает исходное значение
+func VerifySignedValue(purpose, token string) (string, error) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", ErrInvalidSignature
+	}
+
+	gotSig, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(gotSig, signValue(purpose, encoded)) {
+		return "", ErrInvalidSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+	expStr, value, ok := strings.Cut(string(payload), ".")
+	if !ok {
+		return "", ErrInvalidSignature
+	}
+	exp, err := strconv.ParseInt(expStr, 10, 64)
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+	if time.Now().Unix() > exp {
+		return "", ErrSignatureExpired
+	}
+
+	return value, nil
+}
+
+// DeriveValue выводит из value секретное значение для назначения purpose (base64url HMAC-SHA256).
+// Позволяет не хранить на сервере данные, которые восстанавливаются по публичному value
+func DeriveValue(purpose, value string) string {
+	return base64.RawURLEncoding.EncodeToString(signValue(purpose, value))
+}
+
+// signValue считает HMAC от назначения и данных
+func signValue(purpose, encoded string) []byte {
+	mac := hmac.New(sha256.New, jwtSecret)
+	mac.Write([]byte(purpose))
+	mac.Write([]byte{0}) // разделитель, чтобы "ab"+"c" != "a"+"bc"
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
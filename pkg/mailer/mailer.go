@@ -0,0 +1,82 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message - письмо (только текст)
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer - интерфейс отправки писем
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogMailer пишет письма в лог вместо отправки (локальная разработка и тесты)
+type LogMailer struct {
+	logger *log.Logger
+}
+
+// NewLogMailer создает LogMailer, который пишет письма в w
+func NewLogMailer(w io.Writer) *LogMailer {
+	return &LogMailer{logger: log.New(w, "📧 ", log.LstdFlags)}
+}
+
+// Send выводит письмо в лог
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	m.logger.Printf("To: %s\nSubject: %s\n\n%s\n", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileMailer сохраняет каждое письмо в отдельный .eml файл в каталоге
+type FileMailer struct {
+	dir string
+	mu  sync.Mutex
+	seq int
+}
+
+// NewFileMailer создает FileMailer (каталог создается при необходимости)
+func NewFileMailer(dir string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail dir: %w", err)
+	}
+	return &FileMailer{dir: dir}, nil
+}
+
+// Send записывает письмо в файл
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	m.seq++
+	name := fmt.Sprintf("%s-%03d-%s.eml",
+		time.Now().Format("20060102-150405"), m.seq, sanitizeFileName(msg.To))
+	m.mu.Unlock()
+
+	content := fmt.Sprintf("To: %s\r\nSubject: %s\r\n\r\n%s\r\n", msg.To, msg.Subject, msg.Body)
+	if err := os.WriteFile(filepath.Join(m.dir, name), []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to write mail: %w", err)
+	}
+	return nil
+}
+
+// sanitizeFileName оставляет в адресе только безопасные для имени файла символы
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_', r == '@':
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,139 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"blog-backend/pkg/slug"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// defaultHeadingID - якорь заголовка без букв и цифр
+const defaultHeadingID = "section"
+
+// Heading - пункт оглавления: уровень заголовка, текст и якорь (#id в HTML)
+type Heading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	ID    string `json:"id"`
+}
+
+// Result - HTML для показа и оглавление поста
+type Result struct {
+	HTML string
+	TOC  []Heading
+}
+
+// CommonMark + GFM (таблицы, зачеркивание, автоссылки, списки задач).
+// Сырой HTML из текста не выводится, заголовки получают id для ссылок на разделы
+var converter = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+)
+
+// policy - разрешенные теги и атрибуты (все остальное удаляется, включая скрипты,
+// обработчики событий и ссылки javascript:). Вторая линия защиты после goldmark
+var policy = func() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	// Подсветка синтаксиса на клиенте: ```go → <code class="language-go">
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#.-]+$`)).OnElements("code")
+	// Списки задач GFM: - [x] сделано
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	return p
+}()
+
+// Render переводит Markdown в безопасный HTML и собирает оглавление из заголовков.
+// Якоря строятся из текста заголовка как слаги постов ("Введение" → "vvedenie"),
+// повторы различаются суффиксом ("vvedenie-2")
+func Render(source string) (Result, error) {
+	src := []byte(source)
+	ctx := parser.NewContext(parser.WithIDs(&headingIDs{used: map[string]bool{}}))
+	doc := converter.Parser().Parse(text.NewReader(src), parser.WithContext(ctx))
+
+	var buf bytes.Buffer
+	if err := converter.Renderer().Render(&buf, src, doc); err != nil {
+		return Result{}, fmt.Errorf("failed to render markdown: %w", err)
+	}
+
+	toc := []Heading{}
+	err := ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		var id string
+		if value, ok := heading.AttributeString("id"); ok {
+			if b, ok := value.([]byte); ok {
+				id = string(b)
+			}
+		}
+		toc = append(toc, Heading{Level: heading.Level, Text: plainText(heading, src), ID: id})
+		return ast.WalkSkipChildren, nil
+	})
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to build table of contents: %w", err)
+	}
+
+	return Result{HTML: policy.Sanitize(buf.String()), TOC: toc}, nil
+}
+
+// plainText - текст узла без разметки (для оглавления)
+func plainText(n ast.Node, src []byte) string {
+	var buf bytes.Buffer
+	ast.Walk(n, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := child.(type) {
+		case *ast.Text:
+			buf.Write(node.Segment.Value(src))
+			if node.SoftLineBreak() || node.HardLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *ast.String:
+			buf.Write(node.Value)
+		case *ast.CodeSpan:
+			for c := node.FirstChild(); c != nil; c = c.NextSibling() {
+				if t, ok := c.(*ast.Text); ok {
+					buf.Write(t.Segment.Value(src))
+				}
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return buf.String()
+}
+
+// headingIDs - генератор якорей заголовков для goldmark (уникальных в пределах поста)
+type headingIDs struct {
+	used map[string]bool
+}
+
+// Generate строит якорь из текста заголовка
+func (g *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	base := slug.Make(string(value))
+	if base == "" {
+		base = defaultHeadingID
+	}
+	id := base
+	for n := 2; g.used[id]; n++ {
+		id = slug.WithSuffix(base, strconv.Itoa(n))
+	}
+	g.used[id] = true
+	return []byte(id)
+}
+
+// Put запоминает якорь, заданный вручную
+func (g *headingIDs) Put(value []byte) {
+	g.used[string(value)] = true
+}
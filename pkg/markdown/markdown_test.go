@@ -0,0 +1,110 @@
+// pkg/markdown/markdown_test.go
+package markdown_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"blog-backend/pkg/markdown"
+)
+
+// TestRenderSanitizes - опасные ссылки и сырой HTML не попадают в результат
+func TestRenderSanitizes(t *testing.T) {
+	cases := []struct {
+		name     string
+		source   string
+		contains []string
+		banned   []string
+	}{
+		{
+			name:   "javascript link",
+			source: "[нажми](javascript:alert(1))",
+			banned: []string{"javascript:", "alert"},
+		},
+		{
+			name:   "javascript link with entities",
+			source: "[нажми](jav&#x61;script:alert(1))",
+			banned: []string{"javascript:", "script:"},
+		},
+		{
+			// Ссылка удаляется, остается текст
+			name:   "javascript autolink",
+			source: "<javascript:alert(1)>",
+			banned: []string{"<a", "href"},
+		},
+		{
+			name:     "url autolink",
+			source:   "Сайт https://example.com и <https://go.dev>",
+			contains: []string{`<a href="https://example.com" rel="nofollow">`, `<a href="https://go.dev" rel="nofollow">`},
+		},
+		{
+			name:     "email autolink",
+			source:   "<mail@example.com>",
+			contains: []string{`href="mailto:mail@example.com"`},
+		},
+		{
+			name:     "raw html block",
+			source:   "<script>alert(1)</script>\n\n<div onclick=\"x()\">блок</div>\n\nтекст",
+			contains: []string{"<p>текст</p>"},
+			banned:   []string{"<script", "alert", "<div", "onclick"},
+		},
+		{
+			name:     "inline raw html",
+			source:   `текст <img src=x onerror=alert(1)> и <b>жирный</b>`,
+			contains: []string{"<p>текст"},
+			banned:   []string{"<img", "onerror", "<b>"},
+		},
+		{
+			name:     "image with event handler title",
+			source:   `![alt](https://example.com/a.png "t\" onerror=\"alert(1)")`,
+			contains: []string{`src="https://example.com/a.png"`},
+			banned:   []string{"onerror="},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := markdown.Render(tc.source)
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			for _, want := range tc.contains {
+				if !strings.Contains(result.HTML, want) {
+					t.Errorf("expected %q in:\n%s", want, result.HTML)
+				}
+			}
+			for _, banned := range tc.banned {
+				if strings.Contains(result.HTML, banned) {
+					t.Errorf("unexpected %q in:\n%s", banned, result.HTML)
+				}
+			}
+		})
+	}
+}
+
+// TestRenderTOC - якоря заголовков транслитерируются, повторы получают суффикс
+func TestRenderTOC(t *testing.T) {
+	result, err := markdown.Render("# Введение\n\n## `go build` и *тесты*\n\n## Введение\n\n### !!!\n")
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	want := []markdown.Heading{
+		{Level: 1, Text: "Введение", ID: "vvedenie"},
+		{Level: 2, Text: "go build и тесты", ID: "go-build-i-testy"},
+		{Level: 2, Text: "Введение", ID: "vvedenie-2"},
+		{Level: 3, Text: "!!!", ID: "section"},
+	}
+	if !reflect.DeepEqual(result.TOC, want) {
+		t.Errorf("expected toc %+v, got %+v", want, result.TOC)
+	}
+	for _, heading := range want {
+		if !strings.Contains(result.HTML, `id="`+heading.ID+`"`) {
+			t.Errorf("expected heading id %q in:\n%s", heading.ID, result.HTML)
+		}
+	}
+
+	if empty, _ := markdown.Render("без заголовков"); empty.TOC == nil || len(empty.TOC) != 0 {
+		t.Errorf("expected empty non-nil toc, got %#v", empty.TOC)
+	}
+}
@@ -0,0 +1,81 @@
+// Package secretbox шифрует небольшие секреты для хранения в БД (AES-256-GCM)
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeySize - длина ключа AES-256 в байтах
+const KeySize = 32
+
+// prefix отличает зашифрованное значение от открытого текста (и задает версию формата)
+const prefix = "v1:"
+
+// Ошибки ключа и расшифровки
+var (
+	ErrInvalidKey = errors.New("invalid encryption key")
+	ErrDecrypt    = errors.New("failed to decrypt value")
+)
+
+// Box шифрует и расшифровывает значения одним ключом
+type Box struct {
+	aead cipher.AEAD
+}
+
+// New создает Box из ключа в base64 (32 байта: openssl rand -base64 32)
+func New(key string) (*Box, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil || len(raw) != KeySize {
+		return nil, fmt.Errorf("%w: need %d bytes in base64", ErrInvalidKey, KeySize)
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	return &Box{aead: aead}, nil
+}
+
+// Seal шифрует plaintext. binding (например, ID владельца) не хранится, но нужен для расшифровки:
+// значение, скопированное в чужую запись, не расшифруется.
+// Формат: "v1:" base64url(nonce || шифротекст)
+func (b *Box) Seal(plaintext, binding string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), []byte(binding))
+	return prefix + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Open расшифровывает значение, созданное Seal с тем же binding
+func (b *Box) Open(value, binding string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, prefix)
+	if !ok {
+		return "", ErrDecrypt
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < b.aead.NonceSize() {
+		return "", ErrDecrypt
+	}
+	nonce, ciphertext := sealed[:b.aead.NonceSize()], sealed[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, []byte(binding))
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plaintext), nil
+}
+
+// IsSealed - значение зашифровано Seal (а не сохранено открытым текстом до включения шифрования)
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
@@ -0,0 +1,77 @@
+// pkg/secretbox/secretbox_test.go
+package secretbox_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"blog-backend/pkg/secretbox"
+)
+
+const (
+	testKey  = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=" // 32 байта
+	otherKey = "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+)
+
+// newBox - Box с ключом key
+func newBox(t *testing.T, key string) *secretbox.Box {
+	t.Helper()
+
+	box, err := secretbox.New(key)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return box
+}
+
+// TestSealOpen - значение расшифровывается только тем же ключом и с тем же binding
+func TestSealOpen(t *testing.T) {
+	box := newBox(t, testKey)
+
+	sealed, err := box.Seal("JBSWY3DPEHPK3PXP", "1")
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	if !secretbox.IsSealed(sealed) || strings.Contains(sealed, "JBSWY3DPEHPK3PXP") {
+		t.Fatalf("expected sealed value, got %q", sealed)
+	}
+	if again, _ := box.Seal("JBSWY3DPEHPK3PXP", "1"); again == sealed {
+		t.Error("expected a fresh nonce for every Seal")
+	}
+
+	if plain, err := box.Open(sealed, "1"); err != nil || plain != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("Open: expected original value, got %q (%v)", plain, err)
+	}
+
+	tampered := sealed[:len(sealed)-2] + "AA"
+	cases := []struct {
+		name  string
+		box   *secretbox.Box
+		value string
+		bind  string
+	}{
+		{"other binding", box, sealed, "2"},
+		{"other key", newBox(t, otherKey), sealed, "1"},
+		{"tampered", box, tampered, "1"},
+		{"plaintext", box, "JBSWY3DPEHPK3PXP", "1"},
+		{"not base64", box, "v1:***", "1"},
+		{"too short", box, "v1:AAAA", "1"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.box.Open(tc.value, tc.bind); !errors.Is(err, secretbox.ErrDecrypt) {
+				t.Errorf("expected ErrDecrypt, got %v", err)
+			}
+		})
+	}
+}
+
+// TestNewInvalidKey - ключ должен быть ровно 32 байта в base64
+func TestNewInvalidKey(t *testing.T) {
+	for _, key := range []string{"", "not base64!", "MDEyMzQ1Njc4OWFiY2RlZg=="} {
+		if _, err := secretbox.New(key); !errors.Is(err, secretbox.ErrInvalidKey) {
+			t.Errorf("key %q: expected ErrInvalidKey, got %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package slug
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLength - максимальная длина слага (обрезается по границе слова)
+const MaxLength = 80
+
+// cyrillic - транслитерация кириллицы (русский и украинский алфавиты, как в загранпаспортах).
+// Буквы с диакритикой (й, ё, ї) после NFD приходят как и, е, і с отдельным знаком
+var cyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ж': "zh", 'з': "z",
+	'и': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r",
+	'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh",
+	'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "iu", 'я': "ia", 'і': "i",
+	'є': "ie", 'ґ': "g",
+}
+
+// Make строит слаг из заголовка: "Привет, мир!" → "privet-mir".
+// Кириллица транслитерируется, диакритика снимается ("Café" → "cafe"), все, кроме латиницы
+// и цифр, становится одним дефисом. Пустой результат возможен (заголовок из одних символов)
+func Make(title string) string {
+	var b strings.Builder
+	dash := true // без дефиса в начале
+	for _, r := range norm.NFD.String(strings.ToLower(title)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+			dash = false
+		case unicode.Is(unicode.Mn, r):
+			// Диакритические знаки после NFD — отдельные символы, просто пропускаем
+		default:
+			if latin, ok := cyrillic[r]; ok {
+				// Твердый и мягкий знаки пропадают, не разрывая слово
+				if latin != "" {
+					b.WriteString(latin)
+					dash = false
+				}
+				continue
+			}
+			if !dash {
+				b.WriteByte('-')
+				dash = true
+			}
+		}
+	}
+	return truncate(strings.TrimRight(b.String(), "-"))
+}
+
+// truncate обрезает слаг до MaxLength, по возможности по последнему дефису
+func truncate(slug string) string {
+	if len(slug) <= MaxLength {
+		return slug
+	}
+	slug = slug[:MaxLength]
+	if i := strings.LastIndexByte(slug, '-'); i > MaxLength/2 {
+		slug = slug[:i]
+	}
+	return strings.TrimRight(slug, "-")
+}
+
+// WithSuffix добавляет номер для различения одинаковых слагов: "privet-mir" → "privet-mir-2"
+func WithSuffix(slug, suffix string) string {
+	slug = slug[:min(len(slug), MaxLength-len(suffix)-1)]
+	return strings.TrimRight(slug, "-") + "-" + suffix
+}
@@ -0,0 +1,149 @@
+package textdiff
+
+import (
+	"slices"
+	"strings"
+)
+
+// Op - вид изменения строки
+type Op string
+
+const (
+	Equal  Op = "equal"  // строка есть в обеих версиях
+	Insert Op = "insert" // строка добавлена
+	Delete Op = "delete" // строка удалена
+)
+
+// MaxEdits - предел числа правок при поиске кратчайшего сравнения. Память поиска растет как квадрат
+// числа правок, поэтому для сильно разошедшихся текстов сравнение упрощается: отличающаяся середина
+// целиком удаляется и вставляется заново (результат верный, но не кратчайший)
+const MaxEdits = 1000
+
+// Line - строка построчного сравнения
+type Line struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// Lines сравнивает тексты построчно (алгоритм Майерса — кратчайший набор правок).
+// Переводы строк \r\n и \n считаются одинаковыми, завершающий перевод строки не дает пустой строки
+func Lines(a, b string) []Line {
+	x, y := splitLines(a), splitLines(b)
+
+	// Общие начало и конец не участвуют в поиске: обычно правка затрагивает малую часть текста
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]Line, 0, max(len(x), len(y)))
+	for _, text := range x[:prefix] {
+		lines = append(lines, Line{Op: Equal, Text: text})
+	}
+	middleA, middleB := x[prefix:len(x)-suffix], y[prefix:len(y)-suffix]
+	if edits, ok := myers(middleA, middleB); ok {
+		lines = append(lines, edits...)
+	} else {
+		for _, text := range middleA {
+			lines = append(lines, Line{Op: Delete, Text: text})
+		}
+		for _, text := range middleB {
+			lines = append(lines, Line{Op: Insert, Text: text})
+		}
+	}
+	for _, text := range x[len(x)-suffix:] {
+		lines = append(lines, Line{Op: Equal, Text: text})
+	}
+	return lines
+}
+
+// Count возвращает число добавленных и удаленных строк
+func Count(lines []Line) (inserted, deleted int) {
+	for _, line := range lines {
+		switch line.Op {
+		case Insert:
+			inserted++
+		case Delete:
+			deleted++
+		}
+	}
+	return inserted, deleted
+}
+
+// splitLines разбивает текст на строки
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	text = strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	return strings.Split(text, "\n")
+}
+
+// myers находит кратчайший путь правок от a к b. v[k] — самый дальний x на диагонали k = x - y.
+// Чтобы восстановить путь с конца, для каждого числа правок d сохраняется часть v с диагоналями
+// -d..d (остальные на шаге d не нужны). false — правок больше MaxEdits
+func myers(a, b []string) ([]Line, bool) {
+	n, m := len(a), len(b)
+	maxD := min(n+m, MaxEdits)
+	offset := maxD + 1 // диагонали -maxD-1..maxD+1
+	v := make([]int, 2*offset+1)
+
+	var trace [][]int
+	for d := 0; d <= maxD; d++ {
+		trace = append(trace, slices.Clone(v[offset-d:offset+d+1]))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // шаг вниз: вставка из b
+			} else {
+				x = v[offset+k-1] + 1 // шаг вправо: удаление из a
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// backtrack проходит найденный путь от конца к началу. В trace[d] диагональ k хранится по индексу k+d
+func backtrack(trace [][]int, a, b []string) []Line {
+	var lines []Line
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && v[k-1+d] < v[k+1+d]) {
+			prevK = k + 1
+		}
+		prevX := v[prevK+d]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			lines = append(lines, Line{Op: Equal, Text: a[x-1]})
+			x, y = x-1, y-1
+		}
+		if x == prevX {
+			lines = append(lines, Line{Op: Insert, Text: b[y-1]})
+		} else {
+			lines = append(lines, Line{Op: Delete, Text: a[x-1]})
+		}
+		x, y = prevX, prevY
+	}
+	// Без правок (d = 0) остаются только совпадающие строки в начале
+	for ; x > 0; x-- {
+		lines = append(lines, Line{Op: Equal, Text: a[x-1]})
+	}
+	slices.Reverse(lines)
+	return lines
+}
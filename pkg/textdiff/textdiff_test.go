@@ -0,0 +1,98 @@
+// pkg/textdiff/textdiff_test.go
+package textdiff_test
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"blog-backend/pkg/textdiff"
+)
+
+// eq, ins, del - строки ожидаемого результата
+func eq(text string) textdiff.Line  { return textdiff.Line{Op: textdiff.Equal, Text: text} }
+func ins(text string) textdiff.Line { return textdiff.Line{Op: textdiff.Insert, Text: text} }
+func del(text string) textdiff.Line { return textdiff.Line{Op: textdiff.Delete, Text: text} }
+
+// TestLines - построчное сравнение: пустые тексты, переводы строк и минимальный набор правок
+func TestLines(t *testing.T) {
+	cases := []struct {
+		name string
+		a, b string
+		want []textdiff.Line
+	}{
+		{"both empty", "", "", []textdiff.Line{}},
+		{"equal", "один\nдва\n", "один\nдва", []textdiff.Line{eq("один"), eq("два")}},
+		{"crlf equals lf", "один\r\nдва\r\n", "один\nдва\n", []textdiff.Line{eq("один"), eq("два")}},
+		{"from empty", "", "один\nдва", []textdiff.Line{ins("один"), ins("два")}},
+		{"to empty", "один\nдва", "", []textdiff.Line{del("один"), del("два")}},
+		{"pure insert", "a\nc", "a\nb\nc", []textdiff.Line{eq("a"), ins("b"), eq("c")}},
+		{"pure delete", "a\nb\nc", "a\nc", []textdiff.Line{eq("a"), del("b"), eq("c")}},
+		{"replace line", "a\nb\nc", "a\nx\nc", []textdiff.Line{eq("a"), del("b"), ins("x"), eq("c")}},
+		{
+			// Кратчайший путь: 3 правки, общие строки b, c и e сохраняются
+			"minimal edit script", "a\nb\nc\nd\ne", "b\nc\ne\nf",
+			[]textdiff.Line{del("a"), eq("b"), eq("c"), del("d"), eq("e"), ins("f")},
+		},
+		{
+			// Классический пример Майерса: ABCABBA → CBABAC за 5 правок
+			"myers example", "A\nB\nC\nA\nB\nB\nA", "C\nB\nA\nB\nA\nC",
+			[]textdiff.Line{del("A"), del("B"), eq("C"), ins("B"), eq("A"), eq("B"), del("B"), eq("A"), ins("C")},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := textdiff.Lines(tc.a, tc.b)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+// TestLinesReconstruct - из результата собираются обе версии, число правок не больше нужного
+func TestLinesReconstruct(t *testing.T) {
+	a := "заголовок\nпервый абзац\nвторой абзац\nкод\nвывод\nитог"
+	b := "заголовок\nновое вступление\nпервый абзац\nкод\nдругой вывод\nитог\nпостскриптум"
+
+	lines := textdiff.Lines(a, b)
+	var from, to []string
+	for _, line := range lines {
+		if line.Op != textdiff.Insert {
+			from = append(from, line.Text)
+		}
+		if line.Op != textdiff.Delete {
+			to = append(to, line.Text)
+		}
+	}
+	if strings.Join(from, "\n") != a || strings.Join(to, "\n") != b {
+		t.Fatalf("diff does not reconstruct inputs: %v", lines)
+	}
+	if inserted, deleted := textdiff.Count(lines); inserted != 3 || deleted != 2 {
+		t.Errorf("expected 3 inserted and 2 deleted lines, got %d and %d", inserted, deleted)
+	}
+}
+
+// TestLinesTooManyEdits - больше MaxEdits правок: середина заменяется целиком, общие края сохраняются
+func TestLinesTooManyEdits(t *testing.T) {
+	var a, b []string
+	for i := 0; i < textdiff.MaxEdits; i++ {
+		a = append(a, "old "+strconv.Itoa(i))
+		b = append(b, "new "+strconv.Itoa(i))
+	}
+	a = append([]string{"начало"}, append(a, "конец")...)
+	b = append([]string{"начало"}, append(b, "конец")...)
+
+	lines := textdiff.Lines(strings.Join(a, "\n"), strings.Join(b, "\n"))
+	if len(lines) != 2*textdiff.MaxEdits+2 {
+		t.Fatalf("expected %d lines, got %d", 2*textdiff.MaxEdits+2, len(lines))
+	}
+	if lines[0] != eq("начало") || lines[1] != del("old 0") || lines[textdiff.MaxEdits+1] != ins("new 0") ||
+		lines[len(lines)-1] != eq("конец") {
+		t.Errorf("unexpected fallback diff: %v ... %v", lines[:2], lines[len(lines)-2:])
+	}
+	if inserted, deleted := textdiff.Count(lines); inserted != textdiff.MaxEdits || deleted != textdiff.MaxEdits {
+		t.Errorf("expected %d inserted and deleted, got %d and %d", textdiff.MaxEdits, inserted, deleted)
+	}
+}
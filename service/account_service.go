@@ -0,0 +1,194 @@
+// service/account_service.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"blog-backend/internal/config"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+	"blog-backend/pkg/jwt"
+)
+
+// Ограничения профиля
+const (
+	minUsernameLength = 3
+	maxUsernameLength = 30  // как VARCHAR(30) в БД
+	maxBioLength      = 500 // символов, как VARCHAR(500) в БД
+	// Префикс имен обезличенных аккаунтов — занимать его нельзя
+	deletedUsernamePrefix = "deleted-"
+)
+
+// AccountService - изменение профиля, смена пароля и удаление аккаунта
+type AccountService struct {
+	userRepo     repository.UserRepository
+	tokens       *TokenService
+	verifier     *EmailVerificationService
+	avatars      *AvatarService // файлы аватара удаляются вместе с аккаунтом
+	deletionMode string         // Из .env: anonymize или delete
+}
+
+// Создаем сервис управления аккаунтом
+func NewAccountService(userRepo repository.UserRepository, tokens *TokenService, verifier *EmailVerificationService, avatars *AvatarService, cfg *config.Config) *AccountService {
+	// Анонимизация по умолчанию: чужие обсуждения не теряют комментарии
+	mode := cfg.AccountDeletionMode
+	if mode != model.AccountDeletionHard {
+		mode = model.AccountDeletionAnonymize
+	}
+
+	return &AccountService{
+		userRepo:     userRepo,
+		tokens:       tokens,
+		verifier:     verifier,
+		avatars:      avatars,
+		deletionMode: mode,
+	}
+}
+
+// UpdateProfile меняет username, email и bio. Новый email нужно подтвердить заново,
+// а для его смены требуется текущий пароль (иначе украденный токен дает захват аккаунта через сброс пароля)
+func (s *AccountService) UpdateProfile(ctx context.Context, userID int, req model.UpdateProfileRequest) (*model.User, error) {
+	user, err := loadUser(ctx, s.userRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Username
+	if req.Username != nil {
+		username := normalizeUsername(*req.Username)
+		if username != user.Username {
+			if err := validateUsername(username); err != nil {
+				return nil, err
+			}
+			// Смена регистра своего имени ("ivan" → "Ivan") — не конфликт
+			if err := checkUsernameAvailable(ctx, s.userRepo, username, user.ID); err != nil {
+				return nil, err
+			}
+			user.Username = username
+		}
+	}
+
+	// 2. Email
+	emailChanged := false
+	if req.Email != nil {
+		email, err := jwt.NormalizeEmail(*req.Email)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidProfile, err)
+		}
+		if email != user.Email {
+			if _, err := s.checkPassword(ctx, user, req.CurrentPassword); err != nil {
+				return nil, err
+			}
+			existing, err := s.userRepo.GetUserByEmail(ctx, email)
+			if err != nil {
+				return nil, err
+			}
+			if existing != nil {
+				return nil, ErrEmailTaken
+			}
+			user.Email = email
+			user.EmailVerifiedAt = nil
+			emailChanged = true
+		}
+	}
+
+	// 3. Bio
+	if req.Bio != nil {
+		bio := strings.TrimSpace(*req.Bio)
+		if utf8.RuneCountInString(bio) > maxBioLength {
+			return nil, fmt.Errorf("%w: bio must be at most %d characters", ErrInvalidProfile, maxBioLength)
+		}
+		user.Bio = bio
+	}
+
+	if err := s.userRepo.UpdateProfile(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update profile: %w", err)
+	}
+
+	// Ссылка уходит на новый адрес; ошибка отправки не отменяет изменение — письмо можно запросить повторно
+	if emailChanged {
+		if err := s.verifier.SendVerification(ctx, user); err != nil {
+			log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+		}
+	}
+
+	return user, nil
+}
+
+// ChangePassword меняет пароль по текущему паролю.
+// Все сессии завершаются (как при сбросе пароля): пароль могли сменить из-за утечки
+func (s *AccountService) ChangePassword(ctx context.Context, userID int, req model.ChangePasswordRequest) error {
+	user, err := loadUser(ctx, s.userRepo, userID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.checkPassword(ctx, user, req.CurrentPassword); err != nil {
+		return err
+	}
+	if err := jwt.ValidatePassword(req.NewPassword); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPassword, err)
+	}
+
+	passwordHash, err := jwt.HashPassword(req.NewPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := s.userRepo.UpdatePassword(ctx, userID, passwordHash); err != nil {
+		return err
+	}
+
+	// Отзыв по времени выдачи (с точностью до секунды) — новые токены только через повторный вход
+	return s.tokens.LogoutAll(ctx, userID)
+}
+
+// DeleteAccount удаляет аккаунт после подтверждения паролем.
+// Режим задается ACCOUNT_DELETION_MODE: анонимизация или полное удаление с постами и комментариями
+func (s *AccountService) DeleteAccount(ctx context.Context, userID int, password string) error {
+	user, err := loadUser(ctx, s.userRepo, userID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.checkPassword(ctx, user, password); err != nil {
+		return err
+	}
+
+	// Выданные access токены перестают действовать сразу
+	if err := s.tokens.LogoutAll(ctx, userID); err != nil {
+		return err
+	}
+
+	if s.deletionMode == model.AccountDeletionHard {
+		err = s.userRepo.DeleteUser(ctx, userID)
+	} else {
+		err = s.userRepo.AnonymizeUser(ctx, userID)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Аккаунт уже удален: оставшиеся файлы не повод возвращать ошибку
+	if err := s.avatars.DeleteFiles(ctx, userID); err != nil {
+		log.Printf("Failed to delete avatar files of user %d: %v", userID, err)
+	}
+	return nil
+}
+
+// checkPassword проверяет текущий пароль пользователя.
+// GetUserByID не читает password_hash, поэтому хеш загружается по email
+func (s *AccountService) checkPassword(ctx context.Context, user *model.User, password string) (*model.User, error) {
+	if password == "" {
+		return nil, ErrInvalidCurrentPassword
+	}
+	withHash, err := s.userRepo.GetUserByEmail(ctx, user.Email)
+	if err != nil {
+		return nil, err
+	}
+	if withHash == nil || withHash.ID != user.ID || !jwt.CheckPassword(password, withHash.PasswordHash) {
+		return nil, ErrInvalidCurrentPassword
+	}
+	return withHash, nil
+}
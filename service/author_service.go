@@ -0,0 +1,93 @@
+// service/author_service.go
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+)
+
+// AuthorService - публичные страницы авторов (доступны без входа)
+type AuthorService struct {
+	userRepo   repository.UserRepository
+	postRepo   repository.PostRepository
+	followRepo repository.FollowRepository
+}
+
+// Создаем сервис публичных профилей
+func NewAuthorService(userRepo repository.UserRepository, postRepo repository.PostRepository, followRepo repository.FollowRepository) *AuthorService {
+	return &AuthorService{
+		userRepo:   userRepo,
+		postRepo:   postRepo,
+		followRepo: followRepo,
+	}
+}
+
+// GetProfile возвращает публичный профиль автора по username
+func (s *AuthorService) GetProfile(ctx context.Context, username string) (*model.PublicProfile, error) {
+	user, err := s.findAuthor(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	postCount, err := s.postRepo.CountPostsByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	followerCount, err := s.followRepo.CountFollowers(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	followingCount, err := s.followRepo.CountFollowing(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Копируем только публичные поля: email и хеш пароля не попадают в ответ даже случайно
+	return &model.PublicProfile{
+		ID:        user.ID,
+		Username:  user.Username,
+		Bio:       user.Bio,
+		AvatarURL: user.AvatarURL,
+		PostCount: postCount,
+		JoinedAt:  user.CreatedAt,
+
+		AvatarSmallURL: user.AvatarSmallURL,
+
+		FollowerCount:  followerCount,
+		FollowingCount: followingCount,
+	}, nil
+}
+
+// ListPosts возвращает опубликованные посты автора с пагинацией + total
+func (s *AuthorService) ListPosts(ctx context.Context, username string, limit, offset int) ([]*model.Post, int, error) {
+	user, err := s.findAuthor(ctx, username)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	posts, err := s.postRepo.ListPostsByUser(ctx, user.ID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list posts: %w", err)
+	}
+	total, err := s.postRepo.CountPostsByUser(ctx, user.ID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count posts: %w", err)
+	}
+
+	return posts, total, nil
+}
+
+// findAuthor находит пользователя по username. Удаленные аккаунты не показываются
+func (s *AuthorService) findAuthor(ctx context.Context, username string) (*model.User, error) {
+	user, err := s.userRepo.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.DeletedAt != nil {
+		return nil, ErrAuthorNotFound
+	}
+	return user, nil
+}
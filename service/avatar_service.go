@@ -0,0 +1,128 @@
+// service/avatar_service.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"blog-backend/internal/config"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+	"blog-backend/pkg/blobstore"
+	"blog-backend/pkg/imaging"
+)
+
+// Параметры обработки аватаров
+const (
+	avatarSize        = 256 // основная копия для профиля
+	avatarSmallSize   = 64  // копия для списков и комментариев
+	avatarMinSide     = avatarSmallSize
+	avatarMaxPixels   = 40_000_000 // защита от "бомб": 40 Мп по заголовку файла
+	avatarJPEGQuality = 85
+	// 5 МБ по умолчанию, если cfg.AvatarMaxBytes <= 0
+	defaultAvatarMaxBytes = 5 << 20
+)
+
+// AvatarService - загрузка аватаров: проверка, обрезка до квадрата, уменьшение и сохранение
+type AvatarService struct {
+	userRepo repository.UserRepository
+	storage  blobstore.Storage
+	maxBytes int64
+}
+
+// Создаем сервис аватаров
+func NewAvatarService(userRepo repository.UserRepository, storage blobstore.Storage, cfg *config.Config) *AvatarService {
+	maxBytes := cfg.AvatarMaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultAvatarMaxBytes
+	}
+
+	return &AvatarService{
+		userRepo: userRepo,
+		storage:  storage,
+		maxBytes: maxBytes,
+	}
+}
+
+// MaxBytes - максимальный размер загружаемого файла
+func (s *AvatarService) MaxBytes() int64 {
+	return s.maxBytes
+}
+
+// Upload заменяет аватар пользователя. Формат определяется по содержимому файла,
+// сохраняются только перекодированные копии (исходный файл и его метаданные отбрасываются)
+func (s *AvatarService) Upload(ctx context.Context, userID int, data []byte) (*model.User, error) {
+	user, err := loadUser(ctx, s.userRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxBytes {
+		return nil, ErrAvatarTooLarge
+	}
+
+	img, err := imaging.Decode(data, avatarMaxPixels)
+	switch {
+	case errors.Is(err, imaging.ErrUnsupportedFormat):
+		return nil, ErrUnsupportedAvatarFormat
+	case err != nil:
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAvatar, err)
+	}
+	if bounds := img.Bounds(); min(bounds.Dx(), bounds.Dy()) < avatarMinSide {
+		return nil, fmt.Errorf("%w: image must be at least %dx%d", ErrInvalidAvatar, avatarMinSide, avatarMinSide)
+	}
+
+	// Ключи постоянные, а версия в ссылке сбрасывает кеш браузеров и CDN
+	version := strconv.FormatInt(time.Now().UnixNano(), 36)
+	urls := make([]string, 0, 2)
+	for _, size := range []int{avatarSize, avatarSmallSize} {
+		encoded, err := imaging.EncodeJPEG(imaging.SquareThumbnail(img, size), avatarJPEGQuality)
+		if err != nil {
+			return nil, err
+		}
+		key := avatarKey(userID, size)
+		if err := s.storage.Put(ctx, key, encoded, "image/jpeg"); err != nil {
+			return nil, fmt.Errorf("failed to store avatar: %w", err)
+		}
+		urls = append(urls, s.storage.URL(key)+"?v="+version)
+	}
+
+	if err := s.userRepo.UpdateAvatar(ctx, userID, &urls[0], &urls[1]); err != nil {
+		return nil, err
+	}
+	user.AvatarURL, user.AvatarSmallURL = &urls[0], &urls[1]
+	return user, nil
+}
+
+// Remove удаляет аватар пользователя
+func (s *AvatarService) Remove(ctx context.Context, userID int) (*model.User, error) {
+	user, err := loadUser(ctx, s.userRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdateAvatar(ctx, userID, nil, nil); err != nil {
+		return nil, err
+	}
+	if err := s.DeleteFiles(ctx, userID); err != nil {
+		return nil, err
+	}
+	user.AvatarURL, user.AvatarSmallURL = nil, nil
+	return user, nil
+}
+
+// DeleteFiles удаляет файлы аватара из хранилища (при удалении аккаунта)
+func (s *AvatarService) DeleteFiles(ctx context.Context, userID int) error {
+	for _, size := range []int{avatarSize, avatarSmallSize} {
+		if err := s.storage.Delete(ctx, avatarKey(userID, size)); err != nil {
+			return fmt.Errorf("failed to delete avatar: %w", err)
+		}
+	}
+	return nil
+}
+
+// avatarKey - ключ копии аватара в хранилище
+func avatarKey(userID, size int) string {
+	return fmt.Sprintf("avatars/%d/%d.jpg", userID, size)
+}
@@ -0,0 +1,115 @@
+// service/block_service.go
+package service
+
+import (
+	"context"
+
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+)
+
+// BlockService - блокировка и скрытие (mute) пользователей.
+// Блокировка запрещает комментировать посты и подписываться, скрытие убирает
+// посты и комментарии из ленты и списков комментариев того, кто скрыл
+type BlockService struct {
+	blockRepo  repository.BlockRepository
+	followRepo repository.FollowRepository
+	userRepo   repository.UserRepository
+}
+
+// Создаем сервис блокировок
+func NewBlockService(blockRepo repository.BlockRepository, followRepo repository.FollowRepository, userRepo repository.UserRepository) *BlockService {
+	return &BlockService{
+		blockRepo:  blockRepo,
+		followRepo: followRepo,
+		userRepo:   userRepo,
+	}
+}
+
+// Block блокирует пользователя и разрывает подписки в обе стороны
+func (s *BlockService) Block(ctx context.Context, userID, targetID int) error {
+	if err := s.checkTarget(ctx, userID, targetID); err != nil {
+		return err
+	}
+	if err := s.blockRepo.Block(ctx, userID, targetID); err != nil {
+		return err
+	}
+
+	if _, err := s.followRepo.Unfollow(ctx, targetID, userID); err != nil {
+		return err
+	}
+	if _, err := s.followRepo.Unfollow(ctx, userID, targetID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Unblock снимает блокировку (снятие несуществующей блокировки не ошибка)
+func (s *BlockService) Unblock(ctx context.Context, userID, targetID int) error {
+	_, err := s.blockRepo.Unblock(ctx, userID, targetID)
+	return err
+}
+
+// Mute скрывает посты и комментарии пользователя (подписка сохраняется)
+func (s *BlockService) Mute(ctx context.Context, userID, targetID int) error {
+	if err := s.checkTarget(ctx, userID, targetID); err != nil {
+		return err
+	}
+	return s.blockRepo.Mute(ctx, userID, targetID)
+}
+
+// Unmute снова показывает пользователя
+func (s *BlockService) Unmute(ctx context.Context, userID, targetID int) error {
+	_, err := s.blockRepo.Unmute(ctx, userID, targetID)
+	return err
+}
+
+// ListBlocked возвращает заблокированных пользователей
+func (s *BlockService) ListBlocked(ctx context.Context, userID int) ([]*model.RestrictedUser, error) {
+	users, err := s.blockRepo.ListBlocked(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return nonNilRestrictedUsers(users), nil
+}
+
+// ListMuted возвращает скрытых пользователей
+func (s *BlockService) ListMuted(ctx context.Context, userID int) ([]*model.RestrictedUser, error) {
+	users, err := s.blockRepo.ListMuted(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return nonNilRestrictedUsers(users), nil
+}
+
+// checkTarget - нельзя блокировать себя и несуществующих (удаленных) пользователей
+func (s *BlockService) checkTarget(ctx context.Context, userID, targetID int) error {
+	if userID == targetID {
+		return ErrCannotRestrictSelf
+	}
+	user, err := s.userRepo.GetUserByID(ctx, targetID)
+	if err != nil || user == nil || user.DeletedAt != nil {
+		return ErrAuthorNotFound
+	}
+	return nil
+}
+
+// nonNilRestrictedUsers - пустой список сериализуется как [], а не null
+func nonNilRestrictedUsers(users []*model.RestrictedUser) []*model.RestrictedUser {
+	if users == nil {
+		return []*model.RestrictedUser{}
+	}
+	return users
+}
+
+// checkNotBlocked возвращает ErrBlocked, если ownerID заблокировал userID
+func checkNotBlocked(ctx context.Context, blockRepo repository.BlockRepository, ownerID, userID int) error {
+	blocked, err := blockRepo.IsBlocked(ctx, ownerID, userID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrBlocked
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 
+	"blog-backend/internal/config"
 	"blog-backend/internal/model"
 	"blog-backend/internal/repository"
 )
@@ -13,30 +14,50 @@ type CommentService struct {
 	postRepo    repository.PostRepository
 	commentRepo repository.CommentRepository
 	userRepo    repository.UserRepository
+	blockRepo   repository.BlockRepository
+
+	requireVerifiedEmail bool // Из .env
 }
 
 func NewCommentService(
 	postRepo repository.PostRepository,
 	commentRepo repository.CommentRepository,
 	userRepo repository.UserRepository,
+	blockRepo repository.BlockRepository,
+	cfg *config.Config,
 ) *CommentService {
 	return &CommentService{
 		postRepo:    postRepo,
 		commentRepo: commentRepo,
 		userRepo:    userRepo,
+		blockRepo:   blockRepo,
+
+		requireVerifiedEmail: cfg.RequireVerifiedEmail,
 	}
 }
 
 // CreateComment создает комментарий с проверками
 func (s *CommentService) CreateComment(ctx context.Context, userID int, postID int, content string) (*model.Comment, error) {
 	// 1. Проверяем существование поста
-	if _, err := s.postRepo.GetPostByID(ctx, postID); err != nil {
+	post, err := s.postRepo.GetPostByID(ctx, postID)
+	if err != nil {
 		return nil, fmt.Errorf("post not found: %w", err)
 	}
 
-	// 2. Проверяем существование пользователя
-	if _, err := s.userRepo.GetUserByID(ctx, userID); err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
+	// 2. Проверяем существование пользователя и право комментировать
+	user, err := loadUser(ctx, s.userRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !HasPermission(user.Role, PermCommentCreate) {
+		return nil, fmt.Errorf("%w: role %q cannot comment", ErrPermissionDenied, user.Role)
+	}
+	if err := checkEmailVerified(user, s.requireVerifiedEmail); err != nil {
+		return nil, err
+	}
+	// Автор поста мог заблокировать комментатора
+	if err := checkNotBlocked(ctx, s.blockRepo, post.AuthorID, userID); err != nil {
+		return nil, err
 	}
 
 	// 3. Валидация контента
@@ -72,18 +93,46 @@ func (s *CommentService) CreateComment(ctx context.Context, userID int, postID i
 	return nil, fmt.Errorf("created comment not found")
 }
 
-// GetCommentsByPostID возвращает комментарии поста
-func (s *CommentService) GetCommentsByPostID(ctx context.Context, postID int) ([]*model.Comment, error) {
+// GetCommentsByPostID возвращает комментарии поста.
+// viewerID — текущий пользователь (0 = анонимный): комментарии скрытых им авторов не показываются
+func (s *CommentService) GetCommentsByPostID(ctx context.Context, postID, viewerID int) ([]*model.Comment, error) {
 	// Проверяем существование поста
 	if _, err := s.postRepo.GetPostByID(ctx, postID); err != nil {
 		return nil, fmt.Errorf("post not found: %w", err)
 	}
 
 	// Получаем комментарии
-	comments, err := s.commentRepo.GetByPostID(ctx, postID)
+	var comments []*model.Comment
+	var err error
+	if viewerID == 0 {
+		comments, err = s.commentRepo.GetByPostID(ctx, postID)
+	} else {
+		comments, err = s.commentRepo.GetVisibleByPostID(ctx, postID, viewerID)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get comments: %w", err)
 	}
 
 	return comments, nil
 }
+
+// DeleteComment удаляет комментарий (автор комментария или editor/admin)
+func (s *CommentService) DeleteComment(ctx context.Context, userID, postID, commentID int) error {
+	comment, err := s.commentRepo.GetByID(ctx, commentID)
+	if err != nil {
+		return fmt.Errorf("comment not found: %w", err)
+	}
+	if comment.PostID != postID {
+		return fmt.Errorf("comment not found: comment %d does not belong to post %d", commentID, postID)
+	}
+
+	user, err := loadUser(ctx, s.userRepo, userID)
+	if err != nil {
+		return err
+	}
+	if !CanModify(user, comment.AuthorID, PermCommentDeleteOwn, PermCommentDeleteAny) {
+		return fmt.Errorf("%w: can only delete own comments", ErrPermissionDenied)
+	}
+
+	return s.commentRepo.Delete(ctx, commentID)
+}
@@ -0,0 +1,465 @@
+// service/data_export_service.go
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"blog-backend/internal/config"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+	"blog-backend/pkg/jwt"
+	"blog-backend/pkg/mailer"
+)
+
+const (
+	// Назначение подписи ссылок на скачивание архива
+	dataExportPurpose = "data-export"
+
+	// Выгрузка в статусе pending дольше этого считается зависшей (например, после перезапуска)
+	exportJobTimeout = 10 * time.Minute
+
+	// Как часто удаляются архивы с истекшим сроком
+	exportCleanupInterval = time.Hour
+)
+
+// DataExportService - выгрузка персональных данных в ZIP архив (собирается в фоне)
+type DataExportService struct {
+	exportRepo  repository.DataExportRepository
+	userRepo    repository.UserRepository
+	postRepo    repository.PostRepository
+	commentRepo repository.CommentRepository
+	throttle    *LoginThrottleService // журнал неудачных входов
+	sessions    *SessionService       // успешные входы
+	mailer      mailer.Mailer
+	baseURL     string        // Из .env
+	dir         string        // Из .env
+	ttl         time.Duration // Из .env
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Создаем сервис выгрузки данных
+func NewDataExportService(
+	exportRepo repository.DataExportRepository,
+	userRepo repository.UserRepository,
+	postRepo repository.PostRepository,
+	commentRepo repository.CommentRepository,
+	throttle *LoginThrottleService,
+	sessions *SessionService,
+	m mailer.Mailer,
+	cfg *config.Config,
+) *DataExportService {
+	// 24 часа по умолчанию, если cfg.ExportTTL <= 0
+	ttl := cfg.ExportTTL
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	dir := cfg.ExportDir
+	if dir == "" {
+		dir = "tmp/exports"
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &DataExportService{
+		exportRepo:  exportRepo,
+		userRepo:    userRepo,
+		postRepo:    postRepo,
+		commentRepo: commentRepo,
+		throttle:    throttle,
+		sessions:    sessions,
+		mailer:      m,
+		baseURL:     strings.TrimSuffix(cfg.AppBaseURL, "/"),
+		dir:         dir,
+		ttl:         ttl,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Start создает каталог архивов и запускает периодическую очистку
+func (s *DataExportService) Start() error {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create export dir: %w", err)
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(exportCleanupInterval)
+		defer ticker.Stop()
+
+		s.cleanup()
+		for {
+			select {
+			case <-ticker.C:
+				s.cleanup()
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop прерывает сборку архивов и очистку (graceful shutdown)
+func (s *DataExportService) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// RequestExport ставит сборку архива в очередь. Одновременно — не больше одной выгрузки на пользователя
+func (s *DataExportService) RequestExport(ctx context.Context, userID int) (*model.DataExport, error) {
+	if _, err := loadUser(ctx, s.userRepo, userID); err != nil {
+		return nil, err
+	}
+
+	latest, err := s.exportRepo.GetLatestForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.Status == model.ExportStatusPending {
+		if time.Since(latest.CreatedAt) < exportJobTimeout {
+			return nil, ErrExportInProgress
+		}
+		// Зависшая выгрузка (например, после перезапуска) не должна блокировать новую
+		if err := s.exportRepo.MarkFailed(ctx, latest.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Параллельный запрос мог создать выгрузку после проверки — ее не пропустит хранилище
+	export := &model.DataExport{
+		UserID: userID,
+		Status: model.ExportStatusPending,
+	}
+	err = s.exportRepo.Create(ctx, export)
+	if errors.Is(err, repository.ErrExportPending) {
+		return nil, ErrExportInProgress
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Сборка не привязана к запросу: клиент получает 202 и не ждет архива
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run(export)
+	}()
+
+	return export, nil
+}
+
+// GetExport возвращает выгрузку пользователя и ссылку на скачивание (если архив готов)
+func (s *DataExportService) GetExport(ctx context.Context, userID, exportID int) (*model.DataExport, string, error) {
+	export, err := s.exportRepo.GetByID(ctx, exportID)
+	if err != nil {
+		return nil, "", err
+	}
+	// Чужая выгрузка неотличима от несуществующей
+	if export == nil || export.UserID != userID {
+		return nil, "", ErrExportNotFound
+	}
+
+	if export.Status == model.ExportStatusPending && time.Since(export.CreatedAt) >= exportJobTimeout {
+		export.Status = model.ExportStatusFailed
+	}
+	if export.Status != model.ExportStatusReady || export.ExpiresAt == nil || !time.Now().Before(*export.ExpiresAt) {
+		return export, "", nil
+	}
+	return export, s.downloadLink(export), nil
+}
+
+// OpenDownload проверяет ссылку и открывает архив. Закрыть файл должен вызывающий
+func (s *DataExportService) OpenDownload(ctx context.Context, token string) (*model.DataExport, *os.File, error) {
+	value, err := jwt.VerifySignedValue(dataExportPurpose, token)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidExportToken, err)
+	}
+	exportID, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, nil, ErrInvalidExportToken
+	}
+
+	export, err := s.exportRepo.GetByID(ctx, exportID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if export == nil || export.Status != model.ExportStatusReady ||
+		export.ExpiresAt == nil || !time.Now().Before(*export.ExpiresAt) {
+		return nil, nil, ErrInvalidExportToken
+	}
+
+	file, err := os.Open(export.FilePath)
+	if err != nil {
+		// Архив удален очисткой или вместе с аккаунтом
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidExportToken, err)
+	}
+	return export, file, nil
+}
+
+// run собирает архив и уведомляет пользователя письмом
+func (s *DataExportService) run(export *model.DataExport) {
+	ctx := s.ctx
+
+	user, path, size, err := s.build(ctx, export)
+	if err != nil {
+		log.Printf("Data export %d for user %d failed: %v", export.ID, export.UserID, err)
+		if err := s.exportRepo.MarkFailed(context.Background(), export.ID); err != nil {
+			log.Printf("Failed to mark data export %d failed: %v", export.ID, err)
+		}
+		return
+	}
+
+	expiresAt := time.Now().Add(s.ttl)
+	if err := s.exportRepo.MarkReady(ctx, export.ID, path, size, expiresAt); err != nil {
+		log.Printf("Failed to mark data export %d ready: %v", export.ID, err)
+		os.Remove(path)
+		return
+	}
+	export.Status = model.ExportStatusReady
+	export.ExpiresAt = &expiresAt
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Архив с вашими данными готов",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\n"+
+			"Архив с вашими данными можно скачать по ссылке (действует %v):\n%s\n\n"+
+			"Если вы не запрашивали выгрузку, смените пароль.\n",
+			user.Username, s.ttl, s.downloadLink(export)),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		log.Printf("Failed to send data export email to user %d: %v", user.ID, err)
+	}
+}
+
+// build записывает ZIP архив во временный файл и переименовывает его после успешной записи
+func (s *DataExportService) build(ctx context.Context, export *model.DataExport) (*model.User, string, int64, error) {
+	user, err := loadUser(ctx, s.userRepo, export.UserID)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	posts, err := s.postRepo.ListAllPostsByUser(ctx, user.ID)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	comments, err := s.commentRepo.GetByAuthorID(ctx, user.ID)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	attempts, err := s.throttle.ListFailedAttempts(ctx, model.LoginAttemptFilter{
+		Email: user.Email,
+		Limit: maxLoginAttemptsLimit,
+	})
+	if err != nil {
+		return nil, "", 0, err
+	}
+	sessions, err := s.sessions.ListHistory(ctx, user.ID, maxLoginAttemptsLimit)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return nil, "", 0, fmt.Errorf("failed to create export dir: %w", err)
+	}
+
+	// Случайный суффикс: имя файла нельзя угадать по ID выгрузки
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, "", 0, fmt.Errorf("failed to generate file name: %w", err)
+	}
+	path := filepath.Join(s.dir, fmt.Sprintf("export-%d-%s.zip", export.ID, hex.EncodeToString(suffix)))
+
+	tmp, err := os.CreateTemp(s.dir, "export-*.tmp")
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // после Rename файла уже нет
+
+	if err := writeExportArchive(tmp, user, posts, comments, sessions, attempts); err != nil {
+		tmp.Close()
+		return nil, "", 0, err
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		tmp.Close()
+		return nil, "", 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, "", 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, "", 0, fmt.Errorf("failed to save archive: %w", err)
+	}
+
+	return user, path, info.Size(), nil
+}
+
+// exportProfile профиль в архиве (без хеша пароля и секрета 2FA)
+type exportProfile struct {
+	ID              int        `json:"id"`
+	Email           string     `json:"email"`
+	Username        string     `json:"username"`
+	Role            string     `json:"role"`
+	Bio             string     `json:"bio"`
+	CreatedAt       time.Time  `json:"created_at"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	MFAEnabled      bool       `json:"mfa_enabled"`
+}
+
+// exportSession успешный вход (сессия) в истории входов архива
+type exportSession struct {
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	EndedAt    *time.Time `json:"ended_at"` // nil — сессия активна
+}
+
+// writeExportArchive пишет содержимое архива:
+// profile.json, posts.json, posts/<id>.md, comments.json,
+// login_history.json (успешные входы и неудачные попытки)
+func writeExportArchive(f *os.File, user *model.User, posts []*model.Post, comments []*model.Comment, sessions []*model.Session, attempts []*model.LoginAttempt) error {
+	zw := zip.NewWriter(f)
+
+	profile := exportProfile{
+		ID:              user.ID,
+		Email:           user.Email,
+		Username:        user.Username,
+		Role:            user.Role,
+		Bio:             user.Bio,
+		CreatedAt:       user.CreatedAt,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		MFAEnabled:      user.IsMFAEnabled(),
+	}
+
+	// nil-срезы пишем как [], а не null
+	if posts == nil {
+		posts = []*model.Post{}
+	}
+	if comments == nil {
+		comments = []*model.Comment{}
+	}
+	if attempts == nil {
+		attempts = []*model.LoginAttempt{}
+	}
+
+	logins := make([]exportSession, 0, len(sessions))
+	for _, session := range sessions {
+		endedAt := session.RevokedAt
+		if endedAt == nil && !time.Now().Before(session.ExpiresAt) {
+			endedAt = &session.ExpiresAt
+		}
+		logins = append(logins, exportSession{
+			IP:         session.IP,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			EndedAt:    endedAt,
+		})
+	}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", profile},
+		{"posts.json", posts},
+		{"comments.json", comments},
+		{"login_history.json", map[string]interface{}{"sessions": logins, "failed_attempts": attempts}},
+	}
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		if err != nil {
+			return fmt.Errorf("failed to add %s: %w", file.name, err)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(file.data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+
+	for _, post := range posts {
+		w, err := zw.Create(fmt.Sprintf("posts/%d.md", post.ID))
+		if err != nil {
+			return fmt.Errorf("failed to add post %d: %w", post.ID, err)
+		}
+		if _, err := w.Write([]byte(postMarkdown(post))); err != nil {
+			return fmt.Errorf("failed to write post %d: %w", post.ID, err)
+		}
+	}
+
+	return zw.Close()
+}
+
+// postMarkdown - пост в Markdown с метаданными во front matter
+func postMarkdown(post *model.Post) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "id: %d\n", post.ID)
+	fmt.Fprintf(&b, "title: %q\n", post.Title)
+	fmt.Fprintf(&b, "status: %s\n", post.Status)
+	fmt.Fprintf(&b, "created_at: %s\n", post.CreatedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "updated_at: %s\n", post.UpdatedAt.Format(time.RFC3339))
+	if post.PublishAt != nil {
+		fmt.Fprintf(&b, "publish_at: %s\n", post.PublishAt.Format(time.RFC3339))
+	}
+	b.WriteString("---\n\n")
+	fmt.Fprintf(&b, "# %s\n\n", post.Title)
+	b.WriteString(post.Content)
+	b.WriteString("\n")
+	return b.String()
+}
+
+// downloadLink подписанная ссылка на архив, действует до expires_at выгрузки
+func (s *DataExportService) downloadLink(export *model.DataExport) string {
+	token := jwt.SignValue(dataExportPurpose, strconv.Itoa(export.ID), time.Until(*export.ExpiresAt))
+	return fmt.Sprintf("%s/api/exports/download?token=%s", s.baseURL, url.QueryEscape(token))
+}
+
+// cleanup удаляет истекшие выгрузки и архивы старше EXPORT_TTL
+// (в том числе оставшиеся от удаленных аккаунтов)
+func (s *DataExportService) cleanup() {
+	paths, err := s.exportRepo.DeleteExpired(s.ctx, time.Now())
+	if err != nil {
+		log.Printf("Failed to delete expired data exports: %v", err)
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove export archive %s: %v", path, err)
+		}
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		log.Printf("Failed to read export dir: %v", err)
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() || time.Since(info.ModTime()) < s.ttl {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove export archive %s: %v", entry.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// service/email_verification_service.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"blog-backend/internal/config"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+	"blog-backend/pkg/jwt"
+	"blog-backend/pkg/mailer"
+)
+
+// Назначение подписи ссылок подтверждения email
+const verifyEmailPurpose = "verify-email"
+
+// Не чаще одного письма в минуту на пользователя
+const verificationResendCooldown = time.Minute
+
+// EmailVerificationService - подтверждение email по подписанной ссылке из письма
+type EmailVerificationService struct {
+	userRepo repository.UserRepository
+	mailer   mailer.Mailer
+	baseURL  string        // Из .env
+	ttl      time.Duration // Из .env
+
+	mu       sync.Mutex
+	lastSent map[int]time.Time // userID → время последнего письма
+}
+
+// Создаем сервис подтверждения email
+func NewEmailVerificationService(userRepo repository.UserRepository, m mailer.Mailer, cfg *config.Config) *EmailVerificationService {
+	// 48 часов по умолчанию, если cfg.EmailVerificationTTL <= 0
+	ttl := cfg.EmailVerificationTTL
+	if ttl <= 0 {
+		ttl = 48 * time.Hour
+	}
+
+	return &EmailVerificationService{
+		userRepo: userRepo,
+		mailer:   m,
+		baseURL:  strings.TrimSuffix(cfg.AppBaseURL, "/"),
+		ttl:      ttl,
+		lastSent: make(map[int]time.Time),
+	}
+}
+
+// SendVerification отправляет письмо со ссылкой подтверждения.
+// Ссылка привязана к текущему email: после его смены старые ссылки недействительны
+func (s *EmailVerificationService) SendVerification(ctx context.Context, user *model.User) error {
+	if !s.allowSend(user.ID) {
+		return ErrVerificationResendTooSoon
+	}
+
+	token := jwt.SignValue(verifyEmailPurpose, strconv.Itoa(user.ID)+":"+user.Email, s.ttl)
+	link := fmt.Sprintf("%s/api/verify-email?token=%s", s.baseURL, url.QueryEscape(token))
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Подтверждение email",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\n"+
+			"Для подтверждения адреса перейдите по ссылке (действует %v):\n%s\n\n"+
+			"Если вы не регистрировались в блоге, просто проигнорируйте это письмо.\n",
+			user.Username, s.ttl, link),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+	return nil
+}
+
+// Resend повторно отправляет письмо текущему пользователю
+func (s *EmailVerificationService) Resend(ctx context.Context, userID int) error {
+	user, err := loadUser(ctx, s.userRepo, userID)
+	if err != nil {
+		return err
+	}
+	if user.IsEmailVerified() {
+		return ErrEmailAlreadyVerified
+	}
+	return s.SendVerification(ctx, user)
+}
+
+// Verify подтверждает email по токену из ссылки
+func (s *EmailVerificationService) Verify(ctx context.Context, token string) (*model.User, error) {
+	value, err := jwt.VerifySignedValue(verifyEmailPurpose, token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidVerificationToken, err)
+	}
+
+	// value = "<userID>:<email>"
+	idStr, email, ok := strings.Cut(value, ":")
+	if !ok {
+		return nil, ErrInvalidVerificationToken
+	}
+	userID, err := strconv.Atoi(idStr)
+	if err != nil {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	verified, err := s.userRepo.MarkEmailVerified(ctx, userID, email)
+	if err != nil {
+		return nil, err
+	}
+	if !verified {
+		// Пользователь удален или сменил email после отправки ссылки
+		return nil, ErrInvalidVerificationToken
+	}
+
+	return loadUser(ctx, s.userRepo, userID)
+}
+
+// allowSend ограничивает частоту писем одному пользователю
+func (s *EmailVerificationService) allowSend(userID int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if last, ok := s.lastSent[userID]; ok && now.Sub(last) < verificationResendCooldown {
+		return false
+	}
+	s.lastSent[userID] = now
+
+	// Чистим устаревшие записи, чтобы карта не росла бесконечно
+	for id, last := range s.lastSent {
+		if now.Sub(last) >= verificationResendCooldown {
+			delete(s.lastSent, id)
+		}
+	}
+	return true
+}
+
+// checkEmailVerified применяет политику REQUIRE_VERIFIED_EMAIL
+func checkEmailVerified(user *model.User, required bool) error {
+	if required && !user.IsEmailVerified() {
+		return fmt.Errorf("%w: confirm your email first", ErrEmailNotVerified)
+	}
+	return nil
+}
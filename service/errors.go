@@ -0,0 +1,106 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Ошибки сервисного слоя (проверяются в handlers через errors.Is)
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+
+	// Сообщение совпадает с проверкой strings.Contains(err, "permission denied") в handlers
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrInvalidRole      = errors.New("invalid role")
+
+	// Сообщение совпадает с проверкой strings.Contains(err, "post not found") в handlers
+	ErrPostNotFound = errors.New("post not found")
+	ErrPostTooLarge = errors.New("post content is too long")
+
+	ErrInvalidSearchQuery = errors.New("invalid search query")
+
+	ErrInvalidTag  = errors.New("invalid tag")
+	ErrTagNotFound = errors.New("tag not found")
+	ErrTagExists   = errors.New("tag already exists")
+
+	ErrRevisionNotFound = errors.New("revision not found")
+	ErrInvalidRevision  = errors.New("invalid revision")
+
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+	ErrInvalidPassword   = errors.New("invalid password")
+
+	ErrInvalidVerificationToken  = errors.New("invalid or expired email verification token")
+	ErrEmailAlreadyVerified      = errors.New("email already verified")
+	ErrEmailNotVerified          = errors.New("email not verified")
+	ErrVerificationResendTooSoon = errors.New("verification email was sent recently")
+
+	ErrInvalidPersonalAccessToken  = errors.New("invalid personal access token request")
+	ErrPersonalAccessTokenNotFound = errors.New("personal access token not found")
+
+	ErrInvalidMFAToken     = errors.New("invalid or expired mfa token")
+	ErrInvalidMFACode      = errors.New("invalid mfa code")
+	ErrTooManyMFAAttempts  = errors.New("too many invalid mfa codes")
+	ErrMFAAlreadyEnabled   = errors.New("two-factor authentication already enabled")
+	ErrMFANotEnabled       = errors.New("two-factor authentication is not enabled")
+	ErrMFAEnrollmentNeeded = errors.New("start two-factor enrollment first")
+
+	ErrInvalidProfile         = errors.New("invalid profile")
+	ErrUsernameTaken          = errors.New("username already taken")
+	ErrInvalidUsername        = errors.New("invalid username")
+	ErrUsernameConfusable     = errors.New("username is too similar to an existing username")
+	ErrEmailTaken             = errors.New("email already exists")
+	ErrInvalidCurrentPassword = errors.New("current password is incorrect")
+
+	ErrAuthorNotFound = errors.New("author not found")
+
+	ErrCannotFollowSelf = errors.New("cannot follow yourself")
+	ErrInvalidCursor    = errors.New("invalid cursor")
+
+	ErrCannotRestrictSelf = errors.New("cannot block or mute yourself")
+	ErrBlocked            = errors.New("blocked by this user")
+
+	ErrExportInProgress   = errors.New("data export already in progress")
+	ErrExportNotFound     = errors.New("data export not found")
+	ErrInvalidExportToken = errors.New("invalid or expired download link")
+
+	ErrRegistrationClosed    = errors.New("registration is closed")
+	ErrInvitationRequired    = errors.New("registration requires an invitation")
+	ErrInvalidInvitation     = errors.New("invalid or expired invitation")
+	ErrEmailDomainNotAllowed = errors.New("email domain is not allowed")
+	ErrInvitationNotFound    = errors.New("invitation not found")
+	ErrInvalidInvitationData = errors.New("invalid invitation request")
+
+	ErrAvatarTooLarge          = errors.New("avatar file is too large")
+	ErrUnsupportedAvatarFormat = errors.New("avatar must be a JPEG, PNG or WebP image")
+	ErrInvalidAvatar           = errors.New("invalid avatar image")
+
+	ErrSessionNotFound = errors.New("session not found")
+
+	ErrInvalidOIDCState        = errors.New("invalid or expired sign-in state")
+	ErrOIDCLoginFailed         = errors.New("single sign-on failed")
+	ErrOIDCProviderUnavailable = errors.New("identity provider unavailable")
+	ErrOIDCEmailNotVerified    = errors.New("identity provider did not confirm the email address")
+	ErrOIDCAccountNotFound     = errors.New("no account for this email")
+)
+
+// MFARequiredError - пароль верный, но для входа нужен второй фактор.
+// Token обменивается на access токен вместе с кодом через MFAService.CompleteLogin
+type MFARequiredError struct {
+	Token     string
+	ExpiresIn time.Duration
+}
+
+func (e *MFARequiredError) Error() string {
+	return "two-factor authentication required"
+}
+
+// LoginLockedError - вход временно заблокирован после серии неудачных попыток
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry after %v", e.RetryAfter.Round(time.Second))
+}
@@ -0,0 +1,179 @@
+// service/follow_service.go
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+)
+
+// FollowService - подписки на авторов и персональная лента
+type FollowService struct {
+	followRepo repository.FollowRepository
+	blockRepo  repository.BlockRepository
+	userRepo   repository.UserRepository
+	postRepo   repository.PostRepository
+}
+
+// Создаем сервис подписок
+func NewFollowService(followRepo repository.FollowRepository, blockRepo repository.BlockRepository, userRepo repository.UserRepository, postRepo repository.PostRepository) *FollowService {
+	return &FollowService{
+		followRepo: followRepo,
+		blockRepo:  blockRepo,
+		userRepo:   userRepo,
+		postRepo:   postRepo,
+	}
+}
+
+// Follow подписывает пользователя на автора (повторная подписка не ошибка)
+func (s *FollowService) Follow(ctx context.Context, followerID, followeeID int) error {
+	if followerID == followeeID {
+		return ErrCannotFollowSelf
+	}
+	if _, err := s.findUser(ctx, followeeID); err != nil {
+		return err
+	}
+	// Заблокированный пользователь не может подписаться на того, кто его заблокировал
+	if err := checkNotBlocked(ctx, s.blockRepo, followeeID, followerID); err != nil {
+		return err
+	}
+	return s.followRepo.Follow(ctx, followerID, followeeID)
+}
+
+// Unfollow отменяет подписку (отписка от автора, на которого не подписан, не ошибка)
+func (s *FollowService) Unfollow(ctx context.Context, followerID, followeeID int) error {
+	if _, err := s.followRepo.Unfollow(ctx, followerID, followeeID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListFollowers возвращает подписчиков автора с пагинацией + total
+func (s *FollowService) ListFollowers(ctx context.Context, username string, limit, offset int) ([]*model.FollowUser, int, error) {
+	user, err := s.findUserByUsername(ctx, username)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	users, err := s.followRepo.ListFollowers(ctx, user.ID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.followRepo.CountFollowers(ctx, user.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return nonNilFollowUsers(users), total, nil
+}
+
+// ListFollowing возвращает авторов, на которых подписан пользователь, с пагинацией + total
+func (s *FollowService) ListFollowing(ctx context.Context, username string, limit, offset int) ([]*model.FollowUser, int, error) {
+	user, err := s.findUserByUsername(ctx, username)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	users, err := s.followRepo.ListFollowing(ctx, user.ID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.followRepo.CountFollowing(ctx, user.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return nonNilFollowUsers(users), total, nil
+}
+
+// Feed возвращает страницу ленты и курсор следующей страницы ("" — постов больше нет)
+func (s *FollowService) Feed(ctx context.Context, userID int, cursor string, limit int) ([]*model.Post, string, error) {
+	var after *model.FeedCursor
+	if cursor != "" {
+		decoded, err := decodeFeedCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		after = decoded
+	}
+
+	// Берем на один пост больше, чтобы узнать, есть ли следующая страница
+	posts, err := s.postRepo.ListFeed(ctx, userID, after, limit+1)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list feed: %w", err)
+	}
+
+	next := ""
+	if len(posts) > limit {
+		posts = posts[:limit]
+		last := posts[len(posts)-1]
+		next = encodeFeedCursor(model.FeedCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	if posts == nil {
+		posts = []*model.Post{}
+	}
+	return posts, next, nil
+}
+
+// findUser находит пользователя по ID. Удаленные аккаунты не показываются
+func (s *FollowService) findUser(ctx context.Context, id int) (*model.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.DeletedAt != nil {
+		return nil, ErrAuthorNotFound
+	}
+	return user, nil
+}
+
+// findUserByUsername находит пользователя по username. Удаленные аккаунты не показываются
+func (s *FollowService) findUserByUsername(ctx context.Context, username string) (*model.User, error) {
+	user, err := s.userRepo.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.DeletedAt != nil {
+		return nil, ErrAuthorNotFound
+	}
+	return user, nil
+}
+
+// nonNilFollowUsers - пустой список сериализуется как [], а не null
+func nonNilFollowUsers(users []*model.FollowUser) []*model.FollowUser {
+	if users == nil {
+		return []*model.FollowUser{}
+	}
+	return users
+}
+
+// encodeFeedCursor упаковывает позицию в непрозрачную для клиента строку
+func encodeFeedCursor(cursor model.FeedCursor) string {
+	raw := fmt.Sprintf("%d:%d", cursor.CreatedAt.UnixNano(), cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeFeedCursor разбирает курсор из query (ErrInvalidCursor при любой порче)
+func decodeFeedCursor(cursor string) (*model.FeedCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	postID, err := strconv.Atoi(id)
+	if err != nil || postID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &model.FeedCursor{CreatedAt: time.Unix(0, unixNano).UTC(), ID: postID}, nil
+}
@@ -0,0 +1,227 @@
+// service/identity.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"blog-backend/internal/repository"
+	"blog-backend/pkg/jwt"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// reservedUsernames - имена служебных страниц и ролей, которые нельзя занять
+// (сравниваются по скелету, поэтому "Admin", "adm1n" и "аdmin" с кириллической "а" тоже заняты)
+var reservedUsernames = []string{
+	"admin", "administrator", "root", "system", "support", "security", "moderator", "editor",
+	"staff", "api", "auth", "oauth", "oidc", "login", "logout", "register", "signup", "settings",
+	"profile", "feed", "posts", "comments", "users", "tags", "search", "help",
+	"noreply", "postmaster", "abuse", "webmaster", "null", "undefined",
+}
+
+// confusablePairs - буквы кириллицы и греческого, неотличимые от латинских (хотя бы в одном регистре:
+// имена сравниваются без учета регистра). Для обратной замены берется первая пара с этой латинской буквой
+var confusablePairs = [][2]rune{
+	{'а', 'a'}, {'в', 'b'}, {'с', 'c'}, {'ԁ', 'd'}, {'е', 'e'}, {'һ', 'h'}, {'н', 'h'}, {'і', 'i'},
+	{'ј', 'j'}, {'к', 'k'}, {'ӏ', 'l'}, {'м', 'm'}, {'о', 'o'}, {'р', 'p'}, {'ԛ', 'q'}, {'ѕ', 's'},
+	{'т', 't'}, {'у', 'y'}, {'х', 'x'}, {'ԝ', 'w'}, {'ё', 'e'}, {'ї', 'i'},
+	{'α', 'a'}, {'β', 'b'}, {'ε', 'e'}, {'η', 'n'}, {'ι', 'i'}, {'κ', 'k'}, {'μ', 'u'}, {'ν', 'v'},
+	{'ο', 'o'}, {'ρ', 'p'}, {'τ', 't'}, {'υ', 'u'}, {'χ', 'x'}, {'ζ', 'z'},
+}
+
+// skeletonFolds - символы, которые в скелете считаются одинаковыми (0 и o, 1, i и l)
+var skeletonFolds = map[rune]rune{'0': 'o', '1': 'l', 'i': 'l', 'ı': 'l'}
+
+// Таблицы замен, построенные из confusablePairs
+var (
+	toLatin       = map[rune]rune{}
+	latinToScript = map[*unicode.RangeTable]map[rune]rune{unicode.Cyrillic: {}, unicode.Greek: {}}
+	reservedSet   = map[string]bool{}
+)
+
+func init() {
+	for _, pair := range confusablePairs {
+		toLatin[pair[0]] = pair[1]
+		for script, table := range latinToScript {
+			if _, ok := table[pair[1]]; !ok && unicode.Is(script, pair[0]) {
+				table[pair[1]] = pair[0]
+			}
+		}
+	}
+	for _, name := range reservedUsernames {
+		reservedSet[usernameSkeleton(name)] = true
+	}
+}
+
+// lookupEmail приводит введенный адрес к виду, в котором он хранится (см. jwt.NormalizeEmail).
+// Некорректный адрес не ошибка: такого пользователя просто нет
+func lookupEmail(email string) string {
+	if normalized, err := jwt.NormalizeEmail(email); err == nil {
+		return normalized
+	}
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// normalizeUsername убирает пробелы по краям и приводит Unicode к NFC
+// ("й" из двух кодовых точек и из одной — одно и то же имя)
+func normalizeUsername(username string) string {
+	return norm.NFC.String(strings.TrimSpace(username))
+}
+
+// validateUsername проверяет имя: 3-30 символов, буквы одного алфавита, цифры и разделители
+// ".", "-", "_" (не подряд и не по краям), не служебное и не с префиксом обезличенных аккаунтов
+func validateUsername(username string) error {
+	length := utf8.RuneCountInString(username)
+	if length < minUsernameLength || length > maxUsernameLength {
+		return fmt.Errorf("%w: username must be %d-%d characters", ErrInvalidUsername, minUsernameLength, maxUsernameLength)
+	}
+
+	var script *unicode.RangeTable
+	separator := true // имя не может начинаться с разделителя
+	for _, r := range username {
+		switch {
+		case isUsernameSeparator(r):
+			if separator {
+				return fmt.Errorf("%w: username must start with a letter or digit and must not contain consecutive '.', '-' or '_'", ErrInvalidUsername)
+			}
+			separator = true
+			continue
+		case r >= '0' && r <= '9':
+		case unicode.IsLetter(r):
+			// Смесь алфавитов — основной способ подделать чужое имя ("аdmin" с кириллической "а")
+			letterScript := scriptOf(r)
+			if script != nil && letterScript != script {
+				return fmt.Errorf("%w: username must not mix letters of different alphabets", ErrInvalidUsername)
+			}
+			script = letterScript
+		default:
+			return fmt.Errorf("%w: username may contain only letters, digits, '.', '-' and '_'", ErrInvalidUsername)
+		}
+		separator = false
+	}
+	if separator {
+		return fmt.Errorf("%w: username must end with a letter or digit", ErrInvalidUsername)
+	}
+
+	if strings.HasPrefix(strings.ToLower(username), deletedUsernamePrefix) || reservedSet[usernameSkeleton(username)] {
+		return fmt.Errorf("%w: username is reserved", ErrInvalidUsername)
+	}
+	return nil
+}
+
+// checkUsernameAvailable проверяет, что имя не занято другим пользователем — без учета регистра
+// и с учетом двойников из другого алфавита ("асе" кириллицей и "ace" латиницей).
+// selfID — текущий владелец имени (0 при регистрации)
+func checkUsernameAvailable(ctx context.Context, userRepo repository.UserRepository, username string, selfID int) error {
+	existing, err := userRepo.GetUserByUsername(ctx, username)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	if existing != nil && existing.ID != selfID {
+		return ErrUsernameTaken
+	}
+
+	for _, spelling := range lookalikeSpellings(username) {
+		existing, err := userRepo.GetUserByUsername(ctx, spelling)
+		if err != nil {
+			return fmt.Errorf("database error: %w", err)
+		}
+		if existing != nil && existing.ID != selfID {
+			return ErrUsernameConfusable
+		}
+	}
+	return nil
+}
+
+// usernameSkeleton - вид имени без регистра, разделителей и похожих символов
+// (кириллица и греческий — в латиницу, 0 — в o, 1 и i — в l, "rn" — в m)
+func usernameSkeleton(username string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(username) {
+		if isUsernameSeparator(r) {
+			continue
+		}
+		if latin, ok := toLatin[r]; ok {
+			r = latin
+		}
+		if folded, ok := skeletonFolds[r]; ok {
+			r = folded
+		}
+		b.WriteRune(r)
+	}
+	return strings.NewReplacer("rn", "m", "vv", "w").Replace(b.String())
+}
+
+// lookalikeSpellings возвращает написания имени, которые выглядят так же, но набраны другим
+// алфавитом. Вариант есть, только если у каждой буквы есть двойник — иначе подделка видна
+func lookalikeSpellings(username string) []string {
+	lower := strings.ToLower(username)
+
+	// Сначала в латиницу (для имен на кириллице и греческом)
+	latin, ok := replaceLetters(lower, func(r rune) (rune, bool) {
+		if unicode.Is(unicode.Latin, r) {
+			return r, true
+		}
+		latin, ok := toLatin[r]
+		return latin, ok
+	})
+	if !ok {
+		return nil
+	}
+
+	var spellings []string
+	if latin != lower {
+		spellings = append(spellings, latin)
+	}
+	// Затем из латиницы в остальные алфавиты
+	for _, table := range latinToScript {
+		spelling, ok := replaceLetters(latin, func(r rune) (rune, bool) {
+			replacement, ok := table[r]
+			return replacement, ok
+		})
+		if ok && spelling != lower {
+			spellings = append(spellings, spelling)
+		}
+	}
+	return spellings
+}
+
+// replaceLetters заменяет каждую букву; false, если хотя бы для одной замены нет
+func replaceLetters(value string, replace func(rune) (rune, bool)) (string, bool) {
+	var b strings.Builder
+	for _, r := range value {
+		if unicode.IsLetter(r) {
+			replacement, ok := replace(r)
+			if !ok {
+				return "", false
+			}
+			r = replacement
+		}
+		b.WriteRune(r)
+	}
+	return b.String(), true
+}
+
+// scriptOf возвращает алфавит буквы
+func scriptOf(r rune) *unicode.RangeTable {
+	for _, script := range []*unicode.RangeTable{unicode.Latin, unicode.Cyrillic, unicode.Greek} {
+		if unicode.Is(script, r) {
+			return script
+		}
+	}
+	for _, script := range unicode.Scripts {
+		if unicode.Is(script, r) {
+			return script
+		}
+	}
+	return nil
+}
+
+// isUsernameSeparator - допустимые в имени разделители
+func isUsernameSeparator(r rune) bool {
+	return r == '.' || r == '-' || r == '_'
+}
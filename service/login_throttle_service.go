@@ -0,0 +1,195 @@
+// service/login_throttle_service.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"blog-backend/internal/config"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+)
+
+const (
+	// Счетчик неудач забывается через сутки без новых неудач
+	loginFailureMemory = 24 * time.Hour
+
+	defaultLoginAttemptsLimit = 50
+	maxLoginAttemptsLimit     = 500
+)
+
+// LoginThrottleService - защита входа от перебора паролей.
+// Неудачи считаются отдельно по email и по IP: после maxFailures подряд вход блокируется
+// на lockout, каждая следующая неудача удваивает блокировку (не больше maxLockout)
+type LoginThrottleService struct {
+	repo             repository.LoginAttemptRepository
+	maxFailures      int           // Из .env, на один email
+	maxFailuresPerIP int           // Из .env, на один IP по всем email
+	lockout          time.Duration // Из .env
+	maxLockout       time.Duration // Из .env
+}
+
+// Создаем сервис защиты от перебора
+func NewLoginThrottleService(repo repository.LoginAttemptRepository, cfg *config.Config) *LoginThrottleService {
+	s := &LoginThrottleService{
+		repo:             repo,
+		maxFailures:      cfg.LoginMaxFailures,
+		maxFailuresPerIP: cfg.LoginMaxFailuresPerIP,
+		lockout:          cfg.LoginLockout,
+		maxLockout:       cfg.LoginLockoutMax,
+	}
+
+	// Значения по умолчанию, если конфиг не заполнен (тесты)
+	if s.maxFailures <= 0 {
+		s.maxFailures = 5
+	}
+	if s.maxFailuresPerIP <= 0 {
+		s.maxFailuresPerIP = 20
+	}
+	if s.lockout <= 0 {
+		s.lockout = time.Minute
+	}
+	if s.maxLockout < s.lockout {
+		s.maxLockout = time.Hour
+	}
+	return s
+}
+
+// Check возвращает *LoginLockedError, если вход для email или IP временно заблокирован
+func (s *LoginThrottleService) Check(ctx context.Context, email, ip string) error {
+	now := time.Now()
+	var retryAfter time.Duration
+
+	for _, key := range s.keys(email, ip) {
+		throttle, err := s.repo.GetThrottle(ctx, key)
+		if err != nil {
+			return err
+		}
+		if throttle != nil && throttle.IsLocked(now) {
+			retryAfter = max(retryAfter, throttle.LockedUntil.Sub(now))
+		}
+	}
+
+	if retryAfter > 0 {
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// RecordFailure учитывает неудачный вход и пишет его в журнал.
+// Если неудача привела к блокировке, возвращает *LoginLockedError
+func (s *LoginThrottleService) RecordFailure(ctx context.Context, email, ip string, userID *int, reason string) error {
+	now := time.Now()
+
+	attempt := &model.LoginAttempt{
+		Email:  lookupEmail(email),
+		UserID: userID,
+		IP:     ip,
+		Reason: reason,
+	}
+	if err := s.repo.RecordAttempt(ctx, attempt); err != nil {
+		return err
+	}
+
+	var lockedFor time.Duration
+	limits := []int{s.maxFailures, s.maxFailuresPerIP}
+	for i, key := range s.keys(email, ip) {
+		failures, err := s.repo.RegisterFailure(ctx, key, now, now.Add(-loginFailureMemory))
+		if err != nil {
+			return err
+		}
+
+		duration := s.lockoutFor(failures, limits[i])
+		if duration <= 0 {
+			continue
+		}
+		if err := s.repo.LockUntil(ctx, key, now.Add(duration)); err != nil {
+			return err
+		}
+		lockedFor = max(lockedFor, duration)
+	}
+
+	if lockedFor > 0 {
+		return &LoginLockedError{RetryAfter: lockedFor}
+	}
+	return nil
+}
+
+// RecordIPRequest учитывает анонимный запрос, рассылающий письма (например, сброс пароля),
+// в том же счетчике IP, что и неудачные входы. Возвращает *LoginLockedError, если IP заблокирован
+func (s *LoginThrottleService) RecordIPRequest(ctx context.Context, ip string) error {
+	if ip == "" {
+		return nil
+	}
+	now := time.Now()
+	key := ipThrottleKey(ip)
+
+	throttle, err := s.repo.GetThrottle(ctx, key)
+	if err != nil {
+		return err
+	}
+	if throttle != nil && throttle.IsLocked(now) {
+		return &LoginLockedError{RetryAfter: throttle.LockedUntil.Sub(now)}
+	}
+
+	failures, err := s.repo.RegisterFailure(ctx, key, now, now.Add(-loginFailureMemory))
+	if err != nil {
+		return err
+	}
+	if duration := s.lockoutFor(failures, s.maxFailuresPerIP); duration > 0 {
+		return s.repo.LockUntil(ctx, key, now.Add(duration))
+	}
+	return nil
+}
+
+// RecordSuccess сбрасывает счетчик email после успешного входа.
+// Счетчик IP не сбрасывается: иначе вход в свой аккаунт обнулял бы перебор чужих
+func (s *LoginThrottleService) RecordSuccess(ctx context.Context, email string) error {
+	return s.repo.ResetThrottle(ctx, emailThrottleKey(email))
+}
+
+// ListFailedAttempts возвращает журнал неудачных входов (для администраторов)
+func (s *LoginThrottleService) ListFailedAttempts(ctx context.Context, filter model.LoginAttemptFilter) ([]*model.LoginAttempt, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultLoginAttemptsLimit
+	}
+	filter.Limit = min(filter.Limit, maxLoginAttemptsLimit)
+	filter.Email = lookupEmail(filter.Email)
+
+	attempts, err := s.repo.ListAttempts(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list login attempts: %w", err)
+	}
+	return attempts, nil
+}
+
+// lockoutFor вычисляет блокировку после failures неудач: lockout, 2×lockout, 4×lockout...
+func (s *LoginThrottleService) lockoutFor(failures, limit int) time.Duration {
+	if failures < limit {
+		return 0
+	}
+
+	duration := s.lockout
+	for i := limit; i < failures && duration < s.maxLockout; i++ {
+		duration *= 2
+	}
+	return min(duration, s.maxLockout)
+}
+
+// keys возвращает ключи счетчиков: по email и по IP (если известен)
+func (s *LoginThrottleService) keys(email, ip string) []string {
+	keys := []string{emailThrottleKey(email)}
+	if ip != "" {
+		keys = append(keys, ipThrottleKey(ip))
+	}
+	return keys
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+func emailThrottleKey(email string) string {
+	return "email:" + lookupEmail(email)
+}
@@ -0,0 +1,332 @@
+// service/mfa_service.go
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"blog-backend/internal/config"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+	"blog-backend/pkg/jwt"
+	"blog-backend/pkg/secretbox"
+	"blog-backend/pkg/totp"
+)
+
+const (
+	// Назначение подписи токена "пароль проверен, ждем код"
+	mfaPendingPurpose = "mfa-pending"
+	mfaPendingTTL     = 5 * time.Minute
+
+	// После 5 неверных кодов подряд ввод блокируется на mfaPendingTTL
+	maxMFAFailures = 5
+
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10 // символов base32 (50 бит), выводится как xxxxx-xxxxx
+)
+
+// mfaFailures - счетчик неверных кодов пользователя
+type mfaFailures struct {
+	count int
+	since time.Time
+}
+
+// MFAService - двухфакторная аутентификация по TOTP (RFC 6238) с кодами восстановления
+type MFAService struct {
+	userRepo     repository.UserRepository
+	recoveryRepo repository.RecoveryCodeRepository
+	tokens       *TokenService
+	issuer       string         // Из .env, отображается в приложении-аутентификаторе
+	secrets      *secretbox.Box // шифрование секретов TOTP в БД (ключ из .env)
+
+	mu       sync.Mutex
+	failures map[int]*mfaFailures // userID → неверные попытки
+}
+
+// Создаем сервис двухфакторной аутентификации
+func NewMFAService(
+	userRepo repository.UserRepository,
+	recoveryRepo repository.RecoveryCodeRepository,
+	tokens *TokenService,
+	cfg *config.Config,
+) *MFAService {
+	issuer := cfg.MFAIssuer
+	if issuer == "" {
+		issuer = "Blog"
+	}
+	// Ключ проверяется при загрузке конфигурации
+	secrets, err := secretbox.New(cfg.MFAEncryptionKey)
+	if err != nil {
+		panic("MFA_ENCRYPTION_KEY: " + err.Error())
+	}
+
+	return &MFAService{
+		userRepo:     userRepo,
+		recoveryRepo: recoveryRepo,
+		tokens:       tokens,
+		issuer:       issuer,
+		secrets:      secrets,
+		failures:     make(map[int]*mfaFailures),
+	}
+}
+
+// Challenge выдает короткоживущий токен первого шага входа (пароль уже проверен)
+func (s *MFAService) Challenge(user *model.User) *MFARequiredError {
+	return &MFARequiredError{
+		Token:     jwt.SignValue(mfaPendingPurpose, strconv.Itoa(user.ID), mfaPendingTTL),
+		ExpiresIn: mfaPendingTTL,
+	}
+}
+
+// CompleteLogin обменивает токен первого шага и код на пару токенов
+func (s *MFAService) CompleteLogin(ctx context.Context, mfaToken, code string) (*model.User, *model.TokenPair, error) {
+	value, err := jwt.VerifySignedValue(mfaPendingPurpose, mfaToken)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidMFAToken, err)
+	}
+	userID, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, nil, ErrInvalidMFAToken
+	}
+
+	user, err := loadUser(ctx, s.userRepo, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 2FA выключили, пока пользователь вводил код — начинаем вход заново
+	if !user.IsMFAEnabled() {
+		return nil, nil, ErrInvalidMFAToken
+	}
+
+	if err := s.verifyCode(ctx, user, code, true); err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := s.tokens.IssueTokens(ctx, user)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	return user, tokens, nil
+}
+
+// BeginEnrollment создает новый секрет. 2FA включится после ConfirmEnrollment
+func (s *MFAService) BeginEnrollment(ctx context.Context, userID int) (*model.TOTPEnrollment, error) {
+	user, err := loadUser(ctx, s.userRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsMFAEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	// В БД секрет хранится зашифрованным, открытым — только в ответе для приложения
+	sealed, err := s.secrets.Seal(secret, strconv.Itoa(userID))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SetTOTPSecret(ctx, userID, sealed); err != nil {
+		return nil, err
+	}
+
+	return &model.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmEnrollment включает 2FA по первому коду из приложения и выдает коды восстановления
+func (s *MFAService) ConfirmEnrollment(ctx context.Context, userID int, code string) ([]string, error) {
+	user, err := loadUser(ctx, s.userRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsMFAEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrMFAEnrollmentNeeded
+	}
+
+	// Только код из приложения: так проверяем, что секрет действительно сохранен
+	if err := s.verifyCode(ctx, user, code, false); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.EnableTOTP(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return s.replaceRecoveryCodes(ctx, userID)
+}
+
+// Disable выключает 2FA (требуется действующий код)
+func (s *MFAService) Disable(ctx context.Context, userID int, code string) error {
+	user, err := loadUser(ctx, s.userRepo, userID)
+	if err != nil {
+		return err
+	}
+	if !user.IsMFAEnabled() {
+		return ErrMFANotEnabled
+	}
+
+	if err := s.verifyCode(ctx, user, code, true); err != nil {
+		return err
+	}
+	if err := s.userRepo.DisableTOTP(ctx, userID); err != nil {
+		return err
+	}
+	return s.recoveryRepo.DeleteForUser(ctx, userID)
+}
+
+// RegenerateRecoveryCodes заменяет коды восстановления новыми (старые перестают работать)
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error) {
+	user, err := loadUser(ctx, s.userRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsMFAEnabled() {
+		return nil, ErrMFANotEnabled
+	}
+
+	if err := s.verifyCode(ctx, user, code, false); err != nil {
+		return nil, err
+	}
+	return s.replaceRecoveryCodes(ctx, userID)
+}
+
+// totpSecret расшифровывает секрет пользователя (ID пользователя привязан к шифротексту).
+// Секрет, сохраненный открытым текстом до включения шифрования, сразу перезаписывается зашифрованным
+func (s *MFAService) totpSecret(ctx context.Context, user *model.User) (string, error) {
+	binding := strconv.Itoa(user.ID)
+	if secretbox.IsSealed(user.TOTPSecret) {
+		return s.secrets.Open(user.TOTPSecret, binding)
+	}
+
+	secret := user.TOTPSecret
+	sealed, err := s.secrets.Seal(secret, binding)
+	if err != nil {
+		return "", err
+	}
+	if err := s.userRepo.ReplaceTOTPSecret(ctx, user.ID, secret, sealed); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// verifyCode проверяет код TOTP (однократно) или, если разрешено, код восстановления
+func (s *MFAService) verifyCode(ctx context.Context, user *model.User, code string, allowRecovery bool) error {
+	if !s.allowAttempt(user.ID) {
+		return ErrTooManyMFAAttempts
+	}
+
+	secret, err := s.totpSecret(ctx, user)
+	if err != nil {
+		return err
+	}
+	if step, ok := totp.Validate(secret, code, time.Now()); ok {
+		fresh, err := s.userRepo.UseTOTPStep(ctx, user.ID, step)
+		if err != nil {
+			return err
+		}
+		if fresh {
+			s.resetAttempts(user.ID)
+			return nil
+		}
+		// Код уже был использован — считаем неверным
+	} else if allowRecovery {
+		used, err := s.recoveryRepo.Consume(ctx, user.ID, hashRecoveryCode(code))
+		if err != nil {
+			return err
+		}
+		if used {
+			s.resetAttempts(user.ID)
+			return nil
+		}
+	}
+
+	s.recordFailure(user.ID)
+	return ErrInvalidMFACode
+}
+
+// replaceRecoveryCodes генерирует новые коды и сохраняет их хеши
+func (s *MFAService) replaceRecoveryCodes(ctx context.Context, userID int) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		hashes[i] = hashRecoveryCode(code)
+	}
+
+	if err := s.recoveryRepo.ReplaceForUser(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// allowAttempt проверяет, не исчерпан ли лимит неверных кодов
+func (s *MFAService) allowAttempt(userID int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.failures[userID]
+	if !ok {
+		return true
+	}
+	if time.Since(f.since) > mfaPendingTTL {
+		delete(s.failures, userID)
+		return true
+	}
+	return f.count < maxMFAFailures
+}
+
+// recordFailure увеличивает счетчик неверных кодов
+func (s *MFAService) recordFailure(userID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.failures[userID]
+	if !ok || time.Since(f.since) > mfaPendingTTL {
+		f = &mfaFailures{since: time.Now()}
+		s.failures[userID] = f
+	}
+	f.count++
+}
+
+// resetAttempts сбрасывает счетчик после верного кода
+func (s *MFAService) resetAttempts(userID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.failures, userID)
+}
+
+// generateRecoveryCode создает код вида "k3m9x-p2q7w"
+func generateRecoveryCode() (string, error) {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz234567"
+
+	b := make([]byte, recoveryCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate recovery code: %w", err)
+	}
+	for i := range b {
+		b[i] = alphabet[int(b[i])%len(alphabet)] // 256 кратно 32 — без смещения
+	}
+	return string(b[:recoveryCodeLength/2]) + "-" + string(b[recoveryCodeLength/2:]), nil
+}
+
+// hashRecoveryCode нормализует код (регистр, дефисы, пробелы) и хеширует его
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return jwt.HashOpaqueToken(normalized)
+}
@@ -0,0 +1,272 @@
+// service/oidc_service.go
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"blog-backend/internal/config"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+	"blog-backend/pkg/jwt"
+	"blog-backend/pkg/oidc"
+)
+
+// Параметры входа через OpenID Connect
+const (
+	// Сколько действует state: от перехода на страницу IdP до возврата в callback
+	oidcStateTTL = 10 * time.Minute
+	// Назначения подписей: state подписывается, nonce и code_verifier выводятся из flowID
+	oidcStatePurpose    = "oidc-state"
+	oidcNoncePurpose    = "oidc-nonce"
+	oidcVerifierPurpose = "oidc-verifier"
+	// Сколько вариантов username с числовым суффиксом пробовать при совпадении
+	oidcUsernameAttempts = 20
+	// Имя, если из данных IdP не получилось допустимого
+	oidcFallbackUsername = "user"
+)
+
+// OIDCService - вход через внешний IdP (authorization code + PKCE).
+// Пользователь находится по подтвержденному IdP email или создается при первом входе
+type OIDCService struct {
+	provider      *oidc.Provider
+	flows         repository.OIDCFlowRepository
+	userRepo      repository.UserRepository
+	tokens        *TokenService
+	mfa           *MFAService
+	policy        *RegistrationService
+	autoProvision bool
+}
+
+// Создаем сервис входа через OIDC
+func NewOIDCService(
+	provider *oidc.Provider,
+	flows repository.OIDCFlowRepository,
+	userRepo repository.UserRepository,
+	tokens *TokenService,
+	mfa *MFAService,
+	policy *RegistrationService,
+	cfg *config.Config,
+) *OIDCService {
+	return &OIDCService{
+		provider:      provider,
+		flows:         flows,
+		userRepo:      userRepo,
+		tokens:        tokens,
+		mfa:           mfa,
+		policy:        policy,
+		autoProvision: cfg.OIDCAutoProvision,
+	}
+}
+
+// FlowTTL - сколько действует начатый вход (срок cookie с flowID)
+func (s *OIDCService) FlowTTL() time.Duration {
+	return oidcStateTTL
+}
+
+// LoginURL начинает вход: возвращает адрес страницы входа IdP и flowID, который handler
+// кладет в cookie браузера. state подписан секретом приложения, nonce и code_verifier выводятся
+// из flowID HMAC и в браузер не попадают. Callback пройдет только в том же браузере (cookie)
+// и только один раз (вход сохраняется на сервере и удаляется при завершении)
+func (s *OIDCService) LoginURL(ctx context.Context) (authURL, flowID string, err error) {
+	flowID, err = jwt.GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	state := jwt.SignValue(oidcStatePurpose, flowID, oidcStateTTL)
+	authURL, err = s.provider.AuthCodeURL(ctx, state,
+		jwt.DeriveValue(oidcNoncePurpose, flowID),
+		jwt.DeriveValue(oidcVerifierPurpose, flowID))
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrOIDCProviderUnavailable, err)
+	}
+	if err := s.flows.Create(ctx, jwt.HashOpaqueToken(flowID), time.Now().Add(oidcStateTTL)); err != nil {
+		return "", "", err
+	}
+	return authURL, flowID, nil
+}
+
+// Callback завершает вход: меняет код на id_token, находит или создает пользователя и выдает токены.
+// cookieFlowID - flowID из cookie браузера, начавшего вход: без него чужие code и state
+// (перехваченные или подсунутые жертве) не принимаются.
+// При включенной 2FA возвращает *MFARequiredError, как обычный вход по паролю
+func (s *OIDCService) Callback(ctx context.Context, code, state, cookieFlowID string) (*model.User, *model.TokenPair, error) {
+	flowID, err := jwt.VerifySignedValue(oidcStatePurpose, state)
+	if err != nil {
+		return nil, nil, ErrInvalidOIDCState
+	}
+	if subtle.ConstantTimeCompare([]byte(flowID), []byte(cookieFlowID)) != 1 {
+		return nil, nil, fmt.Errorf("%w: login was started in another browser", ErrInvalidOIDCState)
+	}
+	// Вход одноразовый: повторный callback с теми же state и cookie отклоняется
+	consumed, err := s.flows.Consume(ctx, jwt.HashOpaqueToken(flowID))
+	if err != nil {
+		return nil, nil, err
+	}
+	if !consumed {
+		return nil, nil, fmt.Errorf("%w: login already completed or expired", ErrInvalidOIDCState)
+	}
+
+	identity, err := s.provider.Exchange(ctx, code, jwt.DeriveValue(oidcVerifierPurpose, flowID))
+	if errors.Is(err, oidc.ErrProviderUnavailable) {
+		return nil, nil, fmt.Errorf("%w: %v", ErrOIDCProviderUnavailable, err)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+	// nonce связывает id_token с этим входом (защита от подстановки чужого токена)
+	if identity.Nonce != jwt.DeriveValue(oidcNoncePurpose, flowID) {
+		return nil, nil, fmt.Errorf("%w: nonce mismatch", ErrOIDCLoginFailed)
+	}
+	// Связывать аккаунты можно только по адресу, который IdP проверил сам
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, nil, ErrOIDCEmailNotVerified
+	}
+	email, err := jwt.NormalizeEmail(identity.Email)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+	identity.Email = email
+
+	user, err := s.userRepo.GetUserByEmail(ctx, identity.Email)
+	if err != nil {
+		return nil, nil, fmt.Errorf("database error: %w", err)
+	}
+	if user == nil {
+		if user, err = s.provision(ctx, identity); err != nil {
+			return nil, nil, err
+		}
+	} else if !user.IsEmailVerified() {
+		// IdP подтвердил владение адресом — ссылка из письма больше не нужна
+		if _, err := s.userRepo.MarkEmailVerified(ctx, user.ID, user.Email); err != nil {
+			return nil, nil, err
+		}
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	log.Printf("🔑 OIDC login: user=%d subject=%s", user.ID, identity.Subject)
+
+	if user.IsMFAEnabled() {
+		return user, nil, s.mfa.Challenge(user)
+	}
+
+	tokens, err := s.tokens.IssueTokens(ctx, user)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	return user, tokens, nil
+}
+
+// provision создает пользователя при первом входе через IdP.
+// Пароль случайный и никому не известен: задать свой можно через сброс пароля
+func (s *OIDCService) provision(ctx context.Context, identity *oidc.Identity) (*model.User, error) {
+	if !s.autoProvision {
+		return nil, ErrOIDCAccountNotFound
+	}
+	if err := s.policy.CheckDomain(identity.Email); err != nil {
+		return nil, err
+	}
+
+	username, err := s.availableUsername(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+	password, err := jwt.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	passwordHash, err := jwt.HashPassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user, err := s.userRepo.CreateUser(ctx, identity.Email, username, passwordHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	if _, err := s.userRepo.MarkEmailVerified(ctx, user.ID, user.Email); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+
+	log.Printf("👤 OIDC user provisioned: user=%d subject=%s", user.ID, identity.Subject)
+	return user, nil
+}
+
+// availableUsername подбирает свободный username из preferred_username, имени или email
+// по тем же правилам, что и при регистрации
+func (s *OIDCService) availableUsername(ctx context.Context, identity *oidc.Identity) (string, error) {
+	base := oidcFallbackUsername
+	localPart, _, _ := strings.Cut(identity.Email, "@")
+	for _, candidate := range []string{identity.PreferredUsername, identity.Name, localPart} {
+		if candidate = sanitizeUsername(candidate); validateUsername(candidate) == nil {
+			base = candidate
+			break
+		}
+	}
+
+	for attempt := 1; attempt <= oidcUsernameAttempts; attempt++ {
+		username := base
+		if attempt > 1 {
+			username = withUsernameSuffix(base, strconv.Itoa(attempt))
+		}
+		err := checkUsernameAvailable(ctx, s.userRepo, username, 0)
+		if err == nil {
+			return username, nil
+		}
+		if !errors.Is(err, ErrUsernameTaken) && !errors.Is(err, ErrUsernameConfusable) {
+			return "", err
+		}
+	}
+
+	// Все простые варианты заняты — добавляем случайный хвост
+	random, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return withUsernameSuffix(base, fmt.Sprintf("_%06d", random.Int64())), nil
+}
+
+// withUsernameSuffix дописывает суффикс, укорачивая имя до допустимой длины
+func withUsernameSuffix(base, suffix string) string {
+	runes := []rune(base)
+	base = string(runes[:min(len(runes), maxUsernameLength-len(suffix))])
+	return strings.TrimRight(base, "_-.") + suffix
+}
+
+// sanitizeUsername приводит произвольное имя к допустимому виду: оставляет буквы, цифры
+// и одиночные разделители ("_", "-", "."), пробелы заменяет на "_" и обрезает до допустимой длины
+func sanitizeUsername(value string) string {
+	var b strings.Builder
+	separator := true
+	for _, r := range normalizeUsername(value) {
+		switch {
+		case unicode.IsLetter(r), r >= '0' && r <= '9':
+			b.WriteRune(r)
+			separator = false
+		case isUsernameSeparator(r), unicode.IsSpace(r):
+			if !separator {
+				if unicode.IsSpace(r) {
+					r = '_'
+				}
+				b.WriteRune(r)
+			}
+			separator = true
+		}
+	}
+	username := []rune(b.String())
+	username = username[:min(len(username), maxUsernameLength)]
+	return strings.TrimRight(string(username), "_-.")
+}
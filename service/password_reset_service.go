@@ -0,0 +1,198 @@
+// service/password_reset_service.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"blog-backend/internal/config"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+	"blog-backend/pkg/jwt"
+	"blog-backend/pkg/mailer"
+)
+
+// PasswordResetService - сброс забытого пароля через одноразовую ссылку в письме
+type PasswordResetService struct {
+	userRepo  repository.UserRepository
+	resetRepo repository.PasswordResetRepository
+	tokens    *TokenService
+	throttle  *LoginThrottleService // лимит запросов с одного IP (общий счетчик с неудачными входами)
+	mailer    mailer.Mailer
+	baseURL   string        // Из .env
+	resetTTL  time.Duration // Из .env
+
+	wg sync.WaitGroup // запросы сброса, обрабатываемые в фоне
+
+	mu       sync.Mutex
+	lastSent map[int]time.Time // userID → время последнего письма
+}
+
+const (
+	// requestResetTimeout - время на поиск пользователя и отправку письма в фоне
+	requestResetTimeout = 30 * time.Second
+
+	// Не чаще одного письма в минуту на пользователя
+	passwordResetCooldown = time.Minute
+)
+
+// Создаем сервис сброса пароля
+func NewPasswordResetService(
+	userRepo repository.UserRepository,
+	resetRepo repository.PasswordResetRepository,
+	tokens *TokenService,
+	throttle *LoginThrottleService,
+	m mailer.Mailer,
+	cfg *config.Config,
+) *PasswordResetService {
+	// 1 час по умолчанию, если cfg.PasswordResetTTL <= 0
+	resetTTL := cfg.PasswordResetTTL
+	if resetTTL <= 0 {
+		resetTTL = time.Hour
+	}
+
+	return &PasswordResetService{
+		userRepo:  userRepo,
+		resetRepo: resetRepo,
+		tokens:    tokens,
+		throttle:  throttle,
+		mailer:    m,
+		baseURL:   strings.TrimSuffix(cfg.AppBaseURL, "/"),
+		resetTTL:  resetTTL,
+		lastSent:  make(map[int]time.Time),
+	}
+}
+
+// RequestReset отправляет письмо со ссылкой для сброса пароля. Запрос обрабатывается в фоне:
+// ни ответ, ни время ответа не зависят от того, есть ли аккаунт с таким email (защита от перебора),
+// ошибки только пишутся в лог. Запросы с заблокированного IP (счетчик IP защиты входа)
+// и повторные письма одному пользователю чаще passwordResetCooldown молча пропускаются
+func (s *PasswordResetService) RequestReset(ctx context.Context, email, ip string) {
+	// Проверка IP не зависит от email, поэтому не раскрывает существующие адреса
+	if err := s.throttle.RecordIPRequest(ctx, ip); err != nil {
+		log.Printf("Password reset request from %s skipped: %v", ip, err)
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), requestResetTimeout)
+		defer cancel()
+		if err := s.sendResetLink(ctx, email); err != nil {
+			log.Printf("Failed to request password reset: %v", err)
+		}
+	}()
+}
+
+// Wait ждет завершения начатых в фоне запросов сброса (graceful shutdown)
+func (s *PasswordResetService) Wait() {
+	s.wg.Wait()
+}
+
+// sendResetLink создает токен и отправляет письмо.
+// Если пользователь не найден — ничего не делаем
+func (s *PasswordResetService) sendResetLink(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetUserByEmail(ctx, lookupEmail(email))
+	if err != nil || user == nil {
+		log.Printf("Password reset requested for unknown email")
+		return nil
+	}
+	if !s.allowSend(user.ID) {
+		log.Printf("Password reset email for user %d skipped: sent recently", user.ID)
+		return nil
+	}
+
+	// Действует только последняя ссылка
+	if err := s.resetRepo.InvalidateForUser(ctx, user.ID); err != nil {
+		return err
+	}
+
+	token, err := jwt.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	resetToken := &model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: jwt.HashOpaqueToken(token),
+		ExpiresAt: time.Now().Add(s.resetTTL),
+	}
+	if err := s.resetRepo.Create(ctx, resetToken); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", s.baseURL, url.QueryEscape(token))
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Сброс пароля",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\n"+
+			"Для установки нового пароля перейдите по ссылке (действует %v):\n%s\n\n"+
+			"Если вы не запрашивали сброс пароля, просто проигнорируйте это письмо.\n",
+			user.Username, s.resetTTL, link),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("failed to send password reset email: %w", err)
+	}
+
+	return nil
+}
+
+// allowSend ограничивает частоту писем сброса одному пользователю
+func (s *PasswordResetService) allowSend(userID int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if last, ok := s.lastSent[userID]; ok && now.Sub(last) < passwordResetCooldown {
+		return false
+	}
+	s.lastSent[userID] = now
+
+	// Чистим устаревшие записи, чтобы карта не росла бесконечно
+	for id, last := range s.lastSent {
+		if now.Sub(last) >= passwordResetCooldown {
+			delete(s.lastSent, id)
+		}
+	}
+	return true
+}
+
+// ResetPassword устанавливает новый пароль по токену из письма
+// и завершает все сессии пользователя, отзывая также personal access токены
+func (s *PasswordResetService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if err := jwt.ValidatePassword(newPassword); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPassword, err)
+	}
+
+	// 1. Одноразово используем токен
+	userID, err := s.resetRepo.Consume(ctx, jwt.HashOpaqueToken(token))
+	if err != nil {
+		return err
+	}
+	if userID == 0 {
+		return ErrInvalidResetToken
+	}
+
+	// 2. Сохраняем новый пароль
+	passwordHash, err := jwt.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := s.userRepo.UpdatePassword(ctx, userID, passwordHash); err != nil {
+		return err
+	}
+
+	// 3. Остальные ссылки, сессии и personal access токены больше недействительны:
+	// злоумышленник со старым паролем мог выпустить себе токен
+	if err := s.resetRepo.InvalidateForUser(ctx, userID); err != nil {
+		return err
+	}
+	return s.tokens.LogoutAll(ctx, userID)
+}
@@ -0,0 +1,76 @@
+// service/permissions.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+)
+
+// Permission - право на действие
+type Permission string
+
+const (
+	PermPostCreate       Permission = "post:create"
+	PermPostUpdateOwn    Permission = "post:update:own"
+	PermPostUpdateAny    Permission = "post:update:any"
+	PermPostDeleteOwn    Permission = "post:delete:own"
+	PermPostDeleteAny    Permission = "post:delete:any"
+	PermCommentCreate    Permission = "comment:create"
+	PermCommentDeleteOwn Permission = "comment:delete:own"
+	PermCommentDeleteAny Permission = "comment:delete:any"
+	PermTagManage        Permission = "tag:manage"
+	PermUserManageRoles  Permission = "user:manage_roles"
+)
+
+// Права каждой роли (каждая следующая роль включает права предыдущей)
+var rolePermissions = func() map[string]map[Permission]bool {
+	reader := []Permission{PermCommentCreate, PermCommentDeleteOwn}
+	author := slices.Concat(reader, []Permission{PermPostCreate, PermPostUpdateOwn, PermPostDeleteOwn})
+	editor := slices.Concat(author, []Permission{PermPostUpdateAny, PermPostDeleteAny, PermCommentDeleteAny, PermTagManage})
+	admin := slices.Concat(editor, []Permission{PermUserManageRoles})
+
+	toSet := func(perms []Permission) map[Permission]bool {
+		set := make(map[Permission]bool, len(perms))
+		for _, p := range perms {
+			set[p] = true
+		}
+		return set
+	}
+
+	return map[string]map[Permission]bool{
+		model.RoleReader: toSet(reader),
+		model.RoleAuthor: toSet(author),
+		model.RoleEditor: toSet(editor),
+		model.RoleAdmin:  toSet(admin),
+	}
+}()
+
+// HasPermission проверяет, есть ли у роли право
+func HasPermission(role string, perm Permission) bool {
+	return rolePermissions[role][perm]
+}
+
+// CanModify проверяет право на изменение ресурса:
+// владельцу достаточно own-права, остальным нужно any-право
+func CanModify(user *model.User, ownerID int, own, any Permission) bool {
+	if user.ID == ownerID && HasPermission(user.Role, own) {
+		return true
+	}
+	return HasPermission(user.Role, any)
+}
+
+// loadUser загружает пользователя и проверяет, что он существует
+func loadUser(ctx context.Context, userRepo repository.UserRepository, userID int) (*model.User, error) {
+	user, err := userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user not found: %d", userID)
+	}
+	return user, nil
+}
@@ -0,0 +1,149 @@
+// service/personal_access_token_service.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+	"blog-backend/pkg/jwt"
+)
+
+// Ограничения personal access токенов
+const (
+	maxPersonalAccessTokens = 50  // активных токенов на пользователя
+	maxTokenNameLength      = 100 // как VARCHAR(100) в БД
+	maxTokenExpiresInDays   = 365
+	// last_used_at обновляется не чаще раза в минуту, чтобы не писать в БД на каждый запрос
+	lastUsedUpdateInterval = time.Minute
+)
+
+// PersonalAccessTokenService - именованные токены с ограниченными правами для скриптов и CI
+type PersonalAccessTokenService struct {
+	patRepo  repository.PersonalAccessTokenRepository
+	userRepo repository.UserRepository
+}
+
+// Создаем сервис personal access токенов
+func NewPersonalAccessTokenService(patRepo repository.PersonalAccessTokenRepository, userRepo repository.UserRepository) *PersonalAccessTokenService {
+	return &PersonalAccessTokenService{
+		patRepo:  patRepo,
+		userRepo: userRepo,
+	}
+}
+
+// Create выпускает новый токен. Сам токен возвращается только здесь — в БД хранится хеш
+func (s *PersonalAccessTokenService) Create(ctx context.Context, userID int, req model.CreatePersonalAccessTokenRequest) (*model.PersonalAccessToken, string, error) {
+	// 1. Валидация
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxTokenNameLength {
+		return nil, "", fmt.Errorf("%w: name required and max %d chars", ErrInvalidPersonalAccessToken, maxTokenNameLength)
+	}
+	if len(req.Scopes) == 0 {
+		return nil, "", fmt.Errorf("%w: at least one scope required", ErrInvalidPersonalAccessToken)
+	}
+	for _, scope := range req.Scopes {
+		if !model.IsValidScope(scope) {
+			return nil, "", fmt.Errorf("%w: unknown scope %q", ErrInvalidPersonalAccessToken, scope)
+		}
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > maxTokenExpiresInDays {
+		return nil, "", fmt.Errorf("%w: expires_in_days must be 0-%d", ErrInvalidPersonalAccessToken, maxTokenExpiresInDays)
+	}
+
+	// 2. Лимит активных токенов
+	existing, err := s.patRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(existing) >= maxPersonalAccessTokens {
+		return nil, "", fmt.Errorf("%w: limit of %d tokens reached", ErrInvalidPersonalAccessToken, maxPersonalAccessTokens)
+	}
+
+	// 3. Генерируем и сохраняем хеш
+	secret, err := jwt.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	raw := model.PersonalAccessTokenPrefix + secret
+
+	scopes := slices.Clone(req.Scopes)
+	slices.Sort(scopes)
+	token := &model.PersonalAccessToken{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: jwt.HashOpaqueToken(raw),
+		Scopes:    slices.Compact(scopes),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+	if err := s.patRepo.Create(ctx, token); err != nil {
+		return nil, "", err
+	}
+
+	return token, raw, nil
+}
+
+// List возвращает активные токены пользователя (без секретов)
+func (s *PersonalAccessTokenService) List(ctx context.Context, userID int) ([]*model.PersonalAccessToken, error) {
+	tokens, err := s.patRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// Revoke отзывает токен пользователя
+func (s *PersonalAccessTokenService) Revoke(ctx context.Context, userID, tokenID int) error {
+	revoked, err := s.patRepo.Revoke(ctx, userID, tokenID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrPersonalAccessTokenNotFound
+	}
+	return nil
+}
+
+// AuthenticatePAT проверяет токен из заголовка Authorization и возвращает claims владельца.
+// (nil, nil, nil) — токен недействителен (неизвестен, отозван или истек)
+func (s *PersonalAccessTokenService) AuthenticatePAT(ctx context.Context, raw string) (*model.Claims, []string, error) {
+	token, err := s.patRepo.GetByHash(ctx, jwt.HashOpaqueToken(raw))
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	if token == nil || token.RevokedAt != nil || (token.ExpiresAt != nil && now.After(*token.ExpiresAt)) {
+		return nil, nil, nil
+	}
+
+	// Роль берем из БД, а не из момента выпуска токена
+	user, err := s.userRepo.GetUserByID(ctx, token.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		return nil, nil, nil
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > lastUsedUpdateInterval {
+		if err := s.patRepo.TouchLastUsed(ctx, token.ID, now); err != nil {
+			log.Printf("Failed to update last use of token %d: %v", token.ID, err)
+		}
+	}
+
+	claims := &model.Claims{
+		UserID:   user.ID,
+		Email:    user.Email,
+		Username: user.Username,
+		Role:     user.Role,
+	}
+	return claims, token.Scopes, nil
+}
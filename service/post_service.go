@@ -3,16 +3,38 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"blog-backend/internal/config"
 	"blog-backend/internal/model"
 	"blog-backend/internal/repository"
+	"blog-backend/pkg/markdown"
+	"blog-backend/pkg/slug"
 )
 
+// Слаги постов
+const (
+	defaultPostSlug = "post" // для заголовков без букв и цифр
+	maxSlugAttempts = 100    // вариантов с числовым суффиксом
+)
+
+// renderBatchSize - постов за один запрос при заполнении content_html
+const renderBatchSize = 100
+
+// maxSearchQueryLength - максимальная длина поискового запроса в символах
+const maxSearchQueryLength = 200
+
+// maxPostContentLength - максимальная длина текста поста в символах
+// (ограничивает и рендеринг Markdown, и сравнение версий)
+const maxPostContentLength = 100_000
+
 // PostService - бизнес-логика постов (проверка прав + делегирование)
 type PostService struct {
 	postRepo       repository.PostRepository
@@ -24,6 +46,8 @@ type PostService struct {
 	cancel         context.CancelFunc
 	workersCount   int // Из .env
 	batchSize      int // Из .env
+
+	requireVerifiedEmail bool // Из .env
 }
 
 // Создаем сервис с репозиториями
@@ -45,6 +69,8 @@ func NewPostService(postRepo repository.PostRepository, userRepo repository.User
 		tickerDuration: cfg.PostTickerDuration,         // Из .env
 		workersCount:   cfg.PostWorkersCount,           // Из .env
 		batchSize:      cfg.PostBatchSize,              // Из .env
+
+		requireVerifiedEmail: cfg.RequireVerifiedEmail, // Из .env
 	}
 
 	// Запуск планировщика только если флаг включен
@@ -122,6 +148,28 @@ func (s *PostService) worker(postChan <-chan *model.Post, workerID int) {
 	}
 }
 
+// RenderStoredContent строит HTML и оглавление для постов, сохраненных до появления content_html
+// (на существующей базе миграция добавляет столбец пустым). Возвращает число обработанных постов
+func (s *PostService) RenderStoredContent(ctx context.Context) (int, error) {
+	rendered, afterID := 0, 0
+	for {
+		posts, err := s.postRepo.ListUnrenderedPosts(ctx, afterID, renderBatchSize)
+		if err != nil || len(posts) == 0 {
+			return rendered, err
+		}
+		for _, post := range posts {
+			afterID = post.ID
+			if err := renderContent(post); err != nil {
+				return rendered, err
+			}
+			if err := s.postRepo.SetRenderedContent(ctx, post); err != nil {
+				return rendered, err
+			}
+			rendered++
+		}
+	}
+}
+
 // Graceful shutdown
 func (s *PostService) Stop() {
 	s.cancel()
@@ -130,9 +178,16 @@ func (s *PostService) Stop() {
 
 // Создаем пост (текущий user = автор)
 func (s *PostService) CreatePost(ctx context.Context, currentUserID int, post *model.Post) (*model.Post, error) {
-	// Проверяем, что пользователь существует
-	if _, err := s.userRepo.GetUserByID(ctx, currentUserID); err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
+	// Проверяем, что пользователь существует и может писать посты
+	user, err := loadUser(ctx, s.userRepo, currentUserID)
+	if err != nil {
+		return nil, err
+	}
+	if !HasPermission(user.Role, PermPostCreate) {
+		return nil, fmt.Errorf("%w: role %q cannot create posts", ErrPermissionDenied, user.Role)
+	}
+	if err := checkEmailVerified(user, s.requireVerifiedEmail); err != nil {
+		return nil, err
 	}
 
 	// Логика статусов по PublishAt
@@ -158,8 +213,124 @@ func (s *PostService) CreatePost(ctx context.Context, currentUserID int, post *m
 	// Устанавливаем автора поста
 	post.AuthorID = currentUserID
 
+	if post.Tags, err = normalizeTags(post.Tags); err != nil {
+		return nil, err
+	}
+	if err := checkContentLength(post.Content); err != nil {
+		return nil, err
+	}
+	if err := renderContent(post); err != nil {
+		return nil, err
+	}
+
+	// Адрес поста из заголовка
+	if post.Slug, err = s.uniqueSlug(ctx, post.Title, 0); err != nil {
+		return nil, err
+	}
+
 	// Делегируем в Repository
-	return s.postRepo.CreatePost(ctx, post)
+	return s.saveWithFreeSlug(ctx, post, 0, func() (*model.Post, error) {
+		return s.postRepo.CreatePost(ctx, post)
+	})
+}
+
+// Получаем пост по текущему или прежнему слагу (для всех).
+// moved = true, если слаг устарел: клиента нужно перенаправить на post.Slug
+func (s *PostService) GetPostBySlug(ctx context.Context, postSlug string) (post *model.Post, moved bool, err error) {
+	post, err = s.postRepo.GetPostBySlug(ctx, postSlug)
+	if err != nil || post != nil {
+		return post, false, err
+	}
+
+	postID, err := s.postRepo.FindSlugRedirect(ctx, postSlug)
+	if err != nil {
+		return nil, false, err
+	}
+	if postID == 0 {
+		return nil, false, ErrPostNotFound
+	}
+	post, err = s.postRepo.GetPostByID(ctx, postID)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrPostNotFound, err)
+	}
+	return post, true, nil
+}
+
+// uniqueSlug подбирает свободный слаг для заголовка: "privet-mir", "privet-mir-2", ...
+// postID — пост, которому можно вернуть его же прежний слаг (0 для нового поста)
+func (s *PostService) uniqueSlug(ctx context.Context, title string, postID int) (string, error) {
+	base := slug.Make(title)
+	if base == "" {
+		base = defaultPostSlug
+	}
+
+	for attempt := 1; attempt <= maxSlugAttempts; attempt++ {
+		candidate := base
+		if attempt > 1 {
+			candidate = slug.WithSuffix(base, strconv.Itoa(attempt))
+		}
+		taken, err := s.postRepo.SlugTaken(ctx, candidate, postID)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("failed to find free slug for %q after %d attempts", base, maxSlugAttempts)
+}
+
+// saveWithFreeSlug записывает пост через save. Между проверкой SlugTaken и записью параллельный
+// запрос мог занять тот же слаг — тогда подбирается следующий свободный и запись повторяется
+func (s *PostService) saveWithFreeSlug(ctx context.Context, post *model.Post, postID int, save func() (*model.Post, error)) (*model.Post, error) {
+	saved, err := save()
+	for attempt := 1; errors.Is(err, repository.ErrSlugTaken) && attempt < maxSlugAttempts; attempt++ {
+		if post.Slug, err = s.uniqueSlug(ctx, post.Title, postID); err != nil {
+			return nil, err
+		}
+		saved, err = save()
+	}
+	return saved, err
+}
+
+// checkContentLength ограничивает длину текста поста
+func checkContentLength(content string) error {
+	if utf8.RuneCountInString(content) > maxPostContentLength {
+		return fmt.Errorf("%w: content must be at most %d characters", ErrPostTooLarge, maxPostContentLength)
+	}
+	return nil
+}
+
+// renderContent переводит Markdown в HTML и оглавление. Результат хранится вместе с постом,
+// чтобы не разбирать текст при каждом чтении
+func renderContent(post *model.Post) error {
+	rendered, err := markdown.Render(post.Content)
+	if err != nil {
+		return err
+	}
+	post.ContentHTML = rendered.HTML
+	post.TOC = make([]model.TOCEntry, len(rendered.TOC))
+	for i, heading := range rendered.TOC {
+		post.TOC[i] = model.TOCEntry{Level: heading.Level, Text: heading.Text, ID: heading.ID}
+	}
+	return nil
+}
+
+// slugMatches - слаг получен из base (сам base или base с числовым суффиксом)
+func slugMatches(current, base string) bool {
+	if base == "" {
+		base = defaultPostSlug
+	}
+	suffix, ok := strings.CutPrefix(current, base)
+	if !ok {
+		return false
+	}
+	if suffix == "" {
+		return true
+	}
+	number, ok := strings.CutPrefix(suffix, "-")
+	_, err := strconv.Atoi(number)
+	return ok && err == nil
 }
 
 // Получаем пост по ID (для всех)
@@ -170,7 +341,7 @@ func (s *PostService) GetPost(ctx context.Context, id int) (*model.Post, error)
 	return s.postRepo.GetPostByID(ctx, id)
 }
 
-// Обновляет пост (только автор!)
+// Обновляет пост (автор или editor/admin)
 func (s *PostService) UpdatePost(ctx context.Context, currentUserID, postID int, post *model.Post) (*model.Post, error) {
 	// Получаем пост для проверки владельца
 	existingPost, err := s.postRepo.GetPostByID(ctx, postID)
@@ -179,12 +350,49 @@ func (s *PostService) UpdatePost(ctx context.Context, currentUserID, postID int,
 	}
 
 	// ПРОВЕРКА ПРАВ
-	if existingPost.AuthorID != currentUserID {
-		return nil, fmt.Errorf("permission denied: can only update own posts")
+	user, err := loadUser(ctx, s.userRepo, currentUserID)
+	if err != nil {
+		return nil, err
+	}
+	if !CanModify(user, existingPost.AuthorID, PermPostUpdateOwn, PermPostUpdateAny) {
+		return nil, fmt.Errorf("%w: can only update own posts", ErrPermissionDenied)
+	}
+
+	// Поля, которых нет в запросе (например, правка только тегов), не меняются
+	if post.Title == "" {
+		post.Title = existingPost.Title
+	}
+	if post.Content == "" {
+		post.Content = existingPost.Content
+	}
+	if post.PublishAt == nil {
+		post.PublishAt = existingPost.PublishAt
+	}
+
+	// Новый заголовок — новый адрес (прежний репозиторий сохранит для редиректа).
+	// Правка, не меняющая слаг ("привет" → "Привет!"), адрес не трогает
+	post.Slug = existingPost.Slug
+	if post.Title != "" && !slugMatches(existingPost.Slug, slug.Make(post.Title)) {
+		if post.Slug, err = s.uniqueSlug(ctx, post.Title, postID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Теги меняются, только если переданы (пустой список убирает все)
+	if post.Tags, err = normalizeTags(post.Tags); err != nil {
+		return nil, err
+	}
+	if err := checkContentLength(post.Content); err != nil {
+		return nil, err
+	}
+	if err := renderContent(post); err != nil {
+		return nil, err
 	}
 
 	// Repository возвращает ОБНОВЛЕННЫЙ пост с updated_at из БД!
-	updatedPost, err := s.postRepo.UpdatePost(ctx, postID, post)
+	updatedPost, err := s.saveWithFreeSlug(ctx, post, postID, func() (*model.Post, error) {
+		return s.postRepo.UpdatePost(ctx, postID, post)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to update post: %w", err)
 	}
@@ -192,7 +400,7 @@ func (s *PostService) UpdatePost(ctx context.Context, currentUserID, postID int,
 	return updatedPost, nil
 }
 
-// Удаляет пост (только автор!)
+// Удаляет пост (автор или editor/admin)
 func (s *PostService) DeletePost(ctx context.Context, currentUserID, postID int) error {
 	// Проверяем права доступа
 	existingPost, err := s.postRepo.GetPostByID(ctx, postID)
@@ -200,8 +408,12 @@ func (s *PostService) DeletePost(ctx context.Context, currentUserID, postID int)
 		return fmt.Errorf("post not found: %w", err)
 	}
 
-	if existingPost.AuthorID != currentUserID {
-		return fmt.Errorf("permission denied: can only delete own posts")
+	user, err := loadUser(ctx, s.userRepo, currentUserID)
+	if err != nil {
+		return err
+	}
+	if !CanModify(user, existingPost.AuthorID, PermPostDeleteOwn, PermPostDeleteAny) {
+		return fmt.Errorf("%w: can only delete own posts", ErrPermissionDenied)
 	}
 
 	// Делегируем удаление
@@ -224,3 +436,45 @@ func (s *PostService) GetAllPosts(ctx context.Context, limit, offset int) ([]*mo
 
 	return posts, total, nil
 }
+
+// Полнотекстовый поиск по опубликованным постам. Запрос в синтаксисе поисковиков:
+// слова ищутся вместе, "фраза в кавычках" — подряд, or — любое из слов, -слово — исключить
+func (s *PostService) SearchPosts(ctx context.Context, query string, limit, offset int) ([]*model.PostSearchResult, int, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, 0, fmt.Errorf("%w: query is required", ErrInvalidSearchQuery)
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		return nil, 0, fmt.Errorf("%w: query must be at most %d characters", ErrInvalidSearchQuery, maxSearchQueryLength)
+	}
+
+	results, err := s.postRepo.SearchPosts(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search posts: %w", err)
+	}
+	total, err := s.postRepo.CountSearchPosts(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	return results, total, nil
+}
+
+// Опубликованные посты с тегами: хотя бы с одним (matchAll = false) или со всеми
+func (s *PostService) ListPostsByTags(ctx context.Context, tags []string, matchAll bool, limit, offset int) ([]*model.Post, int, error) {
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	posts, err := s.postRepo.ListPostsByTags(ctx, tags, matchAll, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list posts: %w", err)
+	}
+	total, err := s.postRepo.CountPostsByTags(ctx, tags, matchAll)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count posts: %w", err)
+	}
+
+	return posts, total, nil
+}
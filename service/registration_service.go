@@ -0,0 +1,164 @@
+// service/registration_service.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"blog-backend/internal/config"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+	"blog-backend/pkg/jwt"
+)
+
+// Ограничения приглашений
+const maxInvitationExpiresInDays = 90
+
+// RegistrationService - политика регистрации (REGISTRATION_MODE) и приглашения администраторов
+type RegistrationService struct {
+	invitations    repository.InvitationRepository
+	mode           string
+	allowedDomains []string
+	invitationTTL  time.Duration
+}
+
+// Создаем сервис политики регистрации
+func NewRegistrationService(invitations repository.InvitationRepository, cfg *config.Config) *RegistrationService {
+	mode := cfg.RegistrationMode
+	if mode == "" {
+		mode = model.RegistrationOpen
+	}
+	// 7 дней по умолчанию, если cfg.InvitationTTL <= 0
+	invitationTTL := cfg.InvitationTTL
+	if invitationTTL <= 0 {
+		invitationTTL = 7 * 24 * time.Hour
+	}
+	return &RegistrationService{
+		invitations:    invitations,
+		mode:           mode,
+		allowedDomains: cfg.RegistrationAllowedDomains,
+		invitationTTL:  invitationTTL,
+	}
+}
+
+// Check проверяет, можно ли зарегистрироваться с этим email и кодом приглашения.
+// Код не расходуется — это делает UserRepository.CreateInvitedUser вместе с созданием пользователя.
+// Возвращает приглашение (nil, если код не передан)
+func (s *RegistrationService) Check(ctx context.Context, email, inviteCode string) (*model.Invitation, error) {
+	if s.mode == model.RegistrationClosed {
+		return nil, ErrRegistrationClosed
+	}
+
+	var invitation *model.Invitation
+	if inviteCode != "" {
+		var err error
+		invitation, err = s.invitations.GetByHash(ctx, jwt.HashOpaqueToken(inviteCode))
+		if err != nil {
+			return nil, err
+		}
+		if !invitationUsable(invitation, email) {
+			return nil, ErrInvalidInvitation
+		}
+	} else if s.mode == model.RegistrationInviteOnly {
+		return nil, ErrInvitationRequired
+	}
+
+	// Приглашение на конкретный адрес администратор выдал осознанно — allow-list его не ограничивает
+	if invitation != nil && invitation.Email != "" {
+		return invitation, nil
+	}
+	if !s.domainAllowed(email) {
+		return nil, ErrEmailDomainNotAllowed
+	}
+	return invitation, nil
+}
+
+// CreateInvitation выпускает приглашение. Код возвращается только здесь — в БД хранится хеш
+func (s *RegistrationService) CreateInvitation(ctx context.Context, adminID int, req model.CreateInvitationRequest) (*model.Invitation, string, error) {
+	email := strings.TrimSpace(req.Email)
+	if email != "" {
+		normalized, err := jwt.NormalizeEmail(email)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %v", ErrInvalidInvitationData, err)
+		}
+		email = normalized
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > maxInvitationExpiresInDays {
+		return nil, "", fmt.Errorf("%w: expires_in_days must be 0-%d", ErrInvalidInvitationData, maxInvitationExpiresInDays)
+	}
+
+	code, err := jwt.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	expiresAt := time.Now().Add(s.invitationTTL)
+	if req.ExpiresInDays > 0 {
+		expiresAt = time.Now().AddDate(0, 0, req.ExpiresInDays)
+	}
+	invitation := &model.Invitation{
+		CodeHash:  jwt.HashOpaqueToken(code),
+		Email:     email,
+		CreatedBy: &adminID,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.invitations.Create(ctx, invitation); err != nil {
+		return nil, "", err
+	}
+	return invitation, code, nil
+}
+
+// ListInvitations возвращает все приглашения (использованные тоже — для аудита)
+func (s *RegistrationService) ListInvitations(ctx context.Context) ([]*model.Invitation, error) {
+	invitations, err := s.invitations.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if invitations == nil {
+		invitations = []*model.Invitation{}
+	}
+	return invitations, nil
+}
+
+// RevokeInvitation удаляет неиспользованное приглашение
+func (s *RegistrationService) RevokeInvitation(ctx context.Context, id int) error {
+	deleted, err := s.invitations.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+// CheckDomain проверяет только allow-list доменов (для пользователей, созданных при входе через SSO)
+func (s *RegistrationService) CheckDomain(email string) error {
+	if !s.domainAllowed(email) {
+		return ErrEmailDomainNotAllowed
+	}
+	return nil
+}
+
+// domainAllowed проверяет домен email по REGISTRATION_ALLOWED_DOMAINS (пустой список = любой домен)
+func (s *RegistrationService) domainAllowed(email string) bool {
+	if len(s.allowedDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	return slices.Contains(s.allowedDomains, strings.ToLower(email[at+1:]))
+}
+
+// invitationUsable - приглашение существует, не использовано, не истекло и выдано на этот адрес
+func invitationUsable(invitation *model.Invitation, email string) bool {
+	if invitation == nil || invitation.UsedAt != nil || !time.Now().Before(invitation.ExpiresAt) {
+		return false
+	}
+	return invitation.Email == "" || strings.EqualFold(invitation.Email, email)
+}
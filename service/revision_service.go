@@ -0,0 +1,174 @@
+// service/revision_service.go
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+	"blog-backend/pkg/textdiff"
+)
+
+// RevisionService - история версий поста: список, сравнение и возврат к прежней версии.
+// Прежние версии сохраняет репозиторий при каждом изменении заголовка или текста
+type RevisionService struct {
+	postRepo    repository.PostRepository
+	userRepo    repository.UserRepository
+	postService *PostService // восстановление — обычное обновление поста
+}
+
+// Создаем сервис истории версий
+func NewRevisionService(postRepo repository.PostRepository, userRepo repository.UserRepository, postService *PostService) *RevisionService {
+	return &RevisionService{
+		postRepo:    postRepo,
+		userRepo:    userRepo,
+		postService: postService,
+	}
+}
+
+// ListRevisions возвращает версии поста, новые первыми; первая — текущее состояние поста.
+// Историю видят те, кто может редактировать пост (автор или editor/admin)
+func (s *RevisionService) ListRevisions(ctx context.Context, userID, postID int) ([]*model.PostRevision, error) {
+	post, err := s.getEditablePost(ctx, userID, postID)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := s.postRepo.ListRevisions(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	current := currentRevision(post, revisions)
+	current.Content = ""
+	return append([]*model.PostRevision{current}, revisions...), nil
+}
+
+// Diff сравнивает две версии поста построчно (номер текущей версии тоже подходит)
+func (s *RevisionService) Diff(ctx context.Context, userID, postID, from, to int) (*model.RevisionDiff, error) {
+	post, err := s.getEditablePost(ctx, userID, postID)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := s.postRepo.ListRevisions(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	current := currentRevision(post, revisions)
+
+	fromRevision, err := s.getRevision(ctx, postID, from, current)
+	if err != nil {
+		return nil, err
+	}
+	toRevision, err := s.getRevision(ctx, postID, to, current)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := textdiff.Lines(fromRevision.Content, toRevision.Content)
+	inserted, deleted := textdiff.Count(lines)
+	diffLines := make([]model.DiffLine, len(lines))
+	for i, line := range lines {
+		diffLines[i] = model.DiffLine{Op: string(line.Op), Text: line.Text}
+	}
+	return &model.RevisionDiff{
+		From:      from,
+		To:        to,
+		TitleFrom: fromRevision.Title,
+		TitleTo:   toRevision.Title,
+		Inserted:  inserted,
+		Deleted:   deleted,
+		Lines:     diffLines,
+	}, nil
+}
+
+// Restore возвращает посту заголовок и текст прежней версии (только автор поста).
+// Текущее состояние при этом само становится версией, так что восстановление можно отменить
+func (s *RevisionService) Restore(ctx context.Context, userID, postID, revision int) (*model.Post, error) {
+	post, err := s.postRepo.GetPostByID(ctx, postID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPostNotFound, err)
+	}
+	user, err := loadUser(ctx, s.userRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID != post.AuthorID || !HasPermission(user.Role, PermPostUpdateOwn) {
+		return nil, fmt.Errorf("%w: only the author can restore a revision", ErrPermissionDenied)
+	}
+
+	saved, err := s.postRepo.GetRevision(ctx, postID, revision)
+	if err != nil {
+		return nil, err
+	}
+	if saved == nil {
+		revisions, err := s.postRepo.ListRevisions(ctx, postID)
+		if err != nil {
+			return nil, err
+		}
+		if revision == currentRevision(post, revisions).Revision {
+			return nil, fmt.Errorf("%w: revision %d is the current version", ErrInvalidRevision, revision)
+		}
+		return nil, fmt.Errorf("%w: %d", ErrRevisionNotFound, revision)
+	}
+
+	// Статус, дата публикации и теги остаются прежними
+	return s.postService.UpdatePost(ctx, userID, postID, &model.Post{
+		ID:        postID,
+		Title:     saved.Title,
+		Content:   saved.Content,
+		Status:    post.Status,
+		PublishAt: post.PublishAt,
+	})
+}
+
+// getEditablePost загружает пост и проверяет право на его редактирование
+func (s *RevisionService) getEditablePost(ctx context.Context, userID, postID int) (*model.Post, error) {
+	post, err := s.postRepo.GetPostByID(ctx, postID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPostNotFound, err)
+	}
+	user, err := loadUser(ctx, s.userRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !CanModify(user, post.AuthorID, PermPostUpdateOwn, PermPostUpdateAny) {
+		return nil, fmt.Errorf("%w: can only view revisions of own posts", ErrPermissionDenied)
+	}
+	return post, nil
+}
+
+// getRevision загружает версию по номеру, номер текущей версии — само состояние поста
+func (s *RevisionService) getRevision(ctx context.Context, postID, revision int, current *model.PostRevision) (*model.PostRevision, error) {
+	if revision == current.Revision {
+		return current, nil
+	}
+	saved, err := s.postRepo.GetRevision(ctx, postID, revision)
+	if err != nil {
+		return nil, err
+	}
+	if saved == nil {
+		return nil, fmt.Errorf("%w: %d", ErrRevisionNotFound, revision)
+	}
+	return saved, nil
+}
+
+// currentRevision представляет текущее состояние поста версией со следующим номером
+// (revisions отсортированы по убыванию номера)
+func currentRevision(post *model.Post, revisions []*model.PostRevision) *model.PostRevision {
+	number := 1
+	if len(revisions) > 0 {
+		number = revisions[0].Revision + 1
+	}
+	createdAt := post.UpdatedAt
+	if createdAt.IsZero() {
+		createdAt = post.CreatedAt
+	}
+	return &model.PostRevision{
+		Revision:  number,
+		Title:     post.Title,
+		Content:   post.Content,
+		Current:   true,
+		CreatedAt: createdAt,
+	}
+}
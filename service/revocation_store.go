@@ -0,0 +1,176 @@
+// service/revocation_store.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"blog-backend/internal/config"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+	"blog-backend/pkg/jwt"
+)
+
+// RevocationStore - отозванные токены в PostgreSQL + in-memory кеш.
+// Проверка токена в AuthMiddleware идет только по кешу (без запроса в БД),
+// кеш периодически синхронизируется с БД (отзывы с других экземпляров сервиса)
+type RevocationStore struct {
+	repo         repository.RevokedTokenRepository
+	mu           sync.RWMutex
+	tokens       map[string]time.Time // jti → срок действия токена
+	users        map[int]time.Time    // userID → токены, выданные до этого момента, отозваны
+	wg           sync.WaitGroup
+	ctx          context.Context
+	cancel       context.CancelFunc
+	syncInterval time.Duration // Из .env
+}
+
+// Создаем хранилище отозванных токенов
+func NewRevocationStore(repo repository.RevokedTokenRepository, cfg *config.Config) *RevocationStore {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// 30s по умолчанию, если cfg.RevocationSyncInterval <= 0
+	syncInterval := cfg.RevocationSyncInterval
+	if syncInterval <= 0 {
+		syncInterval = 30 * time.Second
+	}
+
+	return &RevocationStore{
+		repo:         repo,
+		tokens:       make(map[string]time.Time),
+		users:        make(map[int]time.Time),
+		ctx:          ctx,
+		cancel:       cancel,
+		syncInterval: syncInterval,
+	}
+}
+
+// Start загружает кеш из БД и запускает фоновую синхронизацию
+func (s *RevocationStore) Start() error {
+	if err := s.Sync(s.ctx); err != nil {
+		return err
+	}
+
+	s.wg.Add(1)
+	go s.syncLoop()
+	return nil
+}
+
+// Фоновая горутина синхронизации кеша
+func (s *RevocationStore) syncLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.syncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.repo.DeleteExpired(s.ctx); err != nil {
+				log.Printf("Failed to delete expired revoked tokens: %v", err)
+			}
+			if err := s.Sync(s.ctx); err != nil {
+				log.Printf("Failed to sync revoked tokens: %v", err)
+			}
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// Sync перечитывает отозванные токены из БД
+func (s *RevocationStore) Sync(ctx context.Context) error {
+	revoked, err := s.repo.ListRevokedTokens(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load revoked tokens: %w", err)
+	}
+
+	// Отзыв старше времени жизни access токена уже ни на что не влияет
+	cutoffs, err := s.repo.ListUserCutoffs(ctx, time.Now().Add(-jwt.AccessTokenTTL()))
+	if err != nil {
+		return fmt.Errorf("failed to load user revocations: %w", err)
+	}
+
+	tokens := make(map[string]time.Time, len(revoked))
+	for _, token := range revoked {
+		tokens[token.JTI] = token.ExpiresAt
+	}
+
+	s.mu.Lock()
+	s.tokens = tokens
+	s.users = cutoffs
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Graceful shutdown
+func (s *RevocationStore) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// IsRevoked проверяет, отозван ли токен (реализует middleware.TokenRevocationChecker)
+func (s *RevocationStore) IsRevoked(ctx context.Context, claims *model.Claims) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// 1. Отозван конкретный токен (logout)
+	if _, revoked := s.tokens[claims.ID]; revoked && claims.ID != "" {
+		return true, nil
+	}
+
+	// 2. Отозваны все токены пользователя (logout со всех устройств).
+	// iat хранится с точностью до секунды, поэтому токен, выданный в ту же секунду, тоже отозван
+	if cutoff, ok := s.users[claims.UserID]; ok {
+		if claims.IssuedAt == nil || claims.IssuedAt.Unix() <= cutoff.Unix() {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// RevokeToken отзывает один access токен до истечения его срока
+func (s *RevocationStore) RevokeToken(ctx context.Context, claims *model.Claims) error {
+	if claims.ID == "" {
+		return fmt.Errorf("token has no jti")
+	}
+
+	expiresAt := time.Now().Add(jwt.AccessTokenTTL())
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	err := s.repo.RevokeToken(ctx, &model.RevokedToken{
+		JTI:       claims.ID,
+		UserID:    claims.UserID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.tokens[claims.ID] = expiresAt
+	s.mu.Unlock()
+
+	return nil
+}
+
+// RevokeAllForUser отзывает все выданные ранее access токены пользователя
+func (s *RevocationStore) RevokeAllForUser(ctx context.Context, userID int) error {
+	now := time.Now()
+	if err := s.repo.RevokeAllForUser(ctx, userID, now); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.users[userID] = now
+	s.mu.Unlock()
+
+	return nil
+}
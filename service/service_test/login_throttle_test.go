@@ -0,0 +1,51 @@
+// service_test/login_throttle_test.go
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"blog-backend/internal/config"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository/memory"
+	"blog-backend/service"
+)
+
+// TestLoginThrottle_Backoff - каждая неудача после блокировки удваивает ее
+func TestLoginThrottle_Backoff(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.LoginMaxFailures = 2
+	cfg.LoginLockout = time.Minute
+	cfg.LoginLockoutMax = 3 * time.Minute
+	throttle := service.NewLoginThrottleService(memory.NewMemoryLoginAttemptRepository(), cfg)
+	ctx := context.Background()
+
+	expected := []time.Duration{0, time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute}
+	for i, want := range expected {
+		err := throttle.RecordFailure(ctx, "user@example.com", "", nil, model.LoginFailureUnknownEmail)
+
+		var got time.Duration
+		var locked *service.LoginLockedError
+		if errors.As(err, &locked) {
+			got = locked.RetryAfter
+		} else if err != nil {
+			t.Fatalf("failure %d: %v", i+1, err)
+		}
+		if got != want {
+			t.Errorf("failure %d: expected lockout %v, got %v", i+1, want, got)
+		}
+	}
+
+	// Успешный вход сбрасывает счетчик
+	if err := throttle.RecordSuccess(ctx, "user@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := throttle.Check(ctx, "user@example.com", ""); err != nil {
+		t.Errorf("after success: expected no lock, got %v", err)
+	}
+	if err := throttle.RecordFailure(ctx, "user@example.com", "", nil, model.LoginFailureUnknownEmail); err != nil {
+		t.Errorf("first failure after reset: expected no lock, got %v", err)
+	}
+}
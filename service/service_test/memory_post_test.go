@@ -14,9 +14,10 @@ import (
 
 // MemoryPostStorage — потокобезопасное in-memory хранилище постов
 type MemoryPostStorage struct {
-	posts  []*model.Post // список всех постов
-	mu     sync.RWMutex  // RWMutex для потокобезопасности
-	nextID int           // автоинкрементный ID
+	posts  []*model.Post  // список всех постов
+	mu     sync.RWMutex   // RWMutex для потокобезопасности
+	nextID int            // автоинкрементный ID
+	slugs  map[string]int // прежние слаги → ID поста
 }
 
 // NewMemoryPostStorage создает новое хранилище и возвращает интерфейс PostRepository
@@ -81,6 +82,17 @@ func (s *MemoryPostStorage) UpdatePost(ctx context.Context, id int, post *model.
 
 	for i, p := range s.posts {
 		if p.ID == post.ID {
+			// Как в postgres: пустой слаг не меняется, прежний попадает в историю
+			if post.Slug == "" {
+				post.Slug = p.Slug
+			}
+			if post.Slug != p.Slug {
+				if s.slugs == nil {
+					s.slugs = map[string]int{}
+				}
+				s.slugs[p.Slug] = p.ID
+				delete(s.slugs, post.Slug)
+			}
 			s.posts[i] = post
 			return post, nil
 		}
@@ -116,6 +128,60 @@ func (s *MemoryPostStorage) CountPosts(ctx context.Context) (int, error) {
 	return count, nil
 }
 
+// ListPostsByUser возвращает опубликованные посты автора с пагинацией
+func (s *MemoryPostStorage) ListPostsByUser(ctx context.Context, userID, limit, offset int) ([]*model.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var posts []*model.Post
+	for _, post := range s.posts {
+		if post.AuthorID == userID && post.Status == "published" {
+			posts = append(posts, post)
+		}
+	}
+	if offset >= len(posts) {
+		return nil, nil
+	}
+	end := offset + limit
+	if end > len(posts) {
+		end = len(posts)
+	}
+	return posts[offset:end], nil
+}
+
+// ListAllPostsByUser возвращает все посты автора, включая черновики
+func (s *MemoryPostStorage) ListAllPostsByUser(ctx context.Context, userID int) ([]*model.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var posts []*model.Post
+	for _, post := range s.posts {
+		if post.AuthorID == userID {
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
+
+// ListFeed — подписок в этом хранилище нет, лента всегда пустая
+func (s *MemoryPostStorage) ListFeed(ctx context.Context, followerID int, cursor *model.FeedCursor, limit int) ([]*model.Post, error) {
+	return nil, nil
+}
+
+// CountPostsByUser возвращает количество опубликованных постов автора
+func (s *MemoryPostStorage) CountPostsByUser(ctx context.Context, userID int) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, post := range s.posts {
+		if post.AuthorID == userID && post.Status == "published" {
+			count++
+		}
+	}
+	return count, nil
+}
+
 // GetReadyToPublish возвращает посты готовые к публикации (publish_at <= now)
 func (s *MemoryPostStorage) GetReadyToPublish(ctx context.Context, batchSize int) ([]*model.Post, error) {
 	s.mu.RLock()
@@ -157,5 +223,119 @@ func (s *MemoryPostStorage) PublishPost(ctx context.Context, id int) error {
 	return errors.New("post not found")
 }
 
+// SearchPosts — поиск в тестах сервиса не используется, ничего не находится
+func (s *MemoryPostStorage) SearchPosts(ctx context.Context, query string, limit, offset int) ([]*model.PostSearchResult, error) {
+	return nil, nil
+}
+
+// CountSearchPosts — ничего не находится
+func (s *MemoryPostStorage) CountSearchPosts(ctx context.Context, query string) (int, error) {
+	return 0, nil
+}
+
+// ListPostsByTags — теги в тестах сервиса не используются, постов с тегами нет
+func (s *MemoryPostStorage) ListPostsByTags(ctx context.Context, tags []string, matchAll bool, limit, offset int) ([]*model.Post, error) {
+	return nil, nil
+}
+
+// CountPostsByTags — постов с тегами нет
+func (s *MemoryPostStorage) CountPostsByTags(ctx context.Context, tags []string, matchAll bool) (int, error) {
+	return 0, nil
+}
+
+// ListTags — тегов нет
+func (s *MemoryPostStorage) ListTags(ctx context.Context) ([]model.Tag, error) {
+	return []model.Tag{}, nil
+}
+
+// GetTag — тегов нет
+func (s *MemoryPostStorage) GetTag(ctx context.Context, name string) (*model.Tag, error) {
+	return nil, nil
+}
+
+// RenameTag — тегов нет
+func (s *MemoryPostStorage) RenameTag(ctx context.Context, name, newName string) error {
+	return errors.New("tag not found")
+}
+
+// MergeTags — тегов нет
+func (s *MemoryPostStorage) MergeTags(ctx context.Context, source, target string) error {
+	return errors.New("tag not found")
+}
+
+// ListRevisions — история версий в тестах сервиса не используется
+func (s *MemoryPostStorage) ListRevisions(ctx context.Context, postID int) ([]*model.PostRevision, error) {
+	return []*model.PostRevision{}, nil
+}
+
+// GetRevision — версий нет
+func (s *MemoryPostStorage) GetRevision(ctx context.Context, postID, revision int) (*model.PostRevision, error) {
+	return nil, nil
+}
+
+// GetPostBySlug возвращает пост по текущему слагу (nil, если нет)
+func (s *MemoryPostStorage) GetPostBySlug(ctx context.Context, slug string) (*model.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, p := range s.posts {
+		if p.Slug == slug {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+// FindSlugRedirect возвращает ID поста, которому принадлежал слаг (0, если нет)
+func (s *MemoryPostStorage) FindSlugRedirect(ctx context.Context, slug string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.slugs[slug], nil
+}
+
+// SlugTaken проверяет, занят ли слаг другим постом (текущий или прежний слаг)
+func (s *MemoryPostStorage) SlugTaken(ctx context.Context, slug string, exceptPostID int) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, p := range s.posts {
+		if p.Slug == slug && p.ID != exceptPostID {
+			return true, nil
+		}
+	}
+	if postID, ok := s.slugs[slug]; ok && postID != exceptPostID {
+		return true, nil
+	}
+	return false, nil
+}
+
+// ListUnrenderedPosts возвращает посты с текстом, но без HTML, по возрастанию ID после afterID
+func (s *MemoryPostStorage) ListUnrenderedPosts(ctx context.Context, afterID, limit int) ([]*model.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var posts []*model.Post
+	for _, p := range s.posts {
+		if p.ID > afterID && p.Content != "" && p.ContentHTML == "" && len(posts) < limit {
+			posts = append(posts, &model.Post{ID: p.ID, Content: p.Content})
+		}
+	}
+	return posts, nil
+}
+
+// SetRenderedContent записывает HTML и оглавление поста
+func (s *MemoryPostStorage) SetRenderedContent(ctx context.Context, post *model.Post) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.posts {
+		if p.ID == post.ID && p.Content == post.Content {
+			p.ContentHTML, p.TOC = post.ContentHTML, post.TOC
+		}
+	}
+	return nil
+}
+
 // Проверка — все методы реализованы
 var _ repository.PostRepository = (*MemoryPostStorage)(nil)
@@ -4,6 +4,8 @@ package service_test
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"blog-backend/internal/model"
 	"blog-backend/internal/repository"
@@ -20,6 +22,7 @@ func NewMockUserRepo() repository.UserRepository {
 				ID:       1,
 				Username: "testuser",
 				Email:    "test@example.com",
+				Role:     model.RoleAuthor,
 			},
 		},
 	}
@@ -56,12 +59,133 @@ func (m *MockUserRepo) CreateUser(ctx context.Context, name, email, password str
 		ID:           len(m.users) + 1,
 		Username:     name,
 		Email:        email,
+		Role:         model.RoleAuthor,
 		PasswordHash: password,
 	}
 	m.users[user.ID] = user
 	return user, nil
 }
 
+// CreateInvitedUser - приглашения в сервисных тестах не используются, код не проверяется
+func (m *MockUserRepo) CreateInvitedUser(ctx context.Context, email, username, passwordHash string, invitationID int) (*model.User, error) {
+	return m.CreateUser(ctx, email, username, passwordHash)
+}
+
+func (m *MockUserRepo) UpdateUserRole(ctx context.Context, id int, role string) error {
+	user, exists := m.users[id]
+	if !exists {
+		return fmt.Errorf("user not found: %d", id)
+	}
+	user.Role = role
+	return nil
+}
+
+func (m *MockUserRepo) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	user, exists := m.users[id]
+	if !exists {
+		return fmt.Errorf("user not found: %d", id)
+	}
+	user.PasswordHash = passwordHash
+	return nil
+}
+
+func (m *MockUserRepo) MarkEmailVerified(ctx context.Context, id int, email string) (bool, error) {
+	user, exists := m.users[id]
+	if !exists || user.Email != email {
+		return false, nil
+	}
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	return true, nil
+}
+
+func (m *MockUserRepo) SetTOTPSecret(ctx context.Context, id int, secret string) error {
+	user, exists := m.users[id]
+	if !exists {
+		return fmt.Errorf("user not found: %d", id)
+	}
+	user.TOTPSecret = secret
+	user.TOTPEnabledAt = nil
+	return nil
+}
+
+func (m *MockUserRepo) ReplaceTOTPSecret(ctx context.Context, id int, oldSecret, newSecret string) error {
+	if user, exists := m.users[id]; exists && user.TOTPSecret == oldSecret {
+		user.TOTPSecret = newSecret
+	}
+	return nil
+}
+
+func (m *MockUserRepo) EnableTOTP(ctx context.Context, id int) error {
+	user, exists := m.users[id]
+	if !exists {
+		return fmt.Errorf("user not found: %d", id)
+	}
+	now := time.Now()
+	user.TOTPEnabledAt = &now
+	return nil
+}
+
+func (m *MockUserRepo) DisableTOTP(ctx context.Context, id int) error {
+	user, exists := m.users[id]
+	if !exists {
+		return fmt.Errorf("user not found: %d", id)
+	}
+	user.TOTPSecret = ""
+	user.TOTPEnabledAt = nil
+	return nil
+}
+
+func (m *MockUserRepo) UseTOTPStep(ctx context.Context, id int, step int64) (bool, error) {
+	return true, nil
+}
+
+func (m *MockUserRepo) GetUserByUsername(ctx context.Context, username string) (*model.User, error) {
+	for _, user := range m.users {
+		if strings.EqualFold(user.Username, username) {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockUserRepo) UpdateProfile(ctx context.Context, user *model.User) error {
+	if _, exists := m.users[user.ID]; !exists {
+		return fmt.Errorf("user not found: %d", user.ID)
+	}
+	m.users[user.ID] = user
+	return nil
+}
+
+func (m *MockUserRepo) UpdateAvatar(ctx context.Context, id int, avatarURL, avatarSmallURL *string) error {
+	user, exists := m.users[id]
+	if !exists {
+		return fmt.Errorf("user not found: %d", id)
+	}
+	user.AvatarURL = avatarURL
+	user.AvatarSmallURL = avatarSmallURL
+	return nil
+}
+
+func (m *MockUserRepo) DeleteUser(ctx context.Context, id int) error {
+	return m.Delete(ctx, id)
+}
+
+func (m *MockUserRepo) AnonymizeUser(ctx context.Context, id int) error {
+	user, exists := m.users[id]
+	if !exists {
+		return fmt.Errorf("user not found: %d", id)
+	}
+	now := time.Now()
+	user.Email = fmt.Sprintf("deleted-%d@deleted.invalid", id)
+	user.Username = fmt.Sprintf("deleted-%d", id)
+	user.PasswordHash = "!"
+	user.DeletedAt = &now
+	return nil
+}
+
 func (m *MockUserRepo) Update(ctx context.Context, user *model.User) error {
 	if _, exists := m.users[user.ID]; !exists {
 		return fmt.Errorf("user not found: %d", user.ID)
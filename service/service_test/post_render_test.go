@@ -0,0 +1,54 @@
+// service_test/post_render_test.go
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"blog-backend/internal/config"
+	"blog-backend/internal/model"
+	"blog-backend/service"
+)
+
+// TestPostService_RenderStoredContent - посты без content_html (сохранены до его появления)
+// получают HTML и оглавление, уже отрендеренные не трогаются
+func TestPostService_RenderStoredContent(t *testing.T) {
+	repo := NewMemoryPostStorage()
+	svc := service.NewPostService(repo, NewMockUserRepo(), &config.Config{PostTickerDuration: 30 * time.Second})
+	ctx := context.Background()
+
+	stored := []*model.Post{
+		{Title: "Старый пост", Content: "# Заголовок\n\nТекст"},
+		{Title: "Пустой пост", Content: ""},
+		{Title: "Уже готов", Content: "текст", ContentHTML: "<p>свой</p>"},
+		{Title: "Только пробелы", Content: "   "},
+	}
+	for _, post := range stored {
+		if _, err := repo.CreatePost(ctx, post); err != nil {
+			t.Fatalf("CreatePost failed: %v", err)
+		}
+	}
+
+	rendered, err := svc.RenderStoredContent(ctx)
+	if err != nil {
+		t.Fatalf("RenderStoredContent failed: %v", err)
+	}
+	if rendered != 2 {
+		t.Errorf("expected 2 rendered posts, got %d", rendered)
+	}
+
+	post, _ := repo.GetPostByID(ctx, stored[0].ID)
+	if !strings.Contains(post.ContentHTML, "<h1") || len(post.TOC) != 1 {
+		t.Errorf("expected rendered HTML with TOC, got %q %+v", post.ContentHTML, post.TOC)
+	}
+	if post, _ := repo.GetPostByID(ctx, stored[2].ID); post.ContentHTML != "<p>свой</p>" {
+		t.Errorf("expected rendered post to stay unchanged, got %q", post.ContentHTML)
+	}
+
+	// Повторно обрабатывается только пост из пробелов: его HTML пуст, но обход по ID не зацикливается
+	if rendered, err := svc.RenderStoredContent(ctx); err != nil || rendered != 1 {
+		t.Errorf("second run: expected only the whitespace post, got %d (%v)", rendered, err)
+	}
+}
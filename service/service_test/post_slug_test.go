@@ -0,0 +1,83 @@
+// service_test/post_slug_test.go
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"blog-backend/internal/config"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+	"blog-backend/service"
+)
+
+// racingPostStorage - хранилище, в котором слаг занимает параллельный запрос: SlugTaken еще не видит
+// чужой пост (он не закоммичен), а запись натыкается на ограничение уникальности, как в postgres
+type racingPostStorage struct {
+	*MemoryPostStorage
+	staleChecks int // сколько проверок SlugTaken отвечают "свободен"
+}
+
+func (s *racingPostStorage) SlugTaken(ctx context.Context, slug string, exceptPostID int) (bool, error) {
+	if s.staleChecks > 0 {
+		s.staleChecks--
+		return false, nil
+	}
+	return s.MemoryPostStorage.SlugTaken(ctx, slug, exceptPostID)
+}
+
+func (s *racingPostStorage) CreatePost(ctx context.Context, post *model.Post) (*model.Post, error) {
+	if err := s.checkUnique(ctx, post.Slug, 0); err != nil {
+		return nil, err
+	}
+	return s.MemoryPostStorage.CreatePost(ctx, post)
+}
+
+func (s *racingPostStorage) UpdatePost(ctx context.Context, id int, post *model.Post) (*model.Post, error) {
+	if err := s.checkUnique(ctx, post.Slug, id); err != nil {
+		return nil, err
+	}
+	return s.MemoryPostStorage.UpdatePost(ctx, id, post)
+}
+
+func (s *racingPostStorage) checkUnique(ctx context.Context, slug string, postID int) error {
+	if taken, _ := s.MemoryPostStorage.SlugTaken(ctx, slug, postID); taken {
+		return fmt.Errorf("%w: %s", repository.ErrSlugTaken, slug)
+	}
+	return nil
+}
+
+// TestPostService_SlugRace - слаг, занятый после проверки, заменяется следующим свободным
+func TestPostService_SlugRace(t *testing.T) {
+	repo := &racingPostStorage{MemoryPostStorage: NewMemoryPostStorage().(*MemoryPostStorage)}
+	svc := service.NewPostService(repo, NewMockUserRepo(), &config.Config{PostTickerDuration: 30 * time.Second})
+	ctx := context.Background()
+
+	if _, err := svc.CreatePost(ctx, 1, &model.Post{Title: "Привет, мир", Content: "первый"}); err != nil {
+		t.Fatalf("CreatePost failed: %v", err)
+	}
+
+	repo.staleChecks = 1
+	created, err := svc.CreatePost(ctx, 1, &model.Post{Title: "Привет, мир!", Content: "второй"})
+	if err != nil {
+		t.Fatalf("CreatePost after race failed: %v", err)
+	}
+	if created.Slug != "privet-mir-2" {
+		t.Errorf("expected slug privet-mir-2, got %q", created.Slug)
+	}
+
+	other, err := svc.CreatePost(ctx, 1, &model.Post{Title: "Другой пост", Content: "третий"})
+	if err != nil {
+		t.Fatalf("CreatePost failed: %v", err)
+	}
+	repo.staleChecks = 2
+	updated, err := svc.UpdatePost(ctx, 1, other.ID, &model.Post{ID: other.ID, Title: "Привет мир", Content: "третий"})
+	if err != nil {
+		t.Fatalf("UpdatePost after race failed: %v", err)
+	}
+	if updated.Slug != "privet-mir-3" {
+		t.Errorf("expected slug privet-mir-3, got %q", updated.Slug)
+	}
+}
@@ -0,0 +1,228 @@
+// service/session_service.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"blog-backend/internal/config"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+	"blog-backend/pkg/auth"
+)
+
+// Ограничения на данные клиента, сохраняемые в сессии
+const (
+	sessionMaxUserAgent = 512
+	sessionMaxIP        = 45
+	// Порог, после которого из кеша проверок удаляются устаревшие записи
+	sessionCacheLimit = 10000
+)
+
+// SessionService - сессии входа: каждая пара токенов, выданная при входе, привязана к сессии.
+// Реализует middleware.TokenRevocationChecker: токен отклоняется, если сессия завершена
+type SessionService struct {
+	repo        repository.SessionRepository
+	revocations *RevocationStore
+
+	// Кеш проверок: sessionID → момент последней успешной проверки в БД.
+	// Завершение сессии на этом экземпляре сбрасывает кеш сразу, на других —
+	// не позже чем через checkInterval (как синхронизация RevocationStore)
+	mu            sync.Mutex
+	checked       map[int]time.Time
+	checkInterval time.Duration // Из .env (REVOCATION_SYNC_INTERVAL)
+}
+
+// Создаем сервис сессий
+func NewSessionService(repo repository.SessionRepository, revocations *RevocationStore, cfg *config.Config) *SessionService {
+	// 30s по умолчанию, если cfg.RevocationSyncInterval <= 0
+	checkInterval := cfg.RevocationSyncInterval
+	if checkInterval <= 0 {
+		checkInterval = 30 * time.Second
+	}
+
+	return &SessionService{
+		repo:          repo,
+		revocations:   revocations,
+		checked:       make(map[int]time.Time),
+		checkInterval: checkInterval,
+	}
+}
+
+// Start создает сессию для нового входа. IP и User-Agent берутся из контекста запроса
+func (s *SessionService) Start(ctx context.Context, userID int, familyID string, expiresAt time.Time) (*model.Session, error) {
+	client := auth.ClientInfoFromContext(ctx)
+	session := &model.Session{
+		UserID:    userID,
+		FamilyID:  familyID,
+		IP:        truncate(client.IP, sessionMaxIP),
+		UserAgent: truncate(client.UserAgent, sessionMaxUserAgent),
+		ExpiresAt: expiresAt,
+	}
+	if err := s.repo.Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	return session, nil
+}
+
+// Continue продлевает сессию цепочки refresh токенов при ротации.
+// Для цепочек, выданных до появления сессий, сессия создается.
+// Завершенная сессия — ErrInvalidRefreshToken
+func (s *SessionService) Continue(ctx context.Context, userID int, familyID string, expiresAt time.Time) (*model.Session, error) {
+	session, err := s.repo.GetByFamily(ctx, familyID)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	if session == nil {
+		return s.Start(ctx, userID, familyID, expiresAt)
+	}
+	if session.RevokedAt != nil || session.UserID != userID {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	now := time.Now()
+	if err := s.repo.Extend(ctx, session.ID, now, expiresAt); err != nil {
+		return nil, err
+	}
+	session.LastSeenAt, session.ExpiresAt = now, expiresAt
+	return session, nil
+}
+
+// IsRevoked проверяет отзыв токена и состояние его сессии (для AuthMiddleware).
+// Токены без сессии (sid = 0) проверяются только по RevocationStore
+func (s *SessionService) IsRevoked(ctx context.Context, claims *model.Claims) (bool, error) {
+	revoked, err := s.revocations.IsRevoked(ctx, claims)
+	if err != nil || revoked {
+		return revoked, err
+	}
+	if claims.SessionID == 0 {
+		return false, nil
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	checkedAt, ok := s.checked[claims.SessionID]
+	s.mu.Unlock()
+	if ok && now.Sub(checkedAt) < s.checkInterval {
+		return false, nil
+	}
+
+	session, err := s.repo.GetByID(ctx, claims.SessionID)
+	if err != nil {
+		return false, err
+	}
+	if session == nil || session.UserID != claims.UserID || session.RevokedAt != nil {
+		s.forget(claims.SessionID)
+		return true, nil
+	}
+
+	// Заодно обновляем время последней активности (не чаще раза в checkInterval)
+	if err := s.repo.TouchLastSeen(ctx, session.ID, now); err != nil {
+		log.Printf("⚠️ Failed to update session %d activity: %v", session.ID, err)
+	}
+	s.remember(session.ID, now)
+	return false, nil
+}
+
+// List возвращает активные сессии пользователя, помечая сессию текущего токена
+func (s *SessionService) List(ctx context.Context, userID, currentSessionID int) ([]*model.Session, error) {
+	sessions, err := s.repo.ListActive(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		session.Current = session.ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// ListHistory возвращает до limit последних сессий пользователя, включая завершенные (успешные входы)
+func (s *SessionService) ListHistory(ctx context.Context, userID, limit int) ([]*model.Session, error) {
+	return s.repo.ListForUser(ctx, userID, limit)
+}
+
+// Terminate завершает сессию пользователя: ее access токены перестают приниматься,
+// а refresh токен больше не обновляется
+func (s *SessionService) Terminate(ctx context.Context, userID, sessionID int) error {
+	session, err := s.repo.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	// Чужая, завершенная или истекшая сессия — не найдена
+	if session == nil || session.UserID != userID || session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return ErrSessionNotFound
+	}
+
+	revoked, err := s.repo.Revoke(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	s.forget(sessionID)
+	if !revoked {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// TerminateFamily завершает сессию цепочки refresh токенов (при повторном использовании токена)
+func (s *SessionService) TerminateFamily(ctx context.Context, familyID string) error {
+	session, err := s.repo.GetByFamily(ctx, familyID)
+	if err != nil || session == nil {
+		return err
+	}
+	if _, err := s.repo.Revoke(ctx, session.ID); err != nil {
+		return err
+	}
+	s.forget(session.ID)
+	return nil
+}
+
+// TerminateAll завершает все сессии пользователя
+func (s *SessionService) TerminateAll(ctx context.Context, userID int) error {
+	if err := s.repo.RevokeAllForUser(ctx, userID); err != nil {
+		return err
+	}
+
+	// ID сессий в кеше не привязаны к пользователю — сбрасываем кеш целиком
+	s.mu.Lock()
+	s.checked = make(map[int]time.Time)
+	s.mu.Unlock()
+	return nil
+}
+
+// remember запоминает успешную проверку сессии
+func (s *SessionService) remember(sessionID int, checkedAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.checked) >= sessionCacheLimit {
+		for id, at := range s.checked {
+			if checkedAt.Sub(at) >= s.checkInterval {
+				delete(s.checked, id)
+			}
+		}
+	}
+	s.checked[sessionID] = checkedAt
+}
+
+// forget удаляет сессию из кеша проверок
+func (s *SessionService) forget(sessionID int) {
+	s.mu.Lock()
+	delete(s.checked, sessionID)
+	s.mu.Unlock()
+}
+
+// truncate обрезает строку до max байт, не разрывая символы UTF-8
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	for max > 0 && !utf8.RuneStart(value[max]) {
+		max--
+	}
+	return value[:max]
+}
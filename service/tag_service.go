@@ -0,0 +1,166 @@
+// service/tag_service.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Ограничения тегов
+const (
+	maxTagLength   = 32
+	maxTagsPerPost = 10
+)
+
+// TagService - список тегов и управление ими (переименование и объединение для editor/admin)
+type TagService struct {
+	postRepo repository.PostRepository
+	userRepo repository.UserRepository
+}
+
+// Создаем сервис тегов
+func NewTagService(postRepo repository.PostRepository, userRepo repository.UserRepository) *TagService {
+	return &TagService{
+		postRepo: postRepo,
+		userRepo: userRepo,
+	}
+}
+
+// ListTags возвращает теги опубликованных постов с количеством постов
+func (s *TagService) ListTags(ctx context.Context) ([]model.Tag, error) {
+	return s.postRepo.ListTags(ctx)
+}
+
+// RenameTag переименовывает тег у всех постов. Если новое имя уже занято — ErrTagExists
+// (такие теги объединяются через MergeTags)
+func (s *TagService) RenameTag(ctx context.Context, actorID int, name, newName string) (*model.Tag, error) {
+	if err := s.checkManage(ctx, actorID); err != nil {
+		return nil, err
+	}
+	source, err := s.getTag(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	newName, err = normalizeTag(newName)
+	if err != nil {
+		return nil, err
+	}
+	if newName == source.Name {
+		return source, nil
+	}
+
+	existing, err := s.postRepo.GetTag(ctx, newName)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("%w: %q", ErrTagExists, newName)
+	}
+
+	if err := s.postRepo.RenameTag(ctx, source.Name, newName); err != nil {
+		return nil, err
+	}
+	return s.getTag(ctx, newName)
+}
+
+// MergeTags переносит посты тега source в target и удаляет source
+func (s *TagService) MergeTags(ctx context.Context, actorID int, source, target string) (*model.Tag, error) {
+	if err := s.checkManage(ctx, actorID); err != nil {
+		return nil, err
+	}
+	sourceTag, err := s.getTag(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	targetTag, err := s.getTag(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	if sourceTag.Name == targetTag.Name {
+		return nil, fmt.Errorf("%w: cannot merge a tag into itself", ErrInvalidTag)
+	}
+
+	if err := s.postRepo.MergeTags(ctx, sourceTag.Name, targetTag.Name); err != nil {
+		return nil, err
+	}
+	return s.getTag(ctx, targetTag.Name)
+}
+
+// checkManage проверяет право на управление тегами
+func (s *TagService) checkManage(ctx context.Context, actorID int) error {
+	actor, err := loadUser(ctx, s.userRepo, actorID)
+	if err != nil {
+		return err
+	}
+	if !HasPermission(actor.Role, PermTagManage) {
+		return fmt.Errorf("%w: cannot manage tags", ErrPermissionDenied)
+	}
+	return nil
+}
+
+// getTag находит тег по имени в любом написании ("Go" и "go" — один тег)
+func (s *TagService) getTag(ctx context.Context, name string) (*model.Tag, error) {
+	normalized, err := normalizeTag(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrTagNotFound, name)
+	}
+	tag, err := s.postRepo.GetTag(ctx, normalized)
+	if err != nil {
+		return nil, err
+	}
+	if tag == nil {
+		return nil, fmt.Errorf("%w: %q", ErrTagNotFound, normalized)
+	}
+	return tag, nil
+}
+
+// normalizeTags нормализует теги поста, убирает повторы и сортирует (как их возвращает репозиторий).
+// nil остается nil: при обновлении это значит "теги не менять"
+func normalizeTags(tags []string) ([]string, error) {
+	if tags == nil {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag, err := normalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > maxTagsPerPost {
+		return nil, fmt.Errorf("%w: a post can have at most %d tags", ErrInvalidTag, maxTagsPerPost)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// normalizeTag приводит тег к виду, в котором он хранится: нижний регистр, NFC,
+// пробелы заменяются дефисом ("Machine Learning" → "machine-learning").
+// Допустимы буквы, цифры и символы "-", "_", ".", "+", "#" (c++, c#, node.js)
+func normalizeTag(tag string) (string, error) {
+	tag = strings.Join(strings.Fields(strings.ToLower(norm.NFC.String(tag))), "-")
+	if tag == "" || utf8.RuneCountInString(tag) > maxTagLength {
+		return "", fmt.Errorf("%w: tag must be 1-%d characters", ErrInvalidTag, maxTagLength)
+	}
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_.+#", r) {
+			return "", fmt.Errorf("%w: %q may contain only letters, digits and '-', '_', '.', '+', '#'", ErrInvalidTag, tag)
+		}
+	}
+	return tag, nil
+}
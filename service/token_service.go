@@ -0,0 +1,229 @@
+// service/token_service.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"blog-backend/internal/config"
+	"blog-backend/internal/model"
+	"blog-backend/internal/repository"
+	"blog-backend/pkg/jwt"
+)
+
+// TokenService - выдача пар токенов и ротация refresh токенов
+type TokenService struct {
+	refreshRepo repository.RefreshTokenRepository
+	patRepo     repository.PersonalAccessTokenRepository
+	userRepo    repository.UserRepository
+	revocations *RevocationStore
+	sessions    *SessionService
+	refreshTTL  time.Duration // Из .env
+}
+
+// Создаем сервис токенов
+func NewTokenService(
+	refreshRepo repository.RefreshTokenRepository,
+	patRepo repository.PersonalAccessTokenRepository,
+	userRepo repository.UserRepository,
+	revocations *RevocationStore,
+	sessions *SessionService,
+	cfg *config.Config,
+) *TokenService {
+	// 30 дней по умолчанию, если cfg.RefreshTokenTTL <= 0
+	refreshTTL := cfg.RefreshTokenTTL
+	if refreshTTL <= 0 {
+		refreshTTL = 30 * 24 * time.Hour
+	}
+
+	return &TokenService{
+		refreshRepo: refreshRepo,
+		patRepo:     patRepo,
+		userRepo:    userRepo,
+		revocations: revocations,
+		sessions:    sessions,
+		refreshTTL:  refreshTTL,
+	}
+}
+
+// IssueTokens выдает новую пару токенов (новая цепочка ротации и новая сессия).
+// IP и User-Agent сессии берутся из контекста (auth.WithClientInfo)
+func (s *TokenService) IssueTokens(ctx context.Context, user *model.User) (*model.TokenPair, error) {
+	familyID, err := jwt.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.sessions.Start(ctx, user.ID, familyID, time.Now().Add(s.refreshTTL))
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(ctx, user, familyID, session.ID)
+}
+
+// issue создает access токен и refresh токен в заданной цепочке
+func (s *TokenService) issue(ctx context.Context, user *model.User, familyID string, sessionID int) (*model.TokenPair, error) {
+	accessToken, err := jwt.GenerateToken(*user, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	refreshToken, err := jwt.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// В БД сохраняем только хеш
+	stored := &model.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: jwt.HashOpaqueToken(refreshToken),
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	}
+	if err := s.refreshRepo.Create(ctx, stored); err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return &model.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(jwt.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+// Refresh меняет refresh токен на новую пару токенов (ротация).
+// Повторное использование уже отозванного токена отзывает всю цепочку
+func (s *TokenService) Refresh(ctx context.Context, refreshToken string) (*model.User, *model.TokenPair, error) {
+	if refreshToken == "" {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	// 1. Ищем токен по хешу
+	stored, err := s.refreshRepo.GetByHash(ctx, jwt.HashOpaqueToken(refreshToken))
+	if err != nil {
+		return nil, nil, fmt.Errorf("database error: %w", err)
+	}
+	if stored == nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	// 2. Токен уже использован/отозван — это кража, отзываем всю цепочку
+	if stored.RevokedAt != nil {
+		return nil, nil, s.revokeReusedFamily(ctx, stored)
+	}
+
+	// 3. Проверяем срок действия
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	// 4. Отзываем текущий токен (атомарно — защита от гонки двух запросов)
+	revoked, err := s.refreshRepo.Revoke(ctx, stored.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("database error: %w", err)
+	}
+	if !revoked {
+		return nil, nil, s.revokeReusedFamily(ctx, stored)
+	}
+
+	// 5. Загружаем актуальные данные пользователя
+	user, err := s.userRepo.GetUserByID(ctx, stored.UserID)
+	if err != nil || user == nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	// 6. Продлеваем сессию; если ее завершили из списка устройств — цепочка больше не обновляется
+	session, err := s.sessions.Continue(ctx, user.ID, stored.FamilyID, time.Now().Add(s.refreshTTL))
+	if errors.Is(err, ErrInvalidRefreshToken) {
+		if err := s.refreshRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+			return nil, nil, fmt.Errorf("failed to revoke token family: %w", err)
+		}
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 7. Выдаем новую пару в той же цепочке
+	pair, err := s.issue(ctx, user, stored.FamilyID, session.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, pair, nil
+}
+
+// revokeReusedFamily отзывает цепочку при повторном использовании токена
+func (s *TokenService) revokeReusedFamily(ctx context.Context, stored *model.RefreshToken) error {
+	log.Printf("⚠️ Refresh token reuse detected: user=%d family=%s", stored.UserID, stored.FamilyID)
+
+	if err := s.refreshRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+		return fmt.Errorf("failed to revoke token family: %w", err)
+	}
+	if err := s.sessions.TerminateFamily(ctx, stored.FamilyID); err != nil {
+		return fmt.Errorf("failed to terminate session: %w", err)
+	}
+	return ErrRefreshTokenReused
+}
+
+// Logout отзывает текущий access токен, завершает его сессию и (если передан) цепочку refresh токена
+func (s *TokenService) Logout(ctx context.Context, claims *model.Claims, refreshToken string) error {
+	if err := s.revocations.RevokeToken(ctx, claims); err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+	if claims.SessionID != 0 {
+		err := s.sessions.Terminate(ctx, claims.UserID, claims.SessionID)
+		if err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return fmt.Errorf("failed to terminate session: %w", err)
+		}
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	stored, err := s.refreshRepo.GetByHash(ctx, jwt.HashOpaqueToken(refreshToken))
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	// Чужой или неизвестный refresh токен молча игнорируем
+	if stored == nil || stored.UserID != claims.UserID {
+		return nil
+	}
+
+	if err := s.refreshRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	return nil
+}
+
+// RevokeAccessTokens отзывает только access токены пользователя (например, после смены роли).
+// Refresh токены остаются рабочими — новый access токен получит актуальные данные
+func (s *TokenService) RevokeAccessTokens(ctx context.Context, userID int) error {
+	if err := s.revocations.RevokeAllForUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+	return nil
+}
+
+// LogoutAll отзывает все access, refresh и personal access токены пользователя (все устройства).
+// PAT отзываются тоже: выход со всех устройств — реакция на утечку, а токен для скриптов
+// мог выпустить себе тот, кто успел войти в аккаунт
+func (s *TokenService) LogoutAll(ctx context.Context, userID int) error {
+	if err := s.revocations.RevokeAllForUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+	if err := s.refreshRepo.RevokeAllForUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	if err := s.patRepo.RevokeAllForUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke personal access tokens: %w", err)
+	}
+	if err := s.sessions.TerminateAll(ctx, userID); err != nil {
+		return fmt.Errorf("failed to terminate sessions: %w", err)
+	}
+	return nil
+}
@@ -5,34 +5,83 @@ import (
 	"blog-backend/internal/repository"
 	"blog-backend/pkg/jwt"
 	"context"
+	"errors"
 	"fmt"
+	"log"
 )
 
 type UserService struct {
 	userRepo repository.UserRepository // интерфейс для гибкости
+	tokens   *TokenService             // выдача access/refresh токенов
+	verifier *EmailVerificationService // письма с подтверждением email
+	mfa      *MFAService               // второй шаг входа при включенной 2FA
+	throttle *LoginThrottleService     // блокировка после серии неудачных входов
+	policy   *RegistrationService      // REGISTRATION_MODE, приглашения и allow-list доменов
 }
 
-func NewUserService(ur repository.UserRepository) *UserService {
+func NewUserService(ur repository.UserRepository, tokens *TokenService, verifier *EmailVerificationService, mfa *MFAService, throttle *LoginThrottleService, policy *RegistrationService) *UserService {
 	return &UserService{
 		userRepo: ur,
+		tokens:   tokens,
+		verifier: verifier,
+		mfa:      mfa,
+		throttle: throttle,
+		policy:   policy,
 	}
 }
 
-// Register создает нового пользователя и возвращает токен
-func (s *UserService) Register(ctx context.Context, email, username, passwordHash string) (*model.User, string, error) {
+// CheckRegistration проверяет политику регистрации без создания пользователя
+// (чтобы не хешировать пароль, когда регистрация все равно запрещена)
+func (s *UserService) CheckRegistration(ctx context.Context, email, inviteCode string) error {
+	_, err := s.policy.Check(ctx, email, inviteCode)
+	return err
+}
+
+// Register создает нового пользователя и возвращает пару токенов.
+// email должен быть уже нормализован (jwt.NormalizeEmail).
+// inviteCode обязателен при REGISTRATION_MODE=invite-only и расходуется при успехе
+func (s *UserService) Register(ctx context.Context, email, username, passwordHash, inviteCode string) (*model.User, *model.TokenPair, error) {
+	invitation, err := s.policy.Check(ctx, email, inviteCode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	username = normalizeUsername(username)
+	if err := validateUsername(username); err != nil {
+		return nil, nil, err
+	}
+	if err := checkUsernameAvailable(ctx, s.userRepo, username, 0); err != nil {
+		return nil, nil, err
+	}
 
-	user, err := s.userRepo.CreateUser(ctx, email, username, passwordHash)
+	// Приглашение расходуется в той же транзакции, что и создание пользователя:
+	// если код успели использовать параллельно (после Check), пользователь не создается
+	var user *model.User
+	if invitation != nil {
+		user, err = s.userRepo.CreateInvitedUser(ctx, email, username, passwordHash, invitation.ID)
+	} else {
+		user, err = s.userRepo.CreateUser(ctx, email, username, passwordHash)
+	}
+	if errors.Is(err, repository.ErrInvitationUsed) {
+		return nil, nil, ErrInvalidInvitation
+	}
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to create user: %w", err)
+		return nil, nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	// Генерируем токен
-	token, err := jwt.GenerateToken(*user)
+	// Аккаунт создается неподтвержденным — отправляем ссылку.
+	// Ошибка отправки не отменяет регистрацию: письмо можно запросить повторно
+	if err := s.verifier.SendVerification(ctx, user); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+	}
+
+	// Генерируем токены
+	tokens, err := s.tokens.IssueTokens(ctx, user)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to generate token: %w", err)
+		return nil, nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
-	return user, token, nil
+	return user, tokens, nil
 }
 
 // GetUserByID возвращает пользователя по ID
@@ -61,27 +110,125 @@ func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*model.
 	return user, nil
 }
 
-// Login выполняет авторизацию пользователя
-func (s *UserService) Login(ctx context.Context, email, password string) (*model.User, string, error) {
+// Login выполняет авторизацию пользователя.
+// ip — адрес клиента для учета неудачных попыток по IP
+func (s *UserService) Login(ctx context.Context, email, password, ip string) (*model.User, *model.TokenPair, error) {
+	email = lookupEmail(email)
+
+	// 0. Вход заблокирован после серии неудач (пароль даже не проверяем)
+	if err := s.throttle.Check(ctx, email, ip); err != nil {
+		return nil, nil, err
+	}
+
 	// 1. Находим пользователя
 	user, err := s.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
-		return nil, "", fmt.Errorf("database error: %w", err)
+		return nil, nil, fmt.Errorf("database error: %w", err)
 	}
 	if user == nil {
-		return nil, "", fmt.Errorf("Invalid email or password")
+		return nil, nil, s.loginFailed(ctx, email, ip, nil, model.LoginFailureUnknownEmail)
 	}
 
 	// 2. Проверяем пароль
 	if !jwt.CheckPassword(password, user.PasswordHash) {
-		return nil, "", fmt.Errorf("Invalid email or password")
+		return nil, nil, s.loginFailed(ctx, email, ip, &user.ID, model.LoginFailureInvalidPassword)
+	}
+	if err := s.throttle.RecordSuccess(ctx, email); err != nil {
+		log.Printf("Failed to reset login failures for %s: %v", email, err)
+	}
+
+	// Хеш старого алгоритма или с устаревшими параметрами пересчитываем, пока известен пароль
+	if jwt.NeedsRehash(user.PasswordHash) {
+		s.rehashPassword(ctx, user, password)
+	}
+
+	// 3. При включенной 2FA токены выдаются только после проверки кода:
+	// возвращаем *MFARequiredError с токеном первого шага
+	if user.IsMFAEnabled() {
+		return user, nil, s.mfa.Challenge(user)
+	}
+
+	// 4. Генерируем access и refresh токены
+	tokens, err := s.tokens.IssueTokens(ctx, user)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
-	// 3. Генерируем JWT токен
-	token, err := jwt.GenerateToken(*user)
+	return user, tokens, nil
+}
+
+// rehashPassword сохраняет хеш пароля текущим алгоритмом.
+// Ошибка не мешает входу: попробуем снова при следующем входе
+func (s *UserService) rehashPassword(ctx context.Context, user *model.User, password string) {
+	hash, err := jwt.HashPassword(password)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to generate token: %w", err)
+		log.Printf("Failed to rehash password of user %d: %v", user.ID, err)
+		return
+	}
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, hash); err != nil {
+		log.Printf("Failed to save rehashed password of user %d: %v", user.ID, err)
+		return
+	}
+	user.PasswordHash = hash
+}
+
+// loginFailed учитывает неудачный вход и возвращает ошибку для клиента:
+// одинаковую для неизвестного email и неверного пароля или *LoginLockedError
+func (s *UserService) loginFailed(ctx context.Context, email, ip string, userID *int, reason string) error {
+	if err := s.throttle.RecordFailure(ctx, email, ip, userID, reason); err != nil {
+		var locked *LoginLockedError
+		if errors.As(err, &locked) {
+			return err
+		}
+		log.Printf("Failed to record login failure for %s: %v", email, err)
+	}
+	return fmt.Errorf("Invalid email or password")
+}
+
+// RefreshTokens обновляет пару токенов по refresh токену (с ротацией)
+func (s *UserService) RefreshTokens(ctx context.Context, refreshToken string) (*model.User, *model.TokenPair, error) {
+	return s.tokens.Refresh(ctx, refreshToken)
+}
+
+// Logout завершает текущую сессию пользователя
+func (s *UserService) Logout(ctx context.Context, claims *model.Claims, refreshToken string) error {
+	return s.tokens.Logout(ctx, claims, refreshToken)
+}
+
+// LogoutAll завершает все сессии пользователя
+func (s *UserService) LogoutAll(ctx context.Context, userID int) error {
+	return s.tokens.LogoutAll(ctx, userID)
+}
+
+// ChangeRole меняет роль пользователя (требуется право управления ролями)
+func (s *UserService) ChangeRole(ctx context.Context, actorID, targetID int, role string) (*model.User, error) {
+	if !model.IsValidRole(role) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRole, role)
+	}
+
+	actor, err := loadUser(ctx, s.userRepo, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if !HasPermission(actor.Role, PermUserManageRoles) {
+		return nil, fmt.Errorf("%w: cannot manage roles", ErrPermissionDenied)
+	}
+	// Защита от потери последнего доступа администратора
+	if actorID == targetID {
+		return nil, fmt.Errorf("%w: cannot change own role", ErrPermissionDenied)
+	}
+
+	if _, err := loadUser(ctx, s.userRepo, targetID); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdateUserRole(ctx, targetID, role); err != nil {
+		return nil, fmt.Errorf("failed to update role: %w", err)
+	}
+
+	// Старые access токены содержат прежнюю роль — отзываем их
+	if err := s.tokens.RevokeAccessTokens(ctx, targetID); err != nil {
+		return nil, err
 	}
 
-	return user, token, nil
+	return loadUser(ctx, s.userRepo, targetID)
 }